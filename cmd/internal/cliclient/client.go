@@ -0,0 +1,199 @@
+// Package cliclient builds and connects the socket.io clients of the
+// command-line tools.
+package cliclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	engineio_v4_client_transport_polling "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/polling"
+	engineio_v4_client_transport_ws "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket"
+	socketio_v5_client "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+	"github.com/maldikhan/go.socket.io/utils"
+	ws_native "github.com/maldikhan/go.socket.io/websocket/native"
+)
+
+// Transport names accepted by Options.Transport.
+const (
+	TransportPolling   = "polling"
+	TransportWebsocket = "websocket"
+	// TransportUpgrade starts on polling and upgrades to websocket.
+	TransportUpgrade = "upgrade"
+)
+
+// Options sets how a client connects.
+type Options struct {
+	Transport string // one of the Transport constants, TransportUpgrade when empty
+	Namespace string // "/" when empty
+	Auth      map[string]interface{}
+	Header    http.Header
+	Query     url.Values
+	Logger    utils.Logger // none when nil
+	Metrics   socketio_v5_client.Metrics
+}
+
+// LogLevels maps the -log-level flag values to utils.DefaultLogger levels.
+var LogLevels = map[string]int{
+	"debug": utils.DEBUG,
+	"info":  utils.INFO,
+	"warn":  utils.WARN,
+	"error": utils.ERROR,
+	"none":  utils.NONE,
+}
+
+// NewClient builds a socket.io client for rawURL, an http or https URL whose
+// path defaults to /socket.io/.
+func NewClient(rawURL string, options Options) (*socketio_v5_client.Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("URL scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/socket.io/"
+	}
+	if len(options.Query) > 0 {
+		query := u.Query()
+		for name, values := range options.Query {
+			query[name] = append(query[name], values...)
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	logger := options.Logger
+	if logger == nil {
+		logger = &utils.DefaultLogger{Level: utils.NONE}
+	}
+	metrics := options.Metrics
+	if metrics == nil {
+		metrics = utils.NopMetrics{}
+	}
+
+	polling, err := engineio_v4_client_transport_polling.NewTransport(
+		engineio_v4_client_transport_polling.WithLogger(logger),
+		engineio_v4_client_transport_polling.WithMetrics(metrics),
+		engineio_v4_client_transport_polling.WithHTTPClient(&headerClient{header: options.Header, client: &http.Client{Timeout: time.Minute}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := engineio_v4_client_transport_ws.NewTransport(
+		engineio_v4_client_transport_ws.WithLogger(logger),
+		engineio_v4_client_transport_ws.WithMetrics(metrics),
+		engineio_v4_client_transport_ws.WithWebSocket(&ws_native.WebSocketConnection{Header: options.Header}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var transports []engineio_v4_client.Transport
+	switch options.Transport {
+	case TransportPolling:
+		transports = []engineio_v4_client.Transport{polling}
+	case TransportWebsocket:
+		transports = []engineio_v4_client.Transport{ws}
+	case TransportUpgrade, "":
+		transports = []engineio_v4_client.Transport{polling, ws}
+	default:
+		return nil, fmt.Errorf("unknown transport %q, want polling, websocket or upgrade", options.Transport)
+	}
+
+	engine, err := engineio_v4_client.NewClient(
+		engineio_v4_client.WithURL(u),
+		engineio_v4_client.WithLogger(logger),
+		engineio_v4_client.WithMetrics(metrics),
+		engineio_v4_client.WithSupportedTransports(transports),
+		engineio_v4_client.WithTransport(transports[0]),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = "/"
+	}
+	client, err := socketio_v5_client.NewClient(
+		socketio_v5_client.WithEngineIOClient(engine),
+		socketio_v5_client.WithLogger(logger),
+		socketio_v5_client.WithMetrics(metrics),
+		socketio_v5_client.WithDefaultNamespace(namespace),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if options.Auth != nil {
+		client.SetHandshakeData(options.Auth)
+	}
+	return client, nil
+}
+
+// headerClient adds headers to the polling requests.
+type headerClient struct {
+	header http.Header
+	client *http.Client
+}
+
+func (c *headerClient) Do(req *http.Request) (*http.Response, error) {
+	for name, values := range c.header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	return c.client.Do(req)
+}
+
+// ConnectError is the CONNECT_ERROR the server answered the namespace
+// CONNECT with.
+type ConnectError struct {
+	Data interface{}
+}
+
+func (e *ConnectError) Error() string {
+	data, _ := json.Marshal(e.Data)
+	return fmt.Sprintf("connect error: %s", data)
+}
+
+// ErrConnectTimeout is returned by Connect when the namespace doesn't connect
+// in time.
+var ErrConnectTimeout = errors.New("timed out waiting for the namespace to connect")
+
+// Connect connects client and waits for its namespace. The client runs on
+// ctx, so it lives until ctx is done or it is closed.
+func Connect(ctx context.Context, client *socketio_v5_client.Client, timeout time.Duration) error {
+	result := make(chan error, 1)
+	var once sync.Once
+	done := func(err error) { once.Do(func() { result <- err }) }
+	client.On("connect", func([]interface{}) { done(nil) })
+	client.On("error", func(args []interface{}) {
+		var data interface{}
+		if len(args) > 0 {
+			data = args[0]
+		}
+		done(&ConnectError{Data: data})
+	})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	if err := client.Connect(ctx); err != nil {
+		return err
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return ErrConnectTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
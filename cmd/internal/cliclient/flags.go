@@ -0,0 +1,85 @@
+package cliclient
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// Flags are the connection flags shared by the tools.
+type Flags struct {
+	Transport      string
+	Namespace      string
+	ConnectTimeout time.Duration
+	auth           string
+	headers        listFlag
+	query          listFlag
+	logLevel       string
+}
+
+// listFlag is a flag that may be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Register defines the flags on flags.
+func (f *Flags) Register(flags *flag.FlagSet) {
+	flags.StringVar(&f.Transport, "transport", "upgrade", "transport: polling, websocket, or upgrade (polling upgraded to websocket)")
+	flags.StringVar(&f.Namespace, "namespace", "/", "namespace to connect to")
+	flags.StringVar(&f.auth, "auth", "", "CONNECT auth payload, a JSON object")
+	flags.Var(&f.headers, "header", `HTTP header "Name: value", may be repeated`)
+	flags.Var(&f.query, "query", `query parameter "name=value", may be repeated`)
+	flags.DurationVar(&f.ConnectTimeout, "connect-timeout", 10*time.Second, "time to wait for the namespace to connect")
+	flags.StringVar(&f.logLevel, "log-level", "none", "client log level on stderr: debug, info, warn, error or none")
+}
+
+// Options returns the client options set by the flags.
+func (f *Flags) Options() (Options, error) {
+	level, ok := LogLevels[f.logLevel]
+	if !ok {
+		return Options{}, fmt.Errorf("unknown log level %q", f.logLevel)
+	}
+	options := Options{
+		Transport: f.Transport,
+		Namespace: f.Namespace,
+		Header:    make(http.Header),
+		Query:     make(url.Values),
+		Logger:    &utils.DefaultLogger{Level: level},
+	}
+
+	for _, param := range f.query {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok || name == "" {
+			return Options{}, fmt.Errorf("invalid query parameter %q, want name=value", param)
+		}
+		options.Query.Add(name, value)
+	}
+
+	for _, h := range f.headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return Options{}, fmt.Errorf("invalid header %q, want \"Name: value\"", h)
+		}
+		options.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	if f.auth != "" {
+		if err := json.Unmarshal([]byte(f.auth), &options.Auth); err != nil || options.Auth == nil {
+			return Options{}, fmt.Errorf("auth must be a JSON object: %q", f.auth)
+		}
+	}
+	return options, nil
+}
@@ -0,0 +1,223 @@
+// Command sio-decode prints the layered breakdown of engine.io frames
+// carrying socket.io packets and validates them against the protocol:
+//
+//	sio-decode [flags] [FRAME...]
+//
+// Frames are read from the arguments, the -file or, without either, stdin,
+// one per line; a line may be a polling payload with its packets separated by
+// \x1e, written as the byte or the four characters `\x1e`. With -trace the
+// frames of a transport recording (engine.io/v4/client/transport/recorder)
+// are decoded. The exit status is 0 when every frame is valid, 1 when one
+// isn't and 2 on bad usage.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/recorder"
+	socketio_v5_inspect "github.com/maldikhan/go.socket.io/socket.io/v5/inspect"
+)
+
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+)
+
+// maxLine is the longest input line accepted.
+const maxLine = 16 * 1024 * 1024
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// frame is an input to decode.
+type frame struct {
+	data []byte
+	// trace is the recorded frame data came from, if any.
+	trace *recorder.Frame
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("sio-decode", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: sio-decode [flags] [FRAME...]\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	file := flags.String("file", "", "read frames from this file, one per line")
+	trace := flags.String("trace", "", "read the frames of a transport recording (JSON Lines)")
+	jsonOutput := flags.Bool("json", false, "print JSON Lines")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	sources := 0
+	for _, set := range []bool{*file != "", *trace != "", flags.NArg() > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		fmt.Fprintln(stderr, "use one of -file, -trace or FRAME arguments")
+		flags.Usage()
+		return exitUsage
+	}
+
+	var frames []frame
+	var err error
+	switch {
+	case flags.NArg() > 0:
+		for _, arg := range flags.Args() {
+			frames = append(frames, frame{data: unescape([]byte(arg))})
+		}
+	case *trace != "":
+		frames, err = readFile(*trace, readTrace)
+	case *file != "":
+		frames, err = readFile(*file, readLines)
+	default:
+		frames, err = readLines(stdin)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	code := exitOK
+	for i, f := range frames {
+		packets, err := socketio_v5_inspect.DecodePayload(f.data)
+		if err != nil {
+			code = exitInvalid
+		}
+		if *jsonOutput {
+			writeJSON(stdout, i+1, f, packets, err)
+		} else {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			writeText(stdout, i+1, f, packets, err)
+		}
+	}
+	return code
+}
+
+func readFile(path string, read func(io.Reader) ([]frame, error)) ([]frame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return read(file)
+}
+
+func readLines(r io.Reader) ([]frame, error) {
+	var frames []frame
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLine)
+	for scanner.Scan() {
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		if len(line) == 0 {
+			continue
+		}
+		frames = append(frames, frame{data: unescape(line)})
+	}
+	return frames, scanner.Err()
+}
+
+func readTrace(r io.Reader) ([]frame, error) {
+	var frames []frame
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var recorded recorder.Frame
+		if err := json.Unmarshal(scanner.Bytes(), &recorded); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+		frames = append(frames, frame{data: recorded.Data, trace: &recorded})
+	}
+	return frames, scanner.Err()
+}
+
+// unescape replaces the characters `\x1e`, as pasted from logs, with the
+// record separator. The sequence can't occur in valid JSON.
+func unescape(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte(`\x1e`), []byte{0x1e})
+}
+
+func writeText(w io.Writer, n int, f frame, packets []*socketio_v5_inspect.Packet, err error) {
+	title := fmt.Sprintf("frame %d", n)
+	if f.trace != nil {
+		title += fmt.Sprintf(" (%s %s %s)", f.trace.Direction, f.trace.Transport, f.trace.Time.Format("15:04:05.000"))
+	}
+	fmt.Fprintf(w, "%s: %s\n", title, display(f.data))
+
+	for _, packet := range packets {
+		if len(packets) > 1 || err != nil {
+			fmt.Fprintf(w, "-- packet at offset %d\n", packet.Offset)
+		}
+		_ = packet.WriteText(w)
+	}
+
+	if err != nil {
+		var frameErr *socketio_v5_inspect.FrameError
+		if errors.As(err, &frameErr) && utf8.Valid(f.data) {
+			// Point at the offending byte under the frame line.
+			column := utf8.RuneCount([]byte(title)) + 2 + utf8.RuneCount(f.data[:frameErr.Offset])
+			fmt.Fprintf(w, "%s^\n", strings.Repeat(" ", column))
+		}
+		fmt.Fprintf(w, "error: %v\n", err)
+	}
+}
+
+// display returns data for a single output line: the record separator is
+// shown as ␞, a single rune so error columns stay aligned.
+func display(data []byte) string {
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("<%d bytes of binary data>", len(data))
+	}
+	return strings.ReplaceAll(string(data), "\x1e", "␞")
+}
+
+type frameJSON struct {
+	Frame     int                           `json:"frame"`
+	Direction recorder.Direction            `json:"direction,omitempty"`
+	Transport string                        `json:"transport,omitempty"`
+	Packets   []*socketio_v5_inspect.Packet `json:"packets"`
+	Error     *errorJSON                    `json:"error,omitempty"`
+}
+
+type errorJSON struct {
+	Offset  int    `json:"offset"`
+	Layer   string `json:"layer,omitempty"`
+	Message string `json:"message"`
+}
+
+func writeJSON(w io.Writer, n int, f frame, packets []*socketio_v5_inspect.Packet, err error) {
+	out := frameJSON{Frame: n, Packets: packets}
+	if out.Packets == nil {
+		out.Packets = []*socketio_v5_inspect.Packet{}
+	}
+	if f.trace != nil {
+		out.Direction = f.trace.Direction
+		out.Transport = string(f.trace.Transport)
+	}
+	if err != nil {
+		out.Error = &errorJSON{Message: err.Error()}
+		var frameErr *socketio_v5_inspect.FrameError
+		if errors.As(err, &frameErr) {
+			out.Error = &errorJSON{Offset: frameErr.Offset, Layer: frameErr.Layer, Message: frameErr.Err.Error()}
+		}
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	"github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/recorder"
+)
+
+func runDecode(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Args(t *testing.T) {
+	code, stdout, stderr := runDecode(t, "", `42/admin,17["evt",{"a":1}]`)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, `frame 1: 42/admin,17["evt",{"a":1}]
+engine.io  message
+socket.io  EVENT
+namespace  /admin
+ack id     17
+event      evt
+args[0]    {
+             "a": 1
+           }
+`, stdout)
+}
+
+func TestRun_Stdin(t *testing.T) {
+	input := `451-["file",{"_placeholder":true,"num":0}]\x1ebAQID` + "\n\n" + "2probe\r\n"
+	code, stdout, stderr := runDecode(t, input)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "frame 1: 451-[\"file\",{\"_placeholder\":true,\"num\":0}]␞bAQID\n-- packet at offset 0\n")
+	assert.Contains(t, stdout, "socket.io  BINARY_EVENT, 1 attachments\n")
+	assert.Contains(t, stdout, "-- packet at offset 43\nbinary  3 bytes 01 02 03\n")
+	assert.Contains(t, stdout, "frame 2: 2probe\nengine.io  ping\ndata       probe\n")
+}
+
+func TestRun_Invalid(t *testing.T) {
+	code, stdout, _ := runDecode(t, "", "2", `42["evt",{"a":1]`)
+	assert.Equal(t, exitInvalid, code)
+	assert.Contains(t, stdout, "frame 2: 42[\"evt\",{\"a\":1]\n"+
+		"                        ^\n"+
+		"error: socket.io: offset 15: invalid JSON")
+
+	code, stdout, _ = runDecode(t, "", "--json", `42["evt",{"a":1]`)
+	assert.Equal(t, exitInvalid, code)
+	var out frameJSON
+	require.NoError(t, json.Unmarshal([]byte(stdout), &out))
+	require.NotNil(t, out.Error)
+	assert.Equal(t, 15, out.Error.Offset)
+	assert.Equal(t, "socket.io", out.Error.Layer)
+}
+
+func TestRun_FileAndTrace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "frames.txt")
+	require.NoError(t, os.WriteFile(path, []byte("40\n42[\"hi\"]\n"), 0o600))
+	code, stdout, stderr := runDecode(t, "", "--json", "--file", path)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout), "\n"), 2)
+
+	var trace bytes.Buffer
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, f := range []recorder.Frame{
+		{Time: now, Transport: engineio_v4.TransportWebsocket, Direction: recorder.Outbound, Data: []byte(`42["hi"]`)},
+		{Time: now, Transport: engineio_v4.TransportWebsocket, Direction: recorder.Inbound, Data: []byte{0xff, 0xfe}},
+	} {
+		line, err := json.Marshal(f)
+		require.NoError(t, err)
+		trace.Write(append(line, '\n'))
+	}
+	tracePath := filepath.Join(dir, "trace.jsonl")
+	require.NoError(t, os.WriteFile(tracePath, trace.Bytes(), 0o600))
+
+	code, stdout, stderr = runDecode(t, "", "--trace", tracePath)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "frame 1 (out websocket 03:04:05.000): 42[\"hi\"]\n")
+	assert.Contains(t, stdout, "frame 2 (in websocket 03:04:05.000): <2 bytes of binary data>\nbinary  2 bytes ff fe\n")
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, _, _ := runDecode(t, "", "--file", "a", "42[]")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runDecode(t, "", "--file", filepath.Join(t.TempDir(), "missing"))
+	assert.Equal(t, exitUsage, code)
+
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0o600))
+	code, _, stderr := runDecode(t, "", "--trace", path)
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "trace line 1")
+}
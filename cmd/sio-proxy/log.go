@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	socketio_v5_inspect "github.com/maldikhan/go.socket.io/socket.io/v5/inspect"
+)
+
+// Actions taken on a packet.
+const (
+	actionForward = "forward"
+	actionDrop    = "drop"
+	actionDelay   = "delay"
+)
+
+// packetLog writes a line per packet going through the proxy.
+type packetLog struct {
+	mu   sync.Mutex
+	w    io.Writer
+	json bool
+	now  func() time.Time
+}
+
+// entry is a packet seen by the proxy.
+type entry struct {
+	Time      time.Time                     `json:"time"`
+	Sid       string                        `json:"sid,omitempty"`
+	Transport engineio_v4.EngineIOTransport `json:"transport"`
+	Direction direction                     `json:"direction"`
+	Action    string                        `json:"action"`
+	Delay     string                        `json:"delay,omitempty"`
+	Packet    *socketio_v5_inspect.Packet   `json:"packet,omitempty"`
+	Raw       string                        `json:"raw,omitempty"` // of invalid packets
+	Error     string                        `json:"error,omitempty"`
+}
+
+func (l *packetLog) write(e entry) {
+	e.Time = l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.json {
+		_ = json.NewEncoder(l.w).Encode(e)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", e.Time.Format("15:04:05.000"), e.Transport, e.Direction)
+	if e.Sid != "" {
+		fmt.Fprintf(&b, " sid=%s", e.Sid)
+	}
+	b.WriteString("  ")
+	switch {
+	case e.Error != "":
+		fmt.Fprintf(&b, "invalid %q: %s", e.Raw, e.Error)
+	default:
+		b.WriteString(summary(e.Packet))
+	}
+	switch e.Action {
+	case actionDrop:
+		b.WriteString("  [dropped]")
+	case actionDelay:
+		fmt.Fprintf(&b, "  [delayed %s]", e.Delay)
+	}
+	b.WriteByte('\n')
+	_, _ = io.WriteString(l.w, b.String())
+}
+
+// summary describes a packet on one line.
+func summary(packet *socketio_v5_inspect.Packet) string {
+	if packet.Binary {
+		return fmt.Sprintf("binary %d bytes", len(packet.Data))
+	}
+	s := packet.SocketIO
+	if s == nil {
+		if len(packet.Data) > 0 {
+			return fmt.Sprintf("%s %s", packet.Engine, packet.Data)
+		}
+		return packet.Engine.String()
+	}
+
+	parts := []string{socketio_v5_inspect.PacketTypeName(s.Type), s.Namespace}
+	if s.AckID != nil {
+		parts = append(parts, fmt.Sprintf("ack=%d", *s.AckID))
+	}
+	if s.Attachments > 0 {
+		parts = append(parts, fmt.Sprintf("attachments=%d", s.Attachments))
+	}
+	if s.Event != "" {
+		parts = append(parts, s.Event)
+	}
+	if len(s.Args) > 0 {
+		args, _ := json.Marshal(s.Args)
+		parts = append(parts, string(args))
+	}
+	if len(s.Data) > 0 {
+		parts = append(parts, string(s.Data))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,112 @@
+// Command sio-proxy sits between a socket.io client and server, forwards
+// long-polling and websocket traffic, including upgrades, and logs every
+// engine.io and socket.io packet in both directions:
+//
+//	sio-proxy [flags] TARGET
+//
+// Point the client at the -listen address instead of TARGET, the server URL.
+// Events can be dropped (-drop) or held (-delay) by name, optionally for one
+// direction only: "client:" for those the client sends, "server:" for those
+// it receives. The exit status is 0 after an interrupt, 1 when serving fails
+// and 2 on bad usage.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+func main() {
+	log.SetOutput(os.Stderr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("sio-proxy", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: sio-proxy [flags] TARGET\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	listen := flags.String("listen", "localhost:8080", "address to listen on")
+	jsonOutput := flags.Bool("json", false, "log packets as JSON Lines")
+	var drops, delays listFlag
+	flags.Var(&drops, "drop", `drop the events "[client:|server:]EVENT", "*" for all, may be repeated`)
+	flags.Var(&delays, "delay", `hold the events "[client:|server:]EVENT=DURATION", may be repeated`)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	target, err := url.Parse(flags.Arg(0))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		fmt.Fprintf(stderr, "target must be an http or https URL, got %q\n", flags.Arg(0))
+		return exitUsage
+	}
+	var r rules
+	for _, value := range drops {
+		drop, err := parseRule(value)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		r.drop = append(r.drop, drop)
+	}
+	for _, value := range delays {
+		delay, err := parseDelayRule(value)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		r.delay = append(r.delay, delay)
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+	server := &http.Server{
+		Handler:           newProxy(target, &r, &packetLog{w: stdout, json: *jsonOutput, now: time.Now}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(stderr, "proxying http://%s to %s\n", listener.Addr(), target)
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+	select {
+	case err = <-served:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+		err = <-served
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maldikhan/go.socket.io/cmd/internal/cliclient"
+	socketio_v5_client "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+	socketio_v5_server "github.com/maldikhan/go.socket.io/socket.io/v5/server"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// syncBuffer is a bytes.Buffer safe for the proxy goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// entries decodes the JSON log.
+func (b *syncBuffer) entries(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var e map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &e), line)
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// findEvent returns the log entry of an event sent from a direction.
+func findEvent(entries []map[string]interface{}, from direction, event string) map[string]interface{} {
+	for _, e := range entries {
+		packet, _ := e["packet"].(map[string]interface{})
+		s, _ := packet["socketio"].(map[string]interface{})
+		if e["direction"] == string(from) && s["event"] == event {
+			return e
+		}
+	}
+	return nil
+}
+
+type fixture struct {
+	proxyURL string
+	log      *syncBuffer
+	received chan string
+}
+
+// newFixture starts a socket.io server that acks "join" and records the
+// events it receives, behind a proxy with rules.
+func newFixture(t *testing.T, r *rules) *fixture {
+	t.Helper()
+	server, err := socketio_v5_server.NewServer(socketio_v5_server.WithLogger(&utils.DefaultLogger{Level: utils.NONE}))
+	require.NoError(t, err)
+	target := httptest.NewServer(server)
+
+	f := &fixture{log: &syncBuffer{}, received: make(chan string, 10)}
+	server.OnConnection(func(socket *socketio_v5_server.Socket) {
+		socket.On("join", func(ack socketio_v5_server.Ack, args []interface{}) {
+			f.received <- "join"
+			_ = ack("ok")
+		})
+		socket.On("secret", func([]interface{}) { f.received <- "secret" })
+		socket.On("after", func([]interface{}) {
+			f.received <- "after"
+			_ = socket.Emit("news", "hello")
+			_ = socket.Emit("weather", "sunny")
+		})
+	})
+
+	targetURL, err := url.Parse(target.URL)
+	require.NoError(t, err)
+	proxyServer := httptest.NewServer(newProxy(targetURL, r, &packetLog{w: f.log, json: true, now: time.Now}))
+	f.proxyURL = proxyServer.URL
+	t.Cleanup(func() {
+		proxyServer.Close()
+		_ = server.Close()
+		target.Close()
+	})
+	return f
+}
+
+func connect(t *testing.T, rawURL, transport string) *socketio_v5_client.Client {
+	t.Helper()
+	client, err := cliclient.NewClient(rawURL, cliclient.Options{Transport: transport})
+	require.NoError(t, err)
+	require.NoError(t, cliclient.Connect(context.Background(), client, 5*time.Second))
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+		return ""
+	}
+}
+
+func TestProxy_Forward(t *testing.T) {
+	for _, transport := range []string{cliclient.TransportPolling, cliclient.TransportWebsocket, cliclient.TransportUpgrade} {
+		t.Run(transport, func(t *testing.T) {
+			f := newFixture(t, &rules{})
+			client := connect(t, f.proxyURL, transport)
+
+			news := make(chan string, 1)
+			client.On("news", func(text string) { news <- text })
+			acked := make(chan string, 1)
+			require.NoError(t, client.Emit("join", "room-1", emit.WithAck(func(status string) { acked <- status })))
+			assert.Equal(t, "ok", receive(t, acked))
+			require.NoError(t, client.Emit("after"))
+			assert.Equal(t, "hello", receive(t, news))
+
+			entries := f.log.entries(t)
+			join := findEvent(entries, fromClient, "join")
+			require.NotNil(t, join, f.log.String())
+			assert.Equal(t, actionForward, join["action"])
+			assert.NotEmpty(t, join["sid"])
+			assert.NotNil(t, findEvent(entries, fromServer, "news"))
+			if transport == cliclient.TransportUpgrade {
+				assert.Contains(t, f.log.String(), `"transport":"websocket","direction":"client","action":"forward","packet":{"offset":0,"engine":"ping","data":"probe"}`)
+			}
+		})
+	}
+}
+
+func TestProxy_Drop(t *testing.T) {
+	for _, transport := range []string{cliclient.TransportPolling, cliclient.TransportWebsocket} {
+		t.Run(transport, func(t *testing.T) {
+			f := newFixture(t, &rules{drop: []rule{{from: fromClient, event: "secret"}, {from: fromServer, event: "news"}}})
+			client := connect(t, f.proxyURL, transport)
+
+			weather := make(chan string, 1)
+			news := make(chan string, 1)
+			client.On("news", func(text string) { news <- text })
+			client.On("weather", func(text string) { weather <- text })
+			require.NoError(t, client.Emit("secret"))
+			require.NoError(t, client.Emit("after"))
+
+			// Events are delivered in order, so "secret" would be first.
+			assert.Equal(t, "after", receive(t, f.received))
+			assert.Equal(t, "sunny", receive(t, weather))
+			assert.Empty(t, news)
+
+			entries := f.log.entries(t)
+			assert.Equal(t, actionDrop, findEvent(entries, fromClient, "secret")["action"])
+			assert.Equal(t, actionDrop, findEvent(entries, fromServer, "news")["action"])
+		})
+	}
+}
+
+func TestProxy_Delay(t *testing.T) {
+	f := newFixture(t, &rules{delay: []rule{{from: fromClient, event: "join", delay: 200 * time.Millisecond}}})
+	client := connect(t, f.proxyURL, cliclient.TransportWebsocket)
+
+	acked := make(chan string, 1)
+	start := time.Now()
+	require.NoError(t, client.Emit("join", "room-1", emit.WithAck(func(status string) { acked <- status })))
+	assert.Equal(t, "ok", receive(t, acked))
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	join := findEvent(f.log.entries(t), fromClient, "join")
+	assert.Equal(t, actionDelay, join["action"])
+	assert.Equal(t, "200ms", join["delay"])
+}
+
+func TestPacketLog_Text(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	l := &packetLog{w: &buf, now: func() time.Time { return now }}
+	p := newProxy(&url.URL{Scheme: "http", Host: "localhost"}, &rules{drop: []rule{{event: "*"}}}, l)
+
+	s := &stream{transport: "polling", from: fromServer}
+	body, _ := p.handlePayload(s, []byte("0{\"sid\":\"abc\"}\x1e42/chat,3[\"news\",{\"a\":1}]\x1e451-[\"f\",{\"_placeholder\":true,\"num\":0}]\x1ebAQ==\x1e42[1]"))
+	assert.Equal(t, "0{\"sid\":\"abc\"}\x1e42[1]", string(body))
+	assert.Equal(t, `03:04:05.000 polling server>client sid=abc  open {"sid":"abc"}
+03:04:05.000 polling server>client sid=abc  EVENT /chat ack=3 news [{"a":1}]  [dropped]
+03:04:05.000 polling server>client sid=abc  BINARY_EVENT / attachments=1 f [{"_placeholder":true,"num":0}]  [dropped]
+03:04:05.000 polling server>client sid=abc  binary 1 bytes  [dropped]
+03:04:05.000 polling server>client sid=abc  invalid "42[1]": socket.io: offset 2: parse event error: json: cannot unmarshal number into Go value of type string
+`, buf.String())
+
+	// Nothing left to forward.
+	body, _ = p.handlePayload(&stream{transport: "polling", from: fromClient}, []byte(`42["x"]`))
+	assert.Equal(t, "6", string(body))
+}
+
+func TestParseRules(t *testing.T) {
+	r, err := parseRule("client:join")
+	require.NoError(t, err)
+	assert.Equal(t, rule{from: fromClient, event: "join"}, r)
+
+	// An unknown prefix is part of the name.
+	r, err = parseRule("chat:message")
+	require.NoError(t, err)
+	assert.Equal(t, rule{event: "chat:message"}, r)
+
+	_, err = parseRule("server:")
+	assert.Error(t, err)
+
+	r, err = parseDelayRule("server:*=1.5s")
+	require.NoError(t, err)
+	assert.Equal(t, rule{from: fromServer, event: "*", delay: 1500 * time.Millisecond}, r)
+
+	for _, value := range []string{"join", "join=soon", "join=-1s", "=1s"} {
+		_, err = parseDelayRule(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var stdout, stderr syncBuffer
+	assert.Equal(t, exitUsage, run(context.Background(), nil, &stdout, &stderr))
+	assert.Equal(t, exitUsage, run(context.Background(), []string{"ws://localhost"}, &stdout, &stderr))
+	assert.Equal(t, exitUsage, run(context.Background(), []string{"--drop", "server:", "http://localhost"}, &stdout, &stderr))
+	assert.Equal(t, exitUsage, run(context.Background(), []string{"--delay", "x", "http://localhost"}, &stdout, &stderr))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int, 1)
+	go func() { done <- run(ctx, []string{"--listen", "127.0.0.1:0", "http://localhost"}, &stdout, &stderr) }()
+	require.Eventually(t, func() bool { return strings.Contains(stderr.String(), "proxying") }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	assert.Equal(t, exitOK, <-done)
+}
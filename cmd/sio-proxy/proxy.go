@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	socketio_v5_inspect "github.com/maldikhan/go.socket.io/socket.io/v5/inspect"
+)
+
+// payloadSeparator joins the packets of a polling payload.
+const payloadSeparator = 0x1e
+
+// hopHeaders aren't copied to the upstream websocket handshake; the websocket
+// library sets its own.
+var hopHeaders = map[string]bool{
+	"Connection":               true,
+	"Upgrade":                  true,
+	"Host":                     true,
+	"Origin":                   true,
+	"Sec-Websocket-Key":        true,
+	"Sec-Websocket-Version":    true,
+	"Sec-Websocket-Extensions": true,
+	"Sec-Websocket-Protocol":   true,
+}
+
+// proxy forwards engine.io traffic to target, logging the packets and
+// applying the rules.
+type proxy struct {
+	target  *url.URL
+	rules   *rules
+	log     *packetLog
+	reverse *httputil.ReverseProxy
+}
+
+func newProxy(target *url.URL, rules *rules, log *packetLog) *proxy {
+	p := &proxy{target: target, rules: rules, log: log}
+	p.reverse = &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			p.rewriteURL(r.URL)
+			r.Host = target.Host
+			// Keep the bodies readable.
+			r.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: p.modifyResponse,
+	}
+	return p
+}
+
+func (p *proxy) rewriteURL(u *url.URL) {
+	u.Scheme = p.target.Scheme
+	u.Host = p.target.Host
+	if base := strings.TrimSuffix(p.target.Path, "/"); base != "" {
+		u.Path = base + u.Path
+	}
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.EqualFold(r.Header.Get("Upgrade"), "websocket"):
+		p.serveWebsocket(w, r)
+	case r.Method == http.MethodPost && isPolling(r):
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		stream := &stream{transport: engineio_v4.TransportPolling, from: fromClient, sid: r.URL.Query().Get("sid")}
+		body, delay := p.handlePayload(stream, body)
+		if !sleep(r.Context(), delay) {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		p.reverse.ServeHTTP(w, r)
+	default:
+		p.reverse.ServeHTTP(w, r)
+	}
+}
+
+func isPolling(r *http.Request) bool {
+	return r.URL.Query().Get("transport") == string(engineio_v4.TransportPolling)
+}
+
+// modifyResponse handles the payloads of polling GET responses.
+func (p *proxy) modifyResponse(resp *http.Response) error {
+	if resp.Request.Method != http.MethodGet || !isPolling(resp.Request) || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	stream := &stream{transport: engineio_v4.TransportPolling, from: fromServer, sid: resp.Request.URL.Query().Get("sid")}
+	body, delay := p.handlePayload(stream, body)
+	if !sleep(resp.Request.Context(), delay) {
+		return resp.Request.Context().Err()
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// stream is one direction of a connection.
+type stream struct {
+	transport engineio_v4.EngineIOTransport
+	from      direction
+
+	mu  sync.Mutex
+	sid string
+	// skip counts the attachments of a dropped binary packet still to drop.
+	skip int
+}
+
+func (s *stream) setSid(sid string) {
+	s.mu.Lock()
+	s.sid = sid
+	s.mu.Unlock()
+}
+
+func (s *stream) getSid() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sid
+}
+
+// handlePayload logs the packets of a polling payload and returns it without
+// the dropped ones, and how long to hold it.
+func (p *proxy) handlePayload(s *stream, body []byte) ([]byte, time.Duration) {
+	var kept [][]byte
+	var delay time.Duration
+	for _, raw := range bytes.Split(body, []byte{payloadSeparator}) {
+		packets, err := socketio_v5_inspect.DecodePayload(raw)
+		var packet *socketio_v5_inspect.Packet
+		if len(packets) > 0 {
+			packet = packets[0]
+		}
+		keep, d := p.handlePacket(s, raw, packet, err)
+		if keep {
+			kept = append(kept, raw)
+		}
+		if d > delay {
+			delay = d
+		}
+	}
+	if len(kept) == 0 {
+		// An empty payload is invalid, answer with a NOOP instead.
+		return []byte{'0' + byte(engineio_v4.PacketNoop)}, delay
+	}
+	return bytes.Join(kept, []byte{payloadSeparator}), delay
+}
+
+// handlePacket logs a packet and returns whether to forward it and how long
+// to hold it first. packet is nil when decoding failed with err.
+func (p *proxy) handlePacket(s *stream, raw []byte, packet *socketio_v5_inspect.Packet, err error) (bool, time.Duration) {
+	e := entry{Transport: s.transport, Direction: s.from, Action: actionForward, Packet: packet}
+	if err != nil {
+		e.Raw, e.Error = string(raw), err.Error()
+		e.Sid = s.getSid()
+		p.log.write(e)
+		return true, 0
+	}
+
+	if packet.Engine == engineio_v4.PacketOpen && !packet.Binary {
+		var handshake engineio_v4.HandshakeResponse
+		if json.Unmarshal(packet.Data, &handshake) == nil {
+			s.setSid(handshake.Sid)
+		}
+	}
+	e.Sid = s.getSid()
+
+	s.mu.Lock()
+	skipped := packet.Binary && s.skip > 0
+	if skipped {
+		s.skip--
+	}
+	s.mu.Unlock()
+
+	drop, delay := skipped, time.Duration(0)
+	if !skipped {
+		drop, delay = p.rules.apply(s.from, packet)
+	}
+	if drop && packet.SocketIO != nil && packet.SocketIO.Type == socketio_v5.PacketBinaryEvent {
+		s.mu.Lock()
+		s.skip = packet.SocketIO.Attachments
+		s.mu.Unlock()
+	}
+	switch {
+	case drop:
+		e.Action = actionDrop
+	case delay > 0:
+		e.Action, e.Delay = actionDelay, delay.String()
+	}
+	p.log.write(e)
+	return !drop, delay
+}
+
+// frame is a websocket message with its type.
+type frame struct {
+	data   []byte
+	binary bool
+}
+
+// frameCodec sends and receives frames keeping their type.
+var frameCodec = websocket.Codec{
+	Marshal: func(v interface{}) ([]byte, byte, error) {
+		f := v.(frame)
+		if f.binary {
+			return f.data, websocket.BinaryFrame, nil
+		}
+		return f.data, websocket.TextFrame, nil
+	},
+	Unmarshal: func(data []byte, payloadType byte, v interface{}) error {
+		f := v.(*frame)
+		f.data, f.binary = data, payloadType == websocket.BinaryFrame
+		return nil
+	},
+}
+
+// serveWebsocket connects to the target, then accepts the client connection
+// and forwards the frames both ways.
+func (p *proxy) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	upstreamURL := *r.URL
+	p.rewriteURL(&upstreamURL)
+	upstreamURL.Scheme = "ws"
+	if p.target.Scheme == "https" {
+		upstreamURL.Scheme = "wss"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = p.target.Scheme + "://" + p.target.Host
+	}
+	config, err := websocket.NewConfig(upstreamURL.String(), origin)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	for name, values := range r.Header {
+		if !hopHeaders[http.CanonicalHeaderKey(name)] {
+			config.Header[name] = values
+		}
+	}
+	upstream, err := config.DialContext(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	websocket.Server{
+		// Accept any origin, the target checks it.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(client *websocket.Conn) {
+			sid := r.URL.Query().Get("sid")
+			up := &stream{transport: engineio_v4.TransportWebsocket, from: fromClient, sid: sid}
+			down := &stream{transport: engineio_v4.TransportWebsocket, from: fromServer, sid: sid}
+
+			done := make(chan struct{}, 2)
+			go func() {
+				p.pump(r.Context(), client, upstream, up, down)
+				done <- struct{}{}
+			}()
+			go func() {
+				p.pump(r.Context(), upstream, client, down, up)
+				done <- struct{}{}
+			}()
+			// Either side closing closes the other.
+			<-done
+			_ = client.Close()
+			_ = upstream.Close()
+			<-done
+		},
+	}.ServeHTTP(w, r)
+	_ = upstream.Close()
+}
+
+// pump forwards frames from one side to the other. The sid learned from the
+// handshake is shared with the other direction.
+func (p *proxy) pump(ctx context.Context, from, to *websocket.Conn, s, other *stream) {
+	for {
+		var f frame
+		if err := frameCodec.Receive(from, &f); err != nil {
+			return
+		}
+
+		var keep bool
+		var delay time.Duration
+		if f.binary {
+			keep, delay = p.handlePacket(s, f.data, &socketio_v5_inspect.Packet{Raw: f.data, Binary: true, Data: f.data}, nil)
+		} else {
+			packet, err := socketio_v5_inspect.DecodePacket(f.data)
+			keep, delay = p.handlePacket(s, f.data, packet, err)
+			if err == nil && packet.Engine == engineio_v4.PacketOpen {
+				other.setSid(s.getSid())
+			}
+		}
+		if !sleep(ctx, delay) {
+			return
+		}
+		if !keep {
+			continue
+		}
+		if err := frameCodec.Send(to, f); err != nil {
+			return
+		}
+	}
+}
+
+// sleep waits for d and returns false if ctx was done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	socketio_v5_inspect "github.com/maldikhan/go.socket.io/socket.io/v5/inspect"
+)
+
+// direction is the way a packet travels through the proxy.
+type direction string
+
+const (
+	fromClient direction = "client" // sent by the client to the server
+	fromServer direction = "server" // sent by the server to the client
+)
+
+func (d direction) String() string {
+	if d == fromClient {
+		return "client>server"
+	}
+	return "server>client"
+}
+
+// rule matches socket.io events by name, "*" for any, sent in a direction,
+// either when empty.
+type rule struct {
+	from  direction
+	event string
+	delay time.Duration
+}
+
+func (r rule) matches(from direction, packet *socketio_v5_inspect.Packet) bool {
+	s := packet.SocketIO
+	if s == nil || (s.Type != socketio_v5.PacketEvent && s.Type != socketio_v5.PacketBinaryEvent) {
+		return false
+	}
+	return (r.from == "" || r.from == from) && (r.event == "*" || r.event == s.Event)
+}
+
+// parseRule parses "[client:|server:]EVENT".
+func parseRule(value string) (rule, error) {
+	r := rule{event: value}
+	if prefix, event, ok := strings.Cut(value, ":"); ok {
+		switch direction(prefix) {
+		case fromClient, fromServer:
+			r.from, r.event = direction(prefix), event
+		}
+	}
+	if r.event == "" {
+		return rule{}, fmt.Errorf("no event name in %q", value)
+	}
+	return r, nil
+}
+
+// parseDelayRule parses "[client:|server:]EVENT=DURATION".
+func parseDelayRule(value string) (rule, error) {
+	spec, duration, ok := strings.Cut(value, "=")
+	if !ok {
+		return rule{}, fmt.Errorf("invalid delay %q, want EVENT=DURATION", value)
+	}
+	r, err := parseRule(spec)
+	if err != nil {
+		return rule{}, err
+	}
+	if r.delay, err = time.ParseDuration(duration); err != nil || r.delay < 0 {
+		return rule{}, fmt.Errorf("invalid delay %q, want EVENT=DURATION", value)
+	}
+	return r, nil
+}
+
+// rules decide what happens to the events going through the proxy.
+type rules struct {
+	drop  []rule
+	delay []rule
+}
+
+// apply returns whether to drop the packet and how long to hold it first.
+func (r *rules) apply(from direction, packet *socketio_v5_inspect.Packet) (bool, time.Duration) {
+	for _, drop := range r.drop {
+		if drop.matches(from, packet) {
+			return true, 0
+		}
+	}
+	var delay time.Duration
+	for _, d := range r.delay {
+		if d.matches(from, packet) && d.delay > delay {
+			delay = d.delay
+		}
+	}
+	return false, delay
+}
+
+// listFlag is a flag that may be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
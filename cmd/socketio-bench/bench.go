@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/maldikhan/go.socket.io/cmd/internal/cliclient"
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	socketio_v5_client "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+)
+
+// Report is the outcome of a run.
+type Report struct {
+	Clients        int            `json:"clients"` // clients started
+	Connected      int64          `json:"connected"`
+	ConnectErrors  int64          `json:"connectErrors"`
+	Disconnects    int64          `json:"disconnects"` // connections lost before the end of the run
+	Emits          int64          `json:"emits"`
+	EmitErrors     int64          `json:"emitErrors"`
+	Acks           int64          `json:"acks"`
+	AckTimeouts    int64          `json:"ackTimeouts"`
+	Elapsed        Duration       `json:"elapsed"`
+	EmitsPerSecond float64        `json:"emitsPerSecond"`
+	AcksPerSecond  float64        `json:"acksPerSecond"`
+	ConnectTime    Summary        `json:"connectTime"`
+	AckLatency     Summary        `json:"ackLatency"`
+	Errors         map[string]int `json:"errors,omitempty"` // error messages and their counts
+}
+
+// bench runs a scenario.
+type bench struct {
+	scenario *Scenario
+	options  cliclient.Options
+
+	connectTimes samples
+	ackLatencies samples
+
+	connected     int64
+	connectErrors int64
+	disconnects   int64
+	emits         int64
+	emitErrors    int64
+	acks          int64
+	ackTimeouts   int64
+
+	errorsMu sync.Mutex
+	errors   map[string]int
+}
+
+func newBench(scenario *Scenario, options cliclient.Options) *bench {
+	return &bench{
+		scenario: scenario,
+		options:  options,
+		errors:   make(map[string]int),
+	}
+}
+
+func (b *bench) fail(counter *int64, err error) {
+	atomic.AddInt64(counter, 1)
+	b.errorsMu.Lock()
+	b.errors[err.Error()]++
+	b.errorsMu.Unlock()
+}
+
+// run starts the clients at the ramp rate and has them emit until the
+// scenario duration elapses or ctx is done.
+func (b *bench) run(ctx context.Context) *Report {
+	start := time.Now()
+	emitCtx, cancel := context.WithDeadline(ctx, start.Add(time.Duration(b.scenario.Duration)))
+	defer cancel()
+
+	var wg sync.WaitGroup
+	started := 0
+	ramp := time.Duration(float64(time.Second) / b.scenario.RampRate)
+ramping:
+	for i := 0; i < b.scenario.Clients; i++ {
+		if i > 0 {
+			timer := time.NewTimer(time.Until(start.Add(time.Duration(i) * ramp)))
+			select {
+			case <-timer.C:
+			case <-emitCtx.Done():
+				timer.Stop()
+				break ramping
+			}
+		}
+		started++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.runClient(ctx, emitCtx)
+		}()
+	}
+	<-emitCtx.Done()
+	elapsed := time.Since(start)
+	wg.Wait()
+
+	report := &Report{
+		Clients:       started,
+		Connected:     atomic.LoadInt64(&b.connected),
+		ConnectErrors: atomic.LoadInt64(&b.connectErrors),
+		Disconnects:   atomic.LoadInt64(&b.disconnects),
+		Emits:         atomic.LoadInt64(&b.emits),
+		EmitErrors:    atomic.LoadInt64(&b.emitErrors),
+		Acks:          atomic.LoadInt64(&b.acks),
+		AckTimeouts:   atomic.LoadInt64(&b.ackTimeouts),
+		Elapsed:       Duration(elapsed),
+		ConnectTime:   b.connectTimes.summary(),
+		AckLatency:    b.ackLatencies.summary(),
+	}
+	report.EmitsPerSecond = float64(report.Emits) / elapsed.Seconds()
+	report.AcksPerSecond = float64(report.Acks) / elapsed.Seconds()
+	b.errorsMu.Lock()
+	if len(b.errors) > 0 {
+		report.Errors = b.errors
+	}
+	b.errorsMu.Unlock()
+	return report
+}
+
+// runClient connects a client and emits the scenario events until emitCtx is
+// done or the connection is lost, then waits for the outstanding acks.
+func (b *bench) runClient(ctx, emitCtx context.Context) {
+	client, err := cliclient.NewClient(b.scenario.URL, b.options)
+	if err != nil {
+		b.fail(&b.connectErrors, err)
+		return
+	}
+
+	var closing int32
+	lost := make(chan struct{})
+	var lostOnce sync.Once
+	onLost := func() {
+		if atomic.LoadInt32(&closing) == 0 {
+			lostOnce.Do(func() {
+				atomic.AddInt64(&b.disconnects, 1)
+				close(lost)
+			})
+		}
+	}
+	client.On("disconnect", func([]interface{}) { onLost() })
+
+	connectStart := time.Now()
+	if err := cliclient.Connect(ctx, client, time.Duration(b.scenario.ConnectTimeout)); err != nil {
+		b.fail(&b.connectErrors, err)
+		atomic.StoreInt32(&closing, 1)
+		_ = client.Close()
+		return
+	}
+	b.connectTimes.add(time.Since(connectStart))
+	atomic.AddInt64(&b.connected, 1)
+	go func() {
+		for {
+			state, changed := client.WatchState()
+			if state == engineio_v4.StateClosed {
+				onLost()
+				return
+			}
+			<-changed
+		}
+	}()
+
+	var pending sync.WaitGroup
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / b.scenario.RPS))
+	defer ticker.Stop()
+emitting:
+	for i := 0; ; i++ {
+		select {
+		case <-emitCtx.Done():
+			break emitting
+		case <-lost:
+			break emitting
+		case <-ticker.C:
+		}
+		b.emit(emitCtx, client, b.scenario.Events[i%len(b.scenario.Events)], &pending)
+	}
+
+	acked := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(acked)
+	}()
+	select {
+	case <-acked:
+	case <-time.After(time.Duration(b.scenario.AckTimeout)):
+	}
+	atomic.StoreInt32(&closing, 1)
+	_ = client.Close()
+}
+
+func (b *bench) emit(ctx context.Context, client *socketio_v5_client.Client, event ScenarioEvent, pending *sync.WaitGroup) {
+	args := make([]interface{}, 0, len(event.Args)+3)
+	for _, arg := range event.Args {
+		args = append(args, json.RawMessage(arg))
+	}
+	args = append(args, emit.WithContext(ctx))
+
+	if event.wantsAck() {
+		sent := time.Now()
+		var once sync.Once
+		pending.Add(1)
+		args = append(args,
+			emit.WithAck(func([]interface{}) {
+				once.Do(func() {
+					b.ackLatencies.add(time.Since(sent))
+					atomic.AddInt64(&b.acks, 1)
+					pending.Done()
+				})
+			}),
+			emit.WithTimeout(time.Duration(b.scenario.AckTimeout), func() {
+				once.Do(func() {
+					atomic.AddInt64(&b.ackTimeouts, 1)
+					pending.Done()
+				})
+			}),
+		)
+		if err := client.Emit(event.Name, args...); err != nil {
+			b.fail(&b.emitErrors, err)
+			once.Do(pending.Done)
+			return
+		}
+	} else if err := client.Emit(event.Name, args...); err != nil {
+		b.fail(&b.emitErrors, err)
+		return
+	}
+	atomic.AddInt64(&b.emits, 1)
+}
@@ -0,0 +1,202 @@
+// Command socketio-bench load tests a socket.io server. It ramps up clients
+// at a given rate, has each of them emit events at a target rate with acks
+// and reports connect times, ack latency percentiles, error and disconnect
+// counts and throughput:
+//
+//	socketio-bench [flags] [URL]
+//
+// The run may be described by a JSON scenario file (-scenario); flags set on
+// the command line override it. The exit status is 0 when the run completed,
+// 2 on bad usage and 3 when no client connected.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maldikhan/go.socket.io/cmd/internal/cliclient"
+)
+
+const (
+	exitOK      = 0
+	exitUsage   = 2
+	exitConnect = 3
+)
+
+func main() {
+	log.SetOutput(os.Stderr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("socketio-bench", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: socketio-bench [flags] [URL]\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	var conn cliclient.Flags
+	conn.Register(flags)
+	defaults := defaultScenario()
+	scenarioPath := flags.String("scenario", "", "JSON scenario file, overridden by the flags set")
+	clients := flags.Int("clients", defaults.Clients, "number of clients")
+	rampRate := flags.Float64("rate", defaults.RampRate, "clients started per second")
+	duration := flags.Duration("duration", time.Duration(defaults.Duration), "run time, from the first client start")
+	rps := flags.Float64("rps", defaults.RPS, "emits per second of each client")
+	event := flags.String("event", defaults.Events[0].Name, "event to emit")
+	eventArgs := flags.String("args", "", "event arguments, a JSON array")
+	ack := flags.Bool("ack", true, "request acks")
+	ackTimeout := flags.Duration("ack-timeout", time.Duration(defaults.AckTimeout), "time to wait for an ack")
+	jsonOutput := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	scenario := defaults
+	if *scenarioPath != "" {
+		if err := loadScenario(*scenarioPath, scenario); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+	}
+	if flags.NArg() == 1 {
+		scenario.URL = flags.Arg(0)
+	}
+	options, err := conn.Options()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["transport"] || scenario.Transport == "" {
+		scenario.Transport = conn.Transport
+	}
+	if set["namespace"] || scenario.Namespace == "" {
+		scenario.Namespace = conn.Namespace
+	}
+	if set["connect-timeout"] {
+		scenario.ConnectTimeout = Duration(conn.ConnectTimeout)
+	}
+	if set["clients"] {
+		scenario.Clients = *clients
+	}
+	if set["rate"] {
+		scenario.RampRate = *rampRate
+	}
+	if set["duration"] {
+		scenario.Duration = Duration(*duration)
+	}
+	if set["rps"] {
+		scenario.RPS = *rps
+	}
+	if set["ack-timeout"] {
+		scenario.AckTimeout = Duration(*ackTimeout)
+	}
+	if set["event"] || set["args"] {
+		e := ScenarioEvent{Name: *event}
+		if *eventArgs != "" {
+			if err := json.Unmarshal([]byte(*eventArgs), &e.Args); err != nil {
+				fmt.Fprintf(stderr, "args must be a JSON array: %q\n", *eventArgs)
+				return exitUsage
+			}
+		}
+		scenario.Events = []ScenarioEvent{e}
+	}
+	if set["ack"] {
+		for i := range scenario.Events {
+			scenario.Events[i].Ack = ack
+		}
+	}
+	if err := scenario.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		flags.Usage()
+		return exitUsage
+	}
+
+	options.Transport = scenario.Transport
+	options.Namespace = scenario.Namespace
+	for name, value := range scenario.Headers {
+		if len(options.Header.Values(name)) == 0 {
+			options.Header.Set(name, value)
+		}
+	}
+	for name, value := range scenario.Query {
+		if !options.Query.Has(name) {
+			options.Query.Set(name, value)
+		}
+	}
+	if options.Auth == nil {
+		options.Auth = scenario.Auth
+	}
+	// Fail on a bad URL or transport before starting any client.
+	if _, err := cliclient.NewClient(scenario.URL, options); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	report := newBench(scenario, options).run(ctx)
+	if *jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	} else {
+		printReport(stdout, report)
+	}
+	if report.Connected == 0 {
+		return exitConnect
+	}
+	return exitOK
+}
+
+func printReport(w io.Writer, report *Report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "clients\t%d started, %d connected, %d connect errors, %d disconnects\n",
+		report.Clients, report.Connected, report.ConnectErrors, report.Disconnects)
+	fmt.Fprintf(tw, "emits\t%d sent, %d errors, %.1f/s\n", report.Emits, report.EmitErrors, report.EmitsPerSecond)
+	fmt.Fprintf(tw, "acks\t%d received, %d timed out, %.1f/s\n", report.Acks, report.AckTimeouts, report.AcksPerSecond)
+	fmt.Fprintf(tw, "elapsed\t%s\n", time.Duration(report.Elapsed))
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "\tcount\tmin\tmean\tp50\tp90\tp99\tmax")
+	for _, row := range []struct {
+		name    string
+		summary Summary
+	}{
+		{"connect", report.ConnectTime},
+		{"ack latency", report.AckLatency},
+	} {
+		s := row.summary
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", row.name, s.Count,
+			time.Duration(s.Min), time.Duration(s.Mean), time.Duration(s.P50),
+			time.Duration(s.P90), time.Duration(s.P99), time.Duration(s.Max))
+	}
+	if len(report.Errors) > 0 {
+		fmt.Fprintln(tw)
+		messages := make([]string, 0, len(report.Errors))
+		for message := range report.Errors {
+			messages = append(messages, message)
+		}
+		sort.Strings(messages)
+		for _, message := range messages {
+			fmt.Fprintf(tw, "error\t%d\t%s\n", report.Errors[message], message)
+		}
+	}
+	_ = tw.Flush()
+}
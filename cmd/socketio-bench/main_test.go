@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	socketio_v5_server "github.com/maldikhan/go.socket.io/socket.io/v5/server"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+func newServer(t *testing.T) (*socketio_v5_server.Server, *httptest.Server) {
+	t.Helper()
+	server, err := socketio_v5_server.NewServer(socketio_v5_server.WithLogger(&utils.DefaultLogger{Level: utils.NONE}))
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		_ = server.Close()
+		httpServer.Close()
+	})
+	return server, httpServer
+}
+
+func runBench(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	code := run(ctx, args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	server, httpServer := newServer(t)
+	var received int64
+	server.OnConnection(func(socket *socketio_v5_server.Socket) {
+		socket.On("ping", func(ack socketio_v5_server.Ack, args []interface{}) {
+			atomic.AddInt64(&received, 1)
+			if ack != nil {
+				_ = ack(args...)
+			}
+		})
+	})
+
+	for _, transport := range []string{"polling", "websocket"} {
+		t.Run(transport, func(t *testing.T) {
+			code, stdout, stderr := runBench(t, "--json", "--transport", transport,
+				"--clients", "4", "--rate", "40", "--duration", "600ms", "--rps", "20",
+				"--args", `["hello", {"n": 1}]`, httpServer.URL)
+			require.Equal(t, exitOK, code, stderr)
+
+			var report Report
+			require.NoError(t, json.Unmarshal([]byte(stdout), &report), stdout)
+			assert.Equal(t, 4, report.Clients)
+			assert.EqualValues(t, 4, report.Connected)
+			assert.Zero(t, report.ConnectErrors)
+			assert.Zero(t, report.Disconnects)
+			assert.Zero(t, report.AckTimeouts)
+			assert.Positive(t, report.Emits)
+			assert.Equal(t, report.Emits, report.Acks)
+			assert.Equal(t, 4, report.ConnectTime.Count)
+			assert.EqualValues(t, report.Acks, report.AckLatency.Count)
+			assert.LessOrEqual(t, report.AckLatency.P50, report.AckLatency.P99)
+			assert.Positive(t, report.AcksPerSecond)
+		})
+	}
+	assert.Positive(t, atomic.LoadInt64(&received))
+}
+
+func TestRun_Scenario(t *testing.T) {
+	server, httpServer := newServer(t)
+	events := make(chan string, 100)
+	server.Of("/bench").OnConnection(func(socket *socketio_v5_server.Socket) {
+		if socket.Request().Header.Get("X-Token") != "secret" {
+			_ = socket.Disconnect(false)
+			return
+		}
+		socket.On("join", func(ack socketio_v5_server.Ack, args []interface{}) {
+			events <- "join"
+			_ = ack("ok")
+		})
+		socket.On("say", func(args []interface{}) { events <- "say" })
+	})
+
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"url": "`+httpServer.URL+`",
+		"transport": "websocket",
+		"namespace": "/bench",
+		"headers": {"x-token": "secret"},
+		"clients": 100,
+		"rampRate": 100,
+		"duration": "500ms",
+		"rps": 20,
+		"ackTimeout": "1s",
+		"events": [
+			{"name": "join", "args": ["room"]},
+			{"name": "say", "args": ["hi"], "ack": false}
+		]
+	}`), 0o600))
+
+	// The flag overrides the scenario.
+	code, stdout, stderr := runBench(t, "--scenario", path, "--clients", "2")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "2 started, 2 connected")
+	assert.Contains(t, stdout, "ack latency")
+
+	seen := make(map[string]bool)
+	for len(events) > 0 {
+		seen[<-events] = true
+	}
+	assert.True(t, seen["join"])
+	assert.True(t, seen["say"])
+}
+
+func TestRun_Errors(t *testing.T) {
+	code, _, _ := runBench(t)
+	assert.Equal(t, exitUsage, code)
+
+	code, _, stderr := runBench(t, "--rps", "0", "http://localhost")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "rps must be positive")
+
+	code, _, _ = runBench(t, "--args", "not json", "http://localhost")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runBench(t, "--scenario", filepath.Join(t.TempDir(), "missing.json"))
+	assert.Equal(t, exitUsage, code)
+
+	// Nothing listens on the port of a closed server.
+	closed := httptest.NewServer(nil)
+	closed.Close()
+	code, stdout, _ := runBench(t, "--json", "--clients", "2", "--rate", "100", "--duration", "300ms",
+		"--connect-timeout", "200ms", closed.URL)
+	assert.Equal(t, exitConnect, code)
+	var report Report
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.EqualValues(t, 2, report.ConnectErrors)
+	assert.NotEmpty(t, report.Errors)
+}
+
+func TestSamples(t *testing.T) {
+	var s samples
+	assert.Equal(t, Summary{}, s.summary())
+
+	for i := 100; i >= 1; i-- {
+		s.add(time.Duration(i) * time.Millisecond)
+	}
+	summary := s.summary()
+	assert.Equal(t, 100, summary.Count)
+	assert.Equal(t, Duration(time.Millisecond), summary.Min)
+	assert.Equal(t, Duration(50*time.Millisecond), summary.P50)
+	assert.Equal(t, Duration(90*time.Millisecond), summary.P90)
+	assert.Equal(t, Duration(99*time.Millisecond), summary.P99)
+	assert.Equal(t, Duration(100*time.Millisecond), summary.Max)
+	assert.Equal(t, Duration(50500*time.Microsecond), summary.Mean)
+}
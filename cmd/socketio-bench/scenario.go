@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Scenario describes a benchmark run. It is read from the -scenario file;
+// flags set on the command line override it.
+type Scenario struct {
+	URL            string                 `json:"url"`
+	Transport      string                 `json:"transport,omitempty"`
+	Namespace      string                 `json:"namespace,omitempty"`
+	Auth           map[string]interface{} `json:"auth,omitempty"`
+	Headers        map[string]string      `json:"headers,omitempty"`
+	Query          map[string]string      `json:"query,omitempty"`
+	Clients        int                    `json:"clients"`
+	RampRate       float64                `json:"rampRate"` // clients started per second
+	Duration       Duration               `json:"duration"` // from the first client start
+	RPS            float64                `json:"rps"`      // emits per second of each client
+	AckTimeout     Duration               `json:"ackTimeout"`
+	ConnectTimeout Duration               `json:"connectTimeout"`
+	// Events are emitted by every client in turn, starting over after the
+	// last one.
+	Events []ScenarioEvent `json:"events"`
+}
+
+// ScenarioEvent is an event the clients emit.
+type ScenarioEvent struct {
+	Name string            `json:"name"`
+	Args []json.RawMessage `json:"args,omitempty"`
+	// Ack requests an acknowledgement, true when omitted.
+	Ack *bool `json:"ack,omitempty"`
+}
+
+func (e ScenarioEvent) wantsAck() bool {
+	return e.Ack == nil || *e.Ack
+}
+
+// Duration is a time.Duration written as a string like "30s" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func defaultScenario() *Scenario {
+	return &Scenario{
+		Clients:        10,
+		RampRate:       10,
+		Duration:       Duration(30 * time.Second),
+		RPS:            1,
+		AckTimeout:     Duration(5 * time.Second),
+		ConnectTimeout: Duration(10 * time.Second),
+		Events:         []ScenarioEvent{{Name: "ping"}},
+	}
+}
+
+// loadScenario reads path over the scenario defaults.
+func loadScenario(path string, scenario *Scenario) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, scenario); err != nil {
+		return fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *Scenario) validate() error {
+	switch {
+	case s.URL == "":
+		return errors.New("no URL")
+	case s.Clients <= 0:
+		return errors.New("clients must be positive")
+	case s.RampRate <= 0:
+		return errors.New("ramp rate must be positive")
+	case s.Duration <= 0:
+		return errors.New("duration must be positive")
+	case s.RPS <= 0:
+		return errors.New("rps must be positive")
+	case s.AckTimeout <= 0 || s.ConnectTimeout <= 0:
+		return errors.New("timeouts must be positive")
+	case len(s.Events) == 0:
+		return errors.New("no events")
+	}
+	for i, event := range s.Events {
+		if event.Name == "" {
+			return fmt.Errorf("event %d has no name", i)
+		}
+	}
+	return nil
+}
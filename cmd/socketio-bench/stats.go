@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// samples collects durations from several goroutines.
+type samples struct {
+	mu     sync.Mutex
+	values []time.Duration
+}
+
+func (s *samples) add(d time.Duration) {
+	s.mu.Lock()
+	s.values = append(s.values, d)
+	s.mu.Unlock()
+}
+
+// Summary describes a set of durations.
+type Summary struct {
+	Count int      `json:"count"`
+	Min   Duration `json:"min"`
+	Mean  Duration `json:"mean"`
+	P50   Duration `json:"p50"`
+	P90   Duration `json:"p90"`
+	P99   Duration `json:"p99"`
+	Max   Duration `json:"max"`
+}
+
+func (s *samples) summary() Summary {
+	s.mu.Lock()
+	values := append([]time.Duration(nil), s.values...)
+	s.mu.Unlock()
+
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var total time.Duration
+	for _, v := range values {
+		total += v
+	}
+	return Summary{
+		Count: len(values),
+		Min:   Duration(values[0]),
+		Mean:  Duration(total / time.Duration(len(values))),
+		P50:   Duration(percentile(values, 50)),
+		P90:   Duration(percentile(values, 90)),
+		P99:   Duration(percentile(values, 99)),
+		Max:   Duration(values[len(values)-1]),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/maldikhan/go.socket.io/cmd/internal/cliclient"
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	socketio_v5_client "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+)
+
+// output writes JSON Lines from several goroutines.
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// eventLine is a received event, ack or disconnect as printed.
+type eventLine struct {
+	Time      time.Time     `json:"time"`
+	Namespace string        `json:"namespace"`
+	Event     string        `json:"event,omitempty"`
+	Ack       bool          `json:"ack,omitempty"`
+	Args      []interface{} `json:"args"`
+}
+
+func (o *output) line(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, _ = o.w.Write(append(data, '\n'))
+}
+
+// parseArgs returns each argument as JSON when it is valid JSON, or as a
+// string.
+func parseArgs(args []string) []interface{} {
+	parsed := make([]interface{}, len(args))
+	for i, arg := range args {
+		if json.Valid([]byte(arg)) {
+			parsed[i] = json.RawMessage(arg)
+		} else {
+			parsed[i] = arg
+		}
+	}
+	return parsed
+}
+
+// parseLineArgs parses a stream of JSON values, or takes the whole line as a
+// string when it isn't one.
+func parseLineArgs(line string) []interface{} {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	var args []interface{}
+	decoder := json.NewDecoder(strings.NewReader(line))
+	for decoder.More() {
+		var arg json.RawMessage
+		if err := decoder.Decode(&arg); err != nil {
+			return []interface{}{line}
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// watch prints the events of the namespace, calls onEvent after each one
+// when set, and closes disconnected on a disconnect.
+func watch(client *socketio_v5_client.Client, namespace string, out *output, onEvent func(), disconnected chan<- struct{}) {
+	client.OnAny(func(event string, args []interface{}) {
+		out.line(eventLine{Time: time.Now(), Namespace: namespace, Event: event, Args: args})
+		if onEvent != nil {
+			onEvent()
+		}
+	})
+	var once sync.Once
+	client.On("disconnect", func(args []interface{}) {
+		out.line(eventLine{Time: time.Now(), Namespace: namespace, Event: "disconnect", Args: args})
+		once.Do(func() { close(disconnected) })
+	})
+}
+
+// engineClosed returns a channel closed once the engine.io connection of a
+// connected client is closed.
+func engineClosed(client *socketio_v5_client.Client) <-chan struct{} {
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			state, changed := client.WatchState()
+			if state == engineio_v4.StateClosed {
+				return
+			}
+			<-changed
+		}
+	}()
+	return closed
+}
+
+func listen(ctx context.Context, args []string, _ io.Reader, out *output, stderr io.Writer) int {
+	flags := newFlagSet("listen", "listen [flags] URL", stderr)
+	var conn cliclient.Flags
+	conn.Register(flags)
+	count := flags.Int("count", 0, "exit after this many events, 0 for no limit")
+
+	reached := make(chan struct{})
+	disconnected := make(chan struct{})
+	var received int64
+	var once sync.Once
+	onEvent := func() {
+		if *count > 0 && atomic.AddInt64(&received, 1) >= int64(*count) {
+			once.Do(func() { close(reached) })
+		}
+	}
+
+	client, _, code := connectClient(ctx, flags, &conn, args, 1, stderr, func(client *socketio_v5_client.Client) {
+		watch(client, conn.Namespace, out, onEvent, disconnected)
+	})
+	if client == nil {
+		return code
+	}
+	defer client.Close()
+
+	closed := engineClosed(client)
+	select {
+	case <-reached:
+		return exitOK
+	case <-disconnected:
+		fmt.Fprintln(stderr, "disconnected")
+		return exitFailure
+	case <-closed:
+		fmt.Fprintln(stderr, "connection closed")
+		return exitFailure
+	case <-ctx.Done():
+		return exitOK
+	}
+}
+
+func emitCommand(ctx context.Context, args []string, _ io.Reader, out *output, stderr io.Writer) int {
+	flags := newFlagSet("emit", "emit [flags] URL EVENT [ARG...]", stderr)
+	var conn cliclient.Flags
+	conn.Register(flags)
+	ack := flags.Bool("ack", false, "wait for the ack and print its arguments")
+	timeout := flags.Duration("timeout", 5*time.Second, "time to wait for the ack")
+
+	client, rest, code := connectClient(ctx, flags, &conn, args, 2, stderr, nil)
+	if client == nil {
+		return code
+	}
+	defer client.Close()
+
+	event, eventArgs := rest[0], parseArgs(rest[1:])
+	if !*ack {
+		if err := client.Emit(event, append(eventArgs, emit.WithContext(ctx))...); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailure
+		}
+		return exitOK
+	}
+
+	if err := emitWithAck(ctx, client, conn.Namespace, event, eventArgs, *timeout, out); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// emitWithAck emits event and prints the ack arguments once they arrive.
+func emitWithAck(ctx context.Context, client *socketio_v5_client.Client, namespace, event string, args []interface{}, timeout time.Duration, out *output) error {
+	acked := make(chan []interface{}, 1)
+	timedOut := make(chan struct{}, 1)
+	err := client.Emit(event, append(args,
+		emit.WithContext(ctx),
+		emit.WithAck(func(ackArgs []interface{}) { acked <- ackArgs }),
+		emit.WithTimeout(timeout, func() { timedOut <- struct{}{} }),
+	)...)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case ackArgs := <-acked:
+		out.line(eventLine{Time: time.Now(), Namespace: namespace, Event: event, Ack: true, Args: ackArgs})
+		return nil
+	case <-timedOut:
+		return fmt.Errorf("no ack for %s within %s", event, timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+const replHelp = `Type an event name followed by its arguments, e.g.
+  chat {"text": "hello"} 1
+Arguments are a sequence of JSON values, or a single string otherwise.
+Commands:
+  /ack EVENT [ARG...]  emit and wait for the ack
+  /help                show this help
+  /quit                disconnect and exit
+Received events are printed as JSON Lines.
+`
+
+func repl(ctx context.Context, args []string, stdin io.Reader, out *output, stderr io.Writer) int {
+	flags := newFlagSet("repl", "repl [flags] URL", stderr)
+	var conn cliclient.Flags
+	conn.Register(flags)
+	timeout := flags.Duration("timeout", 5*time.Second, "time to wait for an ack")
+
+	disconnected := make(chan struct{})
+	client, _, code := connectClient(ctx, flags, &conn, args, 1, stderr, func(client *socketio_v5_client.Client) {
+		watch(client, conn.Namespace, out, nil, disconnected)
+	})
+	if client == nil {
+		return code
+	}
+	defer client.Close()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdin)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	closed := engineClosed(client)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return exitOK
+			}
+			if quit := replLine(ctx, client, conn.Namespace, line, *timeout, out, stderr); quit {
+				return exitOK
+			}
+		case <-disconnected:
+			fmt.Fprintln(stderr, "disconnected")
+			return exitFailure
+		case <-closed:
+			fmt.Fprintln(stderr, "connection closed")
+			return exitFailure
+		case <-ctx.Done():
+			return exitOK
+		}
+	}
+}
+
+// replLine runs one line of input and reports whether to quit.
+func replLine(ctx context.Context, client *socketio_v5_client.Client, namespace, line string, timeout time.Duration, out *output, stderr io.Writer) bool {
+	line = strings.TrimSpace(line)
+	withAck := false
+	switch {
+	case line == "":
+		return false
+	case line == "/quit":
+		return true
+	case line == "/help":
+		fmt.Fprint(stderr, replHelp)
+		return false
+	case strings.HasPrefix(line, "/ack "):
+		withAck = true
+		line = strings.TrimSpace(strings.TrimPrefix(line, "/ack "))
+	case strings.HasPrefix(line, "/"):
+		fmt.Fprintf(stderr, "unknown command %s, try /help\n", line)
+		return false
+	}
+
+	event, rest := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		event, rest = line[:i], line[i+1:]
+	}
+	args := parseLineArgs(rest)
+
+	var err error
+	if withAck {
+		err = emitWithAck(ctx, client, namespace, event, args, timeout, out)
+	} else {
+		err = client.Emit(event, append(args, emit.WithContext(ctx))...)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/maldikhan/go.socket.io/cmd/internal/cliclient"
+	socketio_v5_client "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+)
+
+// connectClient parses the flags common to every command, passes the client
+// to setup, when set, to register handlers, then connects and returns the
+// client and the remaining arguments, or the exit status.
+func connectClient(
+	ctx context.Context,
+	flags *flag.FlagSet,
+	conn *cliclient.Flags,
+	args []string,
+	minArgs int,
+	stderr io.Writer,
+	setup func(*socketio_v5_client.Client),
+) (*socketio_v5_client.Client, []string, int) {
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, exitUsage
+	}
+	if flags.NArg() < minArgs {
+		flags.Usage()
+		return nil, nil, exitUsage
+	}
+
+	options, err := conn.Options()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return nil, nil, exitUsage
+	}
+	client, err := cliclient.NewClient(flags.Arg(0), options)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return nil, nil, exitUsage
+	}
+	if setup != nil {
+		setup(client)
+	}
+	if err := cliclient.Connect(ctx, client, conn.ConnectTimeout); err != nil {
+		fmt.Fprintln(stderr, err)
+		_ = client.Close()
+		return nil, nil, exitConnect
+	}
+	return client, flags.Args()[1:], exitOK
+}
+
+func newFlagSet(name, synopsis string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: socketio %s\n\nFlags:\n", synopsis)
+		flags.PrintDefaults()
+	}
+	return flags
+}
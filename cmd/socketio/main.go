@@ -0,0 +1,69 @@
+// Command socketio connects to a socket.io server to listen to events, emit
+// one or talk to it interactively:
+//
+//	socketio listen [flags] URL
+//	socketio emit [flags] URL EVENT [ARG...]
+//	socketio repl [flags] URL
+//
+// Arguments that are valid JSON are sent as JSON, anything else as a string.
+// Received events are printed as JSON Lines. The exit status is 0 on success,
+// 1 when the command failed (e.g. the ack timed out or the server
+// disconnected), 2 on bad usage and 3 when connecting failed.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitConnect = 3
+)
+
+const usage = `Usage:
+  socketio listen [flags] URL              print received events as JSON Lines
+  socketio emit [flags] URL EVENT [ARG...] emit an event
+  socketio repl [flags] URL                emit events typed on stdin
+
+Run "socketio COMMAND -h" for the flags of a command.
+`
+
+func main() {
+	log.SetOutput(os.Stderr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the exit status.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var command func(ctx context.Context, args []string, stdin io.Reader, out *output, stderr io.Writer) int
+	switch args[0] {
+	case "listen":
+		command = listen
+	case "emit":
+		command = emitCommand
+	case "repl":
+		command = repl
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	return command(ctx, args[1:], stdin, &output{w: stdout}, stderr)
+}
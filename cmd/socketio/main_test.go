@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	socketio_v5_server "github.com/maldikhan/go.socket.io/socket.io/v5/server"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/sockettest"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// syncBuffer is a bytes.Buffer safe for the handler goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newServer(t *testing.T) (*socketio_v5_server.Server, *httptest.Server) {
+	t.Helper()
+	server, err := socketio_v5_server.NewServer(socketio_v5_server.WithLogger(&utils.DefaultLogger{Level: utils.NONE}))
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		_ = server.Close()
+		httpServer.Close()
+	})
+	return server, httpServer
+}
+
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var stdout, stderr syncBuffer
+	code := run(ctx, args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func decodeLines(t *testing.T, out string) []eventLine {
+	t.Helper()
+	var lines []eventLine
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var event eventLine
+		require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		lines = append(lines, event)
+	}
+	return lines
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, _, stderr := runCommand(t, "")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "Usage:")
+
+	code, _, _ = runCommand(t, "", "unknown")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCommand(t, "", "emit", "http://localhost")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, stderr = runCommand(t, "", "listen", "--transport", "carrier-pigeon", "http://localhost")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "unknown transport")
+
+	code, _, _ = runCommand(t, "", "listen", "--auth", "[1]", "http://localhost")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCommand(t, "", "listen", "--header", "no-colon", "http://localhost")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestEmit(t *testing.T) {
+	for _, transport := range []string{"polling", "websocket", "upgrade"} {
+		t.Run(transport, func(t *testing.T) {
+			srv := sockettest.NewServer(t)
+			srv.ExpectEmit("join").WithArgs("room-1", map[string]int{"limit": 10}).ReplyAck("ok", 1)
+
+			code, stdout, stderr := runCommand(t, "", "emit", "--transport", transport, "--ack",
+				srv.URL(), "join", "room-1", `{"limit": 10}`)
+			require.Equal(t, exitOK, code, stderr)
+			srv.Wait()
+
+			lines := decodeLines(t, stdout)
+			require.Len(t, lines, 1)
+			assert.True(t, lines[0].Ack)
+			assert.Equal(t, "join", lines[0].Event)
+			args, err := json.Marshal(lines[0].Args)
+			require.NoError(t, err)
+			assert.JSONEq(t, `["ok", 1]`, string(args))
+		})
+	}
+}
+
+func TestEmit_AckTimeout(t *testing.T) {
+	srv := sockettest.NewServer(t)
+	srv.ExpectEmit("join")
+
+	code, _, stderr := runCommand(t, "", "emit", "--ack", "--timeout", "100ms", srv.URL(), "join")
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "no ack for join")
+}
+
+func TestConnectError(t *testing.T) {
+	srv := sockettest.NewServer(t)
+	srv.RejectConnect("/admin", "unauthorized", map[string]string{"reason": "token"})
+
+	code, _, stderr := runCommand(t, "", "listen", "--namespace", "/admin", srv.URL())
+	assert.Equal(t, exitConnect, code)
+	assert.Contains(t, stderr, "unauthorized")
+
+	// Nothing listens on the port of a closed server.
+	closed := httptest.NewServer(nil)
+	closed.Close()
+	code, _, _ = runCommand(t, "", "emit", "--connect-timeout", "2s", closed.URL, "ping")
+	assert.Equal(t, exitConnect, code)
+}
+
+func TestListen(t *testing.T) {
+	srv := sockettest.NewServer(t)
+	go func() {
+		srv.WaitConnected("/")
+		_ = srv.Push("news", "hello")
+		_ = srv.Push("news", map[string]int{"n": 2})
+	}()
+
+	code, stdout, stderr := runCommand(t, "", "listen", "--count", "2", srv.URL())
+	require.Equal(t, exitOK, code, stderr)
+
+	lines := decodeLines(t, stdout)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "news", lines[0].Event)
+	assert.Equal(t, "/", lines[0].Namespace)
+}
+
+func TestListen_Disconnect(t *testing.T) {
+	server, httpServer := newServer(t)
+	server.OnConnection(func(socket *socketio_v5_server.Socket) {
+		_ = socket.Disconnect(false)
+	})
+
+	code, stdout, stderr := runCommand(t, "", "listen", "--transport", "websocket", httpServer.URL)
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "disconnected")
+	assert.Equal(t, "disconnect", decodeLines(t, stdout)[0].Event)
+}
+
+func TestRepl(t *testing.T) {
+	srv := sockettest.NewServer(t)
+	chat := srv.ExpectEmit("chat").WithArgs(map[string]string{"text": "hi"}, 1)
+	srv.ExpectEmit("say").WithArgs("hello world")
+	srv.ExpectEmit("join").WithArgs("room-1").ReplyAck("ok")
+
+	input := "/help\n" +
+		`chat {"text": "hi"} 1` + "\n" +
+		"say hello world\n" +
+		`/ack join "room-1"` + "\n" +
+		"/quit\n"
+	code, stdout, stderr := runCommand(t, input, "repl", srv.URL())
+	require.Equal(t, exitOK, code, stderr)
+	<-chat.Done()
+	srv.Wait()
+	assert.Contains(t, stderr, "/ack EVENT")
+
+	lines := decodeLines(t, stdout)
+	require.Len(t, lines, 1)
+	assert.True(t, lines[0].Ack)
+}
+
+func TestConnectFlags(t *testing.T) {
+	server, httpServer := newServer(t)
+	type seen struct {
+		header, query string
+		auth          json.RawMessage
+	}
+	connections := make(chan seen, 1)
+	server.Of("/chat").OnConnection(func(socket *socketio_v5_server.Socket) {
+		connections <- seen{
+			header: socket.Request().Header.Get("X-Token"),
+			query:  socket.Request().URL.Query().Get("room"),
+			auth:   socket.Auth(),
+		}
+	})
+
+	for _, transport := range []string{"polling", "websocket"} {
+		t.Run(transport, func(t *testing.T) {
+			code, _, stderr := runCommand(t, "", "emit", "--transport", transport, "--namespace", "/chat",
+				"--header", "X-Token: secret", "--query", "room=1", "--auth", `{"user": "bob"}`,
+				httpServer.URL, "ping")
+			require.Equal(t, exitOK, code, stderr)
+
+			got := <-connections
+			assert.Equal(t, "secret", got.header)
+			assert.Equal(t, "1", got.query)
+			assert.JSONEq(t, `{"user": "bob"}`, string(got.auth))
+		})
+	}
+}
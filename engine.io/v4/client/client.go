@@ -5,21 +5,27 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
 	"sync"
 	"time"
 
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	"github.com/maldikhan/go.socket.io/utils"
 )
 
 type Client struct {
 	url                 *url.URL
 	ctx                 context.Context
 	log                 Logger
+	metrics             Metrics
 	transport           Transport
 	supportedTransports map[engineio_v4.EngineIOTransport]Transport
 	sid                 string
-	pingInterval        *time.Ticker
+	handshake           *engineio_v4.HandshakeResponse // guarded by transportMu
+	pingInterval        utils.Ticker
+	clock               utils.Clock
 	pingTimeout         time.Duration
 	parser              Parser
 	messageHandler      func([]byte)
@@ -27,15 +33,28 @@ type Client struct {
 	reconnectAttempts   int
 	reconnectWait       time.Duration
 	waitUpgrade         chan struct{}
+	upgradeFrom         engineio_v4.EngineIOTransport // guarded by transportMu
+	upgradeTo           engineio_v4.EngineIOTransport
 	hadUpgrade          sync.Once
 	waitHandshake       chan struct{}
 	hadHandshake        sync.Once
+	handshakeErr        error // set before waitHandshake is closed, guarded by transportMu
 	stopPooling         chan struct{}
 	transportClosed     chan error
 	afterConnect        func()
 	messages            chan []byte
 	messagesDone        chan struct{} // closed when messageLoop exits
 
+	// dialContext, httpTransport and unixSocket configure the networking of
+	// the default transports.
+	dialContext   func(ctx context.Context, network, addr string) (net.Conn, error)
+	httpTransport *http.Transport
+	unixSocket    string
+
+	// maxFrameSize and onFrameTooLarge are set by WithMaxFrameSize.
+	maxFrameSize    int
+	onFrameTooLarge func(error)
+
 	// transportMu serializes access to the transport field and
 	// the waitUpgrade / waitHandshake channels so that Send() never
 	// races with transportUpgrade() or Close().
@@ -52,22 +71,71 @@ type Client struct {
 	// (tokens, PII). The zero value is "don't redact" (verbose); NewClient
 	// sets the production-safe default and WithDebugPayload(true) disables it.
 	redactPayload bool
+
+	// redaction is passed on to the default transports by NewClient.
+	redaction *utils.RedactionPolicy
+
+	// stateMu guards state and stateChanged. stateChanged is closed and
+	// replaced on every transition so WatchState() callers are notified.
+	stateMu      sync.Mutex
+	state        engineio_v4.State
+	stateChanged chan struct{}
+
+	// logMu guards sessionLog, the logger with the session id field once
+	// the handshake set it.
+	logMu      sync.RWMutex
+	sessionLog Logger
 }
 
 // payload returns a size marker for debug logging when payload redaction is
 // enabled, or the raw data otherwise. The packet type/prefix stays visible
 // either way.
 func (c *Client) payload(data []byte) string {
+	if c.redaction != nil {
+		return c.redaction.Redact(data)
+	}
 	if c.redactPayload {
 		return fmt.Sprintf("[redacted %d bytes]", len(data))
 	}
 	return string(data)
 }
 
+// logger returns the logger with the session fields, or the configured one
+// before the handshake.
+func (c *Client) logger() Logger {
+	c.logMu.RLock()
+	defer c.logMu.RUnlock()
+	if c.sessionLog == nil {
+		return c.log
+	}
+	return c.sessionLog
+}
+
+// meter returns the metrics, tolerating a Client built without NewClient
+// (e.g. in unit tests).
+func (c *Client) meter() Metrics {
+	if c.metrics == nil {
+		return utils.NopMetrics{}
+	}
+	return c.metrics
+}
+
+// ticker starts a ticker on the configured clock, or on the wall clock for a
+// Client built without NewClient.
+func (c *Client) ticker(d time.Duration) utils.Ticker {
+	if c.clock == nil {
+		return utils.RealClock{}.NewTicker(d)
+	}
+	return c.clock.NewTicker(d)
+}
+
 func (c *Client) Connect(ctx context.Context) error {
 	c.ctx = ctx
+	c.setState(engineio_v4.StateConnecting)
 
+	c.transportMu.Lock()
 	c.messages = make(chan []byte, 100)
+	c.transportMu.Unlock()
 
 	// Run transport before starting the message loop so that a Run()
 	// failure doesn't leak a goroutine.
@@ -75,6 +143,7 @@ func (c *Client) Connect(ctx context.Context) error {
 	err := c.transport.Run(ctx, c.url, c.sid, c.messages, c.transportClosed)
 	if err != nil {
 		close(c.transportClosed)
+		c.setState(engineio_v4.StateClosed)
 		return err
 	}
 
@@ -85,30 +154,66 @@ func (c *Client) Connect(ctx context.Context) error {
 	c.transportMu.Lock()
 	c.hadHandshake = sync.Once{}
 	c.waitHandshake = make(chan struct{}, 1)
+	c.handshakeErr = nil
+	waitHandshake := c.waitHandshake
 	c.transportMu.Unlock()
 
 	err = c.transport.RequestHandshake()
 	if err != nil {
-		// Clean up: stop transport and wait for the message loop to exit
-		// so we don't leak a goroutine.
-		_ = c.transport.Stop()
-		if c.transportClosed != nil {
-			<-c.transportClosed
-		}
-		close(c.messages)
-		<-c.messagesDone
+		c.abortConnect()
+		return err
+	}
+
+	// The polling transport delivers the OPEN packet before RequestHandshake
+	// returns, but the websocket transport only receives it afterwards, so
+	// wait for handleHandshake to settle the session (including a transport
+	// upgrade) before reporting the client as connected.
+	select {
+	case <-waitHandshake:
+		c.transportMu.RLock()
+		err = c.handshakeErr
+		c.transportMu.RUnlock()
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		c.abortConnect()
 		return err
 	}
 
 	return nil
 }
 
+// abortConnect stops the transport and waits for the message loop to exit
+// so a failed Connect() doesn't leak a goroutine.
+func (c *Client) abortConnect() {
+	c.transportMu.RLock()
+	t := c.transport
+	transportClosed := c.transportClosed
+	c.transportMu.RUnlock()
+
+	_ = t.Stop()
+	if transportClosed != nil {
+		<-transportClosed
+	}
+	close(c.messages)
+	<-c.messagesDone
+
+	// Leave nothing for a later Close() to wait on or close again.
+	c.transportMu.Lock()
+	c.transportClosed = nil
+	c.messages = nil
+	c.transportMu.Unlock()
+	c.messagesDone = nil
+	c.setState(engineio_v4.StateClosed)
+}
+
 func (c *Client) messageLoop(ctx context.Context, messages <-chan []byte) {
 	if c.messagesDone != nil {
 		defer close(c.messagesDone)
 	}
 	if messages == nil {
-		c.log.Errorf("messages channel is nil, can't read transport messages")
+		c.logger().Errorf("messages channel is nil, can't read transport messages")
 		return
 	}
 	for {
@@ -119,10 +224,10 @@ func (c *Client) messageLoop(ctx context.Context, messages <-chan []byte) {
 			}
 			err := c.handlePacket(message)
 			if err != nil {
-				c.log.Errorf("handle packet error: %s", err)
+				c.logger().Errorf("handle packet error: %s", err)
 			}
 		case <-ctx.Done():
-			c.log.Warnf("context done, engine.io client stopped processing messages")
+			c.logger().Warnf("context done, engine.io client stopped processing messages")
 			return
 		}
 	}
@@ -130,9 +235,11 @@ func (c *Client) messageLoop(ctx context.Context, messages <-chan []byte) {
 
 func (c *Client) transportUpgrade(transport Transport) error {
 	// Lock while mutating state that Send() reads.
+	c.setState(engineio_v4.StateUpgrading)
 	c.transportMu.Lock()
 	c.hadUpgrade = sync.Once{}
 	c.waitUpgrade = make(chan struct{}, 1)
+	from, to := string(c.upgradeFrom), string(c.upgradeTo)
 
 	// failUpgrade closes the upgrade gate so that Send() callers waiting
 	// on waitUpgrade are unblocked even when the upgrade fails.
@@ -141,12 +248,13 @@ func (c *Client) transportUpgrade(transport Transport) error {
 			close(c.waitUpgrade)
 		})
 		c.transportMu.Unlock()
+		c.meter().Upgrade(from, to, err)
 		return err
 	}
 
 	err := c.transport.Stop()
 	if err != nil {
-		c.log.Errorf("stop transport: %s", err)
+		c.logger().Errorf("stop transport: %s", err)
 		return failUpgrade(err)
 	}
 	<-c.transportClosed
@@ -156,7 +264,7 @@ func (c *Client) transportUpgrade(transport Transport) error {
 	err = c.transport.Run(c.ctx, c.url, c.sid, c.messages, c.transportClosed)
 	if err != nil {
 		close(c.transportClosed)
-		c.log.Errorf("run transport: %s", err)
+		c.logger().Errorf("run transport: %s", err)
 		return failUpgrade(err)
 	}
 	c.transportMu.Unlock()
@@ -172,21 +280,25 @@ func (c *Client) transportUpgrade(transport Transport) error {
 			close(c.waitUpgrade)
 		})
 		c.transportMu.Unlock()
+		c.meter().Upgrade(from, to, err)
 	}
 	return err
 }
 
 func (c *Client) handleHandshake(data []byte) error {
-	c.log.Debugf("apply handshake: %s", c.payload(data))
+	c.logger().Debugf("apply handshake: %s", c.payload(data))
 
 	handshakeResp := &engineio_v4.HandshakeResponse{}
 	err := json.Unmarshal(data, handshakeResp)
 	if err != nil {
+		c.finishHandshake(err)
 		return err
 	}
 
 	if handshakeResp.Sid == "" {
-		return fmt.Errorf("handshake error: no sid")
+		err = fmt.Errorf("handshake error: no sid")
+		c.finishHandshake(err)
+		return err
 	}
 
 	// Upgrade transports
@@ -194,14 +306,20 @@ func (c *Client) handleHandshake(data []byte) error {
 		transport.SetHandshake(handshakeResp)
 	}
 
+	c.transportMu.Lock()
 	c.sid = handshakeResp.Sid
+	c.handshake = handshakeResp
+	c.transportMu.Unlock()
+	c.logMu.Lock()
+	c.sessionLog = utils.WithFields(c.log, utils.Field{Key: utils.FieldSid, Value: handshakeResp.Sid})
+	c.logMu.Unlock()
 	if handshakeResp.PingInterval != 0 {
 		if c.pingInterval != nil {
 			// Reset reuses the existing ticker (shared with the polling transport),
 			// so we don't break the transport's pinger reference.
 			c.pingInterval.Reset(time.Duration(handshakeResp.PingInterval) * time.Millisecond)
 		} else {
-			c.pingInterval = time.NewTicker(time.Duration(handshakeResp.PingInterval) * time.Millisecond)
+			c.pingInterval = c.ticker(time.Duration(handshakeResp.PingInterval) * time.Millisecond)
 		}
 	}
 
@@ -213,34 +331,42 @@ func (c *Client) handleHandshake(data []byte) error {
 	// waitUpgrade is published before waitHandshake is closed. Otherwise
 	// a Send() waiting on waitHandshake would wake with waitUpgrade == nil
 	// and write on the old transport during the upgrade window.
+	upgrading := false
 	if len(handshakeResp.Upgrades) > 0 {
 		for _, newTransportName := range handshakeResp.Upgrades {
-			if c.transport.Transport() == engineio_v4.EngineIOTransport(newTransportName) {
+			current := c.transport.Transport()
+			if current == engineio_v4.EngineIOTransport(newTransportName) {
 				break
 			}
 			if newTransport, found := c.supportedTransports[engineio_v4.EngineIOTransport(newTransportName)]; found {
+				c.transportMu.Lock()
+				c.upgradeFrom = current
+				c.upgradeTo = engineio_v4.EngineIOTransport(newTransportName)
+				c.transportMu.Unlock()
 				err = c.transportUpgrade(newTransport)
 				if err != nil {
-					// Close the handshake gate so that any Send() caller
-					// waiting on waitHandshake doesn't block forever.
-					c.hadHandshake.Do(func() {
-						close(c.waitHandshake)
-					})
+					// Close the handshake gate so that Connect() and any
+					// Send() caller waiting on waitHandshake don't block forever.
+					c.finishHandshake(err)
 					return err
 				}
 
+				upgrading = true
 				break
 			} else {
-				c.log.Warnf("unsupported upgrade: %s", newTransportName)
+				c.logger().Warnf("unsupported upgrade: %s", newTransportName)
 			}
 		}
 	}
 
 	// Close the handshake gate AFTER the upgrade gate (waitUpgrade) is
 	// already published so Send() sees both gates atomically.
-	c.hadHandshake.Do(func() {
-		close(c.waitHandshake)
-	})
+	c.finishHandshake(nil)
+	if !upgrading {
+		// With an upgrade in flight the session becomes open once the
+		// probe is answered (see PacketPong in handlePacket).
+		c.setState(engineio_v4.StateOpen)
+	}
 
 	// Call onConnect hook in a goroutine so that messageLoop can continue
 	// processing engine.io packets (e.g. the WebSocket upgrade probe response
@@ -259,15 +385,31 @@ func (c *Client) handleHandshake(data []byte) error {
 	return nil
 }
 
+// finishHandshake records the handshake outcome for Connect() and closes the
+// handshake gate exactly once. A nil gate (client driven without Connect, e.g.
+// in unit tests) is left alone.
+func (c *Client) finishHandshake(err error) {
+	c.hadHandshake.Do(func() {
+		c.transportMu.Lock()
+		defer c.transportMu.Unlock()
+		if c.waitHandshake == nil {
+			return
+		}
+		c.handshakeErr = err
+		close(c.waitHandshake)
+	})
+}
+
 func (c *Client) handlePacket(packetData []byte) error {
-	c.log.Debugf("handle packet: %s", c.payload(packetData))
+	c.logger().Debugf("handle packet: %s", c.payload(packetData))
 	packet, err := c.parser.Parse(packetData)
 	if err != nil {
-		c.log.Errorf("Can't parse packet: %s %v", string(packetData), err)
+		c.logger().Errorf("Can't parse packet: %s %v", string(packetData), err)
 		return err
 	}
 
-	c.log.Debugf("handle: %d %s", packet.Type, c.payload(packet.Data))
+	utils.WithFields(c.logger(), utils.Field{Key: utils.FieldPacketType, Value: packet.Type}).
+		Debugf("handle: %d %s", packet.Type, c.payload(packet.Data))
 
 	switch packet.Type {
 	case engineio_v4.PacketOpen:
@@ -275,10 +417,11 @@ func (c *Client) handlePacket(packetData []byte) error {
 			packet.Data,
 		)
 		if err != nil {
-			c.log.Errorf("handle handshake error: %s", err)
+			c.logger().Errorf("handle handshake error: %s", err)
 			return err
 		}
 	case engineio_v4.PacketClose:
+		c.setState(engineio_v4.StateClosed)
 		c.handlerMu.RLock()
 		handler := c.closeHandler
 		c.handlerMu.RUnlock()
@@ -290,7 +433,7 @@ func (c *Client) handlePacket(packetData []byte) error {
 			Type: engineio_v4.PacketPong,
 		})
 		if err != nil {
-			c.log.Errorf("send ping error: %s", err)
+			c.logger().Errorf("send ping error: %s", err)
 		}
 		return err
 	case engineio_v4.PacketPong:
@@ -301,11 +444,16 @@ func (c *Client) handlePacket(packetData []byte) error {
 			c.hadUpgrade.Do(func() {
 				close(c.waitUpgrade)
 			})
+			c.transportMu.RLock()
+			from, to := c.upgradeFrom, c.upgradeTo
+			c.transportMu.RUnlock()
+			c.meter().Upgrade(string(from), string(to), err)
 			if err != nil {
-				c.log.Errorf("send upgrade error: %s", err)
+				c.logger().Errorf("send upgrade error: %s", err)
 				return err
 			} else {
-				c.log.Debugf("Protocol upgraded")
+				c.logger().Debugf("Protocol upgraded")
+				c.setState(engineio_v4.StateOpen)
 			}
 		}
 	case engineio_v4.PacketMessage:
@@ -391,6 +539,7 @@ func (c *Client) Close() error {
 	t := c.transport
 	c.transport = nil
 	c.transportMu.Unlock()
+	c.setState(engineio_v4.StateClosed)
 
 	// Stop the ping ticker to prevent goroutine leak
 	if c.pingInterval != nil {
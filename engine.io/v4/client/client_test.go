@@ -15,6 +15,7 @@ import (
 
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
 	mocks "github.com/maldikhan/go.socket.io/engine.io/v4/client/mocks"
+	"github.com/maldikhan/go.socket.io/utils"
 )
 
 func TestClient_Connect(t *testing.T) {
@@ -131,9 +132,18 @@ func TestClient_Connect(t *testing.T) {
 
 		err := client.Connect(ctx)
 		assert.Error(t, err)
-		// Connect already cleaned up — transport is still set but
-		// messageLoop has exited. Nil it to avoid stale pointers.
-		client.transport = nil
+
+		// Close() after the failed Connect() must neither block on the
+		// drained transportClosed nor close messages again.
+		mockTransport.EXPECT().Stop().Return(nil)
+		closed := make(chan error, 1)
+		go func() { closed <- client.Close() }()
+		select {
+		case err := <-closed:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("Close() blocked after a failed Connect()")
+		}
 	})
 }
 
@@ -357,6 +367,8 @@ func TestClient_handleHandshake(t *testing.T) {
 			assert.Fail(t, "handshake not completed")
 		}
 		assert.Equal(t, "test-sid", client.sid)
+		// The session stays upgrading until the probe is answered.
+		assert.Equal(t, engineio_v4.StateUpgrading, client.State())
 	})
 
 	t.Run("afterConnect called after upgrade", func(t *testing.T) {
@@ -483,6 +495,27 @@ func TestClient_handleHandshake(t *testing.T) {
 	})
 }
 
+func TestClient_sessionLogger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mocks.NewMockLogger(ctrl)
+	mockTransport := mocks.NewMockTransport(ctrl)
+	mockTransport.EXPECT().SetHandshake(gomock.Any())
+
+	client := &Client{
+		log:                 utils.NewFieldLogger(mockLogger),
+		transport:           mockTransport,
+		supportedTransports: map[engineio_v4.EngineIOTransport]Transport{engineio_v4.TransportPolling: mockTransport},
+	}
+
+	mockLogger.EXPECT().Debugf("%s", gomock.Any()).AnyTimes()
+	require.NoError(t, client.handleHandshake([]byte(`{"sid":"test-sid"}`)))
+
+	mockLogger.EXPECT().Infof("%s", "session ready sid=test-sid")
+	client.logger().Infof("session %s", "ready")
+}
+
 func TestClient_handlePacket(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -883,7 +916,7 @@ func TestClient_Send_after_close(t *testing.T) {
 
 func TestClient_handleHandshake_ticker_stopped(t *testing.T) {
 	// Test that the ticker is reset (not replaced) during re-handshake to preserve
-	// the reference shared with the polling transport via WithDefaultPinger
+	// the reference shared with the polling transport via WithPinger
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -920,7 +953,7 @@ func TestClient_handleHandshake_ticker_stopped(t *testing.T) {
 	assert.NotNil(t, firstTicker)
 
 	// Second handshake - should reset the existing ticker (same object,
-	// because it's shared with the polling transport via WithDefaultPinger)
+	// because it's shared with the polling transport via WithPinger)
 	client.hadHandshake = sync.Once{}
 	client.waitHandshake = make(chan struct{})
 	err = client.handleHandshake(data)
@@ -953,7 +986,7 @@ func TestClient_Close_stops_ticker(t *testing.T) {
 	}
 
 	// Create a ticker that we'll verify gets stopped
-	client.pingInterval = time.NewTicker(10 * time.Second)
+	client.pingInterval = utils.RealClock{}.NewTicker(10 * time.Second)
 
 	mockTransport.EXPECT().Stop().Return(nil)
 
@@ -963,7 +996,7 @@ func TestClient_Close_stops_ticker(t *testing.T) {
 	// Try to receive from the ticker's channel - it should be closed
 	// If the ticker was properly stopped, we should not be able to receive anymore after a short wait
 	select {
-	case _, ok := <-client.pingInterval.C:
+	case _, ok := <-client.pingInterval.C():
 		// Channel should eventually be closed or we should timeout
 		assert.False(t, ok, "ticker channel should be closed after Close()")
 	case <-time.After(100 * time.Millisecond):
@@ -1076,6 +1109,11 @@ func TestClient_payloadRedaction(t *testing.T) {
 	assert.Equal(t, "[redacted 5 bytes]", c.payload([]byte("hello")))
 	c.redactPayload = false
 	assert.Equal(t, "hello", c.payload([]byte("hello")))
+
+	// A policy takes precedence.
+	assert.Error(t, WithRedactionPolicy(nil)(c))
+	require.NoError(t, WithRedactionPolicy(&utils.RedactionPolicy{})(c))
+	assert.Equal(t, `42["login",{"token":"[redacted]"}]`, c.payload([]byte(`42["login",{"token":"t"}]`)))
 }
 
 func TestClient_WithDebugPayload(t *testing.T) {
@@ -1085,3 +1123,102 @@ func TestClient_WithDebugPayload(t *testing.T) {
 	assert.NoError(t, WithDebugPayload(false)(c))
 	assert.True(t, c.redactPayload)
 }
+
+func TestClient_Connect_waits_for_handshake(t *testing.T) {
+	t.Parallel()
+
+	newClient := func(ctrl *gomock.Controller) (*Client, *mocks.MockTransport, *mocks.MockParser) {
+		mockLogger := mocks.NewMockLogger(ctrl)
+		mockParser := mocks.NewMockParser(ctrl)
+		mockTransport := mocks.NewMockTransport(ctrl)
+		mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+		mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+		mockLogger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+		testURL, _ := url.Parse("http://localhost")
+		client := &Client{
+			url:                 testURL,
+			log:                 mockLogger,
+			parser:              mockParser,
+			supportedTransports: map[engineio_v4.EngineIOTransport]Transport{engineio_v4.TransportWebsocket: mockTransport},
+			transport:           mockTransport,
+		}
+		return client, mockTransport, mockParser
+	}
+
+	t.Run("OPEN delivered after RequestHandshake", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client, mockTransport, mockParser := newClient(ctrl)
+
+		respData, _ := json.Marshal(&engineio_v4.HandshakeResponse{Sid: "ws-sid"})
+		var messages chan<- []byte
+		mockTransport.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ *url.URL, _ string, m chan<- []byte, _ chan<- error) error {
+				messages = m
+				return nil
+			})
+		// Websocket-style: the handshake request is a no-op and OPEN arrives later.
+		mockTransport.EXPECT().RequestHandshake().DoAndReturn(func() error {
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				messages <- respData
+			}()
+			return nil
+		})
+		mockParser.EXPECT().Parse(respData).Return(&engineio_v4.Message{Type: engineio_v4.PacketOpen, Data: respData}, nil)
+		mockTransport.EXPECT().SetHandshake(gomock.Any())
+
+		err := client.Connect(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "ws-sid", client.sid)
+		assert.Equal(t, engineio_v4.StateOpen, client.State())
+
+		mockTransport.EXPECT().Stop().Do(func() { client.transportClosed <- nil })
+		require.NoError(t, client.Close())
+		assert.Equal(t, engineio_v4.StateClosed, client.State())
+	})
+
+	t.Run("Invalid OPEN packet", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client, mockTransport, mockParser := newClient(ctrl)
+
+		respData := []byte(`{"upgrades":[]}`)
+		var messages chan<- []byte
+		mockTransport.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ *url.URL, _ string, m chan<- []byte, _ chan<- error) error {
+				messages = m
+				return nil
+			})
+		mockTransport.EXPECT().RequestHandshake().DoAndReturn(func() error {
+			messages <- respData
+			return nil
+		})
+		mockParser.EXPECT().Parse(respData).Return(&engineio_v4.Message{Type: engineio_v4.PacketOpen, Data: respData}, nil)
+		mockTransport.EXPECT().Stop().Do(func() { client.transportClosed <- nil })
+
+		err := client.Connect(context.Background())
+		assert.ErrorContains(t, err, "no sid")
+	})
+
+	t.Run("Context expires before OPEN", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		client, mockTransport, _ := newClient(ctrl)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		mockTransport.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		mockTransport.EXPECT().RequestHandshake().Return(nil)
+		mockTransport.EXPECT().Stop().Do(func() { client.transportClosed <- ctx.Err() })
+
+		err := client.Connect(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, engineio_v4.StateClosed, client.State())
+	})
+}
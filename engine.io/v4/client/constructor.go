@@ -1,9 +1,13 @@
 package engineio_v4_client
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
@@ -11,6 +15,7 @@ import (
 	engineio_v4_client_transport_ws "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket"
 	engineio_v4_parser "github.com/maldikhan/go.socket.io/engine.io/v4/parser"
 	"github.com/maldikhan/go.socket.io/utils"
+	ws_native "github.com/maldikhan/go.socket.io/websocket/native"
 )
 
 type EngineClientOption func(*Client) error
@@ -19,10 +24,11 @@ func NewClient(options ...EngineClientOption) (*Client, error) {
 	// Создаем клиент с настройками по умолчанию
 	client := &Client{
 		log:               &utils.DefaultLogger{},
+		metrics:           utils.NopMetrics{},
 		parser:            &engineio_v4_parser.EngineIOV4Parser{},
 		reconnectAttempts: 5,
 		reconnectWait:     5 * time.Second,
-		pingInterval:      time.NewTicker(10 * time.Second),
+		clock:             utils.RealClock{},
 		stopPooling:       make(chan struct{}, 1),
 		transportClosed:   make(chan error, 1),
 		redactPayload:     true, // production-safe default; WithDebugPayload(true) opts out
@@ -46,16 +52,44 @@ func NewClient(options ...EngineClientOption) (*Client, error) {
 		return nil, errors.New("parser is nil")
 	}
 
+	if client.metrics == nil {
+		return nil, errors.New("metrics is nil")
+	}
+
+	if client.clock == nil {
+		return nil, errors.New("clock is nil")
+	}
+	client.pingInterval = client.clock.NewTicker(10 * time.Second)
+
 	if len(client.supportedTransports) == 0 {
-		wsTransport, _ := engineio_v4_client_transport_ws.NewTransport(
+		wsOptions := []engineio_v4_client_transport_ws.EngineTransportOption{
 			engineio_v4_client_transport_ws.WithLogger(client.log),
+			engineio_v4_client_transport_ws.WithMetrics(client.metrics),
 			engineio_v4_client_transport_ws.WithDebugPayload(!client.redactPayload),
-		)
-		pollingTransport, _ := engineio_v4_client_transport_polling.NewTransport(
-			engineio_v4_client_transport_polling.WithDefaultPinger(client.pingInterval),
+		}
+		pollingOptions := []engineio_v4_client_transport_polling.EngineTransportOption{
+			engineio_v4_client_transport_polling.WithPinger(client.pingInterval),
+			engineio_v4_client_transport_polling.WithClock(client.clock),
 			engineio_v4_client_transport_polling.WithLogger(client.log),
+			engineio_v4_client_transport_polling.WithMetrics(client.metrics),
 			engineio_v4_client_transport_polling.WithDebugPayload(!client.redactPayload),
-		)
+		}
+		if httpTransport := client.sharedHTTPTransport(); httpTransport != nil {
+			wsOptions = append(wsOptions, engineio_v4_client_transport_ws.WithWebSocket(&ws_native.WebSocketConnection{
+				DialContext: httpTransport.DialContext,
+				TLSConfig:   httpTransport.TLSClientConfig,
+			}))
+			pollingOptions = append(pollingOptions, engineio_v4_client_transport_polling.WithHTTPTransport(httpTransport))
+		}
+		if client.redaction != nil {
+			wsOptions = append(wsOptions, engineio_v4_client_transport_ws.WithRedactionPolicy(client.redaction))
+			pollingOptions = append(pollingOptions, engineio_v4_client_transport_polling.WithRedactionPolicy(client.redaction))
+		}
+		if client.maxFrameSize > 0 {
+			wsOptions = append(wsOptions, engineio_v4_client_transport_ws.WithMaxFrameSize(client.maxFrameSize, client.onFrameTooLarge))
+		}
+		wsTransport, _ := engineio_v4_client_transport_ws.NewTransport(wsOptions...)
+		pollingTransport, _ := engineio_v4_client_transport_polling.NewTransport(pollingOptions...)
 		if client.supportedTransports == nil {
 			client.supportedTransports = make(map[engineio_v4.EngineIOTransport]Transport)
 		}
@@ -83,6 +117,17 @@ func WithURL(url *url.URL) EngineClientOption {
 			url.Scheme = "http"
 		case "wss":
 			url.Scheme = "https"
+		case "unix":
+			// unix:///path/to/socket[:/http/path]
+			socket, path, ok := strings.Cut(url.Path, ":")
+			if socket == "" {
+				return errors.New("unix URL has no socket path")
+			}
+			if !ok || path == "" {
+				path = "/socket.io/"
+			}
+			c.unixSocket = socket
+			url.Scheme, url.Host, url.Path, url.RawPath = "http", "localhost", path, ""
 		default:
 			return fmt.Errorf("invalid URL scheme: %s", url.Scheme)
 		}
@@ -112,6 +157,90 @@ func WithLogger(logger Logger) EngineClientOption {
 	}
 }
 
+// WithMetrics reports upgrades to metrics and passes it to the default
+// transports. Custom transports take their own WithMetrics option.
+func WithMetrics(metrics Metrics) EngineClientOption {
+	return func(c *Client) error {
+		c.metrics = metrics
+		return nil
+	}
+}
+
+// WithDialContext opens the connections of the default transports, polling
+// and websocket, with dial, e.g. to use a custom resolver or source address.
+// Custom transports take their own options.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) EngineClientOption {
+	return func(c *Client) error {
+		if dial == nil {
+			return errors.New("dial function is nil")
+		}
+		c.dialContext = dial
+		return nil
+	}
+}
+
+// WithHTTPTransport sends the polling requests of the default transports
+// through transport; the websocket transport dials with its DialContext and
+// TLSClientConfig. WithDialContext replaces its DialContext.
+func WithHTTPTransport(transport *http.Transport) EngineClientOption {
+	return func(c *Client) error {
+		if transport == nil {
+			return errors.New("HTTP transport is nil")
+		}
+		c.httpTransport = transport
+		return nil
+	}
+}
+
+// WithMaxFrameSize caps the size in bytes of a frame received by the default
+// websocket transport. A larger frame is discarded unread and reported to
+// onExceeded, if not nil, from the transport goroutine; the connection is kept.
+func WithMaxFrameSize(size int, onExceeded func(err error)) EngineClientOption {
+	return func(c *Client) error {
+		if size <= 0 {
+			return errors.New("maxFrameSize must be positive")
+		}
+		c.maxFrameSize = size
+		c.onFrameTooLarge = onExceeded
+		return nil
+	}
+}
+
+// sharedHTTPTransport returns the HTTP transport of the default transports
+// with the dialer set by WithDialContext and a unix URL, or nil to keep their
+// defaults.
+func (c *Client) sharedHTTPTransport() *http.Transport {
+	if c.httpTransport == nil && c.dialContext == nil && c.unixSocket == "" {
+		return nil
+	}
+
+	if c.dialContext == nil && c.unixSocket == "" {
+		// Use it as is, sharing its connection pool.
+		return c.httpTransport
+	}
+
+	var transport *http.Transport
+	if c.httpTransport != nil {
+		transport = c.httpTransport.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	if c.dialContext != nil {
+		transport.DialContext = c.dialContext
+	}
+	if transport.DialContext == nil {
+		transport.DialContext = (&net.Dialer{}).DialContext
+	}
+	if socket := c.unixSocket; socket != "" {
+		dial := transport.DialContext
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx, "unix", socket)
+		}
+		transport.Proxy = nil
+	}
+	return transport
+}
+
 func WithTransport(transport Transport) EngineClientOption {
 	return func(c *Client) error {
 		c.transport = transport
@@ -163,3 +292,27 @@ func WithDebugPayload(enabled bool) EngineClientOption {
 		return nil
 	}
 }
+
+// WithClock runs the ping ticker, and the polling backoff of the default
+// transports, on clock instead of the wall clock.
+func WithClock(clock utils.Clock) EngineClientOption {
+	return func(c *Client) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		c.clock = clock
+		return nil
+	}
+}
+
+// WithRedactionPolicy logs packet payloads at debug level with the secrets
+// masked by policy, here and in the default transports.
+func WithRedactionPolicy(policy *utils.RedactionPolicy) EngineClientOption {
+	return func(c *Client) error {
+		if policy == nil {
+			return errors.New("redaction policy is nil")
+		}
+		c.redaction = policy
+		return nil
+	}
+}
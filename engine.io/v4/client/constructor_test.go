@@ -1,8 +1,11 @@
 package engineio_v4_client
 
 import (
+	"context"
 	"errors"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,7 +18,10 @@ import (
 	engineio_v4_client_transport_polling "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/polling"
 	engineio_v4_client_transport_ws "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket"
 	engineio_v4_parser "github.com/maldikhan/go.socket.io/engine.io/v4/parser"
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
 	"github.com/maldikhan/go.socket.io/utils"
+	"github.com/maldikhan/go.socket.io/utils/fakeclock"
+	ws_native "github.com/maldikhan/go.socket.io/websocket/native"
 )
 
 func TestNewClientEdges(t *testing.T) {
@@ -81,7 +87,7 @@ func TestNewClient(t *testing.T) {
 				parser:            &engineio_v4_parser.EngineIOV4Parser{},
 				reconnectAttempts: 5,
 				reconnectWait:     5 * time.Second,
-				pingInterval:      time.NewTicker(10 * time.Second),
+				pingInterval:      utils.RealClock{}.NewTicker(10 * time.Second),
 				stopPooling:       make(chan struct{}, 1),
 				transportClosed:   make(chan error, 1),
 				supportedTransports: map[engineio_v4.EngineIOTransport]Transport{
@@ -107,7 +113,7 @@ func TestNewClient(t *testing.T) {
 				transport:         mockTransport,
 				reconnectAttempts: 3,
 				reconnectWait:     3 * time.Second,
-				pingInterval:      time.NewTicker(10 * time.Second),
+				pingInterval:      utils.RealClock{}.NewTicker(10 * time.Second),
 				stopPooling:       make(chan struct{}, 1),
 				transportClosed:   make(chan error, 1),
 				supportedTransports: map[engineio_v4.EngineIOTransport]Transport{
@@ -396,3 +402,71 @@ func TestWithReconnectWait(t *testing.T) {
 		})
 	}
 }
+
+func TestWithClock(t *testing.T) {
+	assert.Error(t, WithClock(nil)(&Client{}))
+
+	clock := fakeclock.New(time.Now())
+	client, err := NewClient(WithRawURL("http://localhost"), WithClock(clock))
+	require.NoError(t, err)
+	defer client.pingInterval.Stop()
+
+	// The ping ticker is shared with the polling transport.
+	assert.Equal(t, 1, clock.Waiters())
+	clock.Advance(10 * time.Second)
+	select {
+	case <-client.pingInterval.C():
+	default:
+		t.Error("ping ticker did not follow the clock")
+	}
+}
+
+func TestWithMaxFrameSize(t *testing.T) {
+	assert.Error(t, WithMaxFrameSize(0, nil)(&Client{}))
+
+	server, err := engineio_v4_server.NewServer(engineio_v4_server.WithLogger(&utils.DefaultLogger{Level: utils.NONE}))
+	require.NoError(t, err)
+	server.OnConnection(func(session *engineio_v4_server.Session) {
+		session.On("message", func([]byte) {
+			_ = session.Send([]byte(strings.Repeat("x", 100)))
+			_ = session.Send([]byte("small"))
+		})
+	})
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		_ = server.Close()
+		httpServer.Close()
+	})
+
+	exceeded := make(chan error, 1)
+	client, err := NewClient(
+		WithRawURL(httpServer.URL+"/engine.io/"),
+		WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+		WithMaxFrameSize(64, func(err error) { exceeded <- err }),
+	)
+	require.NoError(t, err)
+	messages := make(chan string, 2)
+	client.On("message", func(message []byte) { messages <- string(message) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, client.Connect(ctx))
+	defer client.Close()
+	require.Eventually(t, func() bool {
+		return client.Transport() == engineio_v4.TransportWebsocket
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, client.Send([]byte("go")))
+	select {
+	case err := <-exceeded:
+		assert.ErrorIs(t, err, ws_native.ErrFrameTooLarge)
+	case <-ctx.Done():
+		t.Fatal("the frame wasn't reported")
+	}
+	select {
+	case message := <-messages:
+		assert.Equal(t, "small", message)
+	case <-ctx.Done():
+		t.Fatal("the connection wasn't kept")
+	}
+}
@@ -5,6 +5,7 @@ package engineio_v4_client
 import (
 	"context"
 	"net/url"
+	"time"
 
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
 )
@@ -42,3 +43,12 @@ type Logger interface {
 	Warnf(format string, v ...any)
 	Errorf(format string, v ...any)
 }
+
+// Metrics receives the client and transport measurements; see the metrics
+// package.
+type Metrics interface {
+	PacketSent(transport, packetType string, bytes int)
+	PacketReceived(transport, packetType string, bytes int)
+	PollingRequest(method string, duration time.Duration, err error)
+	Upgrade(from, to string, err error)
+}
@@ -0,0 +1,133 @@
+package engineio_v4_client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+func TestWithURL_Unix(t *testing.T) {
+	tests := []struct {
+		url, socket, want string
+	}{
+		{"unix:///run/app.sock", "/run/app.sock", "http://localhost/socket.io/?EIO=4"},
+		{"unix:///run/app.sock:/engine.io/?token=1", "/run/app.sock", "http://localhost/engine.io/?EIO=4&token=1"},
+		{"unix:/tmp/s:", "/tmp/s", "http://localhost/socket.io/?EIO=4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			client := &Client{}
+			require.NoError(t, WithRawURL(tt.url)(client))
+			assert.Equal(t, tt.socket, client.unixSocket)
+			assert.Equal(t, tt.want, client.url.String())
+		})
+	}
+
+	assert.Error(t, WithRawURL("unix://")(&Client{}))
+}
+
+func TestWithDialContext(t *testing.T) {
+	assert.Error(t, WithDialContext(nil)(&Client{}))
+	assert.Error(t, WithHTTPTransport(nil)(&Client{}))
+
+	t.Run("Defaults kept", func(t *testing.T) {
+		assert.Nil(t, (&Client{}).sharedHTTPTransport())
+	})
+
+	t.Run("HTTP transport used as is", func(t *testing.T) {
+		transport := &http.Transport{}
+		client := &Client{}
+		require.NoError(t, WithHTTPTransport(transport)(client))
+		assert.Same(t, transport, client.sharedHTTPTransport())
+	})
+
+	t.Run("Dialer replaces the HTTP transport's", func(t *testing.T) {
+		var dialed []string
+		transport := &http.Transport{DialContext: func(context.Context, string, string) (net.Conn, error) {
+			t.Error("the transport's dialer was used")
+			return nil, nil
+		}}
+		client := &Client{}
+		require.NoError(t, WithHTTPTransport(transport)(client))
+		require.NoError(t, WithDialContext(func(_ context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, network+" "+addr)
+			return nil, net.ErrClosed
+		})(client))
+		require.NoError(t, WithRawURL("unix:///run/app.sock")(client))
+
+		shared := client.sharedHTTPTransport()
+		assert.NotSame(t, transport, shared)
+		_, _ = shared.DialContext(context.Background(), "tcp", "localhost:80")
+		assert.Equal(t, []string{"unix /run/app.sock"}, dialed)
+	})
+}
+
+func TestNewClient_UnixSocket(t *testing.T) {
+	server, err := engineio_v4_server.NewServer(engineio_v4_server.WithLogger(&utils.DefaultLogger{Level: utils.NONE}))
+	require.NoError(t, err)
+	server.OnConnection(func(session *engineio_v4_server.Session) {
+		session.On("message", func(message []byte) {
+			_ = session.Send(append([]byte("echo:"), message...))
+		})
+	})
+	socket := filepath.Join(t.TempDir(), "sio.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	httpServer := &http.Server{Handler: server, ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = httpServer.Serve(listener) }()
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = httpServer.Close()
+	})
+
+	// Both transports dial through the same function.
+	var mu sync.Mutex
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		mu.Lock()
+		dialed = append(dialed, network+" "+addr)
+		mu.Unlock()
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+
+	u := &url.URL{Scheme: "unix", Path: socket + ":/engine.io/"}
+	client, err := NewClient(WithURL(u), WithDialContext(dial), WithLogger(&utils.DefaultLogger{Level: utils.NONE}))
+	require.NoError(t, err)
+	echo := make(chan string, 1)
+	client.On("message", func(message []byte) { echo <- string(message) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, client.Connect(ctx))
+	defer client.Close()
+	require.Eventually(t, func() bool {
+		return client.Transport() == engineio_v4.TransportWebsocket
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, client.Send([]byte("hello")))
+	select {
+	case message := <-echo:
+		assert.Equal(t, "echo:hello", message)
+	case <-ctx.Done():
+		t.Fatal("no echo")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.GreaterOrEqual(t, len(dialed), 2, "polling and websocket connections")
+	for _, d := range dialed {
+		assert.Equal(t, "unix "+socket, d)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	context "context"
 	url "net/url"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
@@ -261,3 +262,74 @@ func (mr *MockLoggerMockRecorder) Warnf(format interface{}, v ...interface{}) *g
 	varargs := append([]interface{}{format}, v...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnf", reflect.TypeOf((*MockLogger)(nil).Warnf), varargs...)
 }
+
+// MockMetrics is a mock of Metrics interface.
+type MockMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsMockRecorder
+}
+
+// MockMetricsMockRecorder is the mock recorder for MockMetrics.
+type MockMetricsMockRecorder struct {
+	mock *MockMetrics
+}
+
+// NewMockMetrics creates a new mock instance.
+func NewMockMetrics(ctrl *gomock.Controller) *MockMetrics {
+	mock := &MockMetrics{ctrl: ctrl}
+	mock.recorder = &MockMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
+	return m.recorder
+}
+
+// PacketReceived mocks base method.
+func (m *MockMetrics) PacketReceived(transport, packetType string, bytes int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PacketReceived", transport, packetType, bytes)
+}
+
+// PacketReceived indicates an expected call of PacketReceived.
+func (mr *MockMetricsMockRecorder) PacketReceived(transport, packetType, bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacketReceived", reflect.TypeOf((*MockMetrics)(nil).PacketReceived), transport, packetType, bytes)
+}
+
+// PacketSent mocks base method.
+func (m *MockMetrics) PacketSent(transport, packetType string, bytes int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PacketSent", transport, packetType, bytes)
+}
+
+// PacketSent indicates an expected call of PacketSent.
+func (mr *MockMetricsMockRecorder) PacketSent(transport, packetType, bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacketSent", reflect.TypeOf((*MockMetrics)(nil).PacketSent), transport, packetType, bytes)
+}
+
+// PollingRequest mocks base method.
+func (m *MockMetrics) PollingRequest(method string, duration time.Duration, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PollingRequest", method, duration, err)
+}
+
+// PollingRequest indicates an expected call of PollingRequest.
+func (mr *MockMetricsMockRecorder) PollingRequest(method, duration, err interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PollingRequest", reflect.TypeOf((*MockMetrics)(nil).PollingRequest), method, duration, err)
+}
+
+// Upgrade mocks base method.
+func (m *MockMetrics) Upgrade(from, to string, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Upgrade", from, to, err)
+}
+
+// Upgrade indicates an expected call of Upgrade.
+func (mr *MockMetricsMockRecorder) Upgrade(from, to, err interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upgrade", reflect.TypeOf((*MockMetrics)(nil).Upgrade), from, to, err)
+}
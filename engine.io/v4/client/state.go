@@ -0,0 +1,92 @@
+package engineio_v4_client
+
+import (
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+)
+
+// ID returns the engine.io session id assigned by the server in the OPEN
+// packet, or "" before the handshake.
+func (c *Client) ID() string {
+	c.transportMu.RLock()
+	defer c.transportMu.RUnlock()
+	return c.sid
+}
+
+// Transport returns the name of the transport currently carrying the session,
+// or "" once the client is closed.
+func (c *Client) Transport() engineio_v4.EngineIOTransport {
+	c.transportMu.RLock()
+	defer c.transportMu.RUnlock()
+	if c.transport == nil {
+		return ""
+	}
+	return c.transport.Transport()
+}
+
+// Handshake returns a copy of the OPEN packet values of the session, or nil
+// before the handshake.
+func (c *Client) Handshake() *engineio_v4.HandshakeResponse {
+	c.transportMu.RLock()
+	defer c.transportMu.RUnlock()
+	if c.handshake == nil {
+		return nil
+	}
+	handshake := *c.handshake
+	handshake.Upgrades = append([]string(nil), c.handshake.Upgrades...)
+	return &handshake
+}
+
+// Upgrade returns the transports of the last upgrade attempted, or "" for
+// both when the session was never upgraded. It is in progress while State
+// reports StateUpgrading.
+func (c *Client) Upgrade() (from, to engineio_v4.EngineIOTransport) {
+	c.transportMu.RLock()
+	defer c.transportMu.RUnlock()
+	return c.upgradeFrom, c.upgradeTo
+}
+
+// Buffered returns the number of received packets waiting to be handled.
+func (c *Client) Buffered() int {
+	c.transportMu.RLock()
+	defer c.transportMu.RUnlock()
+	return len(c.messages)
+}
+
+// State returns the current connection state.
+func (c *Client) State() engineio_v4.State {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// WatchState returns the current connection state together with a channel
+// that is closed on the next state change. Call it again after the channel is
+// closed to observe the new state and keep watching:
+//
+//	state, changed := client.WatchState()
+//	for {
+//		<-changed
+//		state, changed = client.WatchState()
+//	}
+func (c *Client) WatchState() (engineio_v4.State, <-chan struct{}) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.stateChanged == nil {
+		c.stateChanged = make(chan struct{})
+	}
+	return c.state, c.stateChanged
+}
+
+func (c *Client) setState(state engineio_v4.State) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.state == state {
+		return
+	}
+	c.logger().Debugf("engine.io state: %s -> %s", c.state, state)
+	c.state = state
+	if c.stateChanged != nil {
+		close(c.stateChanged)
+	}
+	c.stateChanged = make(chan struct{})
+}
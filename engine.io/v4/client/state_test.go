@@ -0,0 +1,96 @@
+package engineio_v4_client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	mocks "github.com/maldikhan/go.socket.io/engine.io/v4/client/mocks"
+)
+
+func TestClient_ID_Transport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTransport := mocks.NewMockTransport(ctrl)
+	mockTransport.EXPECT().Transport().Return(engineio_v4.TransportWebsocket)
+
+	client := &Client{}
+	assert.Equal(t, "", client.ID())
+	assert.Equal(t, engineio_v4.EngineIOTransport(""), client.Transport())
+
+	client.sid = "test-sid"
+	client.transport = mockTransport
+	assert.Equal(t, "test-sid", client.ID())
+	assert.Equal(t, engineio_v4.TransportWebsocket, client.Transport())
+}
+
+func TestClient_Handshake_Upgrade_Buffered(t *testing.T) {
+	client := &Client{}
+	assert.Nil(t, client.Handshake())
+	from, to := client.Upgrade()
+	assert.Equal(t, engineio_v4.EngineIOTransport(""), from)
+	assert.Equal(t, engineio_v4.EngineIOTransport(""), to)
+	assert.Equal(t, 0, client.Buffered())
+
+	client.handshake = &engineio_v4.HandshakeResponse{Sid: "test-sid", Upgrades: []string{"websocket"}, PingInterval: 25000}
+	client.upgradeFrom = engineio_v4.TransportPolling
+	client.upgradeTo = engineio_v4.TransportWebsocket
+	client.messages = make(chan []byte, 2)
+	client.messages <- []byte("4hello")
+
+	handshake := client.Handshake()
+	assert.Equal(t, client.handshake, handshake)
+	handshake.Upgrades[0] = "changed"
+	assert.Equal(t, "websocket", client.handshake.Upgrades[0])
+	from, to = client.Upgrade()
+	assert.Equal(t, engineio_v4.TransportPolling, from)
+	assert.Equal(t, engineio_v4.TransportWebsocket, to)
+	assert.Equal(t, 1, client.Buffered())
+}
+
+func TestClient_WatchState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mocks.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	client := &Client{log: mockLogger}
+	assert.Equal(t, engineio_v4.StateClosed, client.State())
+
+	state, changed := client.WatchState()
+	assert.Equal(t, engineio_v4.StateClosed, state)
+
+	// Setting the same state is not a change.
+	client.setState(engineio_v4.StateClosed)
+	select {
+	case <-changed:
+		t.Fatal("no transition expected")
+	default:
+	}
+
+	go client.setState(engineio_v4.StateConnecting)
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("state change not notified")
+	}
+
+	state, changed = client.WatchState()
+	assert.Equal(t, engineio_v4.StateConnecting, state)
+	client.setState(engineio_v4.StateOpen)
+	<-changed
+	assert.Equal(t, engineio_v4.StateOpen, client.State())
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", engineio_v4.StateClosed.String())
+	assert.Equal(t, "connecting", engineio_v4.StateConnecting.String())
+	assert.Equal(t, "open", engineio_v4.StateOpen.String())
+	assert.Equal(t, "upgrading", engineio_v4.StateUpgrading.String())
+	assert.Equal(t, "unknown", engineio_v4.State(42).String())
+}
@@ -0,0 +1,315 @@
+// Package chaos injects network faults between an engine.io client and its
+// transports, to exercise reconnects, ack timeouts and heartbeats without a
+// real bad network:
+//
+//	c, _ := chaos.New(
+//		chaos.WithSeed(42),
+//		chaos.WithLatency(50*time.Millisecond, 20*time.Millisecond),
+//		chaos.WithDrop(0.05, 0),
+//		chaos.WithCloseAfter(5*time.Second, 10*time.Second),
+//	)
+//	polling := c.Wrap(pollingTransport)
+//	ws, _ := engineio_v4_client_transport_ws.NewTransport(
+//		engineio_v4_client_transport_ws.WithWebSocket(c.WrapWebSocket(&ws_native.WebSocketConnection{})),
+//	)
+//
+// Wrap works on whole engine.io transports, WrapWebSocket one layer down,
+// where a cut is a real connection error. Every fault draws from its own
+// random stream derived from the seed, so a run is reproduced by reusing the
+// seed, and enabling a fault does not change the decisions of the others.
+package chaos
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// ErrCut is reported for a connection closed by WithCloseAfter or Cut: on
+// onClose for a wrapped transport, and from Receive for a wrapped WebSocket.
+var ErrCut = errors.New("chaos: connection cut")
+
+type Option func(*Chaos) error
+
+const (
+	inbound = iota
+	outbound
+)
+
+// Faults, each with its own random stream.
+const (
+	faultLatency = iota
+	faultDropIn
+	faultDropOut
+	faultDuplicateIn
+	faultDuplicateOut
+	faultTruncateIn
+	faultTruncateOut
+	faultStall
+	faultClose
+	faultCount
+)
+
+// Chaos holds the fault settings shared by the transports and connections it
+// wraps. It is safe for concurrent use.
+type Chaos struct {
+	seed  int64
+	clock utils.Clock
+
+	latency, jitter    time.Duration
+	drop               [2]float64 // by direction
+	duplicate          [2]float64
+	truncate           [2]float64
+	stall              float64
+	stallFor           time.Duration
+	closeMin, closeMax time.Duration
+
+	dice [faultCount]*dice
+
+	mu    sync.Mutex
+	links map[link]utils.Timer // the scheduled cut, if any
+}
+
+// link is a running transport or an open connection that can be cut.
+type link interface {
+	cut()
+}
+
+// New returns a Chaos without any fault; enable them with options. The seed
+// defaults to the current time, see Seed.
+func New(options ...Option) (*Chaos, error) {
+	c := &Chaos{
+		seed:  time.Now().UnixNano(),
+		clock: utils.RealClock{},
+		links: make(map[link]utils.Timer),
+	}
+
+	for _, opt := range options {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.clock == nil {
+		return nil, errors.New("clock is nil")
+	}
+
+	for fault := range c.dice {
+		c.dice[fault] = &dice{rand: rand.New(rand.NewSource(c.seed + int64(fault)))}
+	}
+
+	return c, nil
+}
+
+// WithSeed makes the faults reproducible.
+func WithSeed(seed int64) Option {
+	return func(c *Chaos) error {
+		c.seed = seed
+		return nil
+	}
+}
+
+// WithClock times latency, stalls and scheduled cuts on clock, e.g. a
+// fakeclock.Clock.
+func WithClock(clock utils.Clock) Option {
+	return func(c *Chaos) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		c.clock = clock
+		return nil
+	}
+}
+
+// WithLatency delays every frame, in both directions, by latency plus a
+// random duration up to jitter. Frames keep their order.
+func WithLatency(latency, jitter time.Duration) Option {
+	return func(c *Chaos) error {
+		if latency < 0 || jitter < 0 {
+			return errors.New("latency and jitter must not be negative")
+		}
+		c.latency, c.jitter = latency, jitter
+		return nil
+	}
+}
+
+// WithDrop loses received and sent frames with the given probabilities. A
+// dropped send still succeeds.
+func WithDrop(inboundP, outboundP float64) Option {
+	return func(c *Chaos) error {
+		return setProbabilities(&c.drop, inboundP, outboundP)
+	}
+}
+
+// WithDuplicate delivers or sends frames twice with the given probabilities.
+func WithDuplicate(inboundP, outboundP float64) Option {
+	return func(c *Chaos) error {
+		return setProbabilities(&c.duplicate, inboundP, outboundP)
+	}
+}
+
+// WithTruncate cuts frames of more than one byte to a random shorter length
+// with the given probabilities. The packet type is kept, the payload is not.
+func WithTruncate(inboundP, outboundP float64) Option {
+	return func(c *Chaos) error {
+		return setProbabilities(&c.truncate, inboundP, outboundP)
+	}
+}
+
+// WithStall holds a received frame back for duration with the given
+// probability, on top of the latency, like a reader that stops being
+// scheduled.
+func WithStall(probability float64, duration time.Duration) Option {
+	return func(c *Chaos) error {
+		if err := checkProbability(probability); err != nil {
+			return err
+		}
+		if duration < 0 {
+			return errors.New("stall duration must not be negative")
+		}
+		c.stall, c.stallFor = probability, duration
+		return nil
+	}
+}
+
+// WithCloseAfter cuts every run of a wrapped transport, and every wrapped
+// connection, after a random duration between earliest and latest.
+func WithCloseAfter(earliest, latest time.Duration) Option {
+	return func(c *Chaos) error {
+		if earliest <= 0 || latest < earliest {
+			return errors.New("close schedule needs 0 < earliest <= latest")
+		}
+		c.closeMin, c.closeMax = earliest, latest
+		return nil
+	}
+}
+
+func setProbabilities(p *[2]float64, inboundP, outboundP float64) error {
+	if err := checkProbability(inboundP); err != nil {
+		return err
+	}
+	if err := checkProbability(outboundP); err != nil {
+		return err
+	}
+	p[inbound], p[outbound] = inboundP, outboundP
+	return nil
+}
+
+func checkProbability(p float64) error {
+	if p < 0 || p > 1 {
+		return errors.New("probability must be between 0 and 1")
+	}
+	return nil
+}
+
+// Seed returns the seed in use, to be logged so a failing run can be
+// reproduced with WithSeed.
+func (c *Chaos) Seed() int64 {
+	return c.seed
+}
+
+// Cut closes every running transport and open connection wrapped by c.
+func (c *Chaos) Cut() {
+	c.mu.Lock()
+	links := make([]link, 0, len(c.links))
+	for l := range c.links {
+		links = append(links, l)
+	}
+	c.mu.Unlock()
+
+	for _, l := range links {
+		l.cut()
+	}
+}
+
+// attach registers l for Cut and schedules its cut.
+func (c *Chaos) attach(l link) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var timer utils.Timer
+	if c.closeMax > 0 {
+		timer = c.clock.AfterFunc(c.dice[faultClose].duration(c.closeMin, c.closeMax), l.cut)
+	}
+	c.links[l] = timer
+}
+
+// detach undoes attach once l is closed.
+func (c *Chaos) detach(l link) {
+	c.mu.Lock()
+	timer := c.links[l]
+	delete(c.links, l)
+	c.mu.Unlock()
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// frames applies drop, duplication and truncation to a frame going in
+// direction, and returns what is left to deliver.
+func (c *Chaos) frames(direction int, frame []byte) [][]byte {
+	if c.dice[faultDropIn+direction].roll(c.drop[direction]) {
+		return nil
+	}
+	if c.dice[faultTruncateIn+direction].roll(c.truncate[direction]) && len(frame) > 1 {
+		frame = append([]byte(nil), frame[:1+c.dice[faultTruncateIn+direction].intn(len(frame)-1)]...)
+	}
+	if c.dice[faultDuplicateIn+direction].roll(c.duplicate[direction]) {
+		return [][]byte{frame, append([]byte(nil), frame...)}
+	}
+	return [][]byte{frame}
+}
+
+// delay waits out the latency, and a stall for inbound frames. It returns
+// false if done is closed first.
+func (c *Chaos) delay(direction int, done <-chan struct{}) bool {
+	d := c.latency
+	if c.jitter > 0 {
+		d += c.dice[faultLatency].duration(0, c.jitter)
+	}
+	if direction == inbound && c.dice[faultStall].roll(c.stall) {
+		d += c.stallFor
+	}
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-c.clock.After(d):
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// dice is the random stream of one fault.
+type dice struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func (d *dice) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rand.Float64() < p
+}
+
+func (d *dice) intn(n int) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rand.Intn(n)
+}
+
+// duration returns a duration in [from, to].
+func (d *dice) duration(from, to time.Duration) time.Duration {
+	if to <= from {
+		return from
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return from + time.Duration(d.rand.Int63n(int64(to-from)+1))
+}
@@ -0,0 +1,446 @@
+package chaos
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	engineio_v4_client_transport_polling "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/polling"
+	"github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/transporttest"
+	engineio_v4_client_transport_ws "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket"
+	"github.com/maldikhan/go.socket.io/utils"
+	"github.com/maldikhan/go.socket.io/utils/fakeclock"
+	ws_native "github.com/maldikhan/go.socket.io/websocket/native"
+)
+
+var quietLogger = &utils.DefaultLogger{Level: utils.NONE}
+
+// fakeTransport hands the test the channels of the current run.
+type fakeTransport struct {
+	messages chan<- []byte
+	onClose  chan<- error
+	sent     chan []byte
+	stopOnce sync.Once
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{sent: make(chan []byte, 10)}
+}
+
+func (f *fakeTransport) Transport() engineio_v4.EngineIOTransport {
+	return engineio_v4.TransportPolling
+}
+
+func (f *fakeTransport) Run(_ context.Context, _ *url.URL, _ string, messages chan<- []byte, onClose chan<- error) error {
+	f.messages, f.onClose = messages, onClose
+	return nil
+}
+
+func (f *fakeTransport) SetHandshake(*engineio_v4.HandshakeResponse) {}
+
+func (f *fakeTransport) RequestHandshake() error {
+	return nil
+}
+
+func (f *fakeTransport) Stop() error {
+	f.stopOnce.Do(func() { f.onClose <- nil })
+	return nil
+}
+
+func (f *fakeTransport) SendMessage(message []byte) error {
+	f.sent <- message
+	return nil
+}
+
+// fakeWebSocket is a connection the test reads and writes through channels.
+type fakeWebSocket struct {
+	in        chan []byte
+	sent      chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFakeWebSocket() *fakeWebSocket {
+	return &fakeWebSocket{in: make(chan []byte, 10), sent: make(chan []byte, 10), closed: make(chan struct{})}
+}
+
+func (f *fakeWebSocket) Dial(context.Context, *url.URL, *url.URL) error {
+	return nil
+}
+
+func (f *fakeWebSocket) Send(v []byte) error {
+	f.sent <- v
+	return nil
+}
+
+// Receive returns the queued frames before reporting the close.
+func (f *fakeWebSocket) Receive(v *[]byte) error {
+	select {
+	case message := <-f.in:
+		*v = message
+		return nil
+	default:
+	}
+	select {
+	case message := <-f.in:
+		*v = message
+		return nil
+	case <-f.closed:
+		return net.ErrClosed
+	}
+}
+
+func (f *fakeWebSocket) Close() error {
+	f.closeOnce.Do(func() { close(f.closed) })
+	return nil
+}
+
+// running is a wrapped fakeTransport after Run.
+type running struct {
+	fake     *fakeTransport
+	wrapped  engineio_v4_client.Transport
+	messages chan []byte
+	onClose  chan error
+}
+
+func runFake(t *testing.T, c *Chaos) *running {
+	t.Helper()
+	r := &running{fake: newFakeTransport(), messages: make(chan []byte, 10), onClose: make(chan error, 1)}
+	r.wrapped = c.Wrap(r.fake)
+	require.NoError(t, r.wrapped.Run(context.Background(), &url.URL{}, "", r.messages, r.onClose))
+	t.Cleanup(func() { _ = r.wrapped.Stop() })
+	return r
+}
+
+func dialFake(t *testing.T, c *Chaos) (*fakeWebSocket, engineio_v4_client_transport_ws.WebSocket) {
+	t.Helper()
+	fake := newFakeWebSocket()
+	wrapped := c.WrapWebSocket(fake)
+	require.NoError(t, wrapped.Dial(context.Background(), &url.URL{}, &url.URL{}))
+	t.Cleanup(func() { _ = wrapped.Close() })
+	return fake, wrapped
+}
+
+func receive(t *testing.T, ch <-chan []byte) string {
+	t.Helper()
+	select {
+	case message := <-ch:
+		return string(message)
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+		return ""
+	}
+}
+
+func nothing(t *testing.T, ch <-chan []byte) {
+	t.Helper()
+	select {
+	case message := <-ch:
+		t.Fatalf("unexpected frame %q", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNew(t *testing.T) {
+	for name, option := range map[string]Option{
+		"Nil clock":             WithClock(nil),
+		"Negative latency":      WithLatency(-time.Second, 0),
+		"Negative jitter":       WithLatency(0, -time.Second),
+		"Drop above one":        WithDrop(1.5, 0),
+		"Negative duplicate":    WithDuplicate(0, -0.1),
+		"Truncate above one":    WithTruncate(0, 2),
+		"Stall above one":       WithStall(1.1, time.Second),
+		"Negative stall":        WithStall(0.5, -time.Second),
+		"Zero close schedule":   WithCloseAfter(0, time.Second),
+		"Inverted close window": WithCloseAfter(2*time.Second, time.Second),
+	} {
+		option := option
+		t.Run(name, func(t *testing.T) {
+			_, err := New(option)
+			assert.Error(t, err)
+		})
+	}
+
+	c, err := New(WithSeed(42))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), c.Seed())
+}
+
+func TestConformance(t *testing.T) {
+	for name, options := range map[string][]Option{
+		"No faults": nil,
+		"Latency":   {WithLatency(time.Millisecond, time.Millisecond)},
+	} {
+		options := options
+		t.Run(name, func(t *testing.T) {
+			c, err := New(options...)
+			require.NoError(t, err)
+
+			t.Run("polling", func(t *testing.T) {
+				transporttest.Run(t, func(t *testing.T) transporttest.Transport {
+					transport, err := engineio_v4_client_transport_polling.NewTransport(engineio_v4_client_transport_polling.WithLogger(quietLogger))
+					require.NoError(t, err)
+					return c.Wrap(transport)
+				})
+			})
+			t.Run("websocket", func(t *testing.T) {
+				transporttest.Run(t, func(t *testing.T) transporttest.Transport {
+					transport, err := engineio_v4_client_transport_ws.NewTransport(engineio_v4_client_transport_ws.WithLogger(quietLogger))
+					require.NoError(t, err)
+					return c.Wrap(transport)
+				})
+			})
+			t.Run("WebSocket", func(t *testing.T) {
+				transporttest.Run(t, func(t *testing.T) transporttest.Transport {
+					transport, err := engineio_v4_client_transport_ws.NewTransport(
+						engineio_v4_client_transport_ws.WithLogger(quietLogger),
+						engineio_v4_client_transport_ws.WithWebSocket(c.WrapWebSocket(&ws_native.WebSocketConnection{})),
+					)
+					require.NoError(t, err)
+					return transport
+				})
+			})
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("No faults", func(t *testing.T) {
+		c, err := New()
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		r.fake.messages <- []byte("4in")
+		assert.Equal(t, "4in", receive(t, r.messages))
+		require.NoError(t, r.wrapped.SendMessage([]byte("4out")))
+		assert.Equal(t, "4out", receive(t, r.fake.sent))
+		assert.Equal(t, engineio_v4.TransportPolling, r.wrapped.Transport())
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		c, err := New(WithDrop(1, 1))
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		r.fake.messages <- []byte("4in")
+		nothing(t, r.messages)
+		require.NoError(t, r.wrapped.SendMessage([]byte("4out")))
+		nothing(t, r.fake.sent)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		c, err := New(WithDuplicate(1, 1))
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		r.fake.messages <- []byte("4in")
+		assert.Equal(t, "4in", receive(t, r.messages))
+		assert.Equal(t, "4in", receive(t, r.messages))
+		require.NoError(t, r.wrapped.SendMessage([]byte("4out")))
+		assert.Equal(t, "4out", receive(t, r.fake.sent))
+		assert.Equal(t, "4out", receive(t, r.fake.sent))
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		c, err := New(WithTruncate(1, 1))
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		r.fake.messages <- []byte("4inbound")
+		in := receive(t, r.messages)
+		assert.True(t, len(in) >= 1 && len(in) < len("4inbound"), in)
+		assert.Equal(t, "4inbound"[:len(in)], in)
+
+		require.NoError(t, r.wrapped.SendMessage([]byte("4outbound")))
+		out := receive(t, r.fake.sent)
+		assert.True(t, len(out) >= 1 && len(out) < len("4outbound"), out)
+
+		// A bare packet type has nothing to cut.
+		r.fake.messages <- []byte("2")
+		assert.Equal(t, "2", receive(t, r.messages))
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		clock := fakeclock.New(time.Now())
+		c, err := New(WithClock(clock), WithLatency(time.Second, 0))
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		r.fake.messages <- []byte("4in")
+		clock.BlockUntil(1)
+		nothing(t, r.messages)
+		clock.Advance(time.Second)
+		assert.Equal(t, "4in", receive(t, r.messages))
+
+		sent := make(chan error, 1)
+		go func() { sent <- r.wrapped.SendMessage([]byte("4out")) }()
+		clock.BlockUntil(1)
+		nothing(t, r.fake.sent)
+		clock.Advance(time.Second)
+		assert.Equal(t, "4out", receive(t, r.fake.sent))
+		assert.NoError(t, <-sent)
+	})
+
+	t.Run("Stall", func(t *testing.T) {
+		clock := fakeclock.New(time.Now())
+		c, err := New(WithClock(clock), WithStall(1, time.Minute))
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		r.fake.messages <- []byte("4in")
+		clock.BlockUntil(1)
+		clock.Advance(59 * time.Second)
+		nothing(t, r.messages)
+		clock.Advance(time.Second)
+		assert.Equal(t, "4in", receive(t, r.messages))
+
+		// Stalls hold reads only.
+		require.NoError(t, r.wrapped.SendMessage([]byte("4out")))
+		assert.Equal(t, "4out", receive(t, r.fake.sent))
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		c, err := New()
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		require.NoError(t, r.wrapped.Stop())
+		assert.NoError(t, <-r.onClose)
+	})
+
+	t.Run("Cut", func(t *testing.T) {
+		c, err := New()
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		c.Cut()
+		assert.ErrorIs(t, <-r.onClose, ErrCut)
+		// Closed runs are forgotten.
+		c.Cut()
+	})
+
+	t.Run("Close schedule", func(t *testing.T) {
+		clock := fakeclock.New(time.Now())
+		c, err := New(WithClock(clock), WithCloseAfter(time.Second, time.Second))
+		require.NoError(t, err)
+		r := runFake(t, c)
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		assert.ErrorIs(t, <-r.onClose, ErrCut)
+		assert.Equal(t, 0, clock.Waiters())
+	})
+}
+
+func TestWrapWebSocket(t *testing.T) {
+	t.Run("No faults", func(t *testing.T) {
+		c, err := New()
+		require.NoError(t, err)
+		fake, wrapped := dialFake(t, c)
+
+		var message []byte
+		fake.in <- []byte("4in")
+		require.NoError(t, wrapped.Receive(&message))
+		assert.Equal(t, "4in", string(message))
+		require.NoError(t, wrapped.Send([]byte("4out")))
+		assert.Equal(t, "4out", receive(t, fake.sent))
+	})
+
+	t.Run("Drop and duplicate", func(t *testing.T) {
+		// Every inbound frame is duplicated, half of them dropped first.
+		c, err := New(WithSeed(1), WithDrop(0.5, 0), WithDuplicate(1, 0))
+		require.NoError(t, err)
+		fake, wrapped := dialFake(t, c)
+
+		for i := 0; i < 10; i++ {
+			fake.in <- []byte{'4', byte('0' + i)}
+		}
+		_ = fake.Close()
+
+		var got []string
+		for {
+			var message []byte
+			if err := wrapped.Receive(&message); err != nil {
+				assert.ErrorIs(t, err, net.ErrClosed)
+				break
+			}
+			got = append(got, string(message))
+		}
+		require.NotEmpty(t, got)
+		assert.Less(t, len(got), 20)
+		for i := 0; i < len(got); i += 2 {
+			assert.Equal(t, got[i], got[i+1])
+		}
+	})
+
+	t.Run("Cut", func(t *testing.T) {
+		c, err := New()
+		require.NoError(t, err)
+		_, wrapped := dialFake(t, c)
+
+		received := make(chan error, 1)
+		go func() {
+			var message []byte
+			received <- wrapped.Receive(&message)
+		}()
+		c.Cut()
+		assert.ErrorIs(t, <-received, ErrCut)
+		assert.ErrorIs(t, wrapped.Send([]byte("4out")), ErrCut)
+		assert.NoError(t, wrapped.Close())
+	})
+
+	t.Run("Cut during latency", func(t *testing.T) {
+		clock := fakeclock.New(time.Now())
+		c, err := New(WithClock(clock), WithLatency(time.Minute, 0))
+		require.NoError(t, err)
+		fake, wrapped := dialFake(t, c)
+
+		received := make(chan error, 1)
+		go func() {
+			var message []byte
+			received <- wrapped.Receive(&message)
+		}()
+		fake.in <- []byte("4in")
+		clock.BlockUntil(1)
+		c.Cut()
+		assert.ErrorIs(t, <-received, ErrCut)
+	})
+
+	t.Run("Close schedule", func(t *testing.T) {
+		clock := fakeclock.New(time.Now())
+		c, err := New(WithClock(clock), WithCloseAfter(time.Second, 2*time.Second))
+		require.NoError(t, err)
+		_, wrapped := dialFake(t, c)
+
+		clock.BlockUntil(1)
+		clock.Advance(2 * time.Second)
+		var message []byte
+		assert.ErrorIs(t, wrapped.Receive(&message), ErrCut)
+	})
+}
+
+func TestSeed(t *testing.T) {
+	// pattern returns which of 64 inbound frames are dropped.
+	pattern := func(options ...Option) []bool {
+		c, err := New(append([]Option{WithDrop(0.5, 0)}, options...)...)
+		require.NoError(t, err)
+		dropped := make([]bool, 64)
+		for i := range dropped {
+			dropped[i] = len(c.frames(inbound, []byte("4frame"))) == 0
+		}
+		return dropped
+	}
+
+	assert.Equal(t, pattern(WithSeed(7)), pattern(WithSeed(7)))
+	assert.NotEqual(t, pattern(WithSeed(7)), pattern(WithSeed(8)))
+	// Other faults draw from their own streams.
+	assert.Equal(t, pattern(WithSeed(7)), pattern(WithSeed(7), WithDuplicate(0.5, 0.5), WithTruncate(0.5, 0)))
+}
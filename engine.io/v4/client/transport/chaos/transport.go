@@ -0,0 +1,163 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"sync/atomic"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+)
+
+// errStopped is returned by a send whose latency outlived the run.
+var errStopped = errors.New("chaos: transport stopped")
+
+// Wrap returns transport with the faults of c applied to its frames. A cut
+// stops transport and reports ErrCut on onClose.
+func (c *Chaos) Wrap(transport engineio_v4_client.Transport) engineio_v4_client.Transport {
+	return &chaosTransport{chaos: c, transport: transport}
+}
+
+type chaosTransport struct {
+	chaos     *Chaos
+	transport engineio_v4_client.Transport
+
+	mu  sync.Mutex
+	run *run // the current run, nil before the first one
+}
+
+// run is one Run of a wrapped transport.
+type run struct {
+	transport engineio_v4_client.Transport
+	// ctx ends with the Run context, Stop or a cut, and aborts the delayed
+	// frames.
+	ctx     context.Context
+	abort   context.CancelFunc
+	closed  chan struct{} // closed once the wrapped transport has closed
+	cutDone uint32        // atomic; 1 once cut
+}
+
+func (r *run) cut() {
+	if atomic.CompareAndSwapUint32(&r.cutDone, 0, 1) {
+		r.abort()
+		_ = r.transport.Stop()
+	}
+}
+
+func (t *chaosTransport) Run(
+	ctx context.Context,
+	url *url.URL,
+	sid string,
+	messagesChan chan<- []byte,
+	onClose chan<- error,
+) error {
+	r := &run{transport: t.transport, closed: make(chan struct{})}
+	r.ctx, r.abort = context.WithCancel(ctx)
+	// Inbound frames go through the forwarder, which applies the faults in
+	// delivery order; onClose waits for it so no frame follows the close.
+	messages := make(chan []byte)
+	closed := make(chan error, 1)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		t.forward(r, messages, messagesChan)
+	}()
+
+	if err := t.transport.Run(ctx, url, sid, messages, closed); err != nil {
+		r.abort()
+		<-forwarded
+		return err
+	}
+
+	t.mu.Lock()
+	t.run = r
+	t.mu.Unlock()
+	t.chaos.attach(r)
+
+	go func() {
+		err := <-closed
+		t.chaos.detach(r)
+		close(r.closed)
+		<-forwarded
+		r.abort()
+		if atomic.LoadUint32(&r.cutDone) == 1 {
+			err = ErrCut
+		}
+		onClose <- err
+	}()
+	return nil
+}
+
+// forward delivers the inbound frames of r. A frame taken before the wrapped
+// transport closed on its own, e.g. a CLOSE packet, is still delivered.
+func (t *chaosTransport) forward(r *run, in <-chan []byte, out chan<- []byte) {
+	for {
+		select {
+		case message := <-in:
+			for _, frame := range t.chaos.frames(inbound, message) {
+				if !t.chaos.delay(inbound, r.ctx.Done()) {
+					return
+				}
+				select {
+				case out <- frame:
+				case <-r.ctx.Done():
+					return
+				}
+			}
+		case <-r.closed:
+			return
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// current returns the current or last run, nil before the first one.
+func (t *chaosTransport) current() *run {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.run
+}
+
+func (t *chaosTransport) Transport() engineio_v4.EngineIOTransport {
+	return t.transport.Transport()
+}
+
+func (t *chaosTransport) SetHandshake(handshake *engineio_v4.HandshakeResponse) {
+	t.transport.SetHandshake(handshake)
+}
+
+func (t *chaosTransport) RequestHandshake() error {
+	return t.transport.RequestHandshake()
+}
+
+func (t *chaosTransport) SendMessage(message []byte) error {
+	// A nil done, outside a run, never fires.
+	r := t.current()
+	var done <-chan struct{}
+	if r != nil {
+		done = r.ctx.Done()
+	}
+	for _, frame := range t.chaos.frames(outbound, message) {
+		if !t.chaos.delay(outbound, done) {
+			if atomic.LoadUint32(&r.cutDone) == 1 {
+				return ErrCut
+			}
+			return errStopped
+		}
+		if err := t.transport.SendMessage(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop also drops the frames still held back by latency.
+func (t *chaosTransport) Stop() error {
+	if r := t.current(); r != nil {
+		r.abort()
+	}
+	return t.transport.Stop()
+}
@@ -0,0 +1,139 @@
+package chaos
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+
+	engineio_v4_client_transport_ws "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket"
+)
+
+// WrapWebSocket returns ws with the faults of c applied to its messages, for
+// the websocket transport's WithWebSocket. A cut closes the connection, so
+// the transport sees Receive fail with ErrCut.
+func (c *Chaos) WrapWebSocket(ws engineio_v4_client_transport_ws.WebSocket) engineio_v4_client_transport_ws.WebSocket {
+	return &chaosWebSocket{chaos: c, ws: ws}
+}
+
+type chaosWebSocket struct {
+	chaos *Chaos
+	ws    engineio_v4_client_transport_ws.WebSocket
+
+	mu   sync.Mutex
+	conn *connection // the current or last connection, nil before Dial
+}
+
+// connection is one Dial of a wrapped WebSocket.
+type connection struct {
+	chaos     *Chaos
+	ws        engineio_v4_client_transport_ws.WebSocket
+	done      chan struct{} // closed by Close or a cut
+	closeOnce sync.Once
+	cutDone   uint32 // atomic; 1 once cut
+
+	// pending is the second copy of a duplicated frame, returned by the
+	// next Receive. Receive is not called concurrently.
+	pending []byte
+}
+
+func (c *connection) close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.chaos.detach(c)
+		close(c.done)
+		err = c.ws.Close()
+	})
+	return err
+}
+
+func (c *connection) cut() {
+	atomic.StoreUint32(&c.cutDone, 1)
+	_ = c.close()
+}
+
+// closedErr is what Receive and Send report once the connection is closed.
+func (c *connection) closedErr(err error) error {
+	if atomic.LoadUint32(&c.cutDone) == 1 {
+		return ErrCut
+	}
+	return err
+}
+
+func (w *chaosWebSocket) current() *connection {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn
+}
+
+func (w *chaosWebSocket) Dial(ctx context.Context, url *url.URL, origin *url.URL) error {
+	if err := w.ws.Dial(ctx, url, origin); err != nil {
+		return err
+	}
+
+	conn := &connection{chaos: w.chaos, ws: w.ws, done: make(chan struct{})}
+	w.mu.Lock()
+	w.conn = conn
+	w.mu.Unlock()
+	w.chaos.attach(conn)
+	return nil
+}
+
+func (w *chaosWebSocket) Send(v []byte) error {
+	conn := w.current()
+	if conn == nil {
+		return w.ws.Send(v)
+	}
+	select {
+	case <-conn.done:
+		return conn.closedErr(net.ErrClosed)
+	default:
+	}
+	for _, frame := range w.chaos.frames(outbound, v) {
+		if !w.chaos.delay(outbound, conn.done) {
+			return conn.closedErr(net.ErrClosed)
+		}
+		if err := w.ws.Send(frame); err != nil {
+			return conn.closedErr(err)
+		}
+	}
+	return nil
+}
+
+func (w *chaosWebSocket) Receive(v *[]byte) error {
+	conn := w.current()
+	if conn == nil {
+		return w.ws.Receive(v)
+	}
+	if conn.pending != nil {
+		*v, conn.pending = conn.pending, nil
+		return nil
+	}
+	for {
+		var message []byte
+		if err := w.ws.Receive(&message); err != nil {
+			return conn.closedErr(err)
+		}
+		frames := w.chaos.frames(inbound, message)
+		if len(frames) == 0 {
+			continue
+		}
+		if !w.chaos.delay(inbound, conn.done) {
+			return conn.closedErr(net.ErrClosed)
+		}
+		*v = frames[0]
+		if len(frames) > 1 {
+			conn.pending = frames[1]
+		}
+		return nil
+	}
+}
+
+func (w *chaosWebSocket) Close() error {
+	conn := w.current()
+	if conn == nil {
+		return w.ws.Close()
+	}
+	return conn.close()
+}
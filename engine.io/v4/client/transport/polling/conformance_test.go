@@ -0,0 +1,18 @@
+package engineio_v4_client_transport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/transporttest"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+func TestConformance(t *testing.T) {
+	transporttest.Run(t, func(t *testing.T) transporttest.Transport {
+		transport, err := NewTransport(WithLogger(&utils.DefaultLogger{Level: utils.NONE}))
+		require.NoError(t, err)
+		return transport
+	})
+}
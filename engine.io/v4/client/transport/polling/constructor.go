@@ -20,8 +20,9 @@ func NewTransport(options ...EngineTransportOption) (*Transport, error) {
 	// Create default client
 	client := &Transport{
 		log:            &utils.DefaultLogger{},
+		metrics:        utils.NopMetrics{},
 		httpClient:     &http.Client{Timeout: defaultHTTPTimeout},
-		pinger:         time.NewTicker(10 * time.Second),
+		clock:          utils.RealClock{},
 		stopPooling:    make(chan struct{}, 1),
 		stopCh:         make(chan struct{}),
 		maxPayloadSize:   4 * 1024 * 1024, // 4MB default, matches socket.io JS maxHttpBufferSize
@@ -36,13 +37,18 @@ func NewTransport(options ...EngineTransportOption) (*Transport, error) {
 		}
 	}
 
+	if client.clock == nil {
+		return nil, errors.New("clock is nil")
+	}
+
 	if client.pinger == nil {
-		return nil, errors.New("pinger is nil")
+		client.pinger = client.clock.NewTicker(10 * time.Second)
 	}
 
 	if client.log == nil {
 		return nil, errors.New("logger is nil")
 	}
+	client.sessionLog = client.sessionLogger("")
 
 	if client.httpClient == nil {
 		return nil, errors.New("HTTP client is nil")
@@ -51,6 +57,18 @@ func NewTransport(options ...EngineTransportOption) (*Transport, error) {
 	return client, nil
 }
 
+// WithMetrics reports packets and bytes by type and the latency of
+// every HTTP request to metrics.
+func WithMetrics(metrics Metrics) EngineTransportOption {
+	return func(c *Transport) error {
+		if metrics == nil {
+			return errors.New("metrics is nil")
+		}
+		c.metrics = metrics
+		return nil
+	}
+}
+
 func WithLogger(logger Logger) EngineTransportOption {
 	return func(c *Transport) error {
 		c.log = logger
@@ -65,14 +83,55 @@ func WithHTTPClient(httpClient HttpClient) EngineTransportOption {
 	}
 }
 
+// WithHTTPTransport sends the requests through transport, e.g. an
+// *http.Transport with a custom DialContext or TLS configuration, keeping the
+// default request timeout.
+func WithHTTPTransport(transport http.RoundTripper) EngineTransportOption {
+	return func(c *Transport) error {
+		if transport == nil {
+			return errors.New("HTTP transport is nil")
+		}
+		c.httpClient = &http.Client{Transport: transport, Timeout: defaultHTTPTimeout}
+		return nil
+	}
+}
+
+// WithDefaultPinger sets the heartbeat ticker. See WithPinger for a ticker of
+// a utils.Clock.
 func WithDefaultPinger(pinger *time.Ticker) EngineTransportOption {
+	if pinger == nil {
+		return WithPinger(nil)
+	}
+	return WithPinger(utils.WrapTicker(pinger))
+}
+
+// WithPinger sets the heartbeat ticker, e.g. one of the clock given to
+// WithClock.
+func WithPinger(pinger utils.Ticker) EngineTransportOption {
 	return func(c *Transport) error {
-		c.pinger.Stop()
+		if pinger == nil {
+			return errors.New("pinger is nil")
+		}
+		if c.pinger != nil {
+			c.pinger.Stop()
+		}
 		c.pinger = pinger
 		return nil
 	}
 }
 
+// WithClock drives the pinger, the request timings and the backoff after a
+// failed poll from clock.
+func WithClock(clock utils.Clock) EngineTransportOption {
+	return func(c *Transport) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		c.clock = clock
+		return nil
+	}
+}
+
 func WithMaxPayloadSize(size int64) EngineTransportOption {
 	return func(c *Transport) error {
 		if size <= 0 {
@@ -91,3 +150,15 @@ func WithDebugPayload(enabled bool) EngineTransportOption {
 		return nil
 	}
 }
+
+// WithRedactionPolicy logs request and response bodies at debug level with
+// the secrets masked by policy.
+func WithRedactionPolicy(policy *utils.RedactionPolicy) EngineTransportOption {
+	return func(c *Transport) error {
+		if policy == nil {
+			return errors.New("redaction policy is nil")
+		}
+		c.redaction = policy
+		return nil
+	}
+}
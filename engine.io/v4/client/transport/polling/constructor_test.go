@@ -30,7 +30,7 @@ func TestNewTransport(t *testing.T) {
 			want: &Transport{
 				log:         &utils.DefaultLogger{},
 				httpClient:  &http.Client{},
-				pinger:      time.NewTicker(10 * time.Second),
+				pinger:      utils.RealClock{}.NewTicker(10 * time.Second),
 				stopPooling: make(chan struct{}, 1),
 			},
 		},
@@ -42,7 +42,7 @@ func TestNewTransport(t *testing.T) {
 			want: &Transport{
 				log:         mockLogger,
 				httpClient:  &http.Client{},
-				pinger:      time.NewTicker(10 * time.Second),
+				pinger:      utils.RealClock{}.NewTicker(10 * time.Second),
 				stopPooling: make(chan struct{}, 1),
 			},
 		},
@@ -61,7 +61,7 @@ func TestNewTransport(t *testing.T) {
 			want: &Transport{
 				log:         &utils.DefaultLogger{},
 				httpClient:  mockHTTPClient,
-				pinger:      time.NewTicker(10 * time.Second),
+				pinger:      utils.RealClock{}.NewTicker(10 * time.Second),
 				stopPooling: make(chan struct{}, 1),
 			},
 		},
@@ -75,12 +75,12 @@ func TestNewTransport(t *testing.T) {
 		{
 			name: "With custom pinger",
 			options: []EngineTransportOption{
-				WithDefaultPinger(time.NewTicker(5 * time.Second)),
+				WithPinger(utils.RealClock{}.NewTicker(5 * time.Second)),
 			},
 			want: &Transport{
 				log:         &utils.DefaultLogger{},
 				httpClient:  &http.Client{},
-				pinger:      time.NewTicker(5 * time.Second),
+				pinger:      utils.RealClock{}.NewTicker(5 * time.Second),
 				stopPooling: make(chan struct{}, 1),
 			},
 		},
@@ -204,12 +204,28 @@ func TestWithHTTPClient(t *testing.T) {
 	}
 }
 
+func TestWithHTTPTransport(t *testing.T) {
+	roundTripper := &http.Transport{}
+	transport := &Transport{}
+	if err := WithHTTPTransport(roundTripper)(transport); err != nil {
+		t.Fatalf("WithHTTPTransport() returned an error: %v", err)
+	}
+	httpClient, ok := transport.httpClient.(*http.Client)
+	if !ok || httpClient.Transport != roundTripper || httpClient.Timeout != defaultHTTPTimeout {
+		t.Errorf("WithHTTPTransport() httpClient = %#v", transport.httpClient)
+	}
+
+	if err := WithHTTPTransport(nil)(transport); err == nil {
+		t.Error("WithHTTPTransport(nil) should return an error")
+	}
+}
+
 func TestWithDefaultPinger(t *testing.T) {
 	customPinger := time.NewTicker(5 * time.Second)
 	option := WithDefaultPinger(customPinger)
 
 	transport := &Transport{
-		pinger: time.NewTicker(10 * time.Second),
+		pinger: utils.RealClock{}.NewTicker(10 * time.Second),
 	}
 	err := option(transport)
 
@@ -217,11 +233,29 @@ func TestWithDefaultPinger(t *testing.T) {
 		t.Errorf("WithDefaultPinger() returned an error: %v", err)
 	}
 
-	if transport.pinger != customPinger {
+	if transport.pinger.C() != customPinger.C {
 		t.Errorf("WithDefaultPinger() did not set the pinger correctly")
 	}
 }
 
+func TestWithPinger(t *testing.T) {
+	customPinger := utils.RealClock{}.NewTicker(5 * time.Second)
+	option := WithPinger(customPinger)
+
+	transport := &Transport{
+		pinger: utils.RealClock{}.NewTicker(10 * time.Second),
+	}
+	err := option(transport)
+
+	if err != nil {
+		t.Errorf("WithPinger() returned an error: %v", err)
+	}
+
+	if transport.pinger != customPinger {
+		t.Errorf("WithPinger() did not set the pinger correctly")
+	}
+}
+
 func TestWithMaxPayloadSize(t *testing.T) {
 	t.Run("Valid size", func(t *testing.T) {
 		option := WithMaxPayloadSize(8 * 1024 * 1024) // 8MB
@@ -4,6 +4,7 @@ package engineio_v4_client_transport
 
 import (
 	"net/http"
+	"time"
 )
 
 type Logger interface {
@@ -16,3 +17,10 @@ type Logger interface {
 type HttpClient interface {
 	Do(req *http.Request) (resp *http.Response, err error)
 }
+
+// Metrics receives the transport measurements; see the metrics package.
+type Metrics interface {
+	PacketSent(transport, packetType string, bytes int)
+	PacketReceived(transport, packetType string, bytes int)
+	PollingRequest(method string, duration time.Duration, err error)
+}
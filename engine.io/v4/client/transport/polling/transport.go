@@ -16,6 +16,7 @@ import (
 	// to maintain compatibility with Go 1.18 (atomic.Bool requires Go 1.19).
 
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	"github.com/maldikhan/go.socket.io/utils"
 )
 
 // errTransportStopped is an internal sentinel returned by poll() when it
@@ -26,8 +27,10 @@ var errTransportStopped = errors.New("transport stopped")
 
 type Transport struct {
 	log        Logger
+	metrics    Metrics
 	httpClient HttpClient
-	pinger     *time.Ticker
+	pinger     utils.Ticker
+	clock      utils.Clock
 
 	url *url.URL
 	sid string
@@ -68,25 +71,87 @@ type Transport struct {
 	pollErrorBackoff time.Duration
 
 	// mu guards the sid field, which is written by SetHandshake()/Run() and
-	// read by buildHttpUrl() from the polling goroutine.
+	// read by buildHttpUrl() from the polling goroutine, and sessionLog.
 	mu sync.RWMutex
 
+	// sessionLog is log with the transport and session id fields.
+	sessionLog Logger
+
 	// redactPayload, when true, replaces raw payloads in debug logs with a
 	// size marker. Zero value is verbose; NewTransport sets the safe default.
 	redactPayload bool
+
+	// redaction masks the logged request and response bodies when set.
+	redaction *utils.RedactionPolicy
 }
 
 // payload returns a size marker when redaction is enabled, or the raw data.
 func (c *Transport) payload(data []byte) string {
+	if c.redaction != nil {
+		return c.redaction.Redact(data)
+	}
 	if c.redactPayload {
 		return fmt.Sprintf("[redacted %d bytes]", len(data))
 	}
 	return string(data)
 }
 
+// logger returns the logger with the transport and session fields,
+// tolerating a Transport built without NewTransport.
+func (c *Transport) logger() Logger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.sessionLog == nil {
+		return c.log
+	}
+	return c.sessionLog
+}
+
+// setSidLocked sets the session id and the logger fields. The caller must
+// hold c.mu.
+func (c *Transport) setSidLocked(sid string) {
+	c.sid = sid
+	c.sessionLog = c.sessionLogger(sid)
+}
+
+// sessionLogger returns log with the transport field, and the session id
+// field once it is known.
+func (c *Transport) sessionLogger(sid string) Logger {
+	fields := []utils.Field{{Key: utils.FieldTransport, Value: c.Transport()}}
+	if sid != "" {
+		fields = append(fields, utils.Field{Key: utils.FieldSid, Value: sid})
+	}
+	return utils.WithFields(c.log, fields...)
+}
+
+// meter returns the metrics, tolerating a Transport built without
+// NewTransport (e.g. in unit tests).
+func (c *Transport) meter() Metrics {
+	if c.metrics == nil {
+		return utils.NopMetrics{}
+	}
+	return c.metrics
+}
+
+// now reads the clock, tolerating a Transport built without NewTransport.
+func (c *Transport) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// after is time.After on the configured clock.
+func (c *Transport) after(d time.Duration) <-chan time.Time {
+	if c.clock == nil {
+		return time.After(d)
+	}
+	return c.clock.After(d)
+}
+
 func (c *Transport) SetHandshake(handshake *engineio_v4.HandshakeResponse) {
 	c.mu.Lock()
-	c.sid = handshake.Sid
+	c.setSidLocked(handshake.Sid)
 	c.mu.Unlock()
 	pingInterval := 10 * time.Second
 	if handshake.PingInterval != 0 {
@@ -144,7 +209,7 @@ func (c *Transport) Run(
 	// the parent context, so an in-flight long-poll can be interrupted promptly.
 	c.reqCtx, c.pollCancel = context.WithCancel(ctx)
 	c.mu.Lock()
-	c.sid = sid
+	c.setSidLocked(sid)
 	// Fresh handshake gate for this run. If a session id is already known (e.g.
 	// this transport is the target of an upgrade, where SetHandshake() has
 	// already run with handshakeDone still nil), open it immediately so
@@ -171,7 +236,7 @@ func (c *Transport) Run(
 		c.pinger.Stop()
 		err := c.pollingLoop()
 		if err != nil {
-			c.log.Errorf("pollingLoop error: %s", err)
+			c.logger().Errorf("pollingLoop error: %s", err)
 		}
 		// TODO: reconnect
 	}()
@@ -257,9 +322,9 @@ func (c *Transport) pollingLoop() error {
 			}
 			// Genuine transient error (network blip, server hiccup): log and back
 			// off briefly, but stay responsive to stop/cancel during the pause.
-			c.log.Errorf("poll error: %s", err)
+			c.logger().Errorf("poll error: %s", err)
 			select {
-			case <-time.After(c.pollErrorBackoff):
+			case <-c.after(c.pollErrorBackoff):
 			case <-c.stopPooling:
 				return c.finishPolling(true, nil)
 			case <-c.ctx.Done():
@@ -275,9 +340,9 @@ func (c *Transport) pollingLoop() error {
 // for a context shutdown). stopRequested selects the matching debug log.
 func (c *Transport) finishPolling(stopRequested bool, ret error) error {
 	if stopRequested {
-		c.log.Debugf("stop polling")
+		c.logger().Debugf("stop polling")
 	} else {
-		c.log.Debugf("context done, stop http polling")
+		c.logger().Debugf("context done, stop http polling")
 	}
 	atomic.StoreUint32(&c.stopped, 1)
 	if c.onClose != nil {
@@ -297,7 +362,8 @@ func (c *Transport) buildHttpUrl() *url.URL {
 		Path:   c.url.Path,
 	}
 
-	query := reqURL.Query()
+	// Keep the caller's query parameters, e.g. an auth token.
+	query := c.url.Query()
 
 	query.Set("transport", "polling")
 	query.Set("EIO", "4")
@@ -308,10 +374,10 @@ func (c *Transport) buildHttpUrl() *url.URL {
 }
 
 func (c *Transport) poll() error {
-	c.log.Debugf("run polling")
+	c.logger().Debugf("run polling")
 
 	if c.messages == nil {
-		c.log.Errorf("messages channel is nil, can't read transport messages")
+		c.logger().Errorf("messages channel is nil, can't read transport messages")
 		return errors.New("messages channel is nil")
 	}
 
@@ -327,8 +393,10 @@ func (c *Transport) poll() error {
 		return fmt.Errorf("error creating request: %w", err)
 	}
 
+	start := c.now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.meter().PollingRequest(http.MethodGet, c.now().Sub(start), err)
 		return err
 	}
 	defer resp.Body.Close() //nolint:errcheck
@@ -336,9 +404,16 @@ func (c *Transport) poll() error {
 	// A non-2xx response (e.g. 400 "Session ID unknown" after the session was
 	// dropped) is surfaced as an error so the loop backs off instead of
 	// forwarding the error body as an engine.io packet and hot-spinning.
+	// Engine.io error bodies ({"code":1,"message":"Session ID unknown"}) are
+	// wrapped as *engineio_v4.ServerError so callers can match them.
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("unexpected polling response status %d", resp.StatusCode)
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err = fmt.Errorf("unexpected polling response status %d", resp.StatusCode)
+		if serverErr := engineio_v4.ParseServerError(resp.StatusCode, errBody); serverErr != nil {
+			err = fmt.Errorf("unexpected polling response status %d: %w", resp.StatusCode, serverErr)
+		}
+		c.meter().PollingRequest(http.MethodGet, c.now().Sub(start), err)
+		return err
 	}
 
 	// Limit payload size to guard against OOM from a malicious/buggy server.
@@ -355,6 +430,7 @@ func (c *Transport) poll() error {
 		reader = io.LimitReader(resp.Body, readLimit)
 	}
 	body, err := io.ReadAll(reader)
+	c.meter().PollingRequest(http.MethodGet, c.now().Sub(start), err)
 	if err != nil {
 		return err
 	}
@@ -364,36 +440,44 @@ func (c *Transport) poll() error {
 		return fmt.Errorf("inbound payload size %d exceeds maximum allowed %d", len(body), c.maxPayloadSize)
 	}
 
-	c.log.Debugf("receiveHttp: %s", c.payload(body))
+	c.logger().Debugf("receiveHttp: %s", c.payload(body))
 
-	select {
-	case c.messages <- body:
-	case <-c.ctx.Done():
-		return c.ctx.Err()
-	case <-c.stopCh:
-		// Stop() was called while we were blocked sending. stopCh is a closed
-		// channel (broadcast), so pollingLoop's own stopPooling case remains
-		// intact — it will still exit cleanly via the value Stop() enqueued.
-		return errTransportStopped
+	// A polling payload carries one or more packets separated by the
+	// record separator (0x1e).
+	for _, packet := range bytes.Split(body, []byte{0x1e}) {
+		c.meter().PacketReceived(string(engineio_v4.TransportPolling), engineio_v4.FrameType(packet).String(), len(packet))
+		select {
+		case c.messages <- packet:
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		case <-c.stopCh:
+			// Stop() was called while we were blocked sending. stopCh is a closed
+			// channel (broadcast), so pollingLoop's own stopPooling case remains
+			// intact — it will still exit cleanly via the value Stop() enqueued.
+			return errTransportStopped
+		}
 	}
 	return nil
 }
 
 func (c *Transport) SendMessage(msg []byte) error {
 
-	c.log.Debugf("sendHttp: %s", c.payload(msg))
+	c.logger().Debugf("sendHttp: %s", c.payload(msg))
 	req, err := http.NewRequestWithContext(c.ctx, "POST", c.buildHttpUrl().String(), bytes.NewReader(msg))
 	if err != nil {
 		return fmt.Errorf("error creating request: %w", err)
 	}
+	start := c.now()
 	resp, err := c.httpClient.Do(req)
+	c.meter().PollingRequest(http.MethodPost, c.now().Sub(start), err)
 	if err != nil {
 		return err
 	}
+	c.meter().PacketSent(string(engineio_v4.TransportPolling), engineio_v4.FrameType(msg).String(), len(msg))
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 	_, _ = io.Copy(io.Discard, resp.Body)
-	c.log.Debugf("receiveHttp: %s", resp.Status)
+	c.logger().Debugf("receiveHttp: %s", resp.Status)
 	return nil
 }
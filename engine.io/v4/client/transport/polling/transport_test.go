@@ -14,15 +14,18 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
 	mocks "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/polling/mocks"
+	"github.com/maldikhan/go.socket.io/utils"
+	"github.com/maldikhan/go.socket.io/utils/fakeclock"
 )
 
 func TestSetHandshake(t *testing.T) {
 	t.Parallel()
 	client := &Transport{
-		pinger: time.NewTicker(time.Minute),
+		pinger: utils.RealClock{}.NewTicker(time.Minute),
 	}
 
 	handshake := &engineio_v4.HandshakeResponse{
@@ -40,7 +43,7 @@ func TestSetHandshake(t *testing.T) {
 func TestSetHandshakeReleasesGate(t *testing.T) {
 	t.Parallel()
 	client := &Transport{
-		pinger:        time.NewTicker(time.Minute),
+		pinger:        utils.RealClock{}.NewTicker(time.Minute),
 		handshakeDone: make(chan struct{}),
 	}
 
@@ -184,7 +187,7 @@ func TestRun(t *testing.T) {
 	client := &Transport{
 		log:         mockLogger,
 		httpClient:  mockHttpClient,
-		pinger:      time.NewTicker(time.Millisecond),
+		pinger:      utils.RealClock{}.NewTicker(time.Millisecond),
 		url:         url,
 		stopPooling: make(chan struct{}, 1),
 		stopCh:      make(chan struct{}),
@@ -325,7 +328,7 @@ func TestPollingLoop(t *testing.T) {
 			url:              &url.URL{Scheme: "http", Host: "localhost"},
 			httpClient:       mockHttpClient,
 			log:              mockLogger,
-			pinger:           time.NewTicker(10 * time.Millisecond),
+			pinger:           utils.RealClock{}.NewTicker(10 * time.Millisecond),
 			stopPooling:      make(chan struct{}, 1),
 			stopCh:           make(chan struct{}),
 			ctx:              ctx,
@@ -617,6 +620,59 @@ func TestPollingLoop(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("Backoff follows the clock", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		clock := fakeclock.New(time.Now())
+
+		mockLogger := mocks.NewMockLogger(ctrl)
+		mockHttpClient := mocks.NewMockHttpClient(ctrl)
+		mockLogger.EXPECT().Debugf("run polling").AnyTimes()
+		mockLogger.EXPECT().Errorf("poll error: %s", gomock.Any())
+		mockLogger.EXPECT().Debugf("stop polling")
+
+		retried := make(chan struct{})
+		var calls int32
+		mockHttpClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return nil, errors.New("transient")
+			}
+			close(retried)
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}).Times(2)
+
+		client := &Transport{
+			url:              &url.URL{Scheme: "http", Host: "localhost"},
+			httpClient:       mockHttpClient,
+			log:              mockLogger,
+			clock:            clock,
+			stopPooling:      make(chan struct{}, 1),
+			stopCh:           make(chan struct{}),
+			ctx:              ctx,
+			onClose:          make(chan error, 1),
+			messages:         make(chan []byte, 1),
+			pollErrorBackoff: time.Minute,
+		}
+		client.reqCtx, client.pollCancel = context.WithCancel(ctx)
+
+		done := make(chan error, 1)
+		go func() { done <- client.pollingLoop() }()
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute - time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		clock.Advance(time.Millisecond)
+		<-retried
+
+		assert.NoError(t, client.Stop())
+		assert.NoError(t, <-done)
+	})
+
 	t.Run("Backoff interrupted by context", func(t *testing.T) {
 		t.Parallel()
 
@@ -660,7 +716,7 @@ func TestPollingLoop(t *testing.T) {
 func TestSetHandshakeDefaultPingInterval(t *testing.T) {
 	t.Parallel()
 	client := &Transport{
-		pinger: time.NewTicker(time.Minute),
+		pinger: utils.RealClock{}.NewTicker(time.Minute),
 	}
 
 	handshake := &engineio_v4.HandshakeResponse{
@@ -773,6 +829,37 @@ func TestPoll(t *testing.T) {
 		}
 	})
 
+	t.Run("Multiple packets in payload", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLogger := mocks.NewMockLogger(ctrl)
+		mockHTTPClient := mocks.NewMockHttpClient(ctrl)
+
+		messagesChan := make(chan []byte, 3)
+		client := &Transport{
+			log:        mockLogger,
+			httpClient: mockHTTPClient,
+			url:        &url.URL{Scheme: "http", Host: "example.com", Path: "/socket.io/"},
+			sid:        "test-sid",
+			ctx:        context.Background(),
+			messages:   messagesChan,
+		}
+
+		mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+		mockHTTPClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader("4hello\x1e2\x1e4world")),
+		}, nil)
+
+		require.NoError(t, client.poll())
+		require.Equal(t, 3, len(messagesChan))
+		assert.Equal(t, []byte("4hello"), <-messagesChan)
+		assert.Equal(t, []byte("2"), <-messagesChan)
+		assert.Equal(t, []byte("4world"), <-messagesChan)
+	})
+
 	t.Run("Non-2xx response status", func(t *testing.T) {
 		t.Parallel()
 		ctrl := gomock.NewController(t)
@@ -806,6 +893,37 @@ func TestPoll(t *testing.T) {
 		assert.Equal(t, 0, len(messagesChan))
 	})
 
+	t.Run("Engine.io error body", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockLogger := mocks.NewMockLogger(ctrl)
+		mockHTTPClient := mocks.NewMockHttpClient(ctrl)
+
+		client := &Transport{
+			log:        mockLogger,
+			httpClient: mockHTTPClient,
+			url:        &url.URL{Scheme: "http", Host: "example.com", Path: "/socket.io/"},
+			ctx:        context.Background(),
+			messages:   make(chan []byte, 1),
+		}
+
+		mockLogger.EXPECT().Debugf("run polling")
+		mockHTTPClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			StatusCode: 400,
+			Body:       io.NopCloser(strings.NewReader(`{"code":3,"message":"Bad request"}`)),
+		}, nil)
+
+		err := client.poll()
+		assert.ErrorIs(t, err, engineio_v4.ErrBadRequest)
+		var serverErr *engineio_v4.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		assert.Equal(t, 400, serverErr.StatusCode)
+		assert.Equal(t, engineio_v4.ErrorCodeBadRequest, serverErr.Code)
+		assert.Equal(t, "Bad request", serverErr.Message)
+	})
+
 	t.Run("Error creating request", func(t *testing.T) {
 		t.Parallel()
 
@@ -1342,7 +1460,7 @@ func TestRun_stopped_flag_reset(t *testing.T) {
 	client := &Transport{
 		log:         mockLogger,
 		httpClient:  mockHttpClient,
-		pinger:      time.NewTicker(time.Millisecond),
+		pinger:      utils.RealClock{}.NewTicker(time.Millisecond),
 		url:         url,
 		stopPooling: make(chan struct{}, 1),
 	}
@@ -1379,7 +1497,7 @@ func TestRun_stopped_flag_reset(t *testing.T) {
 
 		// Reset channels for second run
 		onCloseChan = make(chan error, 1)
-		client.pinger = time.NewTicker(time.Millisecond)
+		client.pinger = utils.RealClock{}.NewTicker(time.Millisecond)
 
 		// Second Run - this should reset the stopped flag and reinitialize stopPooling
 		err = client.Run(ctx, url, "test-sid-2", messagesChan, onCloseChan)
@@ -1425,7 +1543,7 @@ func TestConcurrentSetHandshakeAndBuildUrl(t *testing.T) {
 
 	client := &Transport{
 		log:    mockLogger,
-		pinger: time.NewTicker(time.Minute),
+		pinger: utils.RealClock{}.NewTicker(time.Minute),
 		url:    &url.URL{Scheme: "http", Host: "example.com", Path: "/socket.io/"},
 	}
 
@@ -1528,7 +1646,7 @@ func TestPollingLoop_StopDuringPoll(t *testing.T) {
 	client := &Transport{
 		log:         mockLogger,
 		httpClient:  mockHTTPClient,
-		pinger:      time.NewTicker(time.Millisecond),
+		pinger:      utils.RealClock{}.NewTicker(time.Millisecond),
 		url:         &url.URL{Scheme: "http", Host: "example.com", Path: "/socket.io/"},
 		ctx:         context.Background(),
 		messages:    make(chan []byte), // unbuffered: poll's send blocks
@@ -1570,6 +1688,11 @@ func TestTransport_payloadRedaction(t *testing.T) {
 	assert.Equal(t, "[redacted 5 bytes]", c.payload([]byte("hello")))
 	c.redactPayload = false
 	assert.Equal(t, "hello", c.payload([]byte("hello")))
+
+	// A policy takes precedence.
+	assert.Error(t, WithRedactionPolicy(nil)(c))
+	require.NoError(t, WithRedactionPolicy(&utils.RedactionPolicy{})(c))
+	assert.Equal(t, `42["login",{"token":"[redacted]"}]`, c.payload([]byte(`42["login",{"token":"t"}]`)))
 }
 
 func TestTransport_WithDebugPayload(t *testing.T) {
@@ -1606,7 +1729,7 @@ func TestHandshakeGateUpgradeToPolling(t *testing.T) {
 	transport := &Transport{
 		log:        mockLogger,
 		httpClient: mockHttpClient,
-		pinger:     time.NewTicker(time.Minute),
+		pinger:     utils.RealClock{}.NewTicker(time.Minute),
 	}
 
 	// SetHandshake happens first, while handshakeDone is still nil (no-op).
@@ -1637,3 +1760,77 @@ func TestHandshakeGateUpgradeToPolling(t *testing.T) {
 		t.Fatal("expected onClose after Stop")
 	}
 }
+
+func TestTransport_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mocks.NewMockLogger(ctrl)
+	mockHTTPClient := mocks.NewMockHttpClient(ctrl)
+	mockMetrics := mocks.NewMockMetrics(ctrl)
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	messages := make(chan []byte, 2)
+	client := &Transport{
+		log:        mockLogger,
+		metrics:    mockMetrics,
+		httpClient: mockHTTPClient,
+		url:        &url.URL{Scheme: "http", Host: "example.com", Path: "/socket.io/"},
+		ctx:        context.Background(),
+		messages:   messages,
+	}
+
+	mockHTTPClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader("4hello\x1e2")),
+	}, nil)
+	mockMetrics.EXPECT().PollingRequest(http.MethodGet, gomock.Any(), nil)
+	mockMetrics.EXPECT().PacketReceived("polling", "message", 6)
+	mockMetrics.EXPECT().PacketReceived("polling", "ping", 1)
+	assert.NoError(t, client.poll())
+
+	mockHTTPClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+		StatusCode: 400,
+		Body:       io.NopCloser(strings.NewReader(`{"code":1,"message":"Session ID unknown"}`)),
+	}, nil)
+	mockMetrics.EXPECT().PollingRequest(http.MethodGet, gomock.Any(), gomock.Not(nil))
+	assert.Error(t, client.poll())
+
+	mockHTTPClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Body:       io.NopCloser(strings.NewReader("ok")),
+	}, nil)
+	mockMetrics.EXPECT().PollingRequest(http.MethodPost, gomock.Any(), nil)
+	mockMetrics.EXPECT().PacketSent("polling", "message", 3)
+	assert.NoError(t, client.SendMessage([]byte("4hi")))
+}
+
+func TestTransport_sessionLogger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mocks.NewMockLogger(ctrl)
+	transport, err := NewTransport(WithLogger(utils.NewFieldLogger(mockLogger)))
+	require.NoError(t, err)
+
+	mockLogger.EXPECT().Debugf("%s", "before handshake transport=polling")
+	transport.logger().Debugf("before handshake")
+
+	transport.SetHandshake(&engineio_v4.HandshakeResponse{Sid: "new-sid"})
+	mockLogger.EXPECT().Debugf("%s", "after handshake transport=polling sid=new-sid")
+	transport.logger().Debugf("after handshake")
+}
+
+func TestTransport_buildHttpUrl_KeepsQuery(t *testing.T) {
+	client := &Transport{
+		sid: "test-sid",
+		url: &url.URL{Scheme: "http", Host: "example.com", Path: "/socket.io/", RawQuery: "token=abc&EIO=3"},
+	}
+
+	query := client.buildHttpUrl().Query()
+	assert.Equal(t, "abc", query.Get("token"))
+	assert.Equal(t, "4", query.Get("EIO"))
+	assert.Equal(t, "polling", query.Get("transport"))
+	assert.Equal(t, "test-sid", query.Get("sid"))
+}
@@ -0,0 +1,37 @@
+package recorder
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	engineio_v4_client_transport_polling "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/polling"
+	"github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/transporttest"
+	engineio_v4_client_transport_ws "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket"
+)
+
+func TestConformance(t *testing.T) {
+	transports := map[string]func() (engineio_v4_client.Transport, error){
+		"polling": func() (engineio_v4_client.Transport, error) {
+			return engineio_v4_client_transport_polling.NewTransport(engineio_v4_client_transport_polling.WithLogger(quietLogger))
+		},
+		"websocket": func() (engineio_v4_client.Transport, error) {
+			return engineio_v4_client_transport_ws.NewTransport(engineio_v4_client_transport_ws.WithLogger(quietLogger))
+		},
+	}
+
+	for name, newTransport := range transports {
+		newTransport := newTransport
+		t.Run(name, func(t *testing.T) {
+			transporttest.Run(t, func(t *testing.T) transporttest.Transport {
+				transport, err := newTransport()
+				require.NoError(t, err)
+				rec, err := NewRecorder(io.Discard)
+				require.NoError(t, err)
+				return rec.Wrap(transport)
+			})
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Package recorder captures the frames of engine.io client transports as JSON
+// Lines and replays them into an engineio_v4_client.Client, so a captured
+// incident can become a deterministic regression test.
+package recorder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+)
+
+// Direction tells whether a frame was received or sent by the client.
+type Direction string
+
+const (
+	Inbound  Direction = "in"
+	Outbound Direction = "out"
+)
+
+// Frame is one engine.io packet as seen by a transport.
+type Frame struct {
+	Time      time.Time
+	Transport engineio_v4.EngineIOTransport
+	Direction Direction
+	Data      []byte
+}
+
+// frameLine is the JSON Lines form of a Frame. Data that isn't valid UTF-8
+// (binary websocket frames) is base64 encoded.
+type frameLine struct {
+	Time      time.Time                     `json:"time"`
+	Transport engineio_v4.EngineIOTransport `json:"transport"`
+	Direction Direction                     `json:"direction"`
+	Data      string                        `json:"data"`
+	Base64    bool                          `json:"base64,omitempty"`
+}
+
+func (f Frame) MarshalJSON() ([]byte, error) {
+	line := frameLine{Time: f.Time, Transport: f.Transport, Direction: f.Direction}
+	if utf8.Valid(f.Data) {
+		line.Data = string(f.Data)
+	} else {
+		line.Data = base64.StdEncoding.EncodeToString(f.Data)
+		line.Base64 = true
+	}
+	return json.Marshal(line)
+}
+
+func (f *Frame) UnmarshalJSON(data []byte) error {
+	var line frameLine
+	if err := json.Unmarshal(data, &line); err != nil {
+		return err
+	}
+	*f = Frame{Time: line.Time, Transport: line.Transport, Direction: line.Direction, Data: []byte(line.Data)}
+	if line.Base64 {
+		decoded, err := base64.StdEncoding.DecodeString(line.Data)
+		if err != nil {
+			return err
+		}
+		f.Data = decoded
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"sync"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+type RecorderOption func(*Recorder) error
+
+// Recorder writes the frames of the transports it wraps to a JSON Lines
+// writer, in the order the client sees them. One Recorder is meant to wrap
+// all the transports of a client, so an upgrade is captured in one file.
+type Recorder struct {
+	scrub func(Frame) []byte
+	clock utils.Clock
+
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+func NewRecorder(w io.Writer, options ...RecorderOption) (*Recorder, error) {
+	if w == nil {
+		return nil, errors.New("writer is nil")
+	}
+	r := &Recorder{w: w, clock: utils.RealClock{}}
+
+	for _, opt := range options {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// WithScrub sets a function returning the data to write for a frame, e.g.
+// with tokens or personal data masked. The client still gets the original.
+func WithScrub(scrub func(Frame) []byte) RecorderOption {
+	return func(r *Recorder) error {
+		if scrub == nil {
+			return errors.New("scrub is nil")
+		}
+		r.scrub = scrub
+		return nil
+	}
+}
+
+// WithClock stamps the frames with the time from clock.
+func WithClock(clock utils.Clock) RecorderOption {
+	return func(r *Recorder) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		r.clock = clock
+		return nil
+	}
+}
+
+// Err returns the first error writing a frame. Recording stops after it;
+// the wrapped transports keep working.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(transport engineio_v4.EngineIOTransport, direction Direction, data []byte) {
+	frame := Frame{
+		Time:      r.clock.Now(),
+		Transport: transport,
+		Direction: direction,
+		Data:      append([]byte(nil), data...),
+	}
+	if r.scrub != nil {
+		frame.Data = r.scrub(frame)
+	}
+	line, err := json.Marshal(frame)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	r.err = err
+}
+
+// Wrap returns transport with its frames recorded.
+func (r *Recorder) Wrap(transport engineio_v4_client.Transport) engineio_v4_client.Transport {
+	return &recordingTransport{transport: transport, recorder: r}
+}
+
+type recordingTransport struct {
+	transport engineio_v4_client.Transport
+	recorder  *Recorder
+
+	mu   sync.Mutex
+	stop chan struct{} // closed by Stop to end the current run's forwarder
+}
+
+func (t *recordingTransport) Run(
+	ctx context.Context,
+	url *url.URL,
+	sid string,
+	messagesChan chan<- []byte,
+	onClose chan<- error,
+) error {
+	stop := make(chan struct{})
+	t.mu.Lock()
+	t.stop = stop
+	t.mu.Unlock()
+
+	// Messages pass through the forwarder so inbound frames are recorded
+	// in delivery order.
+	messages := make(chan []byte)
+	go t.forward(ctx, stop, messages, messagesChan)
+
+	err := t.transport.Run(ctx, url, sid, messages, onClose)
+	if err != nil {
+		t.endRun(stop)
+	}
+	return err
+}
+
+func (t *recordingTransport) forward(ctx context.Context, stop <-chan struct{}, in <-chan []byte, out chan<- []byte) {
+	for {
+		select {
+		case message := <-in:
+			t.recorder.record(t.transport.Transport(), Inbound, message)
+			select {
+			case out <- message:
+			case <-ctx.Done():
+				return
+			}
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *recordingTransport) endRun(stop chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop == stop && stop != nil {
+		close(stop)
+		t.stop = nil
+	}
+}
+
+func (t *recordingTransport) Transport() engineio_v4.EngineIOTransport {
+	return t.transport.Transport()
+}
+
+func (t *recordingTransport) SetHandshake(handshake *engineio_v4.HandshakeResponse) {
+	t.transport.SetHandshake(handshake)
+}
+
+func (t *recordingTransport) RequestHandshake() error {
+	return t.transport.RequestHandshake()
+}
+
+func (t *recordingTransport) SendMessage(message []byte) error {
+	t.recorder.record(t.transport.Transport(), Outbound, message)
+	return t.transport.SendMessage(message)
+}
+
+func (t *recordingTransport) Stop() error {
+	err := t.transport.Stop()
+	t.mu.Lock()
+	stop := t.stop
+	t.mu.Unlock()
+	t.endRun(stop)
+	return err
+}
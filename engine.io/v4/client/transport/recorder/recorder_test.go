@@ -0,0 +1,205 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	engineio_v4_client_transport_polling "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/polling"
+	engineio_v4_client_transport_ws "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket"
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	"github.com/maldikhan/go.socket.io/utils"
+	"github.com/maldikhan/go.socket.io/utils/fakeclock"
+)
+
+var quietLogger = &utils.DefaultLogger{Level: utils.NONE}
+
+func newEchoServer(t *testing.T) *url.URL {
+	t.Helper()
+	server, err := engineio_v4_server.NewServer(engineio_v4_server.WithLogger(quietLogger))
+	require.NoError(t, err)
+	server.OnConnection(func(session *engineio_v4_server.Session) {
+		session.On("message", func(message []byte) {
+			_ = session.Send(append([]byte("echo:"), message...))
+		})
+	})
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		_ = server.Close()
+		httpServer.Close()
+	})
+	u, err := url.Parse(httpServer.URL + "/engine.io/")
+	require.NoError(t, err)
+	return u
+}
+
+// runSession connects a client, sends "hello" once the upgrade settled and
+// returns the echo.
+func runSession(t *testing.T, u *url.URL, options ...engineio_v4_client.EngineClientOption) string {
+	t.Helper()
+	client, err := engineio_v4_client.NewClient(append([]engineio_v4_client.EngineClientOption{
+		engineio_v4_client.WithURL(u),
+		engineio_v4_client.WithLogger(quietLogger),
+	}, options...)...)
+	require.NoError(t, err)
+
+	echo := make(chan []byte, 1)
+	client.On("message", func(message []byte) { echo <- message })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, client.Connect(ctx))
+	defer client.Close()
+
+	require.NoError(t, client.Send([]byte("hello")))
+	select {
+	case message := <-echo:
+		return string(message)
+	case <-ctx.Done():
+		t.Fatal("no echo")
+		return ""
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	var recording bytes.Buffer
+	rec, err := NewRecorder(&recording)
+	require.NoError(t, err)
+
+	polling, err := engineio_v4_client_transport_polling.NewTransport(
+		engineio_v4_client_transport_polling.WithLogger(quietLogger),
+	)
+	require.NoError(t, err)
+	ws, err := engineio_v4_client_transport_ws.NewTransport(
+		engineio_v4_client_transport_ws.WithLogger(quietLogger),
+	)
+	require.NoError(t, err)
+	recordedPolling := rec.Wrap(polling)
+
+	echo := runSession(t, newEchoServer(t),
+		engineio_v4_client.WithSupportedTransports([]engineio_v4_client.Transport{recordedPolling, rec.Wrap(ws)}),
+		engineio_v4_client.WithTransport(recordedPolling),
+	)
+	assert.Equal(t, "echo:hello", echo)
+	require.NoError(t, rec.Err())
+
+	lines := strings.Split(strings.TrimSpace(recording.String()), "\n")
+	require.NotEmpty(t, lines)
+	var first Frame
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, engineio_v4.TransportPolling, first.Transport)
+	assert.Equal(t, Inbound, first.Direction)
+	assert.Contains(t, recording.String(), `"data":"4hello"`)
+	assert.Contains(t, recording.String(), `"transport":"websocket","direction":"out","data":"2probe"`)
+
+	replay, err := NewReplay(bytes.NewReader(recording.Bytes()))
+	require.NoError(t, err)
+	u, err := url.Parse("http://replay.invalid/engine.io/")
+	require.NoError(t, err)
+	echo = runSession(t, u, replay.ClientOptions()...)
+	assert.Equal(t, "echo:hello", echo)
+
+	select {
+	case <-replay.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("replay not done, remaining %v", replay.Remaining())
+	}
+	assert.NoError(t, replay.Err())
+}
+
+func TestReplay_Mismatch(t *testing.T) {
+	recording := `{"transport":"websocket","direction":"in","data":"0{\"sid\":\"s1\",\"upgrades\":[],\"pingInterval\":25000,\"pingTimeout\":20000,\"maxPayload\":1000000}"}
+{"transport":"websocket","direction":"out","data":"4hello"}
+{"transport":"websocket","direction":"in","data":"4echo:hello"}
+`
+	replay, err := NewReplay(strings.NewReader(recording))
+	require.NoError(t, err)
+	transport := replay.Transports()[0]
+
+	messages := make(chan []byte, 1)
+	require.NoError(t, transport.Run(context.Background(), nil, "", messages, make(chan error, 1)))
+	defer transport.Stop()
+	<-messages
+
+	err = transport.SendMessage([]byte("4bye"))
+	var mismatch *MismatchError
+	require.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, 1, mismatch.Index)
+	assert.Equal(t, "4hello", string(mismatch.Expected.Data))
+	assert.Equal(t, err, replay.Err())
+
+	require.NoError(t, transport.SendMessage([]byte("4hello")))
+	assert.Equal(t, "4echo:hello", string(<-messages))
+	<-replay.Done()
+	assert.ErrorIs(t, transport.SendMessage([]byte("4again")), ErrUnexpectedFrame)
+}
+
+func TestRecorder_Scrub(t *testing.T) {
+	var recording bytes.Buffer
+	rec, err := NewRecorder(&recording, WithScrub(func(f Frame) []byte {
+		return bytes.ReplaceAll(f.Data, []byte("secret"), []byte("***"))
+	}))
+	require.NoError(t, err)
+
+	rec.record(engineio_v4.TransportWebsocket, Outbound, []byte("4token=secret"))
+	rec.record(engineio_v4.TransportWebsocket, Inbound, []byte{0x04, 0xff, 0x00})
+	require.NoError(t, rec.Err())
+
+	replay, err := NewReplay(&recording, WithMatcher(func(recorded, sent []byte) bool {
+		return bytes.Equal(recorded, bytes.ReplaceAll(sent, []byte("secret"), []byte("***")))
+	}))
+	require.NoError(t, err)
+	frames := replay.Remaining()
+	require.Len(t, frames, 2)
+	assert.Equal(t, "4token=***", string(frames[0].Data))
+	assert.Equal(t, []byte{0x04, 0xff, 0x00}, frames[1].Data)
+
+	transport := replay.Transports()[0]
+	require.NoError(t, transport.SendMessage([]byte("4token=secret")))
+}
+
+func TestRecorder_Clock(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := fakeclock.New(start)
+	var recording bytes.Buffer
+	rec, err := NewRecorder(&recording, WithClock(clock))
+	require.NoError(t, err)
+
+	rec.record(engineio_v4.TransportPolling, Outbound, []byte("4a"))
+	clock.Advance(time.Second)
+	rec.record(engineio_v4.TransportPolling, Inbound, []byte("4b"))
+	require.NoError(t, rec.Err())
+
+	replay, err := NewReplay(&recording)
+	require.NoError(t, err)
+	frames := replay.Remaining()
+	require.Len(t, frames, 2)
+	assert.True(t, frames[0].Time.Equal(start))
+	assert.True(t, frames[1].Time.Equal(start.Add(time.Second)))
+}
+
+func TestOptions(t *testing.T) {
+	_, err := NewRecorder(nil)
+	assert.Error(t, err)
+	_, err = NewRecorder(&bytes.Buffer{}, WithScrub(nil))
+	assert.Error(t, err)
+	_, err = NewRecorder(&bytes.Buffer{}, WithClock(nil))
+	assert.Error(t, err)
+	_, err = NewReplay(strings.NewReader(""))
+	assert.Error(t, err)
+	_, err = NewReplay(strings.NewReader(`{"transport":"polling","direction":"up","data":""}`))
+	assert.Error(t, err)
+	_, err = NewReplay(strings.NewReader(`{"transport":"polling","direction":"in","data":""}`), WithMatcher(nil))
+	assert.Error(t, err)
+}
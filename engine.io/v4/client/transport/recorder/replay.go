@@ -0,0 +1,316 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+)
+
+type ReplayOption func(*Replay) error
+
+// MismatchError is returned by SendMessage when the client sends a frame
+// other than the next recorded outbound one.
+type MismatchError struct {
+	Index    int // line of the expected frame, from 0
+	Expected Frame
+	Actual   Frame
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("replay: frame %d: sent %q on %s, recorded %q on %s",
+		e.Index, e.Actual.Data, e.Actual.Transport, e.Expected.Data, e.Expected.Transport)
+}
+
+// ErrUnexpectedFrame is returned by SendMessage once every recorded outbound
+// frame has been sent.
+var ErrUnexpectedFrame = errors.New("replay: no more outbound frames recorded")
+
+// Replay feeds a recording back into an engineio_v4_client.Client through a
+// transport per recorded transport name. Recorded timing is ignored: an
+// inbound frame is delivered once the client has sent every outbound frame
+// recorded before it, and each frame the client sends must match the next
+// recorded outbound frame. Inbound frames of a transport the client has moved
+// away from are skipped.
+type Replay struct {
+	frames []Frame
+	match  func(recorded, sent []byte) bool
+
+	mu      sync.Mutex
+	nextIn  int
+	nextOut int
+	running *replayTransport
+	changed chan struct{} // closed and replaced when the cursors or the running transport change
+	done    chan struct{}
+	err     error
+
+	transports []engineio_v4_client.Transport
+}
+
+func NewReplay(r io.Reader, options ...ReplayOption) (*Replay, error) {
+	replay := &Replay{
+		match:   bytes.Equal,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 0; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var frame Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, fmt.Errorf("replay: line %d: %w", line+1, err)
+		}
+		if frame.Direction != Inbound && frame.Direction != Outbound {
+			return nil, fmt.Errorf("replay: line %d: unknown direction %q", line+1, frame.Direction)
+		}
+		replay.frames = append(replay.frames, frame)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(replay.frames) == 0 {
+		return nil, errors.New("replay: no frames")
+	}
+
+	for _, opt := range options {
+		if err := opt(replay); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[engineio_v4.EngineIOTransport]bool)
+	for _, frame := range replay.frames {
+		if !seen[frame.Transport] {
+			seen[frame.Transport] = true
+			replay.transports = append(replay.transports, &replayTransport{replay: replay, name: frame.Transport})
+		}
+	}
+	replay.advanceLocked()
+
+	return replay, nil
+}
+
+// WithMatcher sets how a sent frame is compared to the recorded one, e.g. to
+// accept frames recorded with WithScrub. The default is bytes.Equal.
+func WithMatcher(match func(recorded, sent []byte) bool) ReplayOption {
+	return func(r *Replay) error {
+		if match == nil {
+			return errors.New("matcher is nil")
+		}
+		r.match = match
+		return nil
+	}
+}
+
+// Transports returns a transport per transport name of the recording, the
+// one the recording starts with first.
+func (r *Replay) Transports() []engineio_v4_client.Transport {
+	return append([]engineio_v4_client.Transport{}, r.transports...)
+}
+
+// ClientOptions returns the engineio_v4_client options making a client use
+// the replay transports. A URL is still required; it is not dialed.
+func (r *Replay) ClientOptions() []engineio_v4_client.EngineClientOption {
+	return []engineio_v4_client.EngineClientOption{
+		engineio_v4_client.WithSupportedTransports(r.Transports()),
+		engineio_v4_client.WithTransport(r.transports[0]),
+	}
+}
+
+// Done is closed once every recorded frame has been delivered or sent.
+func (r *Replay) Done() <-chan struct{} {
+	return r.done
+}
+
+// Err returns the first mismatch between the sent and the recorded frames.
+func (r *Replay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Remaining returns the frames not delivered or sent yet.
+func (r *Replay) Remaining() []Frame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var remaining []Frame
+	for i, frame := range r.frames {
+		if frame.Direction == Inbound && i >= r.nextIn || frame.Direction == Outbound && i >= r.nextOut {
+			remaining = append(remaining, frame)
+		}
+	}
+	return remaining
+}
+
+// advanceLocked moves the cursors past frames of the other direction and
+// signals waiters.
+func (r *Replay) advanceLocked() {
+	for r.nextIn < len(r.frames) && r.frames[r.nextIn].Direction != Inbound {
+		r.nextIn++
+	}
+	for r.nextOut < len(r.frames) && r.frames[r.nextOut].Direction != Outbound {
+		r.nextOut++
+	}
+	close(r.changed)
+	r.changed = make(chan struct{})
+	if r.nextIn == len(r.frames) && r.nextOut == len(r.frames) {
+		select {
+		case <-r.done:
+		default:
+			close(r.done)
+		}
+	}
+}
+
+// nextInbound returns the next frame to deliver on t, or a channel to wait
+// on before trying again.
+func (r *Replay) nextInbound(t *replayTransport) ([]byte, bool, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for r.nextIn < len(r.frames) && r.nextIn < r.nextOut && r.running != nil {
+		frame := r.frames[r.nextIn]
+		if frame.Transport == r.running.name {
+			if r.running != t {
+				break
+			}
+			r.nextIn++
+			r.advanceLocked()
+			return frame.Data, true, nil
+		}
+		// The client moved away from the frame's transport.
+		r.nextIn++
+		r.advanceLocked()
+	}
+	return nil, false, r.changed
+}
+
+func (r *Replay) send(t *replayTransport, message []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nextOut == len(r.frames) {
+		return ErrUnexpectedFrame
+	}
+	expected := r.frames[r.nextOut]
+	if expected.Transport != t.name || !r.match(expected.Data, message) {
+		err := &MismatchError{
+			Index:    r.nextOut,
+			Expected: expected,
+			Actual:   Frame{Transport: t.name, Direction: Outbound, Data: append([]byte(nil), message...)},
+		}
+		if r.err == nil {
+			r.err = err
+		}
+		return err
+	}
+	r.nextOut++
+	r.advanceLocked()
+	return nil
+}
+
+func (r *Replay) setRunning(t *replayTransport, running bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if running {
+		r.running = t
+	} else if r.running == t {
+		r.running = nil
+	}
+	r.advanceLocked()
+}
+
+type replayTransport struct {
+	replay *Replay
+	name   engineio_v4.EngineIOTransport
+
+	mu      sync.Mutex
+	stop    chan struct{}
+	onClose chan<- error
+}
+
+func (t *replayTransport) Transport() engineio_v4.EngineIOTransport {
+	return t.name
+}
+
+func (t *replayTransport) Run(
+	ctx context.Context,
+	_ *url.URL,
+	_ string,
+	messagesChan chan<- []byte,
+	onClose chan<- error,
+) error {
+	stop := make(chan struct{})
+	t.mu.Lock()
+	t.stop = stop
+	t.onClose = onClose
+	t.mu.Unlock()
+
+	t.replay.setRunning(t, true)
+	go t.deliver(ctx, stop, messagesChan)
+	return nil
+}
+
+func (t *replayTransport) deliver(ctx context.Context, stop <-chan struct{}, messages chan<- []byte) {
+	for {
+		data, ok, wait := t.replay.nextInbound(t)
+		if ok {
+			select {
+			case messages <- data:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		select {
+		case <-wait:
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *replayTransport) SetHandshake(*engineio_v4.HandshakeResponse) {}
+
+func (t *replayTransport) RequestHandshake() error {
+	return nil
+}
+
+func (t *replayTransport) SendMessage(message []byte) error {
+	return t.replay.send(t, message)
+}
+
+func (t *replayTransport) Stop() error {
+	t.mu.Lock()
+	stop, onClose := t.stop, t.onClose
+	t.stop, t.onClose = nil, nil
+	t.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	close(stop)
+	t.replay.setRunning(t, false)
+	select {
+	case onClose <- nil:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package transporttest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// endpoint is an engine.io server that sends every message it receives
+// back to the client.
+type endpoint struct {
+	engine *engineio_v4_server.Server
+	http   *httptest.Server
+	url    *url.URL
+}
+
+func newEndpoint(t *testing.T, options ...engineio_v4_server.ServerOption) *endpoint {
+	t.Helper()
+	engine, err := engineio_v4_server.NewServer(append([]engineio_v4_server.ServerOption{
+		engineio_v4_server.WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+	}, options...)...)
+	require.NoError(t, err)
+	engine.OnConnection(func(session *engineio_v4_server.Session) {
+		session.On("message", func(data []byte) { _ = session.Send(data) })
+	})
+
+	e := &endpoint{engine: engine, http: httptest.NewServer(engine)}
+	e.url, err = url.Parse(e.http.URL + "/engine.io/")
+	require.NoError(t, err)
+
+	// Closing the sessions first releases held polls, which the HTTP server
+	// would otherwise wait for.
+	t.Cleanup(func() {
+		_ = e.engine.Close()
+		e.http.Close()
+	})
+	return e
+}
+
+// conn is one Run of a transport, with the channels the engine client would
+// pass it.
+type conn struct {
+	transport Transport
+	ctx       context.Context
+	cancel    context.CancelFunc
+	messages  chan []byte
+	onClose   chan error
+
+	handshake engineio_v4.HandshakeResponse
+	session   *engineio_v4_server.Session
+}
+
+func newConn(t *testing.T, transport Transport, buffer int) *conn {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &conn{
+		transport: transport,
+		ctx:       ctx,
+		cancel:    cancel,
+		messages:  make(chan []byte, buffer),
+		onClose:   make(chan error, 1),
+	}
+	t.Cleanup(func() {
+		cancel()
+		_ = transport.Stop()
+	})
+	return c
+}
+
+// open runs transport against e and completes the handshake like the engine
+// client: RequestHandshake, wait for OPEN, then SetHandshake.
+func open(t *testing.T, e *endpoint, transport Transport, buffer int) *conn {
+	t.Helper()
+	c := newConn(t, transport, buffer)
+	require.NoError(t, transport.Run(c.ctx, e.url, "", c.messages, c.onClose), "Run")
+	require.NoError(t, transport.RequestHandshake(), "RequestHandshake")
+
+	packet := c.receive(t)
+	require.Equal(t, engineio_v4.PacketOpen, engineio_v4.FrameType(packet), "OPEN packet, got %q", packet)
+	require.NoError(t, json.Unmarshal(packet[1:], &c.handshake), "handshake %q", packet)
+	transport.SetHandshake(&c.handshake)
+
+	c.session = e.engine.Session(c.handshake.Sid)
+	require.NotNil(t, c.session, "no server session %s", c.handshake.Sid)
+	return c
+}
+
+// receive returns the next packet the transport delivers.
+func (c *conn) receive(t *testing.T) []byte {
+	t.Helper()
+	select {
+	case packet := <-c.messages:
+		return packet
+	case <-time.After(timeout):
+		t.Fatal("no packet delivered")
+		return nil
+	}
+}
+
+// next returns the next packet other than a PING, answering the PINGs on
+// the way.
+func (c *conn) next(t *testing.T) []byte {
+	t.Helper()
+	for {
+		packet := c.receive(t)
+		if engineio_v4.FrameType(packet) != engineio_v4.PacketPing {
+			return packet
+		}
+		require.NoError(t, c.transport.SendMessage([]byte{'0' + byte(engineio_v4.PacketPong)}), "pong")
+	}
+}
+
+// closed waits for onClose and returns the error it carried.
+func (c *conn) closed(t *testing.T) error {
+	t.Helper()
+	select {
+	case err := <-c.onClose:
+		return err
+	case <-time.After(timeout):
+		t.Fatal("onClose not signalled")
+		return nil
+	}
+}
+
+// noClose fails if onClose is signalled (again) shortly.
+func (c *conn) noClose(t *testing.T) {
+	t.Helper()
+	select {
+	case err := <-c.onClose:
+		t.Errorf("unexpected onClose: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,279 @@
+// Package transporttest is a conformance suite for engine.io v4 client
+// transports, the implementations of engineio_v4_client.Transport. A custom
+// transport runs it from its own tests, preferably with -race:
+//
+//	func TestConformance(t *testing.T) {
+//		transporttest.Run(t, func(t *testing.T) transporttest.Transport {
+//			transport, err := mytransport.New()
+//			require.NoError(t, err)
+//			return transport
+//		})
+//	}
+//
+// The suite drives the transport the way the engine client does, against an
+// in-process engine.io server that echoes every message, and checks the
+// contract the client relies on:
+//
+//   - Run starts the transport, and RequestHandshake makes the server's OPEN
+//     packet arrive on the messages channel.
+//   - Frames are delivered one engine.io packet at a time, in order.
+//   - onClose is signalled once per Run that returned nil: nil after Stop, the
+//     context error when the Run context is cancelled, or the transport error.
+//   - Stop never blocks, returns nil, may be called any number of times from
+//     any goroutine, before Run or after the transport is closed, and
+//     interrupts a delivery blocked on a full messages channel.
+//   - Once onClose was signalled, Run may be called again for a new session.
+package transporttest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+)
+
+// Transport is the contract under test; it matches
+// engineio_v4_client.Transport.
+type Transport interface {
+	Transport() engineio_v4.EngineIOTransport
+	Run(ctx context.Context, url *url.URL, sid string, messagesChan chan<- []byte, onClose chan<- error) error
+	SetHandshake(handshake *engineio_v4.HandshakeResponse)
+	RequestHandshake() error
+	Stop() error
+	SendMessage([]byte) error
+}
+
+// timeout bounds every wait of the suite, so a transport that deadlocks
+// fails the subtest instead of hanging the run.
+const timeout = 5 * time.Second
+
+// Run runs the whole suite as subtests of t. factory returns a new,
+// unstarted transport for every subtest.
+func Run(t *testing.T, factory func(t *testing.T) Transport) {
+	t.Helper()
+	t.Run("Handshake", func(t *testing.T) { testHandshake(t, factory(t)) })
+	t.Run("Echo", func(t *testing.T) { testEcho(t, factory(t)) })
+	t.Run("ServerPush", func(t *testing.T) { testServerPush(t, factory(t)) })
+	t.Run("ConcurrentSend", func(t *testing.T) { testConcurrentSend(t, factory(t)) })
+	t.Run("Heartbeat", func(t *testing.T) { testHeartbeat(t, factory(t)) })
+	t.Run("Stop", func(t *testing.T) { testStop(t, factory(t)) })
+	t.Run("ConcurrentStop", func(t *testing.T) { testConcurrentStop(t, factory(t)) })
+	t.Run("StopBeforeRun", func(t *testing.T) { testStopBeforeRun(t, factory(t)) })
+	t.Run("StopWhileDelivering", func(t *testing.T) { testStopWhileDelivering(t, factory(t)) })
+	t.Run("ContextCancel", func(t *testing.T) { testContextCancel(t, factory(t)) })
+	t.Run("ServerClose", func(t *testing.T) { testServerClose(t, factory(t)) })
+	t.Run("Reuse", func(t *testing.T) { testReuse(t, factory(t)) })
+	t.Run("Unreachable", func(t *testing.T) { testUnreachable(t, factory(t)) })
+}
+
+func testHandshake(t *testing.T, transport Transport) {
+	name := transport.Transport()
+	assert.NotEmpty(t, name, "transport name")
+
+	c := open(t, newEndpoint(t), transport, 100)
+	assert.NotEmpty(t, c.handshake.Sid)
+	assert.Equal(t, name, c.session.Transport())
+}
+
+func testEcho(t *testing.T, transport Transport) {
+	c := open(t, newEndpoint(t), transport, 100)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, transport.SendMessage(message(i)))
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, string(message(i)), string(c.next(t)))
+	}
+}
+
+func testServerPush(t *testing.T, transport Transport) {
+	c := open(t, newEndpoint(t), transport, 100)
+	// Sent back to back, these reach a polling transport as one payload
+	// that has to be split into packets.
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.session.Send(message(i)[1:]))
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, string(message(i)), string(c.next(t)))
+	}
+}
+
+func testConcurrentSend(t *testing.T, transport Transport) {
+	const senders, perSender = 8, 5
+	c := open(t, newEndpoint(t), transport, senders*perSender)
+
+	var wg sync.WaitGroup
+	for s := 0; s < senders; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			for i := 0; i < perSender; i++ {
+				assert.NoError(t, transport.SendMessage(message(s*perSender+i)))
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	got := make(map[string]bool)
+	for i := 0; i < senders*perSender; i++ {
+		got[string(c.next(t))] = true
+	}
+	for i := 0; i < senders*perSender; i++ {
+		assert.True(t, got[string(message(i))], "echo of %q", message(i))
+	}
+}
+
+func testHeartbeat(t *testing.T, transport Transport) {
+	c := open(t, newEndpoint(t,
+		engineio_v4_server.WithPingInterval(50*time.Millisecond),
+		engineio_v4_server.WithPingTimeout(200*time.Millisecond),
+	), transport, 100)
+
+	// Answer the pings for a few ping timeouts, then check the server still
+	// sees the session alive.
+	for pings := 0; pings < 5; {
+		packet := c.receive(t)
+		if engineio_v4.FrameType(packet) == engineio_v4.PacketPing {
+			require.NoError(t, transport.SendMessage([]byte{'0' + byte(engineio_v4.PacketPong)}))
+			pings++
+		}
+	}
+	select {
+	case <-c.session.Done():
+		t.Fatal("server closed the session: pongs did not arrive")
+	default:
+	}
+}
+
+func testStop(t *testing.T, transport Transport) {
+	c := open(t, newEndpoint(t), transport, 100)
+
+	stop(t, transport)
+	assert.NoError(t, c.closed(t), "onClose after Stop")
+	stop(t, transport)
+	c.noClose(t)
+}
+
+func testConcurrentStop(t *testing.T, transport Transport) {
+	c := open(t, newEndpoint(t), transport, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stop(t, transport)
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, c.closed(t), "onClose after Stop")
+	c.noClose(t)
+}
+
+func testStopBeforeRun(t *testing.T, transport Transport) {
+	stop(t, transport)
+	stop(t, transport)
+
+	// A Stop that found nothing running must not cut the next Run short.
+	c := open(t, newEndpoint(t), transport, 100)
+	require.NoError(t, transport.SendMessage(message(0)))
+	assert.Equal(t, string(message(0)), string(c.next(t)))
+}
+
+func testStopWhileDelivering(t *testing.T, transport Transport) {
+	c := open(t, newEndpoint(t), transport, 1)
+
+	// Nobody reads messages: the first push fills the channel and the
+	// transport blocks delivering the next one.
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.session.Send(message(i)[1:]))
+	}
+	require.Eventually(t, func() bool { return len(c.messages) == 1 }, timeout, 10*time.Millisecond)
+
+	stop(t, transport)
+	assert.NoError(t, c.closed(t), "onClose after Stop")
+}
+
+func testContextCancel(t *testing.T, transport Transport) {
+	c := open(t, newEndpoint(t), transport, 100)
+
+	c.cancel()
+	assert.ErrorIs(t, c.closed(t), context.Canceled, "onClose after cancel")
+	stop(t, transport)
+	c.noClose(t)
+}
+
+func testServerClose(t *testing.T, transport Transport) {
+	c := open(t, newEndpoint(t), transport, 100)
+
+	require.NoError(t, c.session.Close())
+	assert.Equal(t, engineio_v4.PacketClose, engineio_v4.FrameType(c.next(t)), "CLOSE packet")
+
+	// The transport may report the lost connection on its own; the engine
+	// client stops it on CLOSE anyway, and onClose must fire either way.
+	stop(t, transport)
+	c.closed(t)
+}
+
+func testReuse(t *testing.T, transport Transport) {
+	e := newEndpoint(t)
+	sids := make(map[string]bool)
+	for run := 0; run < 3; run++ {
+		c := open(t, e, transport, 100)
+		assert.False(t, sids[c.handshake.Sid], "run %d reused session %s", run, c.handshake.Sid)
+		sids[c.handshake.Sid] = true
+
+		require.NoError(t, transport.SendMessage(message(run)))
+		assert.Equal(t, string(message(run)), string(c.next(t)), "run %d", run)
+
+		if run%2 == 0 {
+			stop(t, transport)
+		} else {
+			c.cancel()
+		}
+		c.closed(t)
+	}
+}
+
+func testUnreachable(t *testing.T, transport Transport) {
+	e := newEndpoint(t)
+	e.http.Close()
+
+	c := newConn(t, transport, 100)
+	err := transport.Run(c.ctx, e.url, "", c.messages, c.onClose)
+	if err != nil {
+		// Nothing was started, so there is nothing to signal.
+		stop(t, transport)
+		c.noClose(t)
+		return
+	}
+	assert.Error(t, transport.RequestHandshake(), "handshake with a closed server")
+	stop(t, transport)
+	c.closed(t)
+}
+
+// message is the i-th MESSAGE packet a subtest sends.
+func message(i int) []byte {
+	return []byte(fmt.Sprintf("%dmessage %d", engineio_v4.PacketMessage, i))
+}
+
+// stop calls Stop and fails if it blocks or returns an error. It may run on
+// any goroutine.
+func stop(t *testing.T, transport Transport) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- transport.Stop() }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "Stop")
+	case <-time.After(timeout):
+		t.Error("Stop blocked")
+	}
+}
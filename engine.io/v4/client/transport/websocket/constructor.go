@@ -14,6 +14,7 @@ func NewTransport(options ...EngineTransportOption) (*Transport, error) {
 	// Создаем клиент с настройками по умолчанию
 	client := &Transport{
 		log:           &utils.DefaultLogger{},
+		metrics:       utils.NopMetrics{},
 		ws:            &ws_native.WebSocketConnection{},
 		stopPooling:   make(chan struct{}, 1),
 		redactPayload: true, // production-safe default; WithDebugPayload(true) opts out
@@ -29,14 +30,30 @@ func NewTransport(options ...EngineTransportOption) (*Transport, error) {
 	if client.log == nil {
 		return nil, errors.New("logger is nil")
 	}
+	client.sessionLog = client.sessionLogger("")
 
 	if client.ws == nil {
 		return nil, errors.New("websocket connection is nil")
 	}
 
+	if native, ok := client.ws.(*ws_native.WebSocketConnection); ok && client.maxFrameSize > 0 {
+		native.MaxPayloadBytes = client.maxFrameSize
+	}
+
 	return client, nil
 }
 
+// WithMetrics reports packets and bytes by type to metrics.
+func WithMetrics(metrics Metrics) EngineTransportOption {
+	return func(c *Transport) error {
+		if metrics == nil {
+			return errors.New("metrics is nil")
+		}
+		c.metrics = metrics
+		return nil
+	}
+}
+
 func WithLogger(logger Logger) EngineTransportOption {
 	return func(c *Transport) error {
 		c.log = logger
@@ -51,6 +68,21 @@ func WithWebSocket(ws WebSocket) EngineTransportOption {
 	}
 }
 
+// WithMaxFrameSize caps the size in bytes of a received frame. A larger frame
+// is discarded, reported to onExceeded, if not nil, and the connection is kept.
+// The cap is applied to a ws_native connection; another WebSocket signals a
+// discarded frame by returning ws_native.ErrFrameTooLarge from Receive.
+func WithMaxFrameSize(size int, onExceeded func(err error)) EngineTransportOption {
+	return func(c *Transport) error {
+		if size <= 0 {
+			return errors.New("maxFrameSize must be positive")
+		}
+		c.maxFrameSize = size
+		c.onFrameTooLarge = onExceeded
+		return nil
+	}
+}
+
 func WithOrigin(origin *url.URL) EngineTransportOption {
 	return func(c *Transport) error {
 		c.origin = origin
@@ -66,3 +98,15 @@ func WithDebugPayload(enabled bool) EngineTransportOption {
 		return nil
 	}
 }
+
+// WithRedactionPolicy logs frames at debug level with the secrets masked by
+// policy.
+func WithRedactionPolicy(policy *utils.RedactionPolicy) EngineTransportOption {
+	return func(c *Transport) error {
+		if policy == nil {
+			return errors.New("redaction policy is nil")
+		}
+		c.redaction = policy
+		return nil
+	}
+}
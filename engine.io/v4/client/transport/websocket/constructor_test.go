@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	mocks "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket/mocks"
 	"github.com/maldikhan/go.socket.io/utils"
@@ -201,3 +203,14 @@ func TestWithOrigin(t *testing.T) {
 		t.Errorf("WithOrigin() did not set the origin correctly")
 	}
 }
+
+func TestWithMaxFrameSize(t *testing.T) {
+	_, err := NewTransport(WithMaxFrameSize(0, nil))
+	assert.Error(t, err)
+
+	ws := &ws_native.WebSocketConnection{}
+	transport, err := NewTransport(WithWebSocket(ws), WithMaxFrameSize(1024, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 1024, transport.maxFrameSize)
+	assert.Equal(t, 1024, ws.MaxPayloadBytes)
+}
@@ -20,3 +20,9 @@ type WebSocket interface {
 	Receive(v *[]byte) (err error)
 	Close() error
 }
+
+// Metrics receives the transport measurements; see the metrics package.
+type Metrics interface {
+	PacketSent(transport, packetType string, bytes int)
+	PacketReceived(transport, packetType string, bytes int)
+}
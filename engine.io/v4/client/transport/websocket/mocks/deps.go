@@ -181,3 +181,50 @@ func (mr *MockWebSocketMockRecorder) Send(v interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockWebSocket)(nil).Send), v)
 }
+
+// MockMetrics is a mock of Metrics interface.
+type MockMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsMockRecorder
+}
+
+// MockMetricsMockRecorder is the mock recorder for MockMetrics.
+type MockMetricsMockRecorder struct {
+	mock *MockMetrics
+}
+
+// NewMockMetrics creates a new mock instance.
+func NewMockMetrics(ctrl *gomock.Controller) *MockMetrics {
+	mock := &MockMetrics{ctrl: ctrl}
+	mock.recorder = &MockMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
+	return m.recorder
+}
+
+// PacketReceived mocks base method.
+func (m *MockMetrics) PacketReceived(transport, packetType string, bytes int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PacketReceived", transport, packetType, bytes)
+}
+
+// PacketReceived indicates an expected call of PacketReceived.
+func (mr *MockMetricsMockRecorder) PacketReceived(transport, packetType, bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacketReceived", reflect.TypeOf((*MockMetrics)(nil).PacketReceived), transport, packetType, bytes)
+}
+
+// PacketSent mocks base method.
+func (m *MockMetrics) PacketSent(transport, packetType string, bytes int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PacketSent", transport, packetType, bytes)
+}
+
+// PacketSent indicates an expected call of PacketSent.
+func (mr *MockMetricsMockRecorder) PacketSent(transport, packetType, bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacketSent", reflect.TypeOf((*MockMetrics)(nil).PacketSent), transport, packetType, bytes)
+}
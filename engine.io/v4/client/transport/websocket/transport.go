@@ -2,16 +2,20 @@ package engineio_v4_client_transport
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"sync"
 
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	"github.com/maldikhan/go.socket.io/utils"
+	ws_native "github.com/maldikhan/go.socket.io/websocket/native"
 )
 
 type Transport struct {
-	log Logger
-	ws  WebSocket
+	log     Logger
+	metrics Metrics
+	ws      WebSocket
 
 	sid    string
 	url    *url.URL
@@ -21,21 +25,75 @@ type Transport struct {
 	messages    chan<- []byte
 	onClose     chan<- error
 	stopPooling chan struct{}
-	mu          sync.RWMutex
+	// readLoopDone is closed once the read loop of the last Run has exited
+	// and closed the connection.
+	readLoopDone chan struct{}
+	// mu guards sid, stopPooling, which Run replaces while Stop may be
+	// signalling, and sessionLog, log with the transport and session id
+	// fields.
+	mu         sync.RWMutex
+	sessionLog Logger
 
 	// redactPayload, when true, replaces raw payloads in debug logs with a
 	// size marker. Zero value is verbose; NewTransport sets the safe default.
 	redactPayload bool
+
+	// redaction masks the logged frames when set.
+	redaction *utils.RedactionPolicy
+
+	// maxFrameSize and onFrameTooLarge are set by WithMaxFrameSize.
+	maxFrameSize    int
+	onFrameTooLarge func(error)
 }
 
 // payload returns a size marker when redaction is enabled, or the raw data.
 func (c *Transport) payload(data []byte) string {
+	if c.redaction != nil {
+		return c.redaction.Redact(data)
+	}
 	if c.redactPayload {
 		return fmt.Sprintf("[redacted %d bytes]", len(data))
 	}
 	return string(data)
 }
 
+// logger returns the logger with the transport and session fields,
+// tolerating a Transport built without NewTransport.
+func (c *Transport) logger() Logger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.sessionLog == nil {
+		return c.log
+	}
+	return c.sessionLog
+}
+
+// setSidLocked sets the session id and the logger fields. The caller must
+// hold c.mu.
+func (c *Transport) setSidLocked(sid string) {
+	c.sid = sid
+	c.sessionLog = c.sessionLogger(sid)
+}
+
+// sessionLogger returns log with the transport field, and the session id
+// field once it is known.
+func (c *Transport) sessionLogger(sid string) Logger {
+	fields := []utils.Field{{Key: utils.FieldTransport, Value: c.Transport()}}
+	if sid != "" {
+		fields = append(fields, utils.Field{Key: utils.FieldSid, Value: sid})
+	}
+	return utils.WithFields(c.log, fields...)
+}
+
+// meter returns the metrics, tolerating a Transport built without
+// NewTransport (e.g. in unit tests).
+func (c *Transport) meter() Metrics {
+	if c.metrics == nil {
+		return utils.NopMetrics{}
+	}
+	return c.metrics
+}
+
 func (c *Transport) Transport() engineio_v4.EngineIOTransport {
 	return engineio_v4.TransportWebsocket
 }
@@ -44,8 +102,11 @@ func (c *Transport) Stop() (err error) {
 	// Use non-blocking send: if wsReadLoop already exited (e.g. via
 	// ctx.Done() or a WebSocket error), nobody is reading from
 	// stopPooling and a blocking send would deadlock.
+	c.mu.RLock()
+	stop := c.stopPooling
+	c.mu.RUnlock()
 	select {
-	case c.stopPooling <- struct{}{}:
+	case stop <- struct{}{}:
 	default:
 	}
 	return nil
@@ -58,9 +119,20 @@ func (c *Transport) Run(
 	messagesChan chan<- []byte,
 	onClose chan<- error,
 ) error {
+	// The previous read loop signals onClose before it closes its
+	// connection and stops touching the fields below; let it finish.
+	if c.readLoopDone != nil {
+		select {
+		case <-c.readLoopDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 	c.ctx = ctx
 	c.mu.Lock()
-	c.sid = sid
+	c.setSidLocked(sid)
+	// A Stop that found no read loop running must not end this one.
+	c.stopPooling = make(chan struct{}, 1)
 	c.mu.Unlock()
 	c.url = url
 	c.messages = messagesChan
@@ -74,7 +146,7 @@ func (c *Transport) RequestHandshake() error {
 
 func (c *Transport) SetHandshake(handshake *engineio_v4.HandshakeResponse) {
 	c.mu.Lock()
-	c.sid = handshake.Sid
+	c.setSidLocked(handshake.Sid)
 	c.mu.Unlock()
 }
 
@@ -94,7 +166,7 @@ func (c *Transport) buildWsUrl() *url.URL {
 
 	q, err := url.ParseQuery(c.url.RawQuery)
 	if err != nil {
-		c.log.Errorf("malformed query on url: %s", err)
+		c.logger().Errorf("malformed query on url: %s", err)
 	}
 
 	query := wsURL.Query()
@@ -112,7 +184,7 @@ func (c *Transport) buildWsUrl() *url.URL {
 }
 
 func (c *Transport) connectWebSocket() error {
-	c.log.Debugf("open ws")
+	c.logger().Debugf("open ws")
 
 	origin := c.origin
 	if origin == nil {
@@ -124,14 +196,17 @@ func (c *Transport) connectWebSocket() error {
 		return err
 	}
 
+	done := make(chan struct{})
+	c.readLoopDone = done
 	go func() {
+		defer close(done)
 		err := c.wsReadLoop()
 		if err != nil {
-			c.log.Errorf("wsReadLoop: %s", err)
+			c.logger().Errorf("wsReadLoop: %s", err)
 		}
 		err = c.ws.Close()
 		if err != nil {
-			c.log.Errorf("wsClose: %s", err)
+			c.logger().Errorf("wsClose: %s", err)
 		}
 		// TODO: Reconnect
 	}()
@@ -139,33 +214,29 @@ func (c *Transport) connectWebSocket() error {
 	return nil
 }
 
+// received is one result of c.ws.Receive.
+type received struct {
+	message []byte
+	err     error
+}
+
 func (c *Transport) wsReadLoop() error {
-	c.log.Debugf("run ws read loop")
-
-	// Buffered (size 1) so that a per-iteration reader goroutine still blocked
-	// in c.ws.Receive() when wsReadLoop returns can always complete its send
-	// and exit, instead of leaking. After wsReadLoop returns, connectWebSocket
-	// calls c.ws.Close(), which unblocks Receive() with an error; the goroutine
-	// then delivers that error into the buffer (no reader required) and exits.
-	// Only one reader goroutine is ever in flight at a time, so a capacity of 1
-	// is sufficient.
-	messageCh := make(chan []byte, 1)
-	errorCh := make(chan error, 1)
-	for {
-		// Run ws read in goroutine
-		go func() {
-			var message []byte
-			err := c.ws.Receive(&message)
-			if err != nil {
-				errorCh <- err
-				return
-			}
-			messageCh <- message
-		}()
+	c.logger().Debugf("run ws read loop")
 
+	// A single reader goroutine blocks in c.ws.Receive for the whole
+	// connection and hands each result over. Receive cannot be interrupted,
+	// so this loop only stops waiting for it; connectWebSocket then closes
+	// the connection, which fails the pending Receive, and quit lets the
+	// reader drop that result and exit.
+	results := make(chan received)
+	quit := make(chan struct{})
+	defer close(quit)
+	go c.wsReader(results, quit)
+
+	for {
 		select {
 		case <-c.stopPooling:
-			c.log.Debugf("Context cancelled, exiting ws read loop")
+			c.logger().Debugf("Context cancelled, exiting ws read loop")
 			select {
 			case c.onClose <- nil:
 			default:
@@ -174,47 +245,79 @@ func (c *Transport) wsReadLoop() error {
 
 		case <-c.ctx.Done():
 			// Context cancelled
-			c.log.Debugf("Context cancelled, exiting ws read loop")
+			c.logger().Debugf("Context cancelled, exiting ws read loop")
 			select {
 			case c.onClose <- c.ctx.Err():
 			default:
 			}
 			return c.ctx.Err()
 
-		case message := <-messageCh:
+		case r := <-results:
+			if r.err != nil {
+				if errors.Is(r.err, ws_native.ErrFrameTooLarge) {
+					// The frame was discarded, keep reading.
+					c.logger().Warnf("receiveWs: %v", r.err)
+					if c.onFrameTooLarge != nil {
+						c.onFrameTooLarge(fmt.Errorf("%w: limit is %d bytes", r.err, c.maxFrameSize))
+					}
+					continue
+				}
+				// WebSocket error
+				c.logger().Errorf("receiveWsError: %v", r.err)
+				select {
+				case c.onClose <- r.err:
+				default:
+				}
+				return r.err
+			}
+
 			// New message received
-			c.log.Debugf("receiveWs: %s", c.payload(message))
+			message := r.message
+			c.logger().Debugf("receiveWs: %s", c.payload(message))
+			c.meter().PacketReceived(string(engineio_v4.TransportWebsocket), engineio_v4.FrameType(message).String(), len(message))
 			select {
 			case c.messages <- message:
 			case <-c.stopPooling:
-				c.log.Debugf("Stop signal received, exiting ws read loop")
+				c.logger().Debugf("Stop signal received, exiting ws read loop")
 				select {
 				case c.onClose <- nil:
 				default:
 				}
 				return nil
 			case <-c.ctx.Done():
-				c.log.Debugf("Context cancelled, exiting ws read loop")
+				c.logger().Debugf("Context cancelled, exiting ws read loop")
 				select {
 				case c.onClose <- c.ctx.Err():
 				default:
 				}
 				return c.ctx.Err()
 			}
+		}
+	}
+}
 
-		case err := <-errorCh:
-			// WebSocket error
-			c.log.Errorf("receiveWsError: %v", err)
-			select {
-			case c.onClose <- err:
-			default:
-			}
-			return err
+// wsReader receives from c.ws until it fails or quit is closed. A discarded
+// oversized frame is reported but does not end the connection.
+func (c *Transport) wsReader(results chan<- received, quit <-chan struct{}) {
+	for {
+		var r received
+		r.err = c.ws.Receive(&r.message)
+		select {
+		case results <- r:
+		case <-quit:
+			return
+		}
+		if r.err != nil && !errors.Is(r.err, ws_native.ErrFrameTooLarge) {
+			return
 		}
 	}
 }
 
 func (c *Transport) SendMessage(msg []byte) error {
-	c.log.Debugf("sendWs: %s", c.payload(msg))
-	return c.ws.Send(msg)
+	c.logger().Debugf("sendWs: %s", c.payload(msg))
+	if err := c.ws.Send(msg); err != nil {
+		return err
+	}
+	c.meter().PacketSent(string(engineio_v4.TransportWebsocket), engineio_v4.FrameType(msg).String(), len(msg))
+	return nil
 }
@@ -0,0 +1,183 @@
+package engineio_v4_client_transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	"github.com/maldikhan/go.socket.io/utils"
+	ws_native "github.com/maldikhan/go.socket.io/websocket/native"
+)
+
+// BenchmarkReadLoop compares wsReadLoop with the goroutine per Receive design
+// it replaced. Throughput reads frames that are always ready; Latency measures
+// one frame at a time, from its arrival to its delivery on messages.
+func BenchmarkReadLoop(b *testing.B) {
+	loops := []struct {
+		name string
+		run  func(*Transport) error
+	}{
+		{"SingleReader", (*Transport).wsReadLoop},
+		{"GoroutinePerReceive", (*Transport).goroutinePerReceiveReadLoop},
+	}
+
+	for _, loop := range loops {
+		loop := loop
+		b.Run("Throughput/"+loop.name, func(b *testing.B) {
+			messages := make(chan []byte, 100)
+			transport, stop := benchTransport(&readyWebSocket{message: []byte("4message")}, messages, loop.run)
+			defer stop()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				<-messages
+			}
+			b.StopTimer()
+			_ = transport.Stop()
+		})
+
+		b.Run("Latency/"+loop.name, func(b *testing.B) {
+			ws := &pipeWebSocket{in: make(chan []byte), closed: make(chan struct{})}
+			messages := make(chan []byte)
+			transport, stop := benchTransport(ws, messages, loop.run)
+			defer stop()
+			message := []byte("4message")
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ws.in <- message
+				<-messages
+			}
+			b.StopTimer()
+			_ = transport.Stop()
+			close(ws.closed)
+		})
+	}
+}
+
+// benchTransport runs loop over ws like connectWebSocket does. stop waits for
+// the loop to exit.
+func benchTransport(ws WebSocket, messages chan []byte, loop func(*Transport) error) (*Transport, func()) {
+	transport := &Transport{
+		log:         &utils.DefaultLogger{Level: utils.NONE},
+		metrics:     utils.NopMetrics{},
+		ws:          ws,
+		ctx:         context.Background(),
+		url:         &url.URL{},
+		messages:    messages,
+		onClose:     make(chan error, 1),
+		stopPooling: make(chan struct{}, 1),
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = loop(transport)
+		_ = ws.Close()
+	}()
+	return transport, func() { <-done }
+}
+
+// readyWebSocket always has a frame to receive.
+type readyWebSocket struct {
+	message []byte
+}
+
+func (w *readyWebSocket) Dial(context.Context, *url.URL, *url.URL) error { return nil }
+func (w *readyWebSocket) Send([]byte) error                              { return nil }
+func (w *readyWebSocket) Close() error                                   { return nil }
+
+func (w *readyWebSocket) Receive(v *[]byte) error {
+	*v = w.message
+	return nil
+}
+
+// pipeWebSocket receives the frames sent on in, and fails once closed is.
+type pipeWebSocket struct {
+	in     chan []byte
+	closed chan struct{}
+}
+
+func (w *pipeWebSocket) Dial(context.Context, *url.URL, *url.URL) error { return nil }
+func (w *pipeWebSocket) Send([]byte) error                              { return nil }
+func (w *pipeWebSocket) Close() error                                   { return nil }
+
+func (w *pipeWebSocket) Receive(v *[]byte) error {
+	select {
+	case *v = <-w.in:
+		return nil
+	case <-w.closed:
+		return net.ErrClosed
+	}
+}
+
+// goroutinePerReceiveReadLoop is the previous wsReadLoop, kept as the
+// baseline: it starts a goroutine for every Receive to select on it.
+func (c *Transport) goroutinePerReceiveReadLoop() error {
+	messageCh := make(chan []byte, 1)
+	errorCh := make(chan error, 1)
+	for {
+		go func() {
+			var message []byte
+			err := c.ws.Receive(&message)
+			if err != nil {
+				errorCh <- err
+				return
+			}
+			messageCh <- message
+		}()
+
+		select {
+		case <-c.stopPooling:
+			select {
+			case c.onClose <- nil:
+			default:
+			}
+			return nil
+
+		case <-c.ctx.Done():
+			select {
+			case c.onClose <- c.ctx.Err():
+			default:
+			}
+			return c.ctx.Err()
+
+		case message := <-messageCh:
+			c.logger().Debugf("receiveWs: %s", c.payload(message))
+			c.meter().PacketReceived(string(engineio_v4.TransportWebsocket), engineio_v4.FrameType(message).String(), len(message))
+			select {
+			case c.messages <- message:
+			case <-c.stopPooling:
+				select {
+				case c.onClose <- nil:
+				default:
+				}
+				return nil
+			case <-c.ctx.Done():
+				select {
+				case c.onClose <- c.ctx.Err():
+				default:
+				}
+				return c.ctx.Err()
+			}
+
+		case err := <-errorCh:
+			if errors.Is(err, ws_native.ErrFrameTooLarge) {
+				if c.onFrameTooLarge != nil {
+					c.onFrameTooLarge(fmt.Errorf("%w: limit is %d bytes", err, c.maxFrameSize))
+				}
+				continue
+			}
+			select {
+			case c.onClose <- err:
+			default:
+			}
+			return err
+		}
+	}
+}
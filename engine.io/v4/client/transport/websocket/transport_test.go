@@ -16,6 +16,7 @@ import (
 	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
 	mock_engineio_v4_client_transport "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket/mocks"
 	"github.com/maldikhan/go.socket.io/utils"
+	ws_native "github.com/maldikhan/go.socket.io/websocket/native"
 )
 
 func TestTransport_Transport(t *testing.T) {
@@ -116,6 +117,53 @@ func TestTransport_Run(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestTransport_RunWhileReadLoopRunning(t *testing.T) {
+	t.Parallel()
+
+	// The previous read loop never finishes: Run waits for it until the
+	// context is done instead of dialing over its connection.
+	transport := &Transport{
+		log:          &utils.DefaultLogger{Level: utils.NONE},
+		readLoopDone: make(chan struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	u, _ := url.Parse("http://example.com")
+	err := transport.Run(ctx, u, "", make(chan []byte), make(chan error, 1))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTransport_StopDuringRun(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWS := mock_engineio_v4_client_transport.NewMockWebSocket(ctrl)
+	mockWS.EXPECT().Dial(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("dial error")).AnyTimes()
+
+	transport := &Transport{
+		log:         &utils.DefaultLogger{Level: utils.NONE},
+		ws:          mockWS,
+		stopPooling: make(chan struct{}, 1),
+	}
+
+	// Run replaces stopPooling while Stop signals it; -race checks the two.
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, transport.Stop())
+		}
+	}()
+	u, _ := url.Parse("http://example.com")
+	for i := 0; i < 100; i++ {
+		assert.Error(t, transport.Run(context.Background(), u, "", make(chan []byte), make(chan error, 1)))
+	}
+	<-stopped
+}
+
 func TestTransport_RequestHandshake(t *testing.T) {
 	t.Parallel()
 
@@ -124,6 +172,22 @@ func TestTransport_RequestHandshake(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestTransport_sessionLogger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mock_engineio_v4_client_transport.NewMockLogger(ctrl)
+	transport, err := NewTransport(WithLogger(utils.NewFieldLogger(mockLogger)))
+	require.NoError(t, err)
+
+	mockLogger.EXPECT().Debugf("%s", "before handshake transport=websocket")
+	transport.logger().Debugf("before handshake")
+
+	transport.SetHandshake(&engineio_v4.HandshakeResponse{Sid: "new-sid"})
+	mockLogger.EXPECT().Debugf("%s", "after handshake transport=websocket sid=new-sid")
+	transport.logger().Debugf("after handshake")
+}
+
 func TestTransport_SetHandshake(t *testing.T) {
 	t.Parallel()
 
@@ -466,6 +530,64 @@ func TestTransport_wsReadLoop(t *testing.T) {
 		}
 	})
 
+	t.Run("frame too large", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockWS := mock_engineio_v4_client_transport.NewMockWebSocket(ctrl)
+		mockLogger := mock_engineio_v4_client_transport.NewMockLogger(ctrl)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		messages := make(chan []byte, 1)
+		exceeded := make(chan error, 1)
+		transport := &Transport{
+			log:             mockLogger,
+			ws:              mockWS,
+			ctx:             ctx,
+			messages:        messages,
+			onClose:         make(chan error, 1),
+			stopPooling:     make(chan struct{}, 1),
+			maxFrameSize:    16,
+			onFrameTooLarge: func(err error) { exceeded <- err },
+		}
+
+		mockLogger.EXPECT().Debugf(gomock.Any()).AnyTimes()
+		mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+		mockLogger.EXPECT().Warnf("receiveWs: %v", ws_native.ErrFrameTooLarge)
+		gomock.InOrder(
+			mockWS.EXPECT().Receive(gomock.Any()).Return(ws_native.ErrFrameTooLarge),
+			mockWS.EXPECT().Receive(gomock.Any()).DoAndReturn(func(message *[]byte) error {
+				*message = []byte("4next")
+				return nil
+			}),
+			mockWS.EXPECT().Receive(gomock.Any()).DoAndReturn(func(*[]byte) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}).AnyTimes(),
+		)
+
+		go func() { _ = transport.wsReadLoop() }()
+
+		select {
+		case err := <-exceeded:
+			assert.ErrorIs(t, err, ws_native.ErrFrameTooLarge)
+			assert.Contains(t, err.Error(), "limit is 16 bytes")
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for the frame error")
+		}
+		select {
+		case msg := <-messages:
+			assert.Equal(t, "4next", string(msg))
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for message")
+		}
+		transport.stopPooling <- struct{}{}
+	})
+
 	// Check context is canceled
 	t.Run("context canceled", func(t *testing.T) {
 		t.Parallel()
@@ -1173,6 +1295,13 @@ func TestTransport_wsReadLoop_FullChannel(t *testing.T) {
 			*msg = []byte("msg")
 			return nil
 		}).Times(1)
+		// The reader reads ahead while the message waits for the consumer.
+		released := make(chan struct{})
+		defer close(released)
+		mockWS.EXPECT().Receive(gomock.Any()).DoAndReturn(func(msg *[]byte) error {
+			<-released
+			return errors.New("closed")
+		}).AnyTimes()
 		mockWS.EXPECT().Close().Return(nil).AnyTimes()
 
 		go func() {
@@ -1282,6 +1411,11 @@ func TestTransport_payloadRedaction(t *testing.T) {
 	assert.Equal(t, "[redacted 5 bytes]", c.payload([]byte("hello")))
 	c.redactPayload = false
 	assert.Equal(t, "hello", c.payload([]byte("hello")))
+
+	// A policy takes precedence.
+	assert.Error(t, WithRedactionPolicy(nil)(c))
+	require.NoError(t, WithRedactionPolicy(&utils.RedactionPolicy{})(c))
+	assert.Equal(t, `42["login",{"token":"[redacted]"}]`, c.payload([]byte(`42["login",{"token":"t"}]`)))
 }
 
 func TestTransport_WithDebugPayload(t *testing.T) {
@@ -12,6 +12,35 @@ const (
 	PacketNoop    EngineIOPacket = 0x06
 )
 
+func (p EngineIOPacket) String() string {
+	switch p {
+	case PacketOpen:
+		return "open"
+	case PacketClose:
+		return "close"
+	case PacketPing:
+		return "ping"
+	case PacketPong:
+		return "pong"
+	case PacketMessage:
+		return "message"
+	case PacketUpgrade:
+		return "upgrade"
+	case PacketNoop:
+		return "noop"
+	}
+	return "unknown"
+}
+
+// FrameType returns the type of an encoded text packet; its String() is
+// "unknown" for anything else.
+func FrameType(frame []byte) EngineIOPacket {
+	if len(frame) == 0 || frame[0] < '0' || frame[0] > '6' {
+		return EngineIOPacket(0xff)
+	}
+	return EngineIOPacket(frame[0] - '0')
+}
+
 type EngineIOTransport string
 
 const (
@@ -30,4 +59,29 @@ type HandshakeResponse struct {
 	Upgrades     []string `json:"upgrades,omitempty"`
 	PingInterval int      `json:"pingInterval,omitempty"`
 	PingTimeout  int      `json:"pingTimeout,omitempty"`
+	MaxPayload   int64    `json:"maxPayload,omitempty"`
+}
+
+// State is the connection state of an engine.io client.
+type State int
+
+const (
+	StateClosed State = iota
+	StateConnecting
+	StateOpen
+	StateUpgrading
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateConnecting:
+		return "connecting"
+	case StateOpen:
+		return "open"
+	case StateUpgrading:
+		return "upgrading"
+	}
+	return "unknown"
 }
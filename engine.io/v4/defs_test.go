@@ -0,0 +1,16 @@
+package engineio_v4
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameType(t *testing.T) {
+	assert.Equal(t, "message", FrameType([]byte("4hello")).String())
+	assert.Equal(t, "ping", FrameType([]byte("2probe")).String())
+	assert.Equal(t, "noop", FrameType([]byte("6")).String())
+	assert.Equal(t, "unknown", FrameType([]byte("7")).String())
+	assert.Equal(t, "unknown", FrameType([]byte("b4AQ==")).String())
+	assert.Equal(t, "unknown", FrameType(nil).String())
+}
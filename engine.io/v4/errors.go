@@ -0,0 +1,74 @@
+package engineio_v4
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ServerErrorCode is the numeric "code" field of the JSON body an engine.io
+// server sends when it rejects a request, e.g. {"code":3,"message":"Bad request"}.
+type ServerErrorCode int
+
+const (
+	ErrorCodeUnknownTransport           ServerErrorCode = 0
+	ErrorCodeUnknownSid                 ServerErrorCode = 1
+	ErrorCodeBadHandshakeMethod         ServerErrorCode = 2
+	ErrorCodeBadRequest                 ServerErrorCode = 3
+	ErrorCodeForbidden                  ServerErrorCode = 4
+	ErrorCodeUnsupportedProtocolVersion ServerErrorCode = 5
+)
+
+var (
+	ErrUnknownTransport           = errors.New("transport unknown")
+	ErrUnknownSid                 = errors.New("session ID unknown")
+	ErrBadHandshakeMethod         = errors.New("bad handshake method")
+	ErrBadRequest                 = errors.New("bad request")
+	ErrForbidden                  = errors.New("forbidden")
+	ErrUnsupportedProtocolVersion = errors.New("unsupported protocol version")
+)
+
+var serverErrors = map[ServerErrorCode]error{
+	ErrorCodeUnknownTransport:           ErrUnknownTransport,
+	ErrorCodeUnknownSid:                 ErrUnknownSid,
+	ErrorCodeBadHandshakeMethod:         ErrBadHandshakeMethod,
+	ErrorCodeBadRequest:                 ErrBadRequest,
+	ErrorCodeForbidden:                  ErrForbidden,
+	ErrorCodeUnsupportedProtocolVersion: ErrUnsupportedProtocolVersion,
+}
+
+// ServerError is a request rejection reported by the engine.io server. It
+// unwraps to the matching Err* value, so callers can use
+// errors.Is(err, engineio_v4.ErrUnknownSid) without inspecting the code.
+type ServerError struct {
+	StatusCode int
+	Code       ServerErrorCode
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("engine.io server error (status %d, code %d): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Unwrap returns the sentinel error for the code, or nil for a code outside
+// the range defined by the protocol.
+func (e *ServerError) Unwrap() error {
+	return serverErrors[e.Code]
+}
+
+// ParseServerError decodes an engine.io error body. It returns nil if the body
+// is not a JSON object carrying a numeric "code".
+func ParseServerError(statusCode int, body []byte) *ServerError {
+	var resp struct {
+		Code    *ServerErrorCode `json:"code"`
+		Message string           `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Code == nil {
+		return nil
+	}
+	return &ServerError{
+		StatusCode: statusCode,
+		Code:       *resp.Code,
+		Message:    resp.Message,
+	}
+}
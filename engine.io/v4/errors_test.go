@@ -0,0 +1,46 @@
+package engineio_v4
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerError(t *testing.T) {
+	tests := []struct {
+		body     string
+		sentinel error
+	}{
+		{`{"code":0,"message":"Transport unknown"}`, ErrUnknownTransport},
+		{`{"code":1,"message":"Session ID unknown"}`, ErrUnknownSid},
+		{`{"code":2,"message":"Bad handshake method"}`, ErrBadHandshakeMethod},
+		{`{"code":3,"message":"Bad request"}`, ErrBadRequest},
+		{`{"code":4,"message":"Forbidden"}`, ErrForbidden},
+		{`{"code":5,"message":"Unsupported protocol version"}`, ErrUnsupportedProtocolVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			serverErr := ParseServerError(400, []byte(tt.body))
+			require.NotNil(t, serverErr)
+			assert.True(t, errors.Is(serverErr, tt.sentinel))
+			assert.True(t, errors.Is(fmt.Errorf("wrapped: %w", serverErr), tt.sentinel))
+			assert.Equal(t, 400, serverErr.StatusCode)
+		})
+	}
+
+	t.Run("Unknown code", func(t *testing.T) {
+		serverErr := ParseServerError(403, []byte(`{"code":42,"message":"custom"}`))
+		require.NotNil(t, serverErr)
+		assert.Nil(t, serverErr.Unwrap())
+		assert.Equal(t, "engine.io server error (status 403, code 42): custom", serverErr.Error())
+	})
+
+	t.Run("Not an engine.io error", func(t *testing.T) {
+		assert.Nil(t, ParseServerError(502, []byte("Bad Gateway")))
+		assert.Nil(t, ParseServerError(400, []byte(`{"message":"no code"}`)))
+	})
+}
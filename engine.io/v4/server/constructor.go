@@ -0,0 +1,188 @@
+package engineio_v4_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_parser "github.com/maldikhan/go.socket.io/engine.io/v4/parser"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+type ServerOption func(*Server) error
+
+// NewServer builds an engine.io v4 server with the engine.io defaults:
+// pingInterval 25s, pingTimeout 20s, maxPayload 1MB, both transports and
+// polling -> websocket upgrades enabled.
+func NewServer(options ...ServerOption) (*Server, error) {
+	server := &Server{
+		log:            &utils.DefaultLogger{},
+		parser:         &engineio_v4_parser.EngineIOV4Parser{},
+		pingInterval:   25 * time.Second,
+		pingTimeout:    20 * time.Second,
+		upgradeTimeout: 10 * time.Second,
+		maxPayload:     1_000_000,
+		allowUpgrades:  true,
+		transports: map[engineio_v4.EngineIOTransport]bool{
+			engineio_v4.TransportPolling:   true,
+			engineio_v4.TransportWebsocket: true,
+		},
+		sessions:      make(map[string]*Session),
+		clock:         utils.RealClock{},
+		redactPayload: true, // production-safe default; WithDebugPayload(true) opts out
+	}
+
+	for _, opt := range options {
+		if err := opt(server); err != nil {
+			return nil, err
+		}
+	}
+
+	if server.log == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	if server.parser == nil {
+		return nil, errors.New("parser is nil")
+	}
+
+	if server.clock == nil {
+		return nil, errors.New("clock is nil")
+	}
+
+	if len(server.transports) == 0 {
+		return nil, errors.New("no transports enabled")
+	}
+
+	return server, nil
+}
+
+func WithLogger(logger Logger) ServerOption {
+	return func(s *Server) error {
+		s.log = logger
+		return nil
+	}
+}
+
+func WithParser(parser Parser) ServerOption {
+	return func(s *Server) error {
+		s.parser = parser
+		return nil
+	}
+}
+
+// WithPingInterval sets how often the server sends a heartbeat ping.
+func WithPingInterval(interval time.Duration) ServerOption {
+	return func(s *Server) error {
+		if interval <= 0 {
+			return errors.New("ping interval must be positive")
+		}
+		s.pingInterval = interval
+		return nil
+	}
+}
+
+// WithPingTimeout sets how long the server waits for the pong before it
+// closes the session with ReasonPingTimeout.
+func WithPingTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) error {
+		if timeout <= 0 {
+			return errors.New("ping timeout must be positive")
+		}
+		s.pingTimeout = timeout
+		return nil
+	}
+}
+
+// WithUpgradeTimeout sets how long a websocket upgrade may take between the
+// probe and the UPGRADE packet.
+func WithUpgradeTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) error {
+		if timeout <= 0 {
+			return errors.New("upgrade timeout must be positive")
+		}
+		s.upgradeTimeout = timeout
+		return nil
+	}
+}
+
+// WithMaxPayload sets the maximum size in bytes of a polling POST body or a
+// websocket frame. It is announced to clients in the handshake.
+func WithMaxPayload(size int64) ServerOption {
+	return func(s *Server) error {
+		if size <= 0 {
+			return errors.New("max payload must be positive")
+		}
+		s.maxPayload = size
+		return nil
+	}
+}
+
+// WithTransports restricts the transports the server accepts.
+func WithTransports(transports ...engineio_v4.EngineIOTransport) ServerOption {
+	return func(s *Server) error {
+		s.transports = make(map[engineio_v4.EngineIOTransport]bool)
+		for _, transport := range transports {
+			switch transport {
+			case engineio_v4.TransportPolling, engineio_v4.TransportWebsocket:
+				s.transports[transport] = true
+			default:
+				return fmt.Errorf("unsupported transport: %s", transport)
+			}
+		}
+		return nil
+	}
+}
+
+// WithAllowUpgrades enables or disables polling -> websocket upgrades.
+func WithAllowUpgrades(allow bool) ServerOption {
+	return func(s *Server) error {
+		s.allowUpgrades = allow
+		return nil
+	}
+}
+
+// WithAllowRequest sets a check run on every handshake request, e.g. to
+// verify the Origin header or a cookie. A non-nil error rejects the request
+// with 403 and the "Forbidden" engine.io error.
+func WithAllowRequest(allow func(r *http.Request) error) ServerOption {
+	return func(s *Server) error {
+		s.allowRequest = allow
+		return nil
+	}
+}
+
+// WithDebugPayload enables logging of raw packet payloads at debug level.
+// It is disabled by default so production logs do not leak message contents.
+func WithDebugPayload(enabled bool) ServerOption {
+	return func(s *Server) error {
+		s.redactPayload = !enabled
+		return nil
+	}
+}
+
+// WithRedactionPolicy logs packet payloads at debug level with the secrets
+// masked by policy.
+func WithRedactionPolicy(policy *utils.RedactionPolicy) ServerOption {
+	return func(s *Server) error {
+		if policy == nil {
+			return errors.New("redaction policy is nil")
+		}
+		s.redaction = policy
+		return nil
+	}
+}
+
+// WithClock runs the session heartbeats, the ping interval and ping timeout,
+// on clock, and sets the websocket read and write deadlines from it.
+func WithClock(clock utils.Clock) ServerOption {
+	return func(s *Server) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		s.clock = clock
+		return nil
+	}
+}
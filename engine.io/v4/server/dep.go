@@ -0,0 +1,25 @@
+//go:generate mockgen -source=$GOFILE -destination=mocks/deps.go -package=${packageName}
+
+package engineio_v4_server
+
+import (
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+)
+
+type Parser interface {
+	Parse([]byte) (
+		*engineio_v4.Message,
+		error,
+	)
+	Serialize(*engineio_v4.Message) (
+		[]byte,
+		error,
+	)
+}
+
+type Logger interface {
+	Debugf(format string, v ...any)
+	Infof(format string, v ...any)
+	Warnf(format string, v ...any)
+	Errorf(format string, v ...any)
+}
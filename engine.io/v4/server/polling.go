@@ -0,0 +1,116 @@
+package engineio_v4_server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+)
+
+// packetSeparator joins packets in a polling payload.
+var packetSeparator = []byte{0x1e}
+
+func (s *Session) servePolling(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.poll(w, r)
+	case http.MethodPost:
+		s.receivePolling(w, r)
+	default:
+		s.server.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeBadRequest)
+	}
+}
+
+// poll holds a GET until packets are queued, the session is upgraded or
+// closed, or the client goes away.
+func (s *Session) poll(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.transport != engineio_v4.TransportPolling {
+		s.mu.Unlock()
+		s.server.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeBadRequest)
+		return
+	}
+	if s.polling {
+		// Two concurrent polls mean a broken client; engine.io drops the
+		// session.
+		s.mu.Unlock()
+		s.server.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeBadRequest)
+		s.close(ReasonTransportError, false)
+		return
+	}
+	s.polling = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.polling = false
+		s.mu.Unlock()
+	}()
+
+	for {
+		s.mu.Lock()
+		packets := s.buffer
+		s.buffer = nil
+		upgraded := s.transport != engineio_v4.TransportPolling
+		s.mu.Unlock()
+
+		if len(packets) > 0 {
+			writePayload(w, bytes.Join(packets, packetSeparator))
+			return
+		}
+		if upgraded {
+			s.writePacket(w, engineio_v4.PacketNoop)
+			return
+		}
+
+		select {
+		case <-s.flush:
+		case <-s.done:
+			s.mu.Lock()
+			packets = s.buffer
+			s.buffer = nil
+			s.mu.Unlock()
+			if len(packets) > 0 {
+				writePayload(w, bytes.Join(packets, packetSeparator))
+			} else {
+				s.writePacket(w, engineio_v4.PacketClose)
+			}
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// receivePolling handles a POST carrying one or more packets.
+func (s *Session) receivePolling(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, s.server.maxPayload+1))
+	if err != nil {
+		s.server.log.Warnf("engine.io session %s: read body: %v", s.id, err)
+		s.server.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeBadRequest)
+		s.close(ReasonTransportError, false)
+		return
+	}
+	if int64(len(body)) > s.server.maxPayload {
+		s.server.log.Warnf("engine.io session %s: payload exceeds %d bytes", s.id, s.server.maxPayload)
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		s.close(ReasonTransportError, false)
+		return
+	}
+
+	for _, packet := range bytes.Split(body, packetSeparator) {
+		s.handlePacket(packet)
+	}
+
+	writePayload(w, []byte("ok"))
+}
+
+func (s *Session) writePacket(w http.ResponseWriter, packetType engineio_v4.EngineIOPacket) {
+	data, err := s.server.parser.Serialize(&engineio_v4.Message{Type: packetType})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePayload(w, data)
+}
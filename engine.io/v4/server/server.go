@@ -0,0 +1,276 @@
+package engineio_v4_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// Server is an engine.io v4 server. It is an http.Handler meant to be mounted
+// on the engine.io path (usually "/engine.io/" or "/socket.io/").
+type Server struct {
+	log    Logger
+	parser Parser
+
+	pingInterval   time.Duration
+	pingTimeout    time.Duration
+	upgradeTimeout time.Duration
+	maxPayload     int64
+	allowUpgrades  bool
+	transports     map[engineio_v4.EngineIOTransport]bool
+	allowRequest   func(r *http.Request) error
+	clock          utils.Clock
+
+	// handlerMu guards connectionHandler, set by OnConnection() and read for
+	// every new session.
+	handlerMu         sync.RWMutex
+	connectionHandler func(*Session)
+
+	// mu guards sessions, closing and closed.
+	mu       sync.Mutex
+	sessions map[string]*Session
+	closed   bool
+	// closing holds closed polling sessions whose last packets, the CLOSE
+	// packet among them, wait for the client's next poll.
+	closing map[string]*Session
+
+	// redactPayload, when true, replaces raw packet payloads in debug logs
+	// with a size marker. NewServer sets it; WithDebugPayload(true) clears it.
+	redactPayload bool
+
+	// redaction is the WithRedactionPolicy policy, or nil.
+	redaction *utils.RedactionPolicy
+}
+
+// payload returns a size marker when redaction is enabled, or the raw data.
+func (s *Server) payload(data []byte) string {
+	if s.redaction != nil {
+		return s.redaction.Redact(data)
+	}
+	if s.redactPayload {
+		return fmt.Sprintf("[redacted %d bytes]", len(data))
+	}
+	return string(data)
+}
+
+var errorMessages = map[engineio_v4.ServerErrorCode]string{
+	engineio_v4.ErrorCodeUnknownTransport:           "Transport unknown",
+	engineio_v4.ErrorCodeUnknownSid:                 "Session ID unknown",
+	engineio_v4.ErrorCodeBadHandshakeMethod:         "Bad handshake method",
+	engineio_v4.ErrorCodeBadRequest:                 "Bad request",
+	engineio_v4.ErrorCodeForbidden:                  "Forbidden",
+	engineio_v4.ErrorCodeUnsupportedProtocolVersion: "Unsupported protocol version",
+}
+
+// OnConnection sets the handler called for every new session, before the
+// OPEN packet is sent. It runs on the request goroutine and should only
+// register the session handlers.
+func (s *Server) OnConnection(handler func(*Session)) {
+	s.handlerMu.Lock()
+	defer s.handlerMu.Unlock()
+	s.connectionHandler = handler
+}
+
+// Session returns the open session with the given id, or nil.
+func (s *Server) Session(sid string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[sid]
+}
+
+// Count returns the number of open sessions.
+func (s *Server) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// Close closes every session with ReasonServerShutdown and rejects new
+// handshakes.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	sessions := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.mu.Unlock()
+
+	for _, session := range sessions {
+		session.close(ReasonServerShutdown, true)
+	}
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("EIO") != "4" {
+		s.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeUnsupportedProtocolVersion)
+		return
+	}
+
+	transport := engineio_v4.EngineIOTransport(query.Get("transport"))
+	if !s.transports[transport] {
+		s.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeUnknownTransport)
+		return
+	}
+
+	sid := query.Get("sid")
+	if sid == "" {
+		s.handshake(w, r, transport)
+		return
+	}
+
+	session := s.Session(sid)
+	if session == nil && transport == engineio_v4.TransportPolling && r.Method == http.MethodGet {
+		session = s.takeClosing(sid)
+	}
+	if session == nil {
+		s.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeUnknownSid)
+		return
+	}
+
+	switch transport {
+	case engineio_v4.TransportPolling:
+		session.servePolling(w, r)
+	case engineio_v4.TransportWebsocket:
+		session.serveUpgrade(w, r)
+	}
+}
+
+func (s *Server) handshake(w http.ResponseWriter, r *http.Request, transport engineio_v4.EngineIOTransport) {
+	if transport == engineio_v4.TransportPolling && r.Method != http.MethodGet {
+		s.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeBadHandshakeMethod)
+		return
+	}
+
+	if s.allowRequest != nil {
+		if err := s.allowRequest(r); err != nil {
+			s.log.Infof("engine.io handshake rejected: %v", err)
+			s.writeError(w, http.StatusForbidden, engineio_v4.ErrorCodeForbidden)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		s.writeError(w, http.StatusServiceUnavailable, engineio_v4.ErrorCodeBadRequest)
+		return
+	}
+
+	switch transport {
+	case engineio_v4.TransportPolling:
+		session, err := s.newSession(r, transport)
+		if err != nil {
+			s.log.Errorf("engine.io handshake: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		open, err := session.openPacket()
+		if err != nil {
+			session.close(ReasonTransportError, false)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writePayload(w, open)
+		session.start()
+	case engineio_v4.TransportWebsocket:
+		s.serveWebsocketHandshake(w, r)
+	}
+}
+
+// newSession registers a session and runs the connection handler.
+func (s *Server) newSession(r *http.Request, transport engineio_v4.EngineIOTransport) (*Session, error) {
+	sid, err := utils.GenerateID()
+	if err != nil {
+		return nil, err
+	}
+
+	session := newSession(s, sid, r, transport)
+
+	s.mu.Lock()
+	s.sessions[sid] = session
+	s.mu.Unlock()
+
+	s.log.Debugf("engine.io session %s opened over %s", sid, transport)
+
+	s.handlerMu.RLock()
+	handler := s.connectionHandler
+	s.handlerMu.RUnlock()
+	if handler != nil {
+		handler(session)
+	}
+
+	return session, nil
+}
+
+// removeSession drops a closed session. With linger set, one more poll may
+// still collect its queued packets until pingTimeout passes.
+func (s *Server) removeSession(session *Session, linger bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, session.id)
+	if !linger {
+		return
+	}
+	if s.closing == nil {
+		s.closing = make(map[string]*Session)
+	}
+	s.closing[session.id] = session
+	s.clock.AfterFunc(s.pingTimeout, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.closing[session.id] == session {
+			delete(s.closing, session.id)
+		}
+	})
+}
+
+// takeClosing returns the lingering session with the given id, at most once.
+func (s *Server) takeClosing(sid string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.closing[sid]
+	delete(s.closing, sid)
+	return session
+}
+
+func (s *Server) handshakeResponse(sid string, transport engineio_v4.EngineIOTransport) *engineio_v4.HandshakeResponse {
+	upgrades := []string{}
+	if s.allowUpgrades && transport == engineio_v4.TransportPolling && s.transports[engineio_v4.TransportWebsocket] {
+		upgrades = append(upgrades, string(engineio_v4.TransportWebsocket))
+	}
+	return &engineio_v4.HandshakeResponse{
+		Sid:          sid,
+		Upgrades:     upgrades,
+		PingInterval: int(s.pingInterval / time.Millisecond),
+		PingTimeout:  int(s.pingTimeout / time.Millisecond),
+		MaxPayload:   s.maxPayload,
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, code engineio_v4.ServerErrorCode) {
+	body, _ := json.Marshal(struct {
+		Code    engineio_v4.ServerErrorCode `json:"code"`
+		Message string                      `json:"message"`
+	}{code, errorMessages[code]})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// writePayload writes a polling response body.
+func writePayload(w http.ResponseWriter, payload []byte) {
+	w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+	w.Header().Set("Content-Length", fmt.Sprint(len(payload)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(payload)
+}
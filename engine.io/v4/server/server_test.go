@@ -0,0 +1,510 @@
+package engineio_v4_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	"github.com/maldikhan/go.socket.io/utils"
+	"github.com/maldikhan/go.socket.io/utils/fakeclock"
+)
+
+func newTestServer(t *testing.T, options ...ServerOption) (*Server, *httptest.Server) {
+	t.Helper()
+	options = append([]ServerOption{WithLogger(&utils.DefaultLogger{Level: utils.NONE})}, options...)
+	server, err := NewServer(options...)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		_ = server.Close()
+		httpServer.Close()
+	})
+	return server, httpServer
+}
+
+func request(t *testing.T, method, rawURL, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, rawURL, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+// pollingHandshake opens a polling session and returns the handshake.
+func pollingHandshake(t *testing.T, base string) *engineio_v4.HandshakeResponse {
+	t.Helper()
+	status, body := request(t, http.MethodGet, base+"/?EIO=4&transport=polling", "")
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, strings.HasPrefix(body, "0"), body)
+
+	handshake := &engineio_v4.HandshakeResponse{}
+	require.NoError(t, json.Unmarshal([]byte(body[1:]), handshake))
+	return handshake
+}
+
+type sessionEvents struct {
+	messages chan string
+	closed   chan string
+}
+
+func watchSessions(server *Server) *sessionEvents {
+	events := &sessionEvents{
+		messages: make(chan string, 10),
+		closed:   make(chan string, 10),
+	}
+	server.OnConnection(func(session *Session) {
+		session.On("message", func(data []byte) { events.messages <- string(data) })
+		session.On("close", func(reason []byte) { events.closed <- string(reason) })
+	})
+	return events
+}
+
+func receive(t *testing.T, ch chan string) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout")
+		return ""
+	}
+}
+
+func TestServer_HandshakeErrors(t *testing.T) {
+	_, httpServer := newTestServer(t, WithAllowRequest(func(r *http.Request) error {
+		if r.Header.Get("X-Deny") != "" {
+			return assert.AnError
+		}
+		return nil
+	}))
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		header string
+		status int
+		err    error
+	}{
+		{"Unsupported protocol", http.MethodGet, "EIO=3&transport=polling", "", http.StatusBadRequest, engineio_v4.ErrUnsupportedProtocolVersion},
+		{"Unknown transport", http.MethodGet, "EIO=4&transport=flash", "", http.StatusBadRequest, engineio_v4.ErrUnknownTransport},
+		{"Unknown sid", http.MethodGet, "EIO=4&transport=polling&sid=nope", "", http.StatusBadRequest, engineio_v4.ErrUnknownSid},
+		{"Bad handshake method", http.MethodPost, "EIO=4&transport=polling", "", http.StatusBadRequest, engineio_v4.ErrBadHandshakeMethod},
+		{"Forbidden", http.MethodGet, "EIO=4&transport=polling", "1", http.StatusForbidden, engineio_v4.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, httpServer.URL+"/?"+tt.query, nil)
+			require.NoError(t, err)
+			if tt.header != "" {
+				req.Header.Set("X-Deny", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close() //nolint:errcheck
+			body, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			serverErr := engineio_v4.ParseServerError(resp.StatusCode, body)
+			require.NotNil(t, serverErr)
+			assert.ErrorIs(t, serverErr, tt.err)
+		})
+	}
+}
+
+func TestServer_Polling(t *testing.T) {
+	server, httpServer := newTestServer(t,
+		WithPingInterval(time.Minute),
+		WithPingTimeout(time.Minute),
+		WithMaxPayload(100),
+	)
+	events := watchSessions(server)
+
+	handshake := pollingHandshake(t, httpServer.URL)
+	assert.NotEmpty(t, handshake.Sid)
+	assert.Equal(t, []string{"websocket"}, handshake.Upgrades)
+	assert.Equal(t, 60000, handshake.PingInterval)
+	assert.Equal(t, 60000, handshake.PingTimeout)
+	assert.Equal(t, int64(100), handshake.MaxPayload)
+
+	sessionURL := httpServer.URL + "/?EIO=4&transport=polling&sid=" + handshake.Sid
+	session := server.Session(handshake.Sid)
+	require.NotNil(t, session)
+	assert.Equal(t, engineio_v4.TransportPolling, session.Transport())
+	assert.Equal(t, 1, server.Count())
+
+	t.Run("POST with several packets", func(t *testing.T) {
+		status, body := request(t, http.MethodPost, sessionURL, "4hello\x1e4world")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", body)
+		assert.Equal(t, "hello", receive(t, events.messages))
+		assert.Equal(t, "world", receive(t, events.messages))
+	})
+
+	t.Run("GET returns queued packets", func(t *testing.T) {
+		require.NoError(t, session.Send([]byte("one")))
+		require.NoError(t, session.Send([]byte("two")))
+		status, body := request(t, http.MethodGet, sessionURL, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "4one\x1e4two", body)
+	})
+
+	t.Run("GET is held until a packet is sent", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_ = session.Send([]byte("late"))
+		}()
+		_, body := request(t, http.MethodGet, sessionURL, "")
+		assert.Equal(t, "4late", body)
+	})
+
+	t.Run("Payload too large", func(t *testing.T) {
+		status, _ := request(t, http.MethodPost, sessionURL, "4"+strings.Repeat("x", 100))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+		assert.Equal(t, ReasonTransportError, receive(t, events.closed))
+		assert.Nil(t, server.Session(handshake.Sid))
+	})
+}
+
+func TestServer_Close(t *testing.T) {
+	server, httpServer := newTestServer(t)
+	events := watchSessions(server)
+
+	handshake := pollingHandshake(t, httpServer.URL)
+	sessionURL := httpServer.URL + "/?EIO=4&transport=polling&sid=" + handshake.Sid
+
+	t.Run("Client CLOSE packet", func(t *testing.T) {
+		request(t, http.MethodPost, sessionURL, "1")
+		assert.Equal(t, ReasonTransportClose, receive(t, events.closed))
+	})
+
+	t.Run("Held poll receives CLOSE on forced close", func(t *testing.T) {
+		handshake := pollingHandshake(t, httpServer.URL)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_ = server.Session(handshake.Sid).Close()
+		}()
+		_, body := request(t, http.MethodGet, httpServer.URL+"/?EIO=4&transport=polling&sid="+handshake.Sid, "")
+		assert.Equal(t, "1", body)
+		assert.Equal(t, ReasonForcedClose, receive(t, events.closed))
+	})
+
+	t.Run("Drop sends no CLOSE", func(t *testing.T) {
+		handshake := pollingHandshake(t, httpServer.URL)
+		require.NoError(t, server.Session(handshake.Sid).Drop())
+		assert.Equal(t, ReasonTransportClose, receive(t, events.closed))
+
+		status, _ := request(t, http.MethodGet, httpServer.URL+"/?EIO=4&transport=polling&sid="+handshake.Sid, "")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Server shutdown", func(t *testing.T) {
+		pollingHandshake(t, httpServer.URL)
+		require.NoError(t, server.Close())
+		assert.Equal(t, ReasonServerShutdown, receive(t, events.closed))
+		assert.Equal(t, 0, server.Count())
+
+		status, _ := request(t, http.MethodGet, httpServer.URL+"/?EIO=4&transport=polling", "")
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+	})
+}
+
+func TestServer_CloseBetweenPolls(t *testing.T) {
+	clock := fakeclock.New(time.Now())
+	server, httpServer := newTestServer(t, WithClock(clock), WithPingTimeout(time.Second))
+	sessionURL := func(sid string) string {
+		return httpServer.URL + "/?EIO=4&transport=polling&sid=" + sid
+	}
+
+	t.Run("Next poll receives CLOSE", func(t *testing.T) {
+		handshake := pollingHandshake(t, httpServer.URL)
+		require.NoError(t, server.Session(handshake.Sid).Close())
+		assert.Equal(t, 0, server.Count())
+
+		status, body := request(t, http.MethodGet, sessionURL(handshake.Sid), "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "1", body)
+
+		status, _ = request(t, http.MethodGet, sessionURL(handshake.Sid), "")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("No poll within pingTimeout", func(t *testing.T) {
+		handshake := pollingHandshake(t, httpServer.URL)
+		require.NoError(t, server.Session(handshake.Sid).Close())
+		clock.Advance(time.Second)
+
+		status, _ := request(t, http.MethodGet, sessionURL(handshake.Sid), "")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
+
+func TestServer_Heartbeat(t *testing.T) {
+	server, httpServer := newTestServer(t,
+		WithPingInterval(50*time.Millisecond),
+		WithPingTimeout(100*time.Millisecond),
+	)
+	events := watchSessions(server)
+
+	handshake := pollingHandshake(t, httpServer.URL)
+	sessionURL := httpServer.URL + "/?EIO=4&transport=polling&sid=" + handshake.Sid
+
+	// Answer two pings.
+	for i := 0; i < 2; i++ {
+		_, body := request(t, http.MethodGet, sessionURL, "")
+		assert.Equal(t, "2", body)
+		request(t, http.MethodPost, sessionURL, "3")
+	}
+	assert.NotNil(t, server.Session(handshake.Sid))
+
+	// Stop polling: the session expires.
+	assert.Equal(t, ReasonPingTimeout, receive(t, events.closed))
+	assert.Nil(t, server.Session(handshake.Sid))
+}
+
+func TestServer_HeartbeatClock(t *testing.T) {
+	assert.Error(t, WithClock(nil)(&Server{}))
+
+	clock := fakeclock.New(time.Now())
+	server, httpServer := newTestServer(t, WithClock(clock))
+	events := watchSessions(server)
+
+	handshake := pollingHandshake(t, httpServer.URL)
+	sessionURL := httpServer.URL + "/?EIO=4&transport=polling&sid=" + handshake.Sid
+
+	clock.BlockUntil(1)
+	clock.Advance(25 * time.Second)
+	_, body := request(t, http.MethodGet, sessionURL, "")
+	assert.Equal(t, "2", body)
+
+	// No pong: the session lives exactly pingTimeout longer.
+	clock.BlockUntil(1)
+	clock.Advance(20*time.Second - time.Millisecond)
+	assert.NotNil(t, server.Session(handshake.Sid))
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, ReasonPingTimeout, receive(t, events.closed))
+}
+
+func TestServer_WebsocketDeadlineClock(t *testing.T) {
+	// Deadlines are set from the clock: an hour behind, the OPEN write has
+	// already expired.
+	server, httpServer := newTestServer(t, WithClock(fakeclock.New(time.Now().Add(-time.Hour))))
+	events := watchSessions(server)
+
+	conn, err := websocket.Dial(wsURL(httpServer.URL, "EIO=4&transport=websocket"), "", httpServer.URL)
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+
+	assert.Equal(t, ReasonTransportError, receive(t, events.closed))
+	var frame string
+	assert.Error(t, websocket.Message.Receive(conn, &frame))
+}
+
+func wsURL(httpURL, query string) string {
+	return "ws" + strings.TrimPrefix(httpURL, "http") + "/?" + query
+}
+
+func TestServer_WebsocketUpgrade(t *testing.T) {
+	server, httpServer := newTestServer(t, WithPingInterval(time.Minute))
+	events := watchSessions(server)
+	upgraded := make(chan string, 1)
+	server.OnConnection(func(session *Session) {
+		session.On("message", func(data []byte) { events.messages <- string(data) })
+		session.On("upgrade", func(transport []byte) { upgraded <- string(transport) })
+	})
+
+	handshake := pollingHandshake(t, httpServer.URL)
+	session := server.Session(handshake.Sid)
+	pollURL := httpServer.URL + "/?EIO=4&transport=polling&sid=" + handshake.Sid
+
+	heldPoll := make(chan string, 1)
+	go func() {
+		_, body := request(t, http.MethodGet, pollURL, "")
+		heldPoll <- body
+	}()
+
+	conn, err := websocket.Dial(wsURL(httpServer.URL, "EIO=4&transport=websocket&sid="+handshake.Sid), "", httpServer.URL)
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+
+	require.NoError(t, websocket.Message.Send(conn, "2probe"))
+	var frame string
+	require.NoError(t, websocket.Message.Receive(conn, &frame))
+	assert.Equal(t, "3probe", frame)
+
+	// The held poll is released with a NOOP.
+	assert.Equal(t, "6", receive(t, heldPoll))
+
+	// Packets sent during the upgrade are flushed over websocket.
+	require.NoError(t, session.Send([]byte("queued")))
+	require.NoError(t, websocket.Message.Send(conn, "5"))
+	assert.Equal(t, "websocket", receive(t, upgraded))
+	assert.Equal(t, engineio_v4.TransportWebsocket, session.Transport())
+
+	require.NoError(t, websocket.Message.Receive(conn, &frame))
+	assert.Equal(t, "4queued", frame)
+
+	require.NoError(t, websocket.Message.Send(conn, "4over ws"))
+	assert.Equal(t, "over ws", receive(t, events.messages))
+
+	// Polling is rejected after the upgrade.
+	status, _ := request(t, http.MethodGet, pollURL, "")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestServer_WebsocketOnly(t *testing.T) {
+	server, httpServer := newTestServer(t, WithPingInterval(time.Minute))
+	events := watchSessions(server)
+	server.OnConnection(func(session *Session) {
+		session.On("message", func(data []byte) { events.messages <- string(data) })
+		session.On("close", func(reason []byte) { events.closed <- string(reason) })
+		// Sent before OPEN is written: must arrive after it.
+		_ = session.Send([]byte("welcome"))
+	})
+
+	conn, err := websocket.Dial(wsURL(httpServer.URL, "EIO=4&transport=websocket"), "", httpServer.URL)
+	require.NoError(t, err)
+
+	var frame string
+	require.NoError(t, websocket.Message.Receive(conn, &frame))
+	require.True(t, strings.HasPrefix(frame, "0"), frame)
+	handshake := &engineio_v4.HandshakeResponse{}
+	require.NoError(t, json.Unmarshal([]byte(frame[1:]), handshake))
+	assert.Empty(t, handshake.Upgrades)
+
+	require.NoError(t, websocket.Message.Receive(conn, &frame))
+	assert.Equal(t, "4welcome", frame)
+
+	require.NoError(t, websocket.Message.Send(conn, "4hi"))
+	assert.Equal(t, "hi", receive(t, events.messages))
+
+	require.NoError(t, conn.Close())
+	assert.Equal(t, ReasonTransportClose, receive(t, events.closed))
+}
+
+func TestServer_GoClient(t *testing.T) {
+	server, httpServer := newTestServer(t)
+
+	var mu sync.Mutex
+	var serverSession *Session
+	received := make(chan string, 1)
+	server.OnConnection(func(session *Session) {
+		mu.Lock()
+		serverSession = session
+		mu.Unlock()
+		session.On("message", func(data []byte) { received <- string(data) })
+	})
+
+	u, err := url.Parse(httpServer.URL + "/engine.io/")
+	require.NoError(t, err)
+	client, err := engineio_v4_client.NewClient(
+		engineio_v4_client.WithURL(u),
+		engineio_v4_client.WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+	)
+	require.NoError(t, err)
+
+	clientReceived := make(chan string, 1)
+	client.On("message", func(data []byte) { clientReceived <- string(data) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Connect(ctx))
+	defer client.Close() //nolint:errcheck
+
+	require.NoError(t, client.Send([]byte("from client")))
+	assert.Equal(t, "from client", receive(t, received))
+
+	mu.Lock()
+	session := serverSession
+	mu.Unlock()
+	assert.Equal(t, client.ID(), session.ID())
+	assert.Eventually(t, func() bool {
+		return session.Transport() == engineio_v4.TransportWebsocket
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, session.Send([]byte("from server")))
+	assert.Equal(t, "from server", receive(t, clientReceived))
+}
+
+// captureLogger keeps the formatted log lines.
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) add(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *captureLogger) Debugf(format string, v ...any) { l.add(format, v...) }
+func (l *captureLogger) Infof(format string, v ...any)  { l.add(format, v...) }
+func (l *captureLogger) Warnf(format string, v ...any)  { l.add(format, v...) }
+func (l *captureLogger) Errorf(format string, v ...any) { l.add(format, v...) }
+
+func (l *captureLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+func TestServer_RedactionPolicy(t *testing.T) {
+	policy := &utils.RedactionPolicy{}
+	serverLog, clientLog := &captureLogger{}, &captureLogger{}
+	server, httpServer := newTestServer(t, WithLogger(serverLog), WithRedactionPolicy(policy))
+	_, err := NewServer(WithRedactionPolicy(nil))
+	assert.Error(t, err)
+
+	received := make(chan string, 2)
+	server.OnConnection(func(session *Session) {
+		session.On("message", func(data []byte) {
+			received <- string(data)
+			_ = session.Send(data)
+		})
+	})
+
+	client, err := engineio_v4_client.NewClient(
+		engineio_v4_client.WithRawURL(httpServer.URL+"/engine.io/"),
+		engineio_v4_client.WithLogger(clientLog),
+		engineio_v4_client.WithRedactionPolicy(policy),
+	)
+	require.NoError(t, err)
+	echoed := make(chan string, 2)
+	client.On("message", func(data []byte) { echoed <- string(data) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Connect(ctx))
+	defer client.Close() //nolint:errcheck
+
+	message := `2["login",{"user":"bob","password":"s3cret"}]`
+	require.NoError(t, client.Send([]byte(message)))
+	assert.Equal(t, message, receive(t, received))
+	assert.Equal(t, message, receive(t, echoed))
+
+	for _, log := range []*captureLogger{serverLog, clientLog} {
+		assert.NotContains(t, log.String(), "s3cret")
+		assert.Contains(t, log.String(), `"password":"[redacted]"`)
+	}
+	assert.Contains(t, clientLog.String(), `handle: 4 2["login",{"user":"bob","password":"[redacted]"}]`)
+}
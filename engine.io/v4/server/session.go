@@ -0,0 +1,296 @@
+package engineio_v4_server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/websocket"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+)
+
+// Close reasons passed to the "close" handler, matching the engine.io
+// reference server.
+const (
+	ReasonTransportClose = "transport close"
+	ReasonTransportError = "transport error"
+	ReasonPingTimeout    = "ping timeout"
+	ReasonForcedClose    = "forced close"
+	ReasonServerShutdown = "server shutting down"
+)
+
+var ErrSessionClosed = errors.New("session is closed")
+
+// Session is one engine.io connection. It starts on polling or websocket and
+// may be upgraded from polling to websocket.
+type Session struct {
+	server  *Server
+	id      string
+	request *http.Request
+
+	// mu guards the transport state below. Websocket writes happen under mu
+	// so queued packets are flushed in order when an upgrade completes.
+	mu        sync.Mutex
+	transport engineio_v4.EngineIOTransport
+	buffer    [][]byte      // serialized packets waiting for the next poll
+	flush     chan struct{} // signals a held poll that buffer has data
+	polling   bool          // a GET is held open
+	upgrading bool
+	ws        *websocket.Conn
+
+	handlerMu      sync.RWMutex
+	messageHandler func([]byte)
+	closeHandler   func([]byte)
+	upgradeHandler func([]byte)
+
+	pong      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newSession(server *Server, id string, r *http.Request, transport engineio_v4.EngineIOTransport) *Session {
+	return &Session{
+		server:    server,
+		id:        id,
+		request:   r,
+		transport: transport,
+		flush:     make(chan struct{}, 1),
+		pong:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// ID returns the engine.io session id.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Request returns the handshake request, e.g. to read headers or cookies.
+func (s *Session) Request() *http.Request {
+	return s.request
+}
+
+// Transport returns the current transport.
+func (s *Session) Transport() engineio_v4.EngineIOTransport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transport
+}
+
+// Done is closed when the session is closed.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// On registers a handler for "message" (the MESSAGE payload), "close" (the
+// close reason) or "upgrade" (the new transport name). Handlers run on the
+// transport goroutine, in receive order, and must not block.
+func (s *Session) On(event string, handler func([]byte)) {
+	s.handlerMu.Lock()
+	defer s.handlerMu.Unlock()
+
+	switch event {
+	case "message":
+		s.messageHandler = handler
+	case "close":
+		s.closeHandler = handler
+	case "upgrade":
+		s.upgradeHandler = handler
+	default:
+		s.server.log.Warnf("unknown engine.io session event: %s", event)
+	}
+}
+
+// Send sends a MESSAGE packet.
+func (s *Session) Send(message []byte) error {
+	return s.sendPacket(&engineio_v4.Message{
+		Type: engineio_v4.PacketMessage,
+		Data: message,
+	})
+}
+
+// Close sends a CLOSE packet and closes the session with ReasonForcedClose.
+func (s *Session) Close() error {
+	s.close(ReasonForcedClose, true)
+	return nil
+}
+
+// Drop closes the session without a CLOSE packet, as a transport failure
+// would, with ReasonTransportClose.
+func (s *Session) Drop() error {
+	s.close(ReasonTransportClose, false)
+	return nil
+}
+
+func (s *Session) openPacket() ([]byte, error) {
+	handshake, err := json.Marshal(s.server.handshakeResponse(s.id, s.Transport()))
+	if err != nil {
+		return nil, err
+	}
+	return s.server.parser.Serialize(&engineio_v4.Message{
+		Type: engineio_v4.PacketOpen,
+		Data: handshake,
+	})
+}
+
+// start runs the heartbeat once the OPEN packet is out.
+func (s *Session) start() {
+	go s.heartbeat()
+}
+
+// heartbeat pings the client every pingInterval and closes the session when
+// no pong arrives within pingTimeout. A polling client that stops polling
+// never sees the ping, so this also expires abandoned sessions.
+func (s *Session) heartbeat() {
+	timer := s.server.clock.NewTimer(s.server.pingInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C():
+		case <-s.done:
+			return
+		}
+
+		select {
+		case <-s.pong:
+		default:
+		}
+
+		if err := s.sendPacket(&engineio_v4.Message{Type: engineio_v4.PacketPing}); err != nil {
+			s.server.log.Warnf("engine.io session %s: send ping: %v", s.id, err)
+		}
+
+		timer.Reset(s.server.pingTimeout)
+		select {
+		case <-s.pong:
+		case <-timer.C():
+			s.server.log.Infof("engine.io session %s: ping timeout", s.id)
+			s.close(ReasonPingTimeout, false)
+			return
+		case <-s.done:
+			return
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.Reset(s.server.pingInterval)
+	}
+}
+
+func (s *Session) sendPacket(packet *engineio_v4.Message) error {
+	data, err := s.server.parser.Serialize(packet)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-s.done:
+		return ErrSessionClosed
+	default:
+	}
+
+	s.server.log.Debugf("engine.io session %s send: %s", s.id, s.server.payload(data))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ws != nil {
+		return s.writeWebsocketLocked(s.ws, data)
+	}
+	s.buffer = append(s.buffer, data)
+	s.signalLocked()
+	return nil
+}
+
+// signalLocked wakes a held poll. The caller must hold mu.
+func (s *Session) signalLocked() {
+	select {
+	case s.flush <- struct{}{}:
+	default:
+	}
+}
+
+// writeWebsocketLocked writes one frame. The caller must hold mu.
+func (s *Session) writeWebsocketLocked(conn *websocket.Conn, data []byte) error {
+	_ = conn.SetWriteDeadline(s.server.clock.Now().Add(s.server.pingTimeout))
+	return websocket.Message.Send(conn, string(data))
+}
+
+// handlePacket processes a packet received on any transport.
+func (s *Session) handlePacket(data []byte) {
+	s.server.log.Debugf("engine.io session %s receive: %s", s.id, s.server.payload(data))
+
+	packet, err := s.server.parser.Parse(data)
+	if err != nil {
+		s.server.log.Warnf("engine.io session %s: can't parse packet: %v", s.id, err)
+		return
+	}
+
+	switch packet.Type {
+	case engineio_v4.PacketPong:
+		select {
+		case s.pong <- struct{}{}:
+		default:
+		}
+	case engineio_v4.PacketPing:
+		// Clients only ping to probe a transport, which is handled by the
+		// upgrade; answer anything else so the client is not left waiting.
+		if err := s.sendPacket(&engineio_v4.Message{Type: engineio_v4.PacketPong, Data: packet.Data}); err != nil {
+			s.server.log.Warnf("engine.io session %s: send pong: %v", s.id, err)
+		}
+	case engineio_v4.PacketMessage:
+		s.handlerMu.RLock()
+		handler := s.messageHandler
+		s.handlerMu.RUnlock()
+		if handler != nil {
+			handler(packet.Data)
+		}
+	case engineio_v4.PacketClose:
+		s.close(ReasonTransportClose, false)
+	case engineio_v4.PacketUpgrade, engineio_v4.PacketNoop:
+	default:
+		s.server.log.Warnf("engine.io session %s: unexpected packet type %d", s.id, packet.Type)
+	}
+}
+
+// close ends the session once. With notify set, a CLOSE packet is sent to the
+// client first.
+func (s *Session) close(reason string, notify bool) {
+	s.closeOnce.Do(func() {
+		if notify {
+			if err := s.sendPacket(&engineio_v4.Message{Type: engineio_v4.PacketClose}); err != nil {
+				s.server.log.Debugf("engine.io session %s: send close: %v", s.id, err)
+			}
+		}
+
+		// A polling client that is between two polls would never see the
+		// CLOSE packet if the session disappeared right away.
+		s.mu.Lock()
+		ws := s.ws
+		linger := ws == nil && len(s.buffer) > 0 && !s.polling
+		s.mu.Unlock()
+
+		s.server.removeSession(s, linger)
+		close(s.done)
+
+		if ws != nil {
+			_ = ws.Close()
+		}
+
+		s.server.log.Debugf("engine.io session %s closed: %s", s.id, reason)
+
+		s.handlerMu.RLock()
+		handler := s.closeHandler
+		s.handlerMu.RUnlock()
+		if handler != nil {
+			handler([]byte(reason))
+		}
+	})
+}
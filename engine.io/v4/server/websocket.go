@@ -0,0 +1,187 @@
+package engineio_v4_server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+)
+
+// serveWebsocketHandshake opens a session directly over websocket.
+func (s *Server) serveWebsocketHandshake(w http.ResponseWriter, r *http.Request) {
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		conn.MaxPayloadBytes = int(s.maxPayload)
+
+		session, err := s.newSession(r, engineio_v4.TransportWebsocket)
+		if err != nil {
+			s.log.Errorf("engine.io handshake: %v", err)
+			return
+		}
+
+		open, err := session.openPacket()
+		if err != nil {
+			session.close(ReasonTransportError, false)
+			return
+		}
+
+		// Send OPEN, then whatever the connection handler queued.
+		session.mu.Lock()
+		err = session.writeWebsocketLocked(conn, open)
+		if err == nil {
+			session.ws = conn
+			err = session.flushWebsocketLocked()
+		}
+		session.mu.Unlock()
+		if err != nil {
+			s.log.Warnf("engine.io session %s: send open: %v", session.id, err)
+			session.close(ReasonTransportError, false)
+			return
+		}
+
+		session.start()
+		session.readWebsocket(conn)
+	}}.ServeHTTP(w, r)
+}
+
+// serveUpgrade upgrades a polling session to websocket.
+func (s *Session) serveUpgrade(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if !s.server.allowUpgrades || s.transport != engineio_v4.TransportPolling || s.upgrading {
+		s.mu.Unlock()
+		s.server.writeError(w, http.StatusBadRequest, engineio_v4.ErrorCodeBadRequest)
+		return
+	}
+	s.upgrading = true
+	s.mu.Unlock()
+
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		conn.MaxPayloadBytes = int(s.server.maxPayload)
+
+		if err := s.probe(conn); err != nil {
+			s.server.log.Warnf("engine.io session %s: upgrade failed: %v", s.id, err)
+			s.mu.Lock()
+			s.upgrading = false
+			s.mu.Unlock()
+			return
+		}
+
+		s.handlerMu.RLock()
+		handler := s.upgradeHandler
+		s.handlerMu.RUnlock()
+		if handler != nil {
+			handler([]byte(engineio_v4.TransportWebsocket))
+		}
+
+		s.readWebsocket(conn)
+	}}.ServeHTTP(w, r)
+}
+
+// probe runs the upgrade sequence: the client sends "2probe", the server
+// answers "3probe" and releases the pending poll with a NOOP, then the client
+// sends "5" and the session switches to websocket.
+func (s *Session) probe(conn *websocket.Conn) error {
+	_ = conn.SetReadDeadline(s.server.clock.Now().Add(s.server.upgradeTimeout))
+
+	packet, err := s.receive(conn)
+	if err != nil {
+		return err
+	}
+	if packet.Type != engineio_v4.PacketPing || string(packet.Data) != "probe" {
+		return errors.New("expected ping probe")
+	}
+
+	pong, err := s.server.parser.Serialize(&engineio_v4.Message{Type: engineio_v4.PacketPong, Data: []byte("probe")})
+	if err != nil {
+		return err
+	}
+	if err := s.writeWebsocketLocked(conn, pong); err != nil {
+		return err
+	}
+
+	if err := s.sendPacket(&engineio_v4.Message{Type: engineio_v4.PacketNoop}); err != nil {
+		return err
+	}
+
+	for {
+		packet, err := s.receive(conn)
+		if err != nil {
+			return err
+		}
+		if packet.Type == engineio_v4.PacketUpgrade {
+			break
+		}
+		if packet.Type != engineio_v4.PacketNoop {
+			return errors.New("expected upgrade packet")
+		}
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return ErrSessionClosed
+	default:
+	}
+
+	s.transport = engineio_v4.TransportWebsocket
+	s.upgrading = false
+	s.ws = conn
+	// Wake a held poll so it returns now that polling is over.
+	s.signalLocked()
+	return s.flushWebsocketLocked()
+}
+
+func (s *Session) receive(conn *websocket.Conn) (*engineio_v4.Message, error) {
+	var data []byte
+	if err := websocket.Message.Receive(conn, &data); err != nil {
+		return nil, err
+	}
+	s.server.log.Debugf("engine.io session %s receive: %s", s.id, s.server.payload(data))
+	return s.server.parser.Parse(data)
+}
+
+// flushWebsocketLocked writes the packets queued for polling, minus the
+// NOOPs that only served to release a poll. The caller must hold mu.
+func (s *Session) flushWebsocketLocked() error {
+	buffer := s.buffer
+	s.buffer = nil
+	for _, data := range buffer {
+		if len(data) == 1 && engineio_v4.EngineIOPacket(data[0]-'0') == engineio_v4.PacketNoop {
+			continue
+		}
+		if err := s.writeWebsocketLocked(s.ws, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readWebsocket handles incoming frames until the connection fails or the
+// session is closed.
+func (s *Session) readWebsocket(conn *websocket.Conn) {
+	for {
+		var data []byte
+		err := websocket.Message.Receive(conn, &data)
+		if err != nil {
+			select {
+			case <-s.done:
+			default:
+				if errors.Is(err, io.EOF) {
+					s.close(ReasonTransportClose, false)
+				} else {
+					s.server.log.Debugf("engine.io session %s: websocket read: %v", s.id, err)
+					s.close(ReasonTransportError, false)
+				}
+			}
+			return
+		}
+		s.handlePacket(data)
+	}
+}
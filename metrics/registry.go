@@ -0,0 +1,322 @@
+// Package metrics is a dependency-free implementation of the Metrics
+// interfaces of the engine.io and socket.io clients. A Registry can be shared
+// by any number of clients and renders the Prometheus text format and expvar.
+package metrics
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the latency histogram bounds, in seconds.
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type kind string
+
+const (
+	counter   kind = "counter"
+	gauge     kind = "gauge"
+	histogram kind = "histogram"
+)
+
+type family struct {
+	name   string
+	help   string
+	kind   kind
+	labels []string
+	series map[string]*series
+}
+
+type series struct {
+	labels []string
+	value  float64
+	// buckets, sum and count are set for histograms; buckets are not
+	// cumulative.
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+type RegistryOption func(*Registry) error
+
+// Registry collects client metrics. It is safe for concurrent use.
+type Registry struct {
+	buckets []float64
+
+	mu       sync.Mutex
+	families []*family
+
+	packets, bytes, pollingRequests, upgrades           *family
+	ackRoundTrip, pendingAcks, handlerDuration, dropped *family
+}
+
+func NewRegistry(options ...RegistryOption) (*Registry, error) {
+	r := &Registry{buckets: DefaultBuckets}
+
+	for _, opt := range options {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+
+	r.packets = r.family("engineio_packets_total", "Engine.io packets by direction, transport and packet type.", counter, "direction", "transport", "type")
+	r.bytes = r.family("engineio_bytes_total", "Engine.io packet bytes by direction, transport and packet type.", counter, "direction", "transport", "type")
+	r.pollingRequests = r.family("engineio_polling_request_duration_seconds", "Duration of HTTP long-polling requests.", histogram, "method", "result")
+	r.upgrades = r.family("engineio_upgrades_total", "Transport upgrades by outcome.", counter, "from", "to", "result")
+	r.ackRoundTrip = r.family("socketio_ack_round_trip_seconds", "Time from an emit to its acknowledgement.", histogram, "namespace")
+	r.pendingAcks = r.family("socketio_pending_acks", "Emits waiting for an acknowledgement.", gauge)
+	r.handlerDuration = r.family("socketio_handler_duration_seconds", "Event handler execution time.", histogram, "namespace", "event")
+	r.dropped = r.family("socketio_dropped_events_total", "Inbound packets dropped without reaching a handler.", counter, "namespace", "event", "reason")
+
+	return r, nil
+}
+
+// WithBuckets sets the latency histogram bounds, in seconds.
+func WithBuckets(buckets ...float64) RegistryOption {
+	return func(r *Registry) error {
+		if len(buckets) == 0 {
+			return errors.New("no buckets")
+		}
+		if !sort.Float64sAreSorted(buckets) {
+			return errors.New("buckets must be sorted")
+		}
+		r.buckets = append([]float64{}, buckets...)
+		return nil
+	}
+}
+
+func (r *Registry) family(name, help string, k kind, labels ...string) *family {
+	f := &family{name: name, help: help, kind: k, labels: labels, series: make(map[string]*series)}
+	r.families = append(r.families, f)
+	return f
+}
+
+func direction(in bool) string {
+	if in {
+		return "in"
+	}
+	return "out"
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+func (r *Registry) PacketSent(transport, packetType string, bytes int) {
+	r.add(r.packets, 1, direction(false), transport, packetType)
+	r.add(r.bytes, float64(bytes), direction(false), transport, packetType)
+}
+
+func (r *Registry) PacketReceived(transport, packetType string, bytes int) {
+	r.add(r.packets, 1, direction(true), transport, packetType)
+	r.add(r.bytes, float64(bytes), direction(true), transport, packetType)
+}
+
+func (r *Registry) PollingRequest(method string, duration time.Duration, err error) {
+	r.observe(r.pollingRequests, duration, method, result(err))
+}
+
+func (r *Registry) Upgrade(from, to string, err error) {
+	r.add(r.upgrades, 1, from, to, result(err))
+}
+
+func (r *Registry) AckRoundTrip(namespace string, duration time.Duration) {
+	r.observe(r.ackRoundTrip, duration, namespace)
+}
+
+func (r *Registry) PendingAcks(delta int) {
+	r.add(r.pendingAcks, float64(delta))
+}
+
+func (r *Registry) HandlerDuration(namespace, event string, duration time.Duration) {
+	r.observe(r.handlerDuration, duration, namespace, event)
+}
+
+func (r *Registry) EventDropped(namespace, event, reason string) {
+	r.add(r.dropped, 1, namespace, event, reason)
+}
+
+func (r *Registry) seriesLocked(f *family, labels []string) *series {
+	key := strings.Join(labels, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: labels}
+		if f.kind == histogram {
+			s.buckets = make([]uint64, len(r.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (r *Registry) add(f *family, value float64, labels ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seriesLocked(f, labels).value += value
+}
+
+func (r *Registry) observe(f *family, duration time.Duration, labels ...string) {
+	seconds := duration.Seconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.seriesLocked(f, labels)
+	s.sum += seconds
+	s.count++
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			s.buckets[i]++
+			break
+		}
+	}
+}
+
+// sortedSeriesLocked returns the series of f ordered by labels, so output is
+// stable.
+func sortedSeriesLocked(f *family) []*series {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	list := make([]*series, len(keys))
+	for i, key := range keys {
+		list[i] = f.series[key]
+	}
+	return list
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+
+	r.mu.Lock()
+	for _, f := range r.families {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		if f.kind != histogram && len(f.labels) == 0 && len(f.series) == 0 {
+			// Unlabelled counters and gauges start at zero.
+			fmt.Fprintf(&b, "%s 0\n", f.name)
+			continue
+		}
+		for _, s := range sortedSeriesLocked(f) {
+			labels := formatLabels(f.labels, s.labels)
+			if f.kind != histogram {
+				fmt.Fprintf(&b, "%s%s %s\n", f.name, wrapLabels(labels), formatFloat(s.value))
+				continue
+			}
+			var cumulative uint64
+			for i, bound := range r.buckets {
+				cumulative += s.buckets[i]
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", f.name, wrapLabels(joinLabels(labels, `le="`+formatFloat(bound)+`"`)), cumulative)
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", f.name, wrapLabels(joinLabels(labels, `le="+Inf"`)), s.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", f.name, wrapLabels(labels), formatFloat(s.sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", f.name, wrapLabels(labels), s.count)
+		}
+	}
+	r.mu.Unlock()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP serves the Prometheus text format, so a Registry can be mounted
+// on "/metrics".
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.WritePrometheus(w)
+}
+
+// Publish exports the metrics as the expvar variable name, served by
+// expvar's "/debug/vars" handler. Like expvar.Publish, it panics if the name
+// is already registered.
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, expvar.Func(r.Snapshot))
+}
+
+// Snapshot returns the metrics as JSON-friendly values: every family maps to
+// a list of series with their labels and value, or count, sum and
+// cumulative buckets for histograms.
+func (r *Registry) Snapshot() interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := make(map[string]interface{}, len(r.families))
+	for _, f := range r.families {
+		list := make([]map[string]interface{}, 0, len(f.series))
+		for _, s := range sortedSeriesLocked(f) {
+			entry := map[string]interface{}{}
+			if len(f.labels) > 0 {
+				labels := make(map[string]string, len(f.labels))
+				for i, name := range f.labels {
+					labels[name] = s.labels[i]
+				}
+				entry["labels"] = labels
+			}
+			if f.kind != histogram {
+				entry["value"] = s.value
+			} else {
+				buckets := make(map[string]uint64, len(r.buckets))
+				var cumulative uint64
+				for i, bound := range r.buckets {
+					cumulative += s.buckets[i]
+					buckets[formatFloat(bound)] = cumulative
+				}
+				entry["buckets"] = buckets
+				entry["count"] = s.count
+				entry["sum"] = s.sum
+			}
+			list = append(list, entry)
+		}
+		snapshot[f.name] = list
+	}
+	return snapshot
+}
+
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func joinLabels(labels, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	socketio_v5_client "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+)
+
+var (
+	_ engineio_v4_client.Metrics = (*Registry)(nil)
+	_ socketio_v5_client.Metrics = (*Registry)(nil)
+)
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	r, err := NewRegistry(WithBuckets(0.01, 0.1))
+	require.NoError(t, err)
+
+	r.PacketSent("websocket", "message", 10)
+	r.PacketSent("websocket", "message", 5)
+	r.PacketReceived("polling", "open", 100)
+	r.PollingRequest("GET", 50*time.Millisecond, nil)
+	r.PollingRequest("POST", time.Second, errors.New("refused"))
+	r.Upgrade("polling", "websocket", nil)
+	r.AckRoundTrip("/", 5*time.Millisecond)
+	r.PendingAcks(2)
+	r.PendingAcks(-1)
+	r.HandlerDuration("/chat", "message", 20*time.Millisecond)
+	r.EventDropped("/", `say "hi"`, "no handler")
+
+	var out strings.Builder
+	require.NoError(t, r.WritePrometheus(&out))
+	text := out.String()
+
+	for _, line := range []string{
+		"# TYPE engineio_packets_total counter",
+		`engineio_packets_total{direction="out",transport="websocket",type="message"} 2`,
+		`engineio_bytes_total{direction="out",transport="websocket",type="message"} 15`,
+		`engineio_packets_total{direction="in",transport="polling",type="open"} 1`,
+		"# TYPE engineio_polling_request_duration_seconds histogram",
+		`engineio_polling_request_duration_seconds_bucket{method="GET",result="success",le="0.01"} 0`,
+		`engineio_polling_request_duration_seconds_bucket{method="GET",result="success",le="0.1"} 1`,
+		`engineio_polling_request_duration_seconds_bucket{method="GET",result="success",le="+Inf"} 1`,
+		`engineio_polling_request_duration_seconds_sum{method="GET",result="success"} 0.05`,
+		`engineio_polling_request_duration_seconds_count{method="POST",result="error"} 1`,
+		`engineio_upgrades_total{from="polling",to="websocket",result="success"} 1`,
+		`socketio_ack_round_trip_seconds_bucket{namespace="/",le="0.01"} 1`,
+		"socketio_pending_acks 1",
+		`socketio_handler_duration_seconds_count{namespace="/chat",event="message"} 1`,
+		`socketio_dropped_events_total{namespace="/",event="say \"hi\"",reason="no handler"} 1`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+
+	r.PendingAcks(-1)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rec.Body.String(), "socketio_pending_acks 0\n")
+}
+
+func TestRegistry_Expvar(t *testing.T) {
+	r, err := NewRegistry(WithBuckets(0.01))
+	require.NoError(t, err)
+	r.AckRoundTrip("/", time.Millisecond)
+	r.PendingAcks(3)
+
+	r.Publish("socketio_test")
+	var vars map[string][]map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("socketio_test").String()), &vars))
+
+	assert.Equal(t, []map[string]interface{}{{"value": 3.0}}, vars["socketio_pending_acks"])
+	assert.Equal(t, []map[string]interface{}{{
+		"labels":  map[string]interface{}{"namespace": "/"},
+		"buckets": map[string]interface{}{"0.01": 1.0},
+		"count":   1.0,
+		"sum":     0.001,
+	}}, vars["socketio_ack_round_trip_seconds"])
+	assert.Empty(t, vars["socketio_dropped_events_total"])
+}
+
+func TestWithBuckets(t *testing.T) {
+	_, err := NewRegistry(WithBuckets())
+	assert.Error(t, err)
+	_, err = NewRegistry(WithBuckets(1, 0.5))
+	assert.Error(t, err)
+}
@@ -3,16 +3,28 @@ package socketio_v5_client
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
 	"sync"
+	"time"
 
 	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	"github.com/maldikhan/go.socket.io/utils"
 )
 
 type Client struct {
 	engineio EngineIOClient
-	parser   Parser
-	logger   Logger
-	timer    Timer
+	// manager is set for sockets sharing a connection; it then owns the
+	// engine.io lifecycle and message routing.
+	manager *Manager
+	parser  Parser
+	logger  Logger
+	timer   Timer
+	clock   utils.Clock
+	metrics Metrics
+	tracer  Tracer
+
+	// propagation is set by WithTracePropagation.
+	propagation *tracePropagation
 
 	ctx   context.Context
 	mutex sync.RWMutex
@@ -24,14 +36,46 @@ type Client struct {
 
 	ackCallbacks map[int]func([]interface{})
 	ackCounter   int
+	// ackSent holds when each pending ack was requested, for DebugHandler.
+	ackSent map[int]time.Time
+	// ackEvents holds the name of the event each pending ack answers, for
+	// handler error reports and timings.
+	ackEvents map[int]string
+
+	// incoming and outgoing are the client-wide middleware chains, guarded
+	// by mutex.
+	incoming []Middleware
+	outgoing []Middleware
+
+	handlerErrorHook func(HandlerError)
+
+	// limiter enforces WithLimits on the packets the client parses; it is
+	// nil without limits.
+	limiter *limiter
 
 	// redactPayload, when true, replaces raw payloads in debug logs with a
 	// size marker. Zero value is verbose; NewClient sets the safe default.
 	redactPayload bool
+
+	// redaction is shared with the manager and the engine.io client.
+	redaction *utils.RedactionPolicy
+}
+
+// fieldLogger returns the logger with the namespace field, the event field
+// when event is set, and fields.
+func (c *Client) fieldLogger(ns, event string, fields ...utils.Field) Logger {
+	all := []utils.Field{{Key: utils.FieldNamespace, Value: ns}}
+	if event != "" {
+		all = append(all, utils.Field{Key: utils.FieldEvent, Value: event})
+	}
+	return utils.WithFields(c.logger, append(all, fields...)...)
 }
 
 // payload returns a size marker when redaction is enabled, or the raw data.
 func (c *Client) payload(data []byte) string {
+	if c.redaction != nil {
+		return c.redaction.Redact(data)
+	}
 	if c.redactPayload {
 		return fmt.Sprintf("[redacted %d bytes]", len(data))
 	}
@@ -45,10 +89,16 @@ type namespace struct {
 
 	mu          sync.RWMutex
 	handlers    map[string][]func([]interface{})
+	ctxHandlers map[string][]func(context.Context, []interface{}) // handlers taking a leading context.Context
 	anyHandlers []func(string, []interface{})
 
 	waitConnected chan struct{}
 	hadConnected  sync.Once
+
+	// connected and sid are guarded by mu and track the server's CONNECT /
+	// DISCONNECT for this namespace.
+	connected bool
+	sid       string
 }
 
 // SetHandshakeData stores a shallow copy of the provided map as the handshake
@@ -69,16 +119,17 @@ func (c *Client) SetHandshakeData(data map[string]interface{}) {
 func (c *Client) connectSocketIO(_ []byte) {
 	c.mutex.RLock()
 	handshakeData := c.handshakeData
+	ctx := c.ctx
 	c.mutex.RUnlock()
 
 	err := c.sendPacket(&socketio_v5.Message{
 		Type:    socketio_v5.PacketConnect,
 		NS:      c.defaultNs.name,
-		Payload: handshakeData,
+		Payload: c.injectConnectTrace(ctx, handshakeData),
 	})
 
 	if err != nil {
-		c.logger.Errorf("Can't connect: %v", err)
+		c.fieldLogger(c.defaultNs.name, "").Errorf("Can't connect: %v", err)
 	}
 }
 
@@ -89,9 +140,23 @@ func (c *Client) Connect(ctx context.Context, callbacks ...func(arg interface{})
 	for _, callback := range callbacks {
 		c.On("connect", callback)
 	}
+	if c.manager != nil {
+		return c.manager.connect(ctx, c)
+	}
 	return c.engineio.Connect(ctx)
 }
 
+// clientContext returns the Connect context, or context.Background before
+// Connect.
+func (c *Client) clientContext() context.Context {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 func (c *Client) namespace(name string) *namespace {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
@@ -111,11 +176,47 @@ func (c *Client) namespace(name string) *namespace {
 	return ns
 }
 
-func (c *Client) safeGo(fn func()) {
+// handles reports whether a handler is registered for event; OnAny handlers
+// don't count.
+func (n *namespace) handles(event string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(n.handlers[event]) > 0 || len(n.ctxHandlers[event]) > 0
+}
+
+func (c *Client) hasNamespace(name string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	_, ok := c.namespaces[name]
+	return ok
+}
+
+// now reads the clock, tolerating a Client built without NewClient.
+func (c *Client) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// safeGo runs a handler in its own goroutine and recovers a panic, reporting
+// it with the handler details from report. Handlers with a known event,
+// ack callbacks included, are timed.
+func (c *Client) safeGo(report HandlerError, fn func()) {
 	go func() {
+		if report.Event != "" {
+			start := c.now()
+			defer func() {
+				ns, event := c.labels(report.Namespace, report.Event)
+				c.meter().HandlerDuration(ns, event, c.now().Sub(start))
+			}()
+		}
 		defer func() {
 			if r := recover(); r != nil {
-				c.logger.Errorf("panic in event handler: %v", r)
+				c.fieldLogger(report.Namespace, report.Event).Errorf("panic in event handler: %v", r)
+				report.Recovered = r
+				report.Stack = debug.Stack()
+				c.reportHandlerError(report)
 			}
 		}()
 		fn()
@@ -123,5 +224,18 @@ func (c *Client) safeGo(fn func()) {
 }
 
 func (c *Client) Close() error {
-	return c.engineio.Close()
+	if c.manager != nil {
+		return c.manager.release(c)
+	}
+	err := c.engineio.Close()
+	c.onEngineClose(nil)
+	return err
+}
+
+func ackIDField(ackID int) utils.Field {
+	return utils.Field{Key: utils.FieldAckID, Value: ackID}
+}
+
+func packetTypeField(packetType socketio_v5.SocketIOPacket) utils.Field {
+	return utils.Field{Key: utils.FieldPacketType, Value: packetType}
 }
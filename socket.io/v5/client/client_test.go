@@ -10,9 +10,11 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
 	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+	"github.com/maldikhan/go.socket.io/utils"
 )
 
 func TestSetHandshakeData(t *testing.T) {
@@ -336,6 +338,11 @@ func TestClient_payloadRedaction(t *testing.T) {
 	assert.Equal(t, "[redacted 5 bytes]", c.payload([]byte("hello")))
 	c.redactPayload = false
 	assert.Equal(t, "hello", c.payload([]byte("hello")))
+
+	// A policy takes precedence.
+	assert.Error(t, WithRedactionPolicy(nil)(&InitClient{Client: c}))
+	require.NoError(t, WithRedactionPolicy(&utils.RedactionPolicy{})(&InitClient{Client: c}))
+	assert.Equal(t, `42["login",{"token":"[redacted]"}]`, c.payload([]byte(`42["login",{"token":"t"}]`)))
 }
 
 func TestClient_WithDebugPayload(t *testing.T) {
@@ -16,10 +16,49 @@ type ClientOption func(*InitClient) error
 type InitClient struct {
 	url           *url.URL
 	defaultNsName *string
+	// limits and limitErrorHook build the limiter of the connection.
+	limits         *Limits
+	limitErrorHook func(LimitError)
 	*Client
 }
 
+// NewClient builds a socket for one namespace on a connection of its own. Use
+// a Manager to put the sockets of several namespaces on one connection.
 func NewClient(options ...ClientOption) (*Client, error) {
+	client, err := newInitClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if (client.engineio == nil && client.url == nil) || (client.engineio != nil && client.url != nil) {
+		return nil, fmt.Errorf("either WithURL or WithEngineIOClient must be provided")
+	}
+
+	if client.engineio == nil {
+		client.engineio, err = newEngineIOClient(client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client.defaultNs = client.namespace(client.namespaceName())
+
+	if client.limiter != nil {
+		client.limiter.disconnect = func() { _ = client.Close() }
+		client.limiter.joined = client.hasNamespace
+		client.limiter.labels = client.labels
+	}
+
+	client.engineio.On("connect", client.connectSocketIO)
+	client.engineio.On("message", client.onMessage)
+	client.engineio.On("close", client.onEngineClose)
+
+	return client.Client, nil
+}
+
+// newInitClient applies the options over the defaults and validates the
+// client-level dependencies.
+func newInitClient(options []ClientOption) (*InitClient, error) {
 	client := &InitClient{
 		Client: &Client{
 			ctx:           context.Background(), // safe default so Emit before Connect won't panic
@@ -29,6 +68,9 @@ func NewClient(options ...ClientOption) (*Client, error) {
 
 			logger:        &utils.DefaultLogger{},
 			timer:         &utils.DefaultTimer{},
+			clock:         utils.RealClock{},
+			metrics:       utils.NopMetrics{},
+			tracer:        utils.NopTracer{},
 			redactPayload: true, // production-safe default; WithDebugPayload(true) opts out
 		},
 	}
@@ -53,32 +95,55 @@ func NewClient(options ...ClientOption) (*Client, error) {
 		return nil, errors.New("timer is nil")
 	}
 
-	if (client.engineio == nil && client.url == nil) || (client.engineio != nil && client.url != nil) {
-		return nil, fmt.Errorf("either WithURL or WithEngineIOClient must be provided")
+	if client.clock == nil {
+		return nil, errors.New("clock is nil")
 	}
 
-	if client.engineio == nil {
-		engineioClient, err := engineio_v4_client.NewClient(
-			engineio_v4_client.WithURL(client.url),
-			engineio_v4_client.WithLogger(client.logger),
-			engineio_v4_client.WithDebugPayload(!client.redactPayload),
-		)
-		if err != nil {
-			return nil, err
-		}
-		client.engineio = engineioClient
+	if client.metrics == nil {
+		return nil, errors.New("metrics is nil")
 	}
 
-	defaultNsName := "/"
-	if client.defaultNsName != nil {
-		defaultNsName = *client.defaultNsName
+	if client.tracer == nil {
+		return nil, errors.New("tracer is nil")
 	}
-	client.defaultNs = client.namespace(defaultNsName)
 
-	client.engineio.On("connect", client.connectSocketIO)
-	client.engineio.On("message", client.onMessage)
+	if client.limits != nil {
+		client.limiter = newLimiter(*client.limits, client.limitErrorHook, client.logger, client.metrics)
+		client.limiter.now = client.clock.Now
+	}
 
-	return client.Client, nil
+	return client, nil
+}
+
+func newEngineIOClient(client *InitClient) (EngineIOClient, error) {
+	// The engine.io client rewrites the URL it is given; keep the caller's
+	// URL intact.
+	engineURL := *client.url
+	options := []engineio_v4_client.EngineClientOption{
+		engineio_v4_client.WithURL(&engineURL),
+		engineio_v4_client.WithLogger(client.logger),
+		engineio_v4_client.WithMetrics(client.metrics),
+		engineio_v4_client.WithDebugPayload(!client.redactPayload),
+		engineio_v4_client.WithClock(client.clock),
+	}
+	if client.redaction != nil {
+		options = append(options, engineio_v4_client.WithRedactionPolicy(client.redaction))
+	}
+	if client.limiter != nil && client.limiter.limits.MaxFrameSize > 0 {
+		options = append(options, engineio_v4_client.WithMaxFrameSize(client.limiter.limits.MaxFrameSize, client.limiter.frameTooLarge))
+	}
+	engineioClient, err := engineio_v4_client.NewClient(options...)
+	if err != nil {
+		return nil, err
+	}
+	return engineioClient, nil
+}
+
+func (c *InitClient) namespaceName() string {
+	if c.defaultNsName != nil {
+		return *c.defaultNsName
+	}
+	return "/"
 }
 
 func WithURL(url *url.URL) ClientOption {
@@ -133,6 +198,31 @@ func WithTimer(timer Timer) ClientOption {
 	}
 }
 
+// WithClock takes ack timeouts, handler and ack timings, the event rate
+// limit and the engine.io heartbeat from clock; utils/fakeclock makes them
+// deterministic in tests. It also replaces the Timer, unless WithTimer
+// follows it.
+func WithClock(clock utils.Clock) ClientOption {
+	return func(c *InitClient) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		c.clock = clock
+		c.timer = clock
+		return nil
+	}
+}
+
+// WithMetrics reports ack latency, pending acks, handler duration and dropped
+// events to metrics. It is passed to the engine.io client NewClient builds,
+// not to one given with WithEngineIOClient.
+func WithMetrics(metrics Metrics) ClientOption {
+	return func(c *InitClient) error {
+		c.metrics = metrics
+		return nil
+	}
+}
+
 func WithParser(parser Parser) ClientOption {
 	return func(c *InitClient) error {
 		c.parser = parser
@@ -140,6 +230,16 @@ func WithParser(parser Parser) ClientOption {
 	}
 }
 
+// WithHandlerErrorHook sets a hook called when an event handler or ack
+// callback panics, fails to decode its arguments, or returns an error.
+// The hook runs on the handler's goroutine and must be safe for concurrent use.
+func WithHandlerErrorHook(hook func(HandlerError)) ClientOption {
+	return func(c *InitClient) error {
+		c.handlerErrorHook = hook
+		return nil
+	}
+}
+
 // WithDebugPayload enables logging of raw payloads at debug level across the
 // client and the default transports it builds. Disabled by default so
 // production logs do not leak message contents (tokens, PII).
@@ -149,3 +249,47 @@ func WithDebugPayload(enabled bool) ClientOption {
 		return nil
 	}
 }
+
+// WithLimits caps the packets the client accepts from the server. A packet
+// over a limit is dropped and reported to the WithLimitErrorHook hook, and
+// the connection is closed if limits.Disconnect is set. Given to NewManager,
+// the limits apply to the whole connection.
+func WithLimits(limits Limits) ClientOption {
+	return func(c *InitClient) error {
+		if err := limits.validate(); err != nil {
+			return err
+		}
+		c.limits = &limits
+		return nil
+	}
+}
+
+// WithLimitErrorHook sets a hook called when an inbound packet exceeds the
+// WithLimits limits. The hook runs on the transport goroutine and must not
+// block.
+func WithLimitErrorHook(hook func(LimitError)) ClientOption {
+	return func(c *InitClient) error {
+		c.limitErrorHook = hook
+		return nil
+	}
+}
+
+// WithRedactionPolicy logs payloads at debug level with the secrets masked
+// by policy, across the client and the engine.io client it builds.
+func WithRedactionPolicy(policy *utils.RedactionPolicy) ClientOption {
+	return func(c *InitClient) error {
+		if policy == nil {
+			return errors.New("redaction policy is nil")
+		}
+		c.redaction = policy
+		return nil
+	}
+}
+
+// withRedaction passes the policy, possibly nil, to the sockets of a manager.
+func withRedaction(policy *utils.RedactionPolicy) ClientOption {
+	return func(c *InitClient) error {
+		c.redaction = policy
+		return nil
+	}
+}
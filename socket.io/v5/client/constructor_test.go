@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,7 @@ import (
 	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
 	socketio_v5_parser "github.com/maldikhan/go.socket.io/socket.io/v5/parser/default"
 	"github.com/maldikhan/go.socket.io/utils"
+	"github.com/maldikhan/go.socket.io/utils/fakeclock"
 )
 
 func TestNewClient(t *testing.T) {
@@ -149,6 +151,7 @@ func TestNewClient(t *testing.T) {
 			if !tt.wantErr {
 				mockEngineIOClient.EXPECT().On("connect", gomock.Any()).AnyTimes()
 				mockEngineIOClient.EXPECT().On("message", gomock.Any()).AnyTimes()
+				mockEngineIOClient.EXPECT().On("close", gomock.Any()).AnyTimes()
 			}
 
 			got, err := NewClient(tt.options...)
@@ -322,6 +325,19 @@ func TestWithTimer(t *testing.T) {
 	}
 }
 
+func TestWithClock(t *testing.T) {
+	assert.Error(t, WithClock(nil)(&InitClient{Client: &Client{}}))
+
+	clock := fakeclock.New(time.Now())
+	client, err := NewClient(WithRawURL("http://localhost"), WithClock(clock))
+	require.NoError(t, err)
+	assert.Equal(t, clock, client.clock)
+	assert.Equal(t, clock, client.timer)
+	assert.Equal(t, clock.Now(), client.now())
+	// The engine.io client runs its ping ticker on the same clock.
+	assert.Equal(t, 1, clock.Waiters())
+}
+
 func TestWithParser(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
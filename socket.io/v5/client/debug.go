@@ -0,0 +1,200 @@
+package socketio_v5_client
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+)
+
+// DebugSnapshot is the state of a client as rendered by DebugHandler.
+type DebugSnapshot struct {
+	EngineID    string                         `json:"engineId"`
+	Transport   engineio_v4.EngineIOTransport  `json:"transport"`
+	State       string                         `json:"state"`
+	Upgrade     *DebugUpgrade                  `json:"upgrade,omitempty"`
+	Handshake   *engineio_v4.HandshakeResponse `json:"handshake,omitempty"`
+	Namespaces  []DebugNamespace               `json:"namespaces"`
+	PendingAcks []DebugAck                     `json:"pendingAcks"`
+	Queues      DebugQueues                    `json:"queues"`
+}
+
+// DebugUpgrade is the last transport upgrade of the engine.io session.
+type DebugUpgrade struct {
+	From       engineio_v4.EngineIOTransport `json:"from"`
+	To         engineio_v4.EngineIOTransport `json:"to"`
+	InProgress bool                          `json:"inProgress"`
+}
+
+// DebugNamespace is a namespace of the client with its registered handlers.
+type DebugNamespace struct {
+	Name        string         `json:"name"`
+	ID          string         `json:"id,omitempty"`
+	Connected   bool           `json:"connected"`
+	Events      map[string]int `json:"events"` // handler count by event name
+	AnyHandlers int            `json:"anyHandlers"`
+}
+
+// DebugAck is an ack the client is waiting for.
+type DebugAck struct {
+	ID  int           `json:"id"`
+	Age time.Duration `json:"ageNs"`
+}
+
+// DebugQueues holds the depths of the client queues.
+type DebugQueues struct {
+	// EngineMessages is the number of received packets the engine.io client
+	// has not handled yet, or -1 when it doesn't implement EngineIOInspector.
+	EngineMessages int `json:"engineMessages"`
+	PendingAcks    int `json:"pendingAcks"`
+}
+
+// DebugSnapshot returns the current state of the client.
+func (c *Client) DebugSnapshot() DebugSnapshot {
+	snapshot := DebugSnapshot{
+		EngineID:  c.engineio.ID(),
+		Transport: c.engineio.Transport(),
+		State:     c.engineio.State().String(),
+		Queues:    DebugQueues{EngineMessages: -1},
+	}
+	if inspector, ok := c.engineio.(EngineIOInspector); ok {
+		snapshot.Handshake = inspector.Handshake()
+		if from, to := inspector.Upgrade(); to != "" {
+			snapshot.Upgrade = &DebugUpgrade{
+				From:       from,
+				To:         to,
+				InProgress: snapshot.State == engineio_v4.StateUpgrading.String(),
+			}
+		}
+		snapshot.Queues.EngineMessages = inspector.Buffered()
+	}
+
+	now := c.now()
+	c.mutex.RLock()
+	namespaces := make([]*namespace, 0, len(c.namespaces))
+	for _, ns := range c.namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	snapshot.PendingAcks = make([]DebugAck, 0, len(c.ackCallbacks))
+	for id := range c.ackCallbacks {
+		ack := DebugAck{ID: id}
+		if sent, ok := c.ackSent[id]; ok {
+			ack.Age = now.Sub(sent)
+		}
+		snapshot.PendingAcks = append(snapshot.PendingAcks, ack)
+	}
+	c.mutex.RUnlock()
+
+	sort.Slice(snapshot.PendingAcks, func(i, j int) bool { return snapshot.PendingAcks[i].ID < snapshot.PendingAcks[j].ID })
+	snapshot.Queues.PendingAcks = len(snapshot.PendingAcks)
+
+	snapshot.Namespaces = make([]DebugNamespace, 0, len(namespaces))
+	for _, ns := range namespaces {
+		snapshot.Namespaces = append(snapshot.Namespaces, ns.debugSnapshot())
+	}
+	sort.Slice(snapshot.Namespaces, func(i, j int) bool { return snapshot.Namespaces[i].Name < snapshot.Namespaces[j].Name })
+
+	return snapshot
+}
+
+func (n *namespace) debugSnapshot() DebugNamespace {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	events := make(map[string]int, len(n.handlers)+len(n.ctxHandlers))
+	for event, handlers := range n.handlers {
+		events[event] += len(handlers)
+	}
+	for event, handlers := range n.ctxHandlers {
+		events[event] += len(handlers)
+	}
+	return DebugNamespace{
+		Name:        n.name,
+		ID:          n.sid,
+		Connected:   n.connected,
+		Events:      events,
+		AnyHandlers: len(n.anyHandlers),
+	}
+}
+
+// DebugHandler returns an http.Handler rendering snapshots of clients, like
+// net/http/pprof does for the runtime. It serves HTML, or JSON with
+// ?format=json or an Accept header asking for application/json:
+//
+//	http.Handle("/debug/socketio", socketio.DebugHandler(client))
+//
+// Snapshots show handshake values and event names, so don't expose the
+// handler publicly.
+func DebugHandler(clients ...*Client) http.Handler {
+	return &debugHandler{clients: clients}
+}
+
+type debugHandler struct {
+	clients []*Client
+}
+
+func (h *debugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snapshots := make([]DebugSnapshot, len(h.clients))
+	for i, client := range h.clients {
+		snapshots[i] = client.DebugSnapshot()
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(snapshots)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = debugTemplate.Execute(w, snapshots)
+}
+
+var debugTemplate = template.Must(template.New("debug").Funcs(template.FuncMap{
+	"sortedEvents": func(events map[string]int) []string {
+		names := make([]string, 0, len(events))
+		for name := range events {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	},
+	"round": func(d time.Duration) time.Duration { return d.Round(time.Millisecond) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>socket.io clients</title></head>
+<body>
+<h1>socket.io clients</h1>
+<p><a href="?format=json">JSON</a></p>
+{{range $i, $c := .}}
+<h2>Client {{$i}}</h2>
+<table>
+<tr><th align="left">Engine sid</th><td>{{$c.EngineID}}</td></tr>
+<tr><th align="left">Transport</th><td>{{$c.Transport}}</td></tr>
+<tr><th align="left">State</th><td>{{$c.State}}</td></tr>
+<tr><th align="left">Upgrade</th><td>{{with $c.Upgrade}}{{.From}} &rarr; {{.To}}{{if .InProgress}} (in progress){{end}}{{else}}none{{end}}</td></tr>
+{{with $c.Handshake}}<tr><th align="left">Handshake</th><td>upgrades {{.Upgrades}}, pingInterval {{.PingInterval}}ms, pingTimeout {{.PingTimeout}}ms, maxPayload {{.MaxPayload}}</td></tr>{{end}}
+<tr><th align="left">Buffered packets</th><td>{{if lt $c.Queues.EngineMessages 0}}n/a{{else}}{{$c.Queues.EngineMessages}}{{end}}</td></tr>
+<tr><th align="left">Pending acks</th><td>{{$c.Queues.PendingAcks}}</td></tr>
+</table>
+<h3>Namespaces</h3>
+<table border="1">
+<tr><th>Name</th><th>Socket id</th><th>Connected</th><th>Events (handlers)</th><th>Catch-all handlers</th></tr>
+{{range $c.Namespaces}}<tr><td>{{.Name}}</td><td>{{.ID}}</td><td>{{.Connected}}</td><td>{{$events := .Events}}{{range sortedEvents $events}}{{.}} ({{index $events .}}) {{end}}</td><td>{{.AnyHandlers}}</td></tr>
+{{end}}</table>
+{{if $c.PendingAcks}}<h3>Pending acks</h3>
+<table border="1">
+<tr><th>Ack id</th><th>Age</th></tr>
+{{range $c.PendingAcks}}<tr><td>{{.ID}}</td><td>{{round .Age}}</td></tr>
+{{end}}</table>{{end}}
+{{else}}<p>No clients.</p>
+{{end}}
+</body>
+</html>
+`))
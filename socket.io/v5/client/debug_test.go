@@ -0,0 +1,121 @@
+package socketio_v5_client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+)
+
+var _ EngineIOInspector = (*engineio_v4_client.Client)(nil)
+
+type inspectedEngine struct {
+	*mocks.MockEngineIOClient
+	*mocks.MockEngineIOInspector
+}
+
+func newDebugClient(t *testing.T, engine EngineIOClient) *Client {
+	t.Helper()
+	chat := &namespace{
+		name:      "/chat",
+		connected: true,
+		sid:       "chat-sid",
+		handlers: map[string][]func([]interface{}){
+			"message": {func([]interface{}) {}, func([]interface{}) {}},
+		},
+		ctxHandlers: map[string][]func(context.Context, []interface{}){
+			"message": {func(context.Context, []interface{}) {}},
+			"join":    {func(context.Context, []interface{}) {}},
+		},
+		anyHandlers: []func(string, []interface{}){func(string, []interface{}) {}},
+	}
+	root := &namespace{name: "/", handlers: map[string][]func([]interface{}){}}
+	return &Client{
+		engineio:     engine,
+		defaultNs:    root,
+		namespaces:   map[string]*namespace{"/": root, "/chat": chat},
+		ackCallbacks: map[int]func([]interface{}){2: func([]interface{}) {}, 1: func([]interface{}) {}},
+		ackSent:      map[int]time.Time{1: time.Now().Add(-time.Minute), 2: time.Now()},
+	}
+}
+
+func TestClient_DebugSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := inspectedEngine{mocks.NewMockEngineIOClient(ctrl), mocks.NewMockEngineIOInspector(ctrl)}
+	engine.MockEngineIOClient.EXPECT().ID().Return("engine-sid")
+	engine.MockEngineIOClient.EXPECT().Transport().Return(engineio_v4.TransportWebsocket)
+	engine.MockEngineIOClient.EXPECT().State().Return(engineio_v4.StateUpgrading)
+	handshake := &engineio_v4.HandshakeResponse{Sid: "engine-sid", Upgrades: []string{"websocket"}, PingInterval: 25000}
+	engine.MockEngineIOInspector.EXPECT().Handshake().Return(handshake)
+	engine.MockEngineIOInspector.EXPECT().Upgrade().Return(engineio_v4.TransportPolling, engineio_v4.TransportWebsocket)
+	engine.MockEngineIOInspector.EXPECT().Buffered().Return(3)
+
+	snapshot := newDebugClient(t, engine).DebugSnapshot()
+
+	assert.Equal(t, "engine-sid", snapshot.EngineID)
+	assert.Equal(t, engineio_v4.TransportWebsocket, snapshot.Transport)
+	assert.Equal(t, "upgrading", snapshot.State)
+	assert.Equal(t, &DebugUpgrade{From: engineio_v4.TransportPolling, To: engineio_v4.TransportWebsocket, InProgress: true}, snapshot.Upgrade)
+	assert.Equal(t, handshake, snapshot.Handshake)
+	assert.Equal(t, DebugQueues{EngineMessages: 3, PendingAcks: 2}, snapshot.Queues)
+
+	require.Len(t, snapshot.PendingAcks, 2)
+	assert.Equal(t, 1, snapshot.PendingAcks[0].ID)
+	assert.GreaterOrEqual(t, snapshot.PendingAcks[0].Age, time.Minute)
+	assert.Equal(t, 2, snapshot.PendingAcks[1].ID)
+
+	assert.Equal(t, []DebugNamespace{
+		{Name: "/", Events: map[string]int{}},
+		{Name: "/chat", ID: "chat-sid", Connected: true, Events: map[string]int{"message": 3, "join": 1}, AnyHandlers: 1},
+	}, snapshot.Namespaces)
+}
+
+func TestDebugHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Engine clients not implementing EngineIOInspector are still shown.
+	engine := mocks.NewMockEngineIOClient(ctrl)
+	engine.EXPECT().ID().Return("engine-sid").AnyTimes()
+	engine.EXPECT().Transport().Return(engineio_v4.TransportPolling).AnyTimes()
+	engine.EXPECT().State().Return(engineio_v4.StateOpen).AnyTimes()
+
+	handler := DebugHandler(newDebugClient(t, engine))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/socketio?format=json", nil))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var snapshots []DebugSnapshot
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &snapshots))
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, "engine-sid", snapshots[0].EngineID)
+	assert.Nil(t, snapshots[0].Upgrade)
+	assert.Equal(t, -1, snapshots[0].Queues.EngineMessages)
+
+	request := httptest.NewRequest(http.MethodGet, "/debug/socketio", nil)
+	request.Header.Set("Accept", "application/json")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/socketio", nil))
+	assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	assert.Contains(t, body, "engine-sid")
+	assert.Contains(t, body, "/chat")
+	assert.Contains(t, body, "join (1) message (3)")
+	assert.Contains(t, body, "<td>1m0")
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"time"
 
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
 	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
 )
 
@@ -15,12 +16,31 @@ type Parser interface {
 	Serialize(*socketio_v5.Message) ([]byte, error)
 }
 
+// ErrorReportingParser is implemented by parsers that report argument
+// decoding failures and handler errors instead of only logging them. The
+// default parser implements it; other parsers fall back to WrapCallback.
+type ErrorReportingParser interface {
+	WrapCallbackWithError(callback interface{}) func(in []interface{}) error
+}
+
 // EngineIOClient представляет интерфейс для клиента Engine.IO
 type EngineIOClient interface {
 	Connect(ctx context.Context) error
 	Send(message []byte) error
 	On(event string, handler func([]byte))
 	Close() error
+	ID() string
+	Transport() engineio_v4.EngineIOTransport
+	State() engineio_v4.State
+	WatchState() (engineio_v4.State, <-chan struct{})
+}
+
+// EngineIOInspector is implemented by engine.io clients exposing session
+// details to DebugHandler. The default engine.io client implements it.
+type EngineIOInspector interface {
+	Handshake() *engineio_v4.HandshakeResponse
+	Upgrade() (from, to engineio_v4.EngineIOTransport)
+	Buffered() int
 }
 
 // Logger представляет интерфейс для логирования
@@ -35,3 +55,33 @@ type Logger interface {
 type Timer interface {
 	After(d time.Duration) <-chan time.Time
 }
+
+// Metrics receives the client measurements, and is passed to the engine.io
+// client and its default transports; see the metrics package.
+type Metrics interface {
+	PacketSent(transport, packetType string, bytes int)
+	PacketReceived(transport, packetType string, bytes int)
+	PollingRequest(method string, duration time.Duration, err error)
+	Upgrade(from, to string, err error)
+	AckRoundTrip(namespace string, duration time.Duration)
+	PendingAcks(delta int)
+	HandlerDuration(namespace, event string, duration time.Duration)
+	EventDropped(namespace, event, reason string)
+}
+
+// Tracer starts spans around emits, ack waits and handler executions. Each
+// Start method returns the context the operation continues with and a func
+// ending the span with the operation's error, if any. The client depends on
+// no tracing SDK; adapt one to this interface.
+type Tracer interface {
+	StartEmit(ctx context.Context, namespace, event string) (context.Context, func(err error))
+	StartAckWait(ctx context.Context, namespace, event string) (context.Context, func(err error))
+	StartHandler(ctx context.Context, namespace, event string) (context.Context, func(err error))
+}
+
+// Propagator moves trace context between a context and a text map carrier,
+// like an OpenTelemetry TextMapPropagator used with a MapCarrier.
+type Propagator interface {
+	Inject(ctx context.Context, carrier map[string]string)
+	Extract(ctx context.Context, carrier map[string]string) context.Context
+}
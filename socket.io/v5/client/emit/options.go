@@ -1,6 +1,7 @@
 package emit
 
 import (
+	"context"
 	"time"
 )
 
@@ -10,6 +11,7 @@ type EmitOptions struct {
 	timeout         *time.Duration
 	timeoutCallback func()
 	ackCallback     interface{}
+	ctx             context.Context
 }
 
 func (o *EmitOptions) Timeout() *time.Duration {
@@ -23,6 +25,10 @@ func (o *EmitOptions) AckCallback() interface{} {
 	return o.ackCallback
 }
 
+func (o *EmitOptions) Context() context.Context {
+	return o.ctx
+}
+
 func WithTimeout(timeout time.Duration, callback func()) EmitOption {
 	return func(o *EmitOptions) {
 		o.timeout = &timeout
@@ -35,3 +41,11 @@ func WithAck(callback interface{}) EmitOption {
 		o.ackCallback = callback
 	}
 }
+
+// WithContext sets the context the emit waits for the connection with and
+// traces under; an ack callback taking a leading context.Context receives it.
+func WithContext(ctx context.Context) EmitOption {
+	return func(o *EmitOptions) {
+		o.ctx = ctx
+	}
+}
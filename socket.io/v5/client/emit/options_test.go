@@ -1,6 +1,7 @@
 package emit
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
@@ -59,6 +60,18 @@ func TestWithAck(t *testing.T) {
 	}
 }
 
+func TestWithContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	options := &EmitOptions{}
+	WithContext(ctx)(options)
+
+	if options.Context() != ctx {
+		t.Error("Context does not match the set context")
+	}
+}
+
 func TestEmitOptionsCombined(t *testing.T) {
 	timeout := 2 * time.Second
 	timeoutCallback := func() {}
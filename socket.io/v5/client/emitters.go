@@ -1,7 +1,9 @@
 package socketio_v5_client
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"time"
 
 	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
@@ -15,19 +17,6 @@ func (c *Client) Emit(event interface{}, args ...interface{}) error {
 
 func (n *namespace) Emit(event interface{}, args ...interface{}) error {
 
-	if n.waitConnected != nil {
-		// Snapshot ctx under lock to prevent data race
-		n.client.mutex.RLock()
-		ctx := n.client.ctx
-		n.client.mutex.RUnlock()
-
-		select {
-		case <-n.waitConnected:
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-	}
-
 	emitOptions := &emit.EmitOptions{}
 
 	emitEvent := &socketio_v5.Event{}
@@ -63,91 +52,192 @@ func (n *namespace) Emit(event interface{}, args ...interface{}) error {
 		emitEvent.Payloads = args[:optLen]
 	}
 
+	ctx := emitOptions.Context()
+	if ctx == nil {
+		ctx = n.client.clientContext()
+	}
+
+	if n.waitConnected != nil {
+		select {
+		case <-n.waitConnected:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ctx, end := n.client.tracing().StartEmit(ctx, n.name, emitEvent.Name)
+	packet := &socketio_v5.Message{
+		NS:    n.name,
+		Type:  socketio_v5.PacketEvent,
+		Event: n.client.injectEventTrace(ctx, emitEvent),
+	}
+
+	var err error
 	if emitOptions.AckCallback() == nil && emitOptions.Timeout() == nil {
-		return n.client.sendPacket(&socketio_v5.Message{
-			NS:    n.name,
-			Type:  socketio_v5.PacketEvent,
-			Event: emitEvent,
-		})
-	}
-
-	return n.client.sendPacketWithAckTimeout(
-		&socketio_v5.Message{
-			NS:    n.name,
-			Type:  socketio_v5.PacketEvent,
-			Event: emitEvent,
-		},
-		emitOptions.AckCallback(),
-		emitOptions.Timeout(),
-		emitOptions.TimeoutCallback(),
-	)
+		err = n.client.sendPacket(packet)
+	} else {
+		err = n.client.sendPacketWithAckTimeout(
+			ctx,
+			packet,
+			emitOptions.AckCallback(),
+			emitOptions.Timeout(),
+			emitOptions.TimeoutCallback(),
+		)
+	}
+	end(err)
+	return err
 }
 
+// ErrAckTimeout ends the ack wait span of an emit whose ack timed out.
+var ErrAckTimeout = errors.New("ack timeout")
+
+// ErrPacketDropped ends the ack wait span of an emit dropped by outgoing
+// middleware.
+var ErrPacketDropped = errors.New("packet dropped by middleware")
+
 func (c *Client) sendPacketWithAckTimeout(
+	ctx context.Context,
 	packet *socketio_v5.Message,
 	callback interface{},
 	timeout *time.Duration,
 	timeoutCallback func(),
 ) error {
 
+	eventName := eventName(packet)
+
 	var wrappedCallback func([]interface{})
 	if callback != nil {
-		wrappedCallback = c.parser.WrapCallback(callback)
+		if wrapped := c.wrapContextHandler(packet.NS, eventName, callback); wrapped != nil {
+			// ctx is the ack wait context by the time the ack arrives.
+			wrappedCallback = func(in []interface{}) { wrapped(ctx, in) }
+		} else {
+			wrappedCallback = c.wrapHandler(packet.NS, eventName, callback)
+		}
 		if wrappedCallback == nil {
 			return errors.New("callback must be a function")
 		}
 	}
 
+	ctx, endWait := c.tracing().StartAckWait(ctx, packet.NS, eventName)
+	var endOnce sync.Once
+	endAckWait := func(err error) { endOnce.Do(func() { endWait(err) }) }
+
 	var done chan []interface{}
 	if timeout != nil {
 		done = make(chan []interface{}, 1)
 	}
 
+	// acked records the round trip when the ack arrives; a timed out ack is
+	// only counted out of the pending ones.
+	sent := c.now()
+	acked := func() {
+		c.meter().AckRoundTrip(packet.NS, c.now().Sub(sent))
+		c.meter().PendingAcks(-1)
+		endAckWait(nil)
+	}
+
 	c.mutex.Lock()
 	c.ackCounter++
 	counter := c.ackCounter
 	packet.AckId = &counter
 	if timeout != nil {
 		c.ackCallbacks[counter] = func(param []interface{}) {
+			acked()
 			done <- param
 		}
-	} else {
-		if wrappedCallback != nil {
-			c.ackCallbacks[counter] = wrappedCallback
+	} else if wrappedCallback != nil {
+		c.ackCallbacks[counter] = func(param []interface{}) {
+			acked()
+			wrappedCallback(param)
+		}
+	}
+	if _, pending := c.ackCallbacks[counter]; pending {
+		if c.ackSent == nil {
+			c.ackSent = make(map[int]time.Time)
 		}
+		c.ackSent[counter] = sent
+		if c.ackEvents == nil {
+			c.ackEvents = make(map[int]string)
+		}
+		c.ackEvents[counter] = eventName
+		c.meter().PendingAcks(1)
 	}
 	c.mutex.Unlock()
 
+	// released is closed when the packet didn't reach the server, so no ack
+	// can come.
+	released := make(chan struct{})
+
 	if timeout != nil {
 		// Snapshot ctx under lock to prevent data race
 		c.mutex.RLock()
-		ctx := c.ctx
+		clientCtx := c.ctx
 		c.mutex.RUnlock()
 
 		go func(done chan []interface{}) {
 			select {
 			case <-c.timer.After(*timeout):
-				c.logger.Warnf("ack timeout: %v", timeout)
+				c.fieldLogger(packet.NS, eventName, ackIDField(counter)).Warnf("ack timeout: %v", timeout)
 				c.mutex.Lock()
-				delete(c.ackCallbacks, counter)
+				_, pending := c.ackCallbacks[counter]
+				c.removeAckLocked(counter)
 				c.mutex.Unlock()
+				if pending {
+					c.meter().PendingAcks(-1)
+					endAckWait(ErrAckTimeout)
+				}
 				if timeoutCallback != nil {
 					timeoutCallback()
 				}
-			case <-ctx.Done():
-				c.logger.Warnf("context is done: %v", ctx.Err())
+			case <-clientCtx.Done():
+				c.logger.Warnf("context is done: %v", clientCtx.Err())
+				endAckWait(clientCtx.Err())
 			case param := <-done:
 				if wrappedCallback != nil {
 					wrappedCallback(param)
 				}
+			case <-released:
 			}
 		}(done)
 	}
 
-	return c.sendPacket(packet)
+	delivered := false
+	err := c.handleOutgoing(packet, func(ctx context.Context, packet *socketio_v5.Message) error {
+		delivered = true
+		return c.writePacket(ctx, packet)
+	})
+	if err == nil && delivered {
+		return nil
+	}
+
+	c.mutex.Lock()
+	_, pending := c.ackCallbacks[counter]
+	c.removeAckLocked(counter)
+	c.mutex.Unlock()
+	close(released)
+	if pending {
+		c.meter().PendingAcks(-1)
+	}
+	if err != nil {
+		endAckWait(err)
+		return err
+	}
+
+	// A dropped packet is never acknowledged: the emit times out at once.
+	endAckWait(ErrPacketDropped)
+	if pending && timeoutCallback != nil {
+		timeoutCallback()
+	}
+	return nil
 }
 
 func (c *Client) sendPacket(packet *socketio_v5.Message) error {
+	return c.handleOutgoing(packet, c.writePacket)
+}
+
+// writePacket serializes and sends a packet, at the end of the outgoing
+// middleware.
+func (c *Client) writePacket(_ context.Context, packet *socketio_v5.Message) error {
 	packetData, err := c.parser.Serialize(packet)
 	if err != nil {
 		return err
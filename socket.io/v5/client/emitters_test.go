@@ -12,6 +12,7 @@ import (
 	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
 	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
 	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+	"github.com/maldikhan/go.socket.io/utils/fakeclock"
 )
 
 func TestEmitBeforeConnected(t *testing.T) {
@@ -295,7 +296,7 @@ func TestSendPacketWithAckTimeout(t *testing.T) {
 				}
 			}
 
-			err := client.sendPacketWithAckTimeout(&socketio_v5.Message{}, tt.callback, tt.timeout, tt.timeoutCallback)
+			err := client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{}, tt.callback, tt.timeout, tt.timeoutCallback)
 			assert.Equal(t, tt.expectedError, err)
 			if tt.timeout != nil {
 				select {
@@ -346,7 +347,7 @@ func TestSendPacketWithAckTimeout(t *testing.T) {
 
 		timeout := 50 * time.Millisecond
 
-		err := client.sendPacketWithAckTimeout(&socketio_v5.Message{}, func() {}, &timeout, timeoutCallback)
+		err := client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{}, func() {}, &timeout, timeoutCallback)
 		go func() {
 			<-time.After(time.Millisecond * 10)
 			client.ackCallbacks[1]([]interface{}{})
@@ -412,7 +413,7 @@ func TestSendPacketWithAckTimeout(t *testing.T) {
 
 		timeout := 50 * time.Millisecond
 
-		err := client.sendPacketWithAckTimeout(&socketio_v5.Message{}, func() {}, &timeout, timeoutCallback)
+		err := client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{}, func() {}, &timeout, timeoutCallback)
 		go func() {
 			<-time.After(time.Millisecond * 30)
 			client.ackCallbacks[1]([]interface{}{})
@@ -432,6 +433,50 @@ func TestSendPacketWithAckTimeout(t *testing.T) {
 		}
 
 	})
+
+	t.Run("Timeout on a fake clock", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEngineIO := mocks.NewMockEngineIOClient(ctrl)
+		mockParser := mocks.NewMockParser(ctrl)
+		mockLogger := mocks.NewMockLogger(ctrl)
+
+		mockParser.EXPECT().Serialize(gomock.Any()).Return([]byte{}, nil)
+		mockParser.EXPECT().WrapCallback(gomock.Any()).Return(func([]interface{}) {})
+		mockEngineIO.EXPECT().Send(gomock.Any()).Return(nil)
+		mockLogger.EXPECT().Warnf("ack timeout: %v", gomock.Any())
+
+		clock := fakeclock.New(time.Now())
+		client := &Client{
+			engineio:     mockEngineIO,
+			parser:       mockParser,
+			ackCallbacks: make(map[int]func([]interface{})),
+			timer:        clock,
+			clock:        clock,
+			ctx:          context.Background(),
+			logger:       mockLogger,
+		}
+
+		onTimeout := make(chan struct{})
+		timeout := time.Minute
+		err := client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{}, func() {}, &timeout, func() { close(onTimeout) })
+		assert.NoError(t, err)
+
+		clock.BlockUntil(1)
+		clock.Advance(timeout - time.Millisecond)
+		select {
+		case <-onTimeout:
+			assert.Fail(t, "timeout callback fired early")
+		default:
+		}
+
+		clock.Advance(time.Millisecond)
+		<-onTimeout
+		client.mutex.RLock()
+		assert.Empty(t, client.ackCallbacks)
+		client.mutex.RUnlock()
+	})
 }
 
 func TestSendPacket(t *testing.T) {
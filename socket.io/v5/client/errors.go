@@ -0,0 +1,84 @@
+package socketio_v5_client
+
+import (
+	"errors"
+	"fmt"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
+
+// HandlerError describes a failed event handler or ack callback. Exactly one
+// of Recovered, DecodeErr and Err is set.
+type HandlerError struct {
+	Namespace string
+	// Event is the event name; for an ack callback it is the name of the
+	// emitted event the ack answers, if known.
+	Event    string
+	Payloads []interface{}
+
+	// Recovered and Stack describe a panic inside the handler.
+	Recovered interface{}
+	Stack     []byte
+
+	// DecodeErr is set when the payloads could not be decoded into the
+	// handler's parameter types; the handler was not called.
+	DecodeErr error
+
+	// Err is the error returned by a handler with an error result.
+	Err error
+}
+
+func (e HandlerError) Error() string {
+	switch {
+	case e.Recovered != nil:
+		return fmt.Sprintf("panic in handler for %q on %s: %v", e.Event, e.Namespace, e.Recovered)
+	case e.DecodeErr != nil:
+		return fmt.Sprintf("decode arguments for %q on %s: %v", e.Event, e.Namespace, e.DecodeErr)
+	}
+	return fmt.Sprintf("handler for %q on %s: %v", e.Event, e.Namespace, e.Err)
+}
+
+func (e HandlerError) Unwrap() error {
+	if e.DecodeErr != nil {
+		return e.DecodeErr
+	}
+	return e.Err
+}
+
+func (c *Client) reportHandlerError(report HandlerError) {
+	if c.handlerErrorHook != nil {
+		c.handlerErrorHook(report)
+	}
+}
+
+// wrapHandler wraps a user handler with the parser's argument decoding. When
+// the parser supports it, decoding failures and errors returned by the handler
+// are logged and passed to the handler error hook.
+func (c *Client) wrapHandler(ns, event string, handler interface{}) func([]interface{}) {
+	parser, ok := c.parser.(ErrorReportingParser)
+	if !ok {
+		return c.parser.WrapCallback(handler)
+	}
+
+	wrapped := parser.WrapCallbackWithError(handler)
+	if wrapped == nil {
+		return nil
+	}
+
+	return func(in []interface{}) {
+		err := wrapped(in)
+		if err == nil {
+			return
+		}
+		report := HandlerError{Namespace: ns, Event: event, Payloads: in}
+		var decodeErr *socketio_v5.DecodeError
+		if errors.As(err, &decodeErr) {
+			c.fieldLogger(ns, event).Errorf("Can't decode arguments for %s: %v", event, err)
+			report.DecodeErr = err
+		} else {
+			c.fieldLogger(ns, event).Errorf("Handler for %s returned error: %v", event, err)
+			report.Err = err
+		}
+		c.reportHandlerError(report)
+	}
+}
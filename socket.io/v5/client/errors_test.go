@@ -0,0 +1,125 @@
+package socketio_v5_client
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+	socketio_v5_parser_default "github.com/maldikhan/go.socket.io/socket.io/v5/parser/default"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+func TestHandlerErrorHook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mocks.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	reports := make(chan HandlerError, 1)
+	client := &Client{
+		logger: mockLogger,
+		parser: socketio_v5_parser_default.NewParser(
+			socketio_v5_parser_default.WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+		),
+		handlerErrorHook: func(report HandlerError) { reports <- report },
+	}
+	ns := &namespace{client: client, name: "/chat", handlers: make(map[string][]func([]interface{}))}
+
+	waitReport := func(t *testing.T) HandlerError {
+		t.Helper()
+		select {
+		case report := <-reports:
+			return report
+		case <-time.After(time.Second):
+			t.Fatal("handler error not reported")
+		}
+		return HandlerError{}
+	}
+
+	payloads := []interface{}{json.RawMessage(`"text"`)}
+
+	t.Run("Panic", func(t *testing.T) {
+		ns.On("panic", func(string) { panic("boom") })
+		client.handleEvent(ns, &socketio_v5.Event{Name: "panic", Payloads: payloads})
+
+		report := waitReport(t)
+		assert.Equal(t, "/chat", report.Namespace)
+		assert.Equal(t, "panic", report.Event)
+		assert.Equal(t, payloads, report.Payloads)
+		assert.Equal(t, "boom", report.Recovered)
+		assert.Contains(t, string(report.Stack), "goroutine")
+		assert.Contains(t, report.Error(), "boom")
+	})
+
+	t.Run("Decode error", func(t *testing.T) {
+		ns.On("decode", func(int) { t.Error("handler must not be called") })
+		client.handleEvent(ns, &socketio_v5.Event{Name: "decode", Payloads: payloads})
+
+		report := waitReport(t)
+		assert.Equal(t, "decode", report.Event)
+		var decodeErr *socketio_v5.DecodeError
+		require.ErrorAs(t, report, &decodeErr)
+		assert.Equal(t, 0, decodeErr.Index)
+		assert.Nil(t, report.Recovered)
+	})
+
+	t.Run("Returned error", func(t *testing.T) {
+		handlerErr := errors.New("rejected")
+		ns.On("fail", func(string) error { return handlerErr })
+		client.handleEvent(ns, &socketio_v5.Event{Name: "fail", Payloads: payloads})
+
+		report := waitReport(t)
+		assert.Equal(t, "fail", report.Event)
+		assert.ErrorIs(t, report, handlerErr)
+		assert.Nil(t, report.DecodeErr)
+	})
+
+	t.Run("Ack callback", func(t *testing.T) {
+		callback := client.wrapHandler("/chat", "echo", func(int) {})
+		callback(payloads)
+
+		report := waitReport(t)
+		assert.Equal(t, "echo", report.Event)
+		assert.NotNil(t, report.DecodeErr)
+	})
+
+	t.Run("Ack callback panic", func(t *testing.T) {
+		client.ackCallbacks = map[int]func([]interface{}){1: func([]interface{}) { panic("boom") }}
+		client.ackEvents = map[int]string{1: "echo"}
+		client.handleAck("/chat", &socketio_v5.Event{Payloads: payloads}, 1)
+
+		report := waitReport(t)
+		assert.Equal(t, "echo", report.Event)
+		assert.Equal(t, "boom", report.Recovered)
+		assert.Empty(t, client.ackEvents)
+	})
+
+	t.Run("No error", func(t *testing.T) {
+		called := make(chan struct{})
+		ns.On("ok", func(string) error { close(called); return nil })
+		client.handleEvent(ns, &socketio_v5.Event{Name: "ok", Payloads: payloads})
+
+		<-called
+		select {
+		case report := <-reports:
+			t.Fatalf("unexpected report: %v", report)
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+}
+
+func TestWithHandlerErrorHook(t *testing.T) {
+	var called bool
+	c := &InitClient{Client: &Client{}}
+	assert.NoError(t, WithHandlerErrorHook(func(HandlerError) { called = true })(c))
+	c.reportHandlerError(HandlerError{})
+	assert.True(t, called)
+}
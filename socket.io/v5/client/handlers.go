@@ -1,6 +1,11 @@
 package socketio_v5_client
 
-import socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+import (
+	"context"
+	"encoding/json"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
 
 func (c *Client) On(event string, handler interface{}) {
 	c.defaultNs.On(event, handler)
@@ -10,8 +15,21 @@ func (c *Client) OnAny(handler func(string, []interface{})) {
 	c.defaultNs.OnAny(handler)
 }
 
+// On registers a handler for event. A handler whose first parameter is a
+// context.Context receives the client context, carrying the trace context
+// extracted from the event when WithTracePropagation is set.
 func (n *namespace) On(event string, handler interface{}) {
-	wrapped := n.client.parser.WrapCallback(handler)
+	if wrapped := n.client.wrapContextHandler(n.name, event, handler); wrapped != nil {
+		n.mu.Lock()
+		if n.ctxHandlers == nil {
+			n.ctxHandlers = make(map[string][]func(context.Context, []interface{}))
+		}
+		n.ctxHandlers[event] = append(n.ctxHandlers[event], wrapped)
+		n.mu.Unlock()
+		return
+	}
+
+	wrapped := n.client.wrapHandler(n.name, event, handler)
 
 	n.mu.Lock()
 	n.handlers[event] = append(n.handlers[event], wrapped)
@@ -27,12 +45,35 @@ func (n *namespace) OnAny(handler func(string, []interface{})) {
 func (c *Client) onMessage(data []byte) {
 	c.logger.Debugf("socketio receive %s", c.payload(data))
 
+	if !c.limiter.allowRaw(data) {
+		return
+	}
 	msg, err := c.parser.Parse(data)
 	if err != nil {
 		c.logger.Errorf("Can't parse message: %v", err)
+		c.meter().EventDropped("", "", DropParseError)
+		return
+	}
+	if !c.limiter.allow(msg) {
 		return
 	}
 
+	c.handleMessage(msg)
+}
+
+// handleMessage runs a parsed packet through the incoming middleware and
+// dispatches it.
+func (c *Client) handleMessage(msg *socketio_v5.Message) {
+	if err := c.handleIncoming(msg, c.dispatchMessage); err != nil {
+		c.fieldLogger(msg.NS, eventName(msg), packetTypeField(msg.Type)).Errorf("Incoming middleware error: %v", err)
+		ns, event := c.labels(msg.NS, eventName(msg))
+		c.meter().EventDropped(ns, event, DropMiddleware)
+	}
+}
+
+// dispatchMessage routes a parsed packet to ack callbacks or namespace
+// handlers.
+func (c *Client) dispatchMessage(msg *socketio_v5.Message) {
 	// Handle ACK packets first — they don't carry a meaningful namespace,
 	// so they must not be dropped by the unknown-namespace guard below.
 	if msg.Type == socketio_v5.PacketAck {
@@ -41,14 +82,14 @@ func (c *Client) onMessage(data []byte) {
 			return
 		}
 		if msg.Event == nil {
-			c.logger.Errorf("received ACK packet without event data, dropping")
+			c.fieldLogger(msg.NS, "", ackIDField(*msg.AckId)).Errorf("received ACK packet without event data, dropping")
 			// Clean up the callback to prevent memory leak
 			c.mutex.Lock()
-			delete(c.ackCallbacks, *msg.AckId)
+			c.removeAckLocked(*msg.AckId)
 			c.mutex.Unlock()
 			return
 		}
-		c.handleAck(msg.Event, *msg.AckId)
+		c.handleAck(msg.NS, msg.Event, *msg.AckId)
 		return
 	}
 
@@ -64,7 +105,9 @@ func (c *Client) onMessage(data []byte) {
 			ns = c.namespace(msg.NS)
 		} else {
 			// For other packet types, log warning and return
-			c.logger.Warnf("Received %v for unknown namespace: %s", msg.Type, msg.NS)
+			c.fieldLogger(msg.NS, eventName(msg), packetTypeField(msg.Type)).Warnf("Received %v for unknown namespace: %s", msg.Type, msg.NS)
+			ns, event := otherLabels(msg.NS, eventName(msg))
+			c.meter().EventDropped(ns, event, DropUnknownNamespace)
 			return
 		}
 	}
@@ -77,49 +120,61 @@ func (c *Client) onMessage(data []byte) {
 	case socketio_v5.PacketEvent:
 		c.handleEvent(ns, msg.Event)
 	case socketio_v5.PacketConnectError:
-		c.handleConnectError(ns, msg.Payload)
-		c.logger.Errorf("Connect error: %v", *msg.ErrorMessage)
+		c.handleConnectError(ns, connectErrorPayload(msg))
+		c.fieldLogger(ns.name, "").Errorf("Connect error: %v", *msg.ErrorMessage)
+	}
+}
+
+// connectErrorPayload returns the CONNECT_ERROR payload handed to "error"
+// handlers: the raw JSON the default parser keeps in ErrorMessage, so that
+// {"message", "data"} decodes into the handler's parameter type.
+func connectErrorPayload(msg *socketio_v5.Message) interface{} {
+	if msg.Payload != nil || msg.ErrorMessage == nil {
+		return msg.Payload
+	}
+	if json.Valid([]byte(*msg.ErrorMessage)) {
+		return json.RawMessage(*msg.ErrorMessage)
 	}
+	return *msg.ErrorMessage
 }
 
 func (c *Client) handleConnectError(ns *namespace, payload interface{}) {
-	c.logger.Infof("Connect error, namespace: %s", ns.name)
+	c.fieldLogger(ns.name, "").Infof("Connect error, namespace: %s", ns.name)
+	ns.setConnected(false, "")
 
 	ns.mu.RLock()
 	handlers, ok := ns.handlers["error"]
+	ctxHandlers := ns.ctxHandlers["error"]
 	ns.mu.RUnlock()
 
-	if !ok {
-		c.logger.Infof("No handlers for event: %s", "error")
+	if !ok && len(ctxHandlers) == 0 {
+		c.fieldLogger(ns.name, "error").Infof("No handlers for event: %s", "error")
 		return
 	}
 
-	for _, handler := range handlers {
-		h := handler
-		c.safeGo(func() { h([]interface{}{payload}) })
-	}
+	c.runLifecycleHandlers(ns.name, "error", payload, handlers, ctxHandlers)
 }
 
 func (c *Client) handleDisconnect(ns *namespace, payload interface{}) {
-	c.logger.Infof("Disconnected from namespace: %s", ns.name)
+	c.fieldLogger(ns.name, "").Infof("Disconnected from namespace: %s", ns.name)
+	ns.setConnected(false, "")
 
 	ns.mu.RLock()
 	handlers, ok := ns.handlers["disconnect"]
+	ctxHandlers := ns.ctxHandlers["disconnect"]
 	ns.mu.RUnlock()
 
-	if !ok {
-		c.logger.Infof("No handlers for event: %s", "disconnect")
+	if !ok && len(ctxHandlers) == 0 {
+		c.fieldLogger(ns.name, "disconnect").Infof("No handlers for event: %s", "disconnect")
 		return
 	}
 
-	for _, handler := range handlers {
-		h := handler
-		c.safeGo(func() { h([]interface{}{payload}) })
-	}
+	c.runLifecycleHandlers(ns.name, "disconnect", payload, handlers, ctxHandlers)
 }
 
 func (c *Client) handleConnect(ns *namespace, payload interface{}) {
-	c.logger.Infof("Connected to namespace: %s", ns.name)
+	c.fieldLogger(ns.name, "").Infof("Connected to namespace: %s", ns.name)
+	ns.setConnected(true, connectSid(payload))
 	ns.hadConnected.Do(func() {
 		if ns.waitConnected != nil {
 			close(ns.waitConnected)
@@ -128,51 +183,94 @@ func (c *Client) handleConnect(ns *namespace, payload interface{}) {
 
 	ns.mu.RLock()
 	handlers, ok := ns.handlers["connect"]
+	ctxHandlers := ns.ctxHandlers["connect"]
 	ns.mu.RUnlock()
 
-	if !ok {
-		c.logger.Debugf("No handlers for event: %s", "connect")
+	if !ok && len(ctxHandlers) == 0 {
+		c.fieldLogger(ns.name, "connect").Debugf("No handlers for event: %s", "connect")
 		return
 	}
 
+	// Only the sid is read from the CONNECT payload; handlers get nil, as
+	// they always have.
+	c.runLifecycleHandlers(ns.name, "connect", nil, handlers, ctxHandlers)
+}
+
+// runLifecycleHandlers runs the connect, disconnect or error handlers with
+// payload as their single argument.
+func (c *Client) runLifecycleHandlers(ns, event string, payload interface{}, handlers []func([]interface{}), ctxHandlers []func(context.Context, []interface{})) {
+	ctx := c.clientContext()
+
+	report := HandlerError{Namespace: ns, Event: event, Payloads: []interface{}{payload}}
 	for _, handler := range handlers {
 		h := handler
-		c.safeGo(func() { h([]interface{}{payload}) })
+		c.safeGo(report, func() { h([]interface{}{payload}) })
+	}
+	for _, handler := range ctxHandlers {
+		h := handler
+		c.safeGo(report, func() { h(ctx, []interface{}{payload}) })
 	}
 }
 
 func (c *Client) handleEvent(ns *namespace, event *socketio_v5.Event) {
 	ns.mu.RLock()
 	handlers, ok := ns.handlers[event.Name]
+	ctxHandlers := ns.ctxHandlers[event.Name]
 	anyHandlers := ns.anyHandlers
 	ns.mu.RUnlock()
 
-	if !ok && len(anyHandlers) == 0 {
-		c.logger.Infof("No handlers for event: %s", event.Name)
+	if !ok && len(ctxHandlers) == 0 && len(anyHandlers) == 0 {
+		c.fieldLogger(ns.name, event.Name).Infof("No handlers for event: %s", event.Name)
+		c.meter().EventDropped(ns.name, OtherLabel, DropNoHandler)
 		return
 	}
 
+	ctx, payloads := c.extractEventTrace(c.clientContext(), event.Payloads)
+
+	report := HandlerError{Namespace: ns.name, Event: event.Name, Payloads: payloads}
+
 	for _, handler := range anyHandlers {
 		h := handler
-		c.safeGo(func() { h(event.Name, event.Payloads) })
+		c.safeGo(report, func() {
+			c.traceHandler(ctx, ns.name, event.Name, func(context.Context) { h(event.Name, payloads) })
+		})
 	}
 
 	for _, handler := range handlers {
 		h := handler
-		c.safeGo(func() { h(event.Payloads) })
+		c.safeGo(report, func() {
+			c.traceHandler(ctx, ns.name, event.Name, func(context.Context) { h(payloads) })
+		})
+	}
+
+	for _, handler := range ctxHandlers {
+		h := handler
+		c.safeGo(report, func() {
+			c.traceHandler(ctx, ns.name, event.Name, func(ctx context.Context) { h(ctx, payloads) })
+		})
 	}
 }
 
-func (c *Client) handleAck(event *socketio_v5.Event, ackId int) {
+func (c *Client) handleAck(ns string, event *socketio_v5.Event, ackId int) {
 	c.mutex.Lock()
 	callback, ok := c.ackCallbacks[ackId]
-	delete(c.ackCallbacks, ackId)
+	eventName := c.ackEvents[ackId]
+	c.removeAckLocked(ackId)
 	c.mutex.Unlock()
 
 	if !ok {
-		c.logger.Infof("No ack callback for id: %d", ackId)
+		c.fieldLogger(ns, "", ackIDField(ackId)).Infof("No ack callback for id: %d", ackId)
+		label, _ := c.labels(ns, "")
+		c.meter().EventDropped(label, "", DropNoAckCallback)
 		return
 	}
 
-	c.safeGo(func() { callback(event.Payloads) })
+	c.safeGo(HandlerError{Namespace: ns, Event: eventName, Payloads: event.Payloads}, func() { callback(event.Payloads) })
+}
+
+// removeAckLocked forgets a pending ack. The caller holds c.mutex.
+func (c *Client) removeAckLocked(ackId int) {
+	delete(c.ackCallbacks, ackId)
+	delete(c.ackSent, ackId)
+	delete(c.ackEvents, ackId)
 }
@@ -1,6 +1,7 @@
 package socketio_v5_client
 
 import (
+	"encoding/json"
 	"sync"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 
 	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
 	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+	"github.com/maldikhan/go.socket.io/utils"
 )
 
 //go:generate mockgen -destination=mocks_test.go -package=socketio_v5_client github.com/maldikhan/go.socket.io/socket.io/v5/client/emit Parser,Logger
@@ -402,16 +404,18 @@ func TestClientHandleConnect(t *testing.T) {
 
 	t.Run("With Handlers", func(t *testing.T) {
 		mockLogger.EXPECT().Infof(gomock.Any(), gomock.Any())
-		handlerCalled := make(chan bool)
+		handlerArgs := make(chan []interface{})
 		ns.handlers["connect"] = []func([]interface{}){
 			func(args []interface{}) {
-				handlerCalled <- true
+				handlerArgs <- args
 			},
 		}
 
-		client.handleConnect(ns, "test")
+		client.handleConnect(ns, json.RawMessage(`{"sid":"socket-sid"}`))
 
-		assert.True(t, <-handlerCalled)
+		// The payload only feeds the sid; handlers get nil.
+		assert.Equal(t, []interface{}{nil}, <-handlerArgs)
+		assert.Equal(t, "socket-sid", client.ID())
 	})
 
 	t.Run("With channel notify", func(t *testing.T) {
@@ -518,7 +522,7 @@ func TestClientHandleAck(t *testing.T) {
 	t.Run("No Callback", func(t *testing.T) {
 		mockLogger.EXPECT().Infof(gomock.Any(), gomock.Any())
 
-		client.handleAck(&socketio_v5.Event{}, 1)
+		client.handleAck("/", &socketio_v5.Event{}, 1)
 	})
 
 	t.Run("With Callback", func(t *testing.T) {
@@ -527,7 +531,7 @@ func TestClientHandleAck(t *testing.T) {
 			callbackCalled <- true
 		}
 
-		client.handleAck(&socketio_v5.Event{}, 1)
+		client.handleAck("/", &socketio_v5.Event{}, 1)
 
 		assert.True(t, <-callbackCalled)
 		assert.Len(t, client.ackCallbacks, 0)
@@ -554,7 +558,7 @@ func TestSafeGoPanicRecovery(t *testing.T) {
 			handlerExecuted <- true
 		})
 
-		client.safeGo(func() {
+		client.safeGo(HandlerError{}, func() {
 			panic(panicMsg)
 		})
 
@@ -568,7 +572,7 @@ func TestSafeGoPanicRecovery(t *testing.T) {
 	t.Run("Handler that completes normally works fine", func(t *testing.T) {
 		handlerExecuted := make(chan bool)
 
-		client.safeGo(func() {
+		client.safeGo(HandlerError{}, func() {
 			handlerExecuted <- true
 		})
 
@@ -618,3 +622,35 @@ func TestSafeGoPanicRecovery(t *testing.T) {
 		}
 	})
 }
+
+func TestConnectErrorPayload(t *testing.T) {
+	raw := `{"message":"unauthorized","data":{"reason":"token"}}`
+	text := "unauthorized"
+
+	assert.Equal(t, json.RawMessage(raw), connectErrorPayload(&socketio_v5.Message{ErrorMessage: &raw}))
+	assert.Equal(t, "unauthorized", connectErrorPayload(&socketio_v5.Message{ErrorMessage: &text}))
+	assert.Equal(t, "decoded", connectErrorPayload(&socketio_v5.Message{Payload: "decoded", ErrorMessage: &raw}))
+	assert.Nil(t, connectErrorPayload(&socketio_v5.Message{}))
+}
+
+func TestClient_logFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mocks.NewMockLogger(ctrl)
+	client := &Client{
+		logger:       utils.NewFieldLogger(mockLogger),
+		namespaces:   map[string]*namespace{},
+		ackCallbacks: map[int]func([]interface{}){},
+	}
+
+	mockLogger.EXPECT().Warnf("%s", "Received 2 for unknown namespace: /admin namespace=/admin event=news packetType=2")
+	client.dispatchMessage(&socketio_v5.Message{
+		Type:  socketio_v5.PacketEvent,
+		NS:    "/admin",
+		Event: &socketio_v5.Event{Name: "news"},
+	})
+
+	mockLogger.EXPECT().Infof("%s", "No ack callback for id: 7 namespace=/ ackId=7")
+	client.handleAck("/", &socketio_v5.Event{}, 7)
+}
@@ -0,0 +1,276 @@
+package socketio_v5_client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
+
+// Limits caps what the client accepts from the server, so that a hostile or
+// buggy server can't exhaust its memory. A zero field is unlimited.
+type Limits struct {
+	// MaxFrameSize caps the size in bytes of a websocket frame received by
+	// the engine.io client NewClient builds. Larger frames are discarded
+	// unread.
+	MaxFrameSize int
+	// MaxArgs caps the arguments of an event or ack.
+	MaxArgs int
+	// MaxDepth caps the JSON nesting depth of a packet; the arguments of an
+	// event are at depth 1, the fields of an object argument at depth 2.
+	MaxDepth int
+	// MaxEventNameLength caps the length in bytes of an event name.
+	MaxEventNameLength int
+	// MaxAttachments caps the binary attachments a packet announces.
+	MaxAttachments int
+	// EventsPerSecond caps the events received on each namespace, allowing
+	// bursts of EventBurst events, by default EventsPerSecond rounded up.
+	EventsPerSecond float64
+	EventBurst      int
+	// Disconnect closes the connection on a violation instead of only
+	// dropping the packet.
+	Disconnect bool
+}
+
+func (l Limits) validate() error {
+	if l.MaxFrameSize < 0 || l.MaxArgs < 0 || l.MaxDepth < 0 || l.MaxEventNameLength < 0 ||
+		l.MaxAttachments < 0 || l.EventsPerSecond < 0 || l.EventBurst < 0 {
+		return errors.New("limits must not be negative")
+	}
+	return nil
+}
+
+// limiter enforces Limits on the packets of a connection, before and after
+// they are parsed.
+type limiter struct {
+	limits  Limits
+	hook    func(LimitError)
+	logger  Logger
+	metrics Metrics
+	now     func() time.Time
+
+	// disconnect closes the connection; it is set by the owner of the
+	// engine.io client.
+	disconnect func()
+	// joined, also set by the owner, reports whether the client uses a
+	// namespace. Only those get a bucket: the server can name any number.
+	joined func(ns string) bool
+	// labels bounds the metric labels of a violation, see Client.labels.
+	labels func(ns, event string) (string, string)
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket // by namespace
+}
+
+func newLimiter(limits Limits, hook func(LimitError), logger Logger, metrics Metrics) *limiter {
+	return &limiter{
+		limits:  limits,
+		hook:    hook,
+		logger:  logger,
+		metrics: metrics,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allowRaw checks a packet before it is parsed.
+func (l *limiter) allowRaw(data []byte) bool {
+	if l == nil {
+		return true
+	}
+	if max := l.limits.MaxAttachments; max > 0 {
+		if n := attachments(data); n > max {
+			return l.violate(LimitError{Err: ErrTooManyAttachments, Value: n, Max: max})
+		}
+	}
+	if max := l.limits.MaxDepth; max > 0 {
+		if depth := jsonDepth(data); depth > max {
+			return l.violate(LimitError{Err: ErrTooDeep, Value: depth, Max: max})
+		}
+	}
+	return true
+}
+
+// allow checks a parsed packet.
+func (l *limiter) allow(msg *socketio_v5.Message) bool {
+	if l == nil || msg.Event == nil {
+		return true
+	}
+	event := msg.Event.Name
+	if max := l.limits.MaxEventNameLength; max > 0 && len(event) > max {
+		// Don't report a name that long.
+		return l.violate(LimitError{Err: ErrEventNameTooLong, Namespace: msg.NS, Value: len(event), Max: max})
+	}
+	if max := l.limits.MaxArgs; max > 0 && len(msg.Event.Payloads) > max {
+		return l.violate(LimitError{Err: ErrTooManyArgs, Namespace: msg.NS, Event: event, Value: len(msg.Event.Payloads), Max: max})
+	}
+	if l.limits.EventsPerSecond > 0 && msg.Type == socketio_v5.PacketEvent && !l.take(msg.NS) {
+		return l.violate(LimitError{Err: ErrRateLimited, Namespace: msg.NS, Event: event})
+	}
+	return true
+}
+
+// frameTooLarge reports a websocket frame discarded by the transport.
+func (l *limiter) frameTooLarge(error) {
+	l.violate(LimitError{Err: ErrFrameTooLarge, Max: l.limits.MaxFrameSize})
+}
+
+// violate reports a violation and disconnects if configured; it returns
+// false, the packet is dropped.
+func (l *limiter) violate(report LimitError) bool {
+	l.logger.Warnf("Dropping inbound packet: %v", report)
+	ns, event := report.Namespace, report.Event
+	if l.labels != nil {
+		ns, event = l.labels(ns, event)
+	}
+	l.metrics.EventDropped(ns, event, DropLimit)
+	if l.hook != nil {
+		l.hook(report)
+	}
+	if l.limits.Disconnect && l.disconnect != nil {
+		// Closing the engine.io client waits for the message loop this
+		// runs on.
+		go l.disconnect()
+	}
+	return false
+}
+
+// take takes a token from the namespace's bucket.
+func (l *limiter) take(ns string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[ns]
+	if !ok {
+		if l.joined != nil && !l.joined(ns) {
+			// Dropped as an unknown namespace once past the limits.
+			return true
+		}
+		burst := float64(l.limits.EventBurst)
+		if burst == 0 {
+			burst = math.Max(1, math.Ceil(l.limits.EventsPerSecond))
+		}
+		b = &tokenBucket{tokens: burst, burst: burst, last: l.now()}
+		l.buckets[ns] = b
+	}
+	return b.take(l.now(), l.limits.EventsPerSecond)
+}
+
+// tokenBucket refills at a rate per second up to burst tokens.
+type tokenBucket struct {
+	tokens float64
+	burst  float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(now time.Time, rate float64) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// attachments returns the attachments announced by a BINARY_EVENT or
+// BINARY_ACK header, e.g. 2 for `52-["upload",...]`.
+func attachments(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+	switch socketio_v5.SocketIOPacket(data[0] - '0') {
+	case socketio_v5.PacketBinaryEvent, socketio_v5.PacketBinaryAck:
+	default:
+		return 0
+	}
+	n := 0
+	for _, ch := range data[1:] {
+		if ch < '0' || ch > '9' {
+			break
+		}
+		if n > math.MaxInt32/10 {
+			return math.MaxInt32
+		}
+		n = n*10 + int(ch-'0')
+	}
+	return n
+}
+
+// jsonDepth returns the deepest nesting of JSON arrays and objects in data,
+// skipping strings.
+func jsonDepth(data []byte) int {
+	depth, max := 0, 0
+	inString, escaped := false, false
+	for _, ch := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch ch {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+		case ch == '[' || ch == '{':
+			depth++
+			if depth > max {
+				max = depth
+			}
+		case ch == ']' || ch == '}':
+			depth--
+		}
+	}
+	return max
+}
+
+// LimitError reports an inbound packet exceeding the Limits; it was dropped.
+type LimitError struct {
+	// Err is the exceeded limit: ErrFrameTooLarge, ErrTooManyArgs,
+	// ErrTooDeep, ErrEventNameTooLong, ErrTooManyAttachments or
+	// ErrRateLimited.
+	Err error
+	// Namespace and Event are set when known.
+	Namespace string
+	Event     string
+	// Value is the size, count or depth measured, over Max. It is unset
+	// for ErrFrameTooLarge and ErrRateLimited.
+	Value int
+	Max   int
+}
+
+var (
+	ErrFrameTooLarge      = errors.New("frame too large")
+	ErrTooManyArgs        = errors.New("too many arguments")
+	ErrTooDeep            = errors.New("JSON nested too deep")
+	ErrEventNameTooLong   = errors.New("event name too long")
+	ErrTooManyAttachments = errors.New("too many binary attachments")
+	ErrRateLimited        = errors.New("event rate exceeded")
+)
+
+func (e LimitError) Error() string {
+	s := e.Err.Error()
+	if e.Value > 0 {
+		s += fmt.Sprintf(" (%d > %d)", e.Value, e.Max)
+	} else if e.Max > 0 {
+		s += fmt.Sprintf(" (max %d)", e.Max)
+	}
+	if e.Event != "" {
+		s += fmt.Sprintf(" for %q", e.Event)
+	}
+	if e.Namespace != "" {
+		s += " on " + e.Namespace
+	}
+	return s
+}
+
+func (e LimitError) Unwrap() error {
+	return e.Err
+}
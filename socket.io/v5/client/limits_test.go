@@ -0,0 +1,223 @@
+package socketio_v5_client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+	socketio_v5_parser_default "github.com/maldikhan/go.socket.io/socket.io/v5/parser/default"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+func TestLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := newFakeEngine(ctrl)
+	metrics := mocks.NewMockMetrics(ctrl)
+	reports := make(chan LimitError, 1)
+	client, err := NewClient(
+		WithEngineIOClient(engine),
+		WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+		WithMetrics(metrics),
+		WithLimits(Limits{MaxArgs: 2, MaxDepth: 3, MaxEventNameLength: 8, MaxAttachments: 1, MaxFrameSize: 64}),
+		WithLimitErrorHook(func(report LimitError) { reports <- report }),
+	)
+	require.NoError(t, err)
+	metrics.EXPECT().HandlerDuration(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	received := make(chan string, 1)
+	client.On("ev", func(text string, _ map[string]string) { received <- text })
+
+	tests := []struct {
+		name   string
+		packet string
+		want   LimitError
+		text   string
+		// labels are the namespace and event reported to the metrics.
+		labels [2]string
+	}{
+		{"Args", `2["ev",1,2,3]`, LimitError{Err: ErrTooManyArgs, Namespace: "/", Event: "ev", Value: 3, Max: 2}, `too many arguments (3 > 2) for "ev" on /`, [2]string{"/", "ev"}},
+		{"Depth", `2["ev",{"a":[[1]]}]`, LimitError{Err: ErrTooDeep, Value: 4, Max: 3}, "JSON nested too deep (4 > 3)", [2]string{}},
+		{"Brackets in strings", `2["ev","[[[[",{"a":"]]"}]`, LimitError{}, "", [2]string{}},
+		{"Event name", `2["too-long-name"]`, LimitError{Err: ErrEventNameTooLong, Namespace: "/", Value: 13, Max: 8}, "event name too long (13 > 8) on /", [2]string{"/", ""}},
+		{"Attachments", `52-["ev",{"_placeholder":true,"num":0}]`, LimitError{Err: ErrTooManyAttachments, Value: 2, Max: 1}, "too many binary attachments (2 > 1)", [2]string{}},
+		{"Unhandled event", `2["other-ev",1,2,3]`, LimitError{Err: ErrTooManyArgs, Namespace: "/", Event: "other-ev", Value: 3, Max: 2}, `too many arguments (3 > 2) for "other-ev" on /`, [2]string{"/", OtherLabel}},
+		{"Ack args", `3/chat,1[1,2,3]`, LimitError{Err: ErrTooManyArgs, Namespace: "/chat", Value: 3, Max: 2}, "too many arguments (3 > 2) on /chat", [2]string{OtherLabel, ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.want.Err == nil {
+				engine.fire("message", []byte(tt.packet))
+				select {
+				case <-received:
+				case <-time.After(time.Second):
+					t.Fatal("the event wasn't delivered")
+				}
+				return
+			}
+
+			metrics.EXPECT().EventDropped(tt.labels[0], tt.labels[1], DropLimit)
+			engine.fire("message", []byte(tt.packet))
+			report := <-reports
+			assert.Equal(t, tt.want, report)
+			assert.Equal(t, tt.text, report.Error())
+			assert.ErrorIs(t, report, tt.want.Err)
+			assert.Empty(t, received)
+		})
+	}
+
+	t.Run("Frame size", func(t *testing.T) {
+		metrics.EXPECT().EventDropped("", "", DropLimit)
+		client.limiter.frameTooLarge(errors.New("websocket: frame payload size exceeds limit"))
+		report := <-reports
+		assert.ErrorIs(t, report, ErrFrameTooLarge)
+		assert.Equal(t, "frame too large (max 64)", report.Error())
+	})
+}
+
+func TestLimits_Rate(t *testing.T) {
+	reports := make(chan LimitError, 10)
+	l := newLimiter(Limits{EventsPerSecond: 2}, func(report LimitError) { reports <- report },
+		&utils.DefaultLogger{Level: utils.NONE}, utils.NopMetrics{})
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	allow := func(packet string) bool {
+		msg, err := socketio_v5_parser_default.NewParser().Parse([]byte(packet))
+		require.NoError(t, err)
+		return l.allow(msg)
+	}
+
+	// The burst defaults to the rate, per namespace.
+	assert.True(t, allow(`2["a"]`))
+	assert.True(t, allow(`2["a"]`))
+	assert.False(t, allow(`2["a"]`))
+	assert.True(t, allow(`2/chat,["a"]`))
+	// Acks aren't events.
+	assert.True(t, allow(`31[]`))
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, allow(`2["a"]`))
+	assert.False(t, allow(`2["a"]`))
+
+	assert.Equal(t, LimitError{Err: ErrRateLimited, Namespace: "/", Event: "a"}, <-reports)
+	assert.Equal(t, `event rate exceeded for "a" on /`, (<-reports).Error())
+}
+
+func TestLimits_UnknownNamespaces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Events on namespaces the client never joined must not each get a
+	// rate bucket.
+	flood := func(engine *fakeEngine) {
+		for i := 0; i < 1000; i++ {
+			engine.fire("message", []byte(fmt.Sprintf(`2/bogus-%d,["ev"]`, i)))
+		}
+		engine.fire("message", []byte(`2["ev"]`))
+	}
+
+	t.Run("Client", func(t *testing.T) {
+		engine := newFakeEngine(ctrl)
+		client, err := NewClient(
+			WithEngineIOClient(engine),
+			WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+			WithLimits(Limits{EventsPerSecond: 10}),
+		)
+		require.NoError(t, err)
+
+		flood(engine)
+		client.limiter.mu.Lock()
+		defer client.limiter.mu.Unlock()
+		assert.Equal(t, 1, len(client.limiter.buckets))
+		assert.Contains(t, client.limiter.buckets, "/")
+	})
+
+	t.Run("Manager", func(t *testing.T) {
+		engine := newFakeEngine(ctrl)
+		m, err := NewManager(
+			WithEngineIOClient(engine),
+			WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+			WithLimits(Limits{EventsPerSecond: 10}),
+		)
+		require.NoError(t, err)
+		_, err = m.Socket("/")
+		require.NoError(t, err)
+
+		flood(engine)
+		m.limiter.mu.Lock()
+		defer m.limiter.mu.Unlock()
+		assert.Equal(t, 1, len(m.limiter.buckets))
+		assert.Contains(t, m.limiter.buckets, "/")
+	})
+}
+
+func TestLimits_Disconnect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("Client", func(t *testing.T) {
+		engine := newFakeEngine(ctrl)
+		client, err := NewClient(
+			WithEngineIOClient(engine),
+			WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+			WithLimits(Limits{MaxArgs: 1, Disconnect: true}),
+		)
+		require.NoError(t, err)
+		engine.fire("message", []byte(`0{"sid":"abc"}`))
+		require.True(t, client.Connected())
+
+		closed := make(chan struct{})
+		engine.EXPECT().Close().DoAndReturn(func() error {
+			close(closed)
+			return nil
+		})
+		engine.fire("message", []byte(`2["ev",1,2]`))
+		<-closed
+		assert.Eventually(t, func() bool { return !client.Connected() }, time.Second, time.Millisecond)
+	})
+
+	t.Run("Manager", func(t *testing.T) {
+		engine := newFakeEngine(ctrl)
+		m, err := NewManager(
+			WithEngineIOClient(engine),
+			WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+			WithLimits(Limits{MaxEventNameLength: 4, Disconnect: true}),
+		)
+		require.NoError(t, err)
+		socket, err := m.Socket("/")
+		require.NoError(t, err)
+		engine.fire("message", []byte(`0{"sid":"abc"}`))
+		require.True(t, socket.Connected())
+
+		closed := make(chan struct{})
+		engine.EXPECT().Close().DoAndReturn(func() error {
+			close(closed)
+			return nil
+		})
+		engine.fire("message", []byte(`2["`+strings.Repeat("x", 5)+`"]`))
+		<-closed
+		assert.Eventually(t, func() bool { return !socket.Connected() }, time.Second, time.Millisecond)
+	})
+}
+
+func TestWithLimits(t *testing.T) {
+	_, err := NewClient(WithRawURL("http://localhost"), WithLimits(Limits{MaxArgs: -1}))
+	assert.Error(t, err)
+
+	client, err := NewClient(WithRawURL("http://localhost"), WithLimits(Limits{MaxFrameSize: 1024}))
+	require.NoError(t, err)
+	assert.Equal(t, 1024, client.limiter.limits.MaxFrameSize)
+
+	client, err = NewClient(WithRawURL("http://localhost"))
+	require.NoError(t, err)
+	assert.Nil(t, client.limiter)
+}
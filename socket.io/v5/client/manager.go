@@ -0,0 +1,341 @@
+package socketio_v5_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// Manager owns one engine.io connection and multiplexes sockets for several
+// namespaces over it, like io.Manager in the JS client. The connection is
+// opened by the first socket's Connect and closed when the last socket is
+// closed.
+type Manager struct {
+	engineio EngineIOClient
+	parser   Parser
+	logger   Logger
+	metrics  Metrics
+	limiter  *limiter
+
+	redactPayload bool
+	redaction     *utils.RedactionPolicy
+
+	// defaults holds the options sockets inherit (logger, parser, timer,
+	// metrics, tracing, payload redaction, handler error hook).
+	defaults []ClientOption
+
+	// cancel ends the context the connection runs on.
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	sockets    map[string]*Client
+	requested  map[*Client]bool // sockets whose Connect() is pending or done
+	connecting chan struct{}    // closed once engineio.Connect() returned
+	connectErr error
+	open       bool // engine.io handshake completed
+	started    bool // engineio.Connect() succeeded at least once
+	closed     bool
+}
+
+var ErrManagerClosed = errors.New("manager is closed")
+
+// NewManager builds a manager from the same options as NewClient. Either
+// WithURL / WithRawURL or WithEngineIOClient is required; WithDefaultNamespace
+// is ignored. The logger, parser, timer, metrics, tracing,
+// debug payload and handler error hook options become the defaults of every
+// socket; the WithLimits limits apply to the connection.
+func NewManager(options ...ClientOption) (*Manager, error) {
+	client, err := newInitClient(options)
+	if err != nil {
+		return nil, err
+	}
+	if (client.engineio == nil && client.url == nil) || (client.engineio != nil && client.url != nil) {
+		return nil, fmt.Errorf("either WithURL or WithEngineIOClient must be provided")
+	}
+	return newManager(client)
+}
+
+func newManager(client *InitClient) (*Manager, error) {
+	engineio := client.engineio
+	if engineio == nil {
+		var err error
+		engineio, err = newEngineIOClient(client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m := &Manager{
+		engineio: engineio,
+		parser:   client.parser,
+		logger:   client.logger,
+		metrics:  client.metrics,
+		limiter:  client.limiter,
+
+		redactPayload: client.redactPayload,
+		redaction:     client.redaction,
+		defaults: []ClientOption{
+			WithLogger(client.logger),
+			WithParser(client.parser),
+			WithClock(client.clock),
+			WithTimer(client.timer),
+			WithMetrics(client.metrics),
+			WithTracer(client.tracer),
+			withPropagation(client.propagation),
+			WithDebugPayload(!client.redactPayload),
+			withRedaction(client.redaction),
+			WithHandlerErrorHook(client.handlerErrorHook),
+		},
+		sockets:   make(map[string]*Client),
+		requested: make(map[*Client]bool),
+	}
+
+	if m.limiter != nil {
+		m.limiter.disconnect = func() {
+			_ = m.engineio.Close()
+			m.onEngineClose(nil)
+		}
+		m.limiter.joined = func(ns string) bool {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return m.sockets[ns] != nil
+		}
+		m.limiter.labels = m.labels
+	}
+
+	engineio.On("connect", m.onEngineConnect)
+	engineio.On("message", m.onMessage)
+	engineio.On("close", m.onEngineClose)
+
+	return m, nil
+}
+
+func (m *Manager) payload(data []byte) string {
+	if m.redaction != nil {
+		return m.redaction.Redact(data)
+	}
+	if m.redactPayload {
+		return fmt.Sprintf("[redacted %d bytes]", len(data))
+	}
+	return string(data)
+}
+
+// labels returns the metric labels of a packet, see Client.labels.
+func (m *Manager) labels(ns, event string) (string, string) {
+	m.mu.Lock()
+	c := m.sockets[ns]
+	m.mu.Unlock()
+	if c == nil {
+		return otherLabels(ns, event)
+	}
+	return c.labels(ns, event)
+}
+
+// Socket returns a new socket for the namespace. Options override the
+// manager's defaults; WithURL, WithRawURL and WithEngineIOClient are not
+// allowed. Each namespace can have one open socket.
+func (m *Manager) Socket(ns string, options ...ClientOption) (*Client, error) {
+	client, err := newInitClient(append(append([]ClientOption{}, m.defaults...), options...))
+	if err != nil {
+		return nil, err
+	}
+	if client.url != nil || client.engineio != nil {
+		return nil, errors.New("connection options are not allowed for a manager socket")
+	}
+	client.defaultNsName = &ns
+	return m.socket(client)
+}
+
+func (m *Manager) socket(client *InitClient) (*Client, error) {
+	name := client.namespaceName()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+	if m.sockets[name] != nil {
+		return nil, fmt.Errorf("namespace %s already has a socket", name)
+	}
+
+	client.engineio = m.engineio
+	client.manager = m
+	client.defaultNs = client.namespace(name)
+	m.sockets[name] = client.Client
+
+	return client.Client, nil
+}
+
+// connect opens the shared connection on the first call and sends the
+// socket's namespace CONNECT once the engine.io session is open.
+func (m *Manager) connect(ctx context.Context, c *Client) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrManagerClosed
+	}
+	m.requested[c] = true
+
+	if m.open {
+		m.mu.Unlock()
+		c.connectSocketIO(nil)
+		return nil
+	}
+
+	if m.connecting == nil {
+		// The first Connect() opens the connection. It runs on a context
+		// of the manager, so that no socket's context ends it for the
+		// others; ctx only bounds the handshake.
+		connecting := make(chan struct{})
+		m.connecting = connecting
+		connCtx, cancel := context.WithCancel(context.Background())
+		if m.cancel != nil {
+			m.cancel()
+		}
+		m.cancel = cancel
+		m.mu.Unlock()
+
+		result := make(chan error, 1)
+		go func() { result <- m.engineio.Connect(connCtx) }()
+		var err error
+		select {
+		case err = <-result:
+		case <-ctx.Done():
+			cancel()
+			<-result
+			err = ctx.Err()
+		}
+
+		m.mu.Lock()
+		m.connectErr = err
+		m.started = m.started || err == nil
+		if err != nil {
+			m.connecting = nil
+			delete(m.requested, c)
+		}
+		m.mu.Unlock()
+		close(connecting)
+		return err
+	}
+
+	connecting := m.connecting
+	m.mu.Unlock()
+
+	select {
+	case <-connecting:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.connectErr != nil {
+		delete(m.requested, c)
+	}
+	return m.connectErr
+}
+
+// release detaches a closed socket: it leaves its namespace and closes the
+// connection when it was the last socket.
+func (m *Manager) release(c *Client) error {
+	m.mu.Lock()
+	name := c.defaultNs.name
+	if m.sockets[name] == c {
+		delete(m.sockets, name)
+	}
+	delete(m.requested, c)
+	last := len(m.sockets) == 0 && !m.closed
+	if last {
+		m.closed = true
+	}
+	open, started, cancel := m.open, m.started, m.cancel
+	m.mu.Unlock()
+
+	if open && !last && c.Connected() {
+		if err := c.sendPacket(&socketio_v5.Message{
+			Type: socketio_v5.PacketDisconnect,
+			NS:   name,
+		}); err != nil {
+			utils.WithFields(m.logger, utils.Field{Key: utils.FieldNamespace, Value: name}).Errorf("Can't disconnect namespace %s: %v", name, err)
+		}
+	}
+	c.onEngineClose(nil)
+
+	if !last {
+		return nil
+	}
+	var err error
+	if started {
+		err = m.engineio.Close()
+	}
+	if cancel != nil {
+		cancel()
+	}
+	return err
+}
+
+func (m *Manager) onEngineConnect(_ []byte) {
+	m.mu.Lock()
+	m.open = true
+	requested := make([]*Client, 0, len(m.requested))
+	for c := range m.requested {
+		requested = append(requested, c)
+	}
+	m.mu.Unlock()
+
+	for _, c := range requested {
+		c.connectSocketIO(nil)
+	}
+}
+
+func (m *Manager) onEngineClose(_ []byte) {
+	m.mu.Lock()
+	m.open = false
+	m.connecting = nil
+	sockets := make([]*Client, 0, len(m.sockets))
+	for _, c := range m.sockets {
+		sockets = append(sockets, c)
+	}
+	m.mu.Unlock()
+
+	for _, c := range sockets {
+		c.onEngineClose(nil)
+	}
+}
+
+// onMessage parses a packet once and routes it to the socket of its
+// namespace.
+func (m *Manager) onMessage(data []byte) {
+	m.logger.Debugf("socketio receive %s", m.payload(data))
+
+	if !m.limiter.allowRaw(data) {
+		return
+	}
+	msg, err := m.parser.Parse(data)
+	if err != nil {
+		m.logger.Errorf("Can't parse message: %v", err)
+		m.metrics.EventDropped("", "", DropParseError)
+		return
+	}
+	if !m.limiter.allow(msg) {
+		return
+	}
+
+	m.mu.Lock()
+	c := m.sockets[msg.NS]
+	m.mu.Unlock()
+
+	if c == nil {
+		utils.WithFields(m.logger, utils.Field{Key: utils.FieldNamespace, Value: msg.NS}, packetTypeField(msg.Type)).
+			Warnf("Received %v for unknown namespace: %s", msg.Type, msg.NS)
+		ns, event := otherLabels(msg.NS, eventName(msg))
+		m.metrics.EventDropped(ns, event, DropUnknownNamespace)
+		return
+	}
+	c.handleMessage(msg)
+}
@@ -0,0 +1,238 @@
+package socketio_v5_client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// fakeEngine records the handlers a manager registers and the packets sent.
+type fakeEngine struct {
+	*mocks.MockEngineIOClient
+
+	mu       sync.Mutex
+	handlers map[string]func([]byte)
+	sent     []string
+}
+
+func newFakeEngine(ctrl *gomock.Controller) *fakeEngine {
+	e := &fakeEngine{
+		MockEngineIOClient: mocks.NewMockEngineIOClient(ctrl),
+		handlers:           make(map[string]func([]byte)),
+	}
+	e.EXPECT().On(gomock.Any(), gomock.Any()).DoAndReturn(func(event string, handler func([]byte)) {
+		e.mu.Lock()
+		e.handlers[event] = handler
+		e.mu.Unlock()
+	}).AnyTimes()
+	e.EXPECT().Send(gomock.Any()).DoAndReturn(func(data []byte) error {
+		e.mu.Lock()
+		e.sent = append(e.sent, string(data))
+		e.mu.Unlock()
+		return nil
+	}).AnyTimes()
+	return e
+}
+
+func (e *fakeEngine) fire(event string, data []byte) {
+	e.mu.Lock()
+	handler := e.handlers[event]
+	e.mu.Unlock()
+	handler(data)
+}
+
+func (e *fakeEngine) packets() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.sent...)
+}
+
+func newTestManager(t *testing.T, engine *fakeEngine) *Manager {
+	t.Helper()
+	logger := mocks.NewMockLogger(gomock.NewController(t))
+	logger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	m, err := NewManager(WithEngineIOClient(engine), WithLogger(logger))
+	require.NoError(t, err)
+	return m
+}
+
+func TestManager_Multiplexing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := newFakeEngine(ctrl)
+	m := newTestManager(t, engine)
+
+	root, err := m.Socket("/")
+	require.NoError(t, err)
+	chat, err := m.Socket("/chat")
+	require.NoError(t, err)
+
+	_, err = m.Socket("/chat")
+	assert.Error(t, err, "one socket per namespace")
+	_, err = m.Socket("/other", WithRawURL("http://localhost"))
+	assert.Error(t, err, "connection options are rejected")
+
+	// The engine connects once; the second socket waits for it.
+	engine.EXPECT().Connect(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+		engine.fire("connect", nil)
+		return nil
+	}).Times(1)
+
+	require.NoError(t, root.Connect(context.Background()))
+	require.NoError(t, chat.Connect(context.Background()))
+	assert.ElementsMatch(t, []string{"0", "0/chat,"}, engine.packets())
+
+	// Packets are routed by namespace.
+	chatEvents := make(chan string, 1)
+	rootEvents := make(chan string, 1)
+	chat.On("msg", func(msg string) { chatEvents <- msg })
+	root.On("msg", func(msg string) { rootEvents <- msg })
+
+	engine.fire("message", []byte(`0/chat,{"sid":"chat-sid"}`))
+	engine.fire("message", []byte(`2/chat,["msg","hello"]`))
+	assert.Equal(t, "hello", <-chatEvents)
+	assert.True(t, chat.Connected())
+	assert.Equal(t, "chat-sid", chat.ID())
+	assert.False(t, root.Connected())
+	assert.Empty(t, rootEvents)
+
+	// Closing one socket leaves its namespace but keeps the connection.
+	require.NoError(t, chat.Close())
+	assert.Contains(t, engine.packets(), "1/chat,")
+	assert.False(t, chat.Connected())
+
+	// The namespace can be reused once released.
+	chat2, err := m.Socket("/chat")
+	require.NoError(t, err)
+	require.NoError(t, chat2.Connect(context.Background()))
+	assert.Equal(t, "0/chat,", engine.packets()[len(engine.packets())-1])
+
+	// An engine close marks every socket disconnected.
+	engine.fire("message", []byte(`0{"sid":"root-sid"}`))
+	assert.True(t, root.Connected())
+	engine.fire("close", nil)
+	assert.False(t, root.Connected())
+
+	// The last socket closes the engine.
+	require.NoError(t, chat2.Close())
+	engine.EXPECT().Close().Return(nil).Times(1)
+	require.NoError(t, root.Close())
+
+	_, err = m.Socket("/")
+	assert.ErrorIs(t, err, ErrManagerClosed)
+	assert.ErrorIs(t, root.Connect(context.Background()), ErrManagerClosed)
+}
+
+func TestManager_ConnectError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := newFakeEngine(ctrl)
+	m := newTestManager(t, engine)
+
+	socket, err := m.Socket("/")
+	require.NoError(t, err)
+
+	connectErr := errors.New("dial failed")
+	engine.EXPECT().Connect(gomock.Any()).Return(connectErr)
+	assert.ErrorIs(t, socket.Connect(context.Background()), connectErr)
+
+	// A failed connect can be retried.
+	engine.EXPECT().Connect(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+		engine.fire("connect", nil)
+		return nil
+	})
+	require.NoError(t, socket.Connect(context.Background()))
+	assert.Equal(t, []string{"0"}, engine.packets())
+}
+
+func TestNewClient_DedicatedConnection(t *testing.T) {
+	root, err := NewClient(WithRawURL("http://localhost"))
+	require.NoError(t, err)
+	chat, err := NewClient(WithRawURL("http://localhost"), WithDefaultNamespace("/chat"))
+	require.NoError(t, err)
+	assert.Nil(t, root.manager)
+	assert.NotSame(t, root.engineio, chat.engineio)
+}
+
+func TestManager_ConnectionContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := newFakeEngine(ctrl)
+	m := newTestManager(t, engine)
+	root, err := m.Socket("/")
+	require.NoError(t, err)
+	chat, err := m.Socket("/chat")
+	require.NoError(t, err)
+
+	var connCtx context.Context
+	engine.EXPECT().Connect(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+		connCtx = ctx
+		engine.fire("connect", nil)
+		return nil
+	})
+	rootCtx, cancelRoot := context.WithCancel(context.Background())
+	require.NoError(t, root.Connect(rootCtx))
+	require.NoError(t, chat.Connect(context.Background()))
+
+	// The connection doesn't end with the context of the socket that
+	// opened it.
+	cancelRoot()
+	assert.NoError(t, connCtx.Err())
+
+	require.NoError(t, root.Close())
+	assert.NoError(t, connCtx.Err())
+
+	// It ends when the last socket is released.
+	engine.EXPECT().Close().Return(nil)
+	require.NoError(t, chat.Close())
+	assert.ErrorIs(t, connCtx.Err(), context.Canceled)
+}
+
+func TestManager_ConnectCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := newFakeEngine(ctrl)
+	m := newTestManager(t, engine)
+	socket, err := m.Socket("/")
+	require.NoError(t, err)
+
+	// The caller's context still bounds the handshake.
+	engine.EXPECT().Connect(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, socket.Connect(ctx), context.DeadlineExceeded)
+}
+
+func TestManager_RedactionPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	policy := &utils.RedactionPolicy{Keys: []string{"secret"}}
+	m, err := NewManager(WithEngineIOClient(newFakeEngine(ctrl)), WithRedactionPolicy(policy))
+	require.NoError(t, err)
+	socket, err := m.Socket("/")
+	require.NoError(t, err)
+
+	packet := []byte(`2["ev",{"secret":1}]`)
+	assert.Equal(t, `2["ev",{"secret":"[redacted]"}]`, m.payload(packet))
+	assert.Equal(t, m.payload(packet), socket.payload(packet))
+}
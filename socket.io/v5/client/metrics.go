@@ -0,0 +1,65 @@
+package socketio_v5_client
+
+import (
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// Reasons reported with Metrics.EventDropped.
+const (
+	DropParseError       = "parse error"
+	DropUnknownNamespace = "unknown namespace"
+	DropNoHandler        = "no handler"
+	DropNoAckCallback    = "no ack callback"
+	DropMiddleware       = "middleware"
+	DropLimit            = "limit"
+)
+
+// OtherLabel is the namespace or event label reported for a namespace the
+// client doesn't use or an event it has no handler for. The server picks
+// those names, and a collecting Metrics keeps a series for each.
+const OtherLabel = "other"
+
+// labels returns the metric labels of a namespace and event, with the names
+// the client doesn't know replaced by OtherLabel.
+func (c *Client) labels(ns, event string) (string, string) {
+	c.mutex.RLock()
+	n := c.namespaces[ns]
+	c.mutex.RUnlock()
+	if n == nil {
+		return otherLabels(ns, event)
+	}
+	if event != "" && !n.handles(event) {
+		event = OtherLabel
+	}
+	return ns, event
+}
+
+// otherLabels returns the labels of a namespace the client doesn't use; an
+// empty name stays empty.
+func otherLabels(ns, event string) (string, string) {
+	if ns != "" {
+		ns = OtherLabel
+	}
+	if event != "" {
+		event = OtherLabel
+	}
+	return ns, event
+}
+
+// meter returns the metrics, tolerating a Client built without NewClient
+// (e.g. in unit tests).
+func (c *Client) meter() Metrics {
+	if c.metrics == nil {
+		return utils.NopMetrics{}
+	}
+	return c.metrics
+}
+
+// eventName returns the event name of a packet, or "" if it has none.
+func eventName(msg *socketio_v5.Message) string {
+	if msg.Event == nil {
+		return ""
+	}
+	return msg.Event.Name
+}
@@ -0,0 +1,137 @@
+package socketio_v5_client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+)
+
+func TestClientMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEngineIO := mocks.NewMockEngineIOClient(ctrl)
+	mockParser := mocks.NewMockParser(ctrl)
+	mockLogger := mocks.NewMockLogger(ctrl)
+	mockTimer := mocks.NewMockTimer(ctrl)
+	mockMetrics := mocks.NewMockMetrics(ctrl)
+	mockLogger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	client := &Client{
+		engineio:     mockEngineIO,
+		parser:       mockParser,
+		logger:       mockLogger,
+		timer:        mockTimer,
+		metrics:      mockMetrics,
+		ctx:          context.Background(),
+		namespaces:   make(map[string]*namespace),
+		ackCallbacks: make(map[int]func([]interface{})),
+	}
+	ns := client.namespace("/")
+
+	// Names the client doesn't know are reported as OtherLabel.
+	t.Run("Dropped events", func(t *testing.T) {
+		mockMetrics.EXPECT().EventDropped("/", OtherLabel, DropNoHandler)
+		client.dispatchMessage(&socketio_v5.Message{
+			Type:  socketio_v5.PacketEvent,
+			NS:    "/",
+			Event: &socketio_v5.Event{Name: "unknown"},
+		})
+
+		mockMetrics.EXPECT().EventDropped(OtherLabel, OtherLabel, DropUnknownNamespace)
+		client.dispatchMessage(&socketio_v5.Message{
+			Type:  socketio_v5.PacketEvent,
+			NS:    "/admin",
+			Event: &socketio_v5.Event{Name: "news"},
+		})
+
+		mockMetrics.EXPECT().EventDropped("/", "", DropNoAckCallback)
+		client.handleAck("/", &socketio_v5.Event{}, 42)
+
+		mockMetrics.EXPECT().EventDropped(OtherLabel, "", DropNoAckCallback)
+		client.handleAck("/admin", &socketio_v5.Event{}, 43)
+	})
+
+	t.Run("Handler duration", func(t *testing.T) {
+		done := make(chan struct{})
+		mockMetrics.EXPECT().HandlerDuration("/", "news", gomock.Any()).Do(
+			func(string, string, time.Duration) { close(done) })
+
+		ns.handlers["news"] = []func([]interface{}){func([]interface{}) {}}
+		client.handleEvent(ns, &socketio_v5.Event{Name: "news"})
+		<-done
+	})
+
+	t.Run("Catch-all handler duration", func(t *testing.T) {
+		done := make(chan struct{})
+		mockMetrics.EXPECT().HandlerDuration("/", OtherLabel, gomock.Any()).Do(
+			func(string, string, time.Duration) { close(done) })
+
+		ns.anyHandlers = []func(string, []interface{}){func(string, []interface{}) {}}
+		client.handleEvent(ns, &socketio_v5.Event{Name: "random-42"})
+		<-done
+		ns.anyHandlers = nil
+	})
+
+	t.Run("Ack round trip", func(t *testing.T) {
+		mockParser.EXPECT().WrapCallback(gomock.Any()).Return(func([]interface{}) {})
+		mockParser.EXPECT().Serialize(gomock.Any()).Return([]byte("21[]"), nil)
+		mockEngineIO.EXPECT().Send(gomock.Any()).Return(nil)
+
+		acked := make(chan struct{})
+		gomock.InOrder(
+			mockMetrics.EXPECT().PendingAcks(1),
+			mockMetrics.EXPECT().AckRoundTrip("/", gomock.Any()),
+			mockMetrics.EXPECT().PendingAcks(-1).Do(func(int) { close(acked) }),
+		)
+
+		require.NoError(t, client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{
+			Type:  socketio_v5.PacketEvent,
+			NS:    "/",
+			Event: &socketio_v5.Event{Name: "ping"},
+		}, func() {}, nil, nil))
+
+		// The ack callback is timed under the emitted event's name.
+		timed := make(chan struct{})
+		mockMetrics.EXPECT().HandlerDuration("/", OtherLabel, gomock.Any()).Do(
+			func(string, string, time.Duration) { close(timed) })
+
+		client.handleAck("/", &socketio_v5.Event{}, client.ackCounter)
+		<-acked
+		<-timed
+	})
+
+	t.Run("Ack timeout", func(t *testing.T) {
+		timeoutCh := make(chan time.Time)
+		mockTimer.EXPECT().After(time.Second).Return(timeoutCh)
+		mockParser.EXPECT().Serialize(gomock.Any()).Return([]byte("22[]"), nil)
+		mockEngineIO.EXPECT().Send(gomock.Any()).Return(nil)
+
+		timedOut := make(chan struct{})
+		gomock.InOrder(
+			mockMetrics.EXPECT().PendingAcks(1),
+			mockMetrics.EXPECT().PendingAcks(-1),
+		)
+
+		timeout := time.Second
+		require.NoError(t, client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{
+			Type:  socketio_v5.PacketEvent,
+			NS:    "/",
+			Event: &socketio_v5.Event{Name: "ping"},
+		}, nil, &timeout, func() { close(timedOut) }))
+
+		timeoutCh <- time.Now()
+		<-timedOut
+		client.mutex.Lock()
+		assert.Empty(t, client.ackCallbacks)
+		client.mutex.Unlock()
+	})
+}
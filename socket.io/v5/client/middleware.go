@@ -0,0 +1,75 @@
+package socketio_v5_client
+
+import (
+	"context"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
+
+// PacketHandler processes a socket.io packet at the end of a middleware chain.
+type PacketHandler func(ctx context.Context, msg *socketio_v5.Message) error
+
+// Middleware intercepts socket.io packets. It may inspect or mutate msg, pass
+// it (or a replacement) on by calling next, drop it by returning nil without
+// calling next, or reject it by returning an error. Outgoing errors are
+// returned from Emit; incoming errors are logged. An emit that is dropped or
+// rejected is not acknowledged: its ack callback is forgotten, and a dropped
+// emit runs its timeout callback, if any, right away.
+type Middleware func(ctx context.Context, msg *socketio_v5.Message, next PacketHandler) error
+
+// UseIncoming appends middleware applied to every packet received from the
+// server, before it is dispatched to handlers or ack callbacks. The sockets
+// of a Manager each have their own chains, so middleware added to
+// manager.Socket("/chat") only sees the packets of "/chat".
+func (c *Client) UseIncoming(middleware ...Middleware) {
+	c.mutex.Lock()
+	c.incoming = append(c.incoming, middleware...)
+	c.mutex.Unlock()
+}
+
+// UseOutgoing appends middleware applied to every packet sent to the server
+// (CONNECT and events) before it is serialized.
+func (c *Client) UseOutgoing(middleware ...Middleware) {
+	c.mutex.Lock()
+	c.outgoing = append(c.outgoing, middleware...)
+	c.mutex.Unlock()
+}
+
+// runMiddleware calls the chain in order and ends with final.
+func runMiddleware(ctx context.Context, msg *socketio_v5.Message, chain []Middleware, final PacketHandler) error {
+	if len(chain) == 0 {
+		return final(ctx, msg)
+	}
+	return chain[0](ctx, msg, func(ctx context.Context, msg *socketio_v5.Message) error {
+		return runMiddleware(ctx, msg, chain[1:], final)
+	})
+}
+
+// handleIncoming runs the incoming chain around final.
+func (c *Client) handleIncoming(msg *socketio_v5.Message, final func(*socketio_v5.Message)) error {
+	c.mutex.RLock()
+	ctx := c.ctx
+	chain := c.incoming
+	c.mutex.RUnlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return runMiddleware(ctx, msg, chain, func(_ context.Context, msg *socketio_v5.Message) error {
+		final(msg)
+		return nil
+	})
+}
+
+// handleOutgoing runs the outgoing chain around final.
+func (c *Client) handleOutgoing(msg *socketio_v5.Message, final PacketHandler) error {
+	c.mutex.RLock()
+	ctx := c.ctx
+	chain := c.outgoing
+	c.mutex.RUnlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return runMiddleware(ctx, msg, chain, final)
+}
@@ -0,0 +1,232 @@
+package socketio_v5_client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+)
+
+func TestOutgoingMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEngineIO := mocks.NewMockEngineIOClient(ctrl)
+	mockParser := mocks.NewMockParser(ctrl)
+
+	client := &Client{
+		ctx:      context.Background(),
+		engineio: mockEngineIO,
+		parser:   mockParser,
+	}
+
+	var order []string
+	client.UseOutgoing(func(ctx context.Context, msg *socketio_v5.Message, next PacketHandler) error {
+		order = append(order, "first")
+		return next(ctx, msg)
+	})
+	client.UseOutgoing(func(ctx context.Context, msg *socketio_v5.Message, next PacketHandler) error {
+		order = append(order, "second")
+		if msg.Event != nil {
+			msg.Event.Payloads = append(msg.Event.Payloads, "tenant-1")
+		}
+		return next(ctx, msg)
+	})
+
+	t.Run("Mutate", func(t *testing.T) {
+		order = nil
+		mockParser.EXPECT().Serialize(&socketio_v5.Message{
+			Type:  socketio_v5.PacketEvent,
+			NS:    "/admin",
+			Event: &socketio_v5.Event{Name: "evt", Payloads: []interface{}{"tenant-1"}},
+		}).Return([]byte("packet"), nil)
+		mockEngineIO.EXPECT().Send([]byte("packet")).Return(nil)
+
+		err := client.sendPacket(&socketio_v5.Message{
+			Type:  socketio_v5.PacketEvent,
+			NS:    "/admin",
+			Event: &socketio_v5.Event{Name: "evt"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, order)
+	})
+
+	t.Run("Drop and reject", func(t *testing.T) {
+		rejected := errors.New("schema violation")
+		client.UseOutgoing(func(ctx context.Context, msg *socketio_v5.Message, next PacketHandler) error {
+			switch msg.Event.Name {
+			case "drop":
+				return nil
+			case "reject":
+				return rejected
+			}
+			return next(ctx, msg)
+		})
+
+		// Neither packet reaches the parser or the engine.
+		assert.NoError(t, client.sendPacket(&socketio_v5.Message{
+			Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{Name: "drop"},
+		}))
+		assert.ErrorIs(t, client.sendPacket(&socketio_v5.Message{
+			Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{Name: "reject"},
+		}), rejected)
+	})
+}
+
+func TestOutgoingMiddleware_Acks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParser := mocks.NewMockParser(ctrl)
+	mockTimer := mocks.NewMockTimer(ctrl)
+	mockMetrics := mocks.NewMockMetrics(ctrl)
+
+	client := &Client{
+		ctx:          context.Background(),
+		parser:       mockParser,
+		timer:        mockTimer,
+		metrics:      mockMetrics,
+		ackCallbacks: make(map[int]func([]interface{})),
+	}
+	rejected := errors.New("schema violation")
+	client.UseOutgoing(func(ctx context.Context, msg *socketio_v5.Message, next PacketHandler) error {
+		if msg.Event.Name == "reject" {
+			return rejected
+		}
+		return nil
+	})
+	released := func(t *testing.T) {
+		t.Helper()
+		client.mutex.RLock()
+		defer client.mutex.RUnlock()
+		assert.Empty(t, client.ackCallbacks)
+		assert.Empty(t, client.ackSent)
+	}
+
+	t.Run("Rejected", func(t *testing.T) {
+		mockParser.EXPECT().WrapCallback(gomock.Any()).Return(func([]interface{}) {})
+		gomock.InOrder(
+			mockMetrics.EXPECT().PendingAcks(1),
+			mockMetrics.EXPECT().PendingAcks(-1),
+		)
+		err := client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{
+			Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{Name: "reject"},
+		}, func() { t.Error("ack callback called") }, nil, nil)
+		assert.ErrorIs(t, err, rejected)
+		released(t)
+	})
+
+	t.Run("Dropped", func(t *testing.T) {
+		mockParser.EXPECT().WrapCallback(gomock.Any()).Return(func([]interface{}) {})
+		gomock.InOrder(
+			mockMetrics.EXPECT().PendingAcks(1),
+			mockMetrics.EXPECT().PendingAcks(-1),
+		)
+		err := client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{
+			Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{Name: "drop"},
+		}, func() { t.Error("ack callback called") }, nil, nil)
+		assert.NoError(t, err)
+		released(t)
+	})
+
+	t.Run("Dropped with timeout", func(t *testing.T) {
+		mockTimer.EXPECT().After(time.Minute).Return(make(chan time.Time)).MaxTimes(1)
+		gomock.InOrder(
+			mockMetrics.EXPECT().PendingAcks(1),
+			mockMetrics.EXPECT().PendingAcks(-1),
+		)
+		timedOut := 0
+		timeout := time.Minute
+		err := client.sendPacketWithAckTimeout(context.Background(), &socketio_v5.Message{
+			Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{Name: "drop"},
+		}, nil, &timeout, func() { timedOut++ })
+		assert.NoError(t, err)
+		assert.Equal(t, 1, timedOut, "the timeout callback runs once, right away")
+		released(t)
+	})
+}
+
+func TestIncomingMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockParser := mocks.NewMockParser(ctrl)
+	mockLogger := mocks.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	received := make(chan string, 1)
+	ns := &namespace{
+		name: "/",
+		handlers: map[string][]func([]interface{}){
+			"renamed": {func([]interface{}) { received <- "renamed" }},
+			"evt":     {func([]interface{}) { received <- "evt" }},
+		},
+	}
+	client := &Client{
+		parser:     mockParser,
+		logger:     mockLogger,
+		namespaces: map[string]*namespace{"/": ns},
+	}
+
+	var order []string
+	client.UseIncoming(func(ctx context.Context, msg *socketio_v5.Message, next PacketHandler) error {
+		order = append(order, "first")
+		if msg.Event.Name == "drop" {
+			return nil
+		}
+		if msg.Event.Name == "fail" {
+			return errors.New("fail")
+		}
+		return next(ctx, msg)
+	})
+	client.UseIncoming(func(ctx context.Context, msg *socketio_v5.Message, next PacketHandler) error {
+		order = append(order, "second")
+		msg.Event.Name = "renamed"
+		return next(ctx, msg)
+	})
+
+	t.Run("Mutate", func(t *testing.T) {
+		mockParser.EXPECT().Parse([]byte("data")).Return(&socketio_v5.Message{
+			Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{Name: "evt"},
+		}, nil)
+		client.onMessage([]byte("data"))
+
+		select {
+		case name := <-received:
+			assert.Equal(t, "renamed", name)
+		case <-time.After(time.Second):
+			t.Fatal("handler not called")
+		}
+		assert.Equal(t, []string{"first", "second"}, order)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		order = nil
+		mockParser.EXPECT().Parse([]byte("data")).Return(&socketio_v5.Message{
+			Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{Name: "drop"},
+		}, nil)
+		client.onMessage([]byte("data"))
+		assert.Equal(t, []string{"first"}, order)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockParser.EXPECT().Parse([]byte("data")).Return(&socketio_v5.Message{
+			Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{Name: "fail"},
+		}, nil)
+		mockLogger.EXPECT().Errorf("Incoming middleware error: %v", gomock.Any())
+		client.onMessage([]byte("data"))
+	})
+
+	select {
+	case name := <-received:
+		t.Fatalf("unexpected handler call: %s", name)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -10,6 +10,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
 	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
 )
 
@@ -80,6 +81,43 @@ func (mr *MockParserMockRecorder) WrapCallback(callback interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WrapCallback", reflect.TypeOf((*MockParser)(nil).WrapCallback), callback)
 }
 
+// MockErrorReportingParser is a mock of ErrorReportingParser interface.
+type MockErrorReportingParser struct {
+	ctrl     *gomock.Controller
+	recorder *MockErrorReportingParserMockRecorder
+}
+
+// MockErrorReportingParserMockRecorder is the mock recorder for MockErrorReportingParser.
+type MockErrorReportingParserMockRecorder struct {
+	mock *MockErrorReportingParser
+}
+
+// NewMockErrorReportingParser creates a new mock instance.
+func NewMockErrorReportingParser(ctrl *gomock.Controller) *MockErrorReportingParser {
+	mock := &MockErrorReportingParser{ctrl: ctrl}
+	mock.recorder = &MockErrorReportingParserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockErrorReportingParser) EXPECT() *MockErrorReportingParserMockRecorder {
+	return m.recorder
+}
+
+// WrapCallbackWithError mocks base method.
+func (m *MockErrorReportingParser) WrapCallbackWithError(callback interface{}) func([]interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WrapCallbackWithError", callback)
+	ret0, _ := ret[0].(func([]interface{}) error)
+	return ret0
+}
+
+// WrapCallbackWithError indicates an expected call of WrapCallbackWithError.
+func (mr *MockErrorReportingParserMockRecorder) WrapCallbackWithError(callback interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WrapCallbackWithError", reflect.TypeOf((*MockErrorReportingParser)(nil).WrapCallbackWithError), callback)
+}
+
 // MockEngineIOClient is a mock of EngineIOClient interface.
 type MockEngineIOClient struct {
 	ctrl     *gomock.Controller
@@ -131,6 +169,20 @@ func (mr *MockEngineIOClientMockRecorder) Connect(ctx interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockEngineIOClient)(nil).Connect), ctx)
 }
 
+// ID mocks base method.
+func (m *MockEngineIOClient) ID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ID indicates an expected call of ID.
+func (mr *MockEngineIOClientMockRecorder) ID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ID", reflect.TypeOf((*MockEngineIOClient)(nil).ID))
+}
+
 // On mocks base method.
 func (m *MockEngineIOClient) On(event string, handler func([]byte)) {
 	m.ctrl.T.Helper()
@@ -157,6 +209,115 @@ func (mr *MockEngineIOClientMockRecorder) Send(message interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockEngineIOClient)(nil).Send), message)
 }
 
+// State mocks base method.
+func (m *MockEngineIOClient) State() engineio_v4.State {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(engineio_v4.State)
+	return ret0
+}
+
+// State indicates an expected call of State.
+func (mr *MockEngineIOClientMockRecorder) State() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockEngineIOClient)(nil).State))
+}
+
+// Transport mocks base method.
+func (m *MockEngineIOClient) Transport() engineio_v4.EngineIOTransport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transport")
+	ret0, _ := ret[0].(engineio_v4.EngineIOTransport)
+	return ret0
+}
+
+// Transport indicates an expected call of Transport.
+func (mr *MockEngineIOClientMockRecorder) Transport() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transport", reflect.TypeOf((*MockEngineIOClient)(nil).Transport))
+}
+
+// WatchState mocks base method.
+func (m *MockEngineIOClient) WatchState() (engineio_v4.State, <-chan struct{}) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchState")
+	ret0, _ := ret[0].(engineio_v4.State)
+	ret1, _ := ret[1].(<-chan struct{})
+	return ret0, ret1
+}
+
+// WatchState indicates an expected call of WatchState.
+func (mr *MockEngineIOClientMockRecorder) WatchState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchState", reflect.TypeOf((*MockEngineIOClient)(nil).WatchState))
+}
+
+// MockEngineIOInspector is a mock of EngineIOInspector interface.
+type MockEngineIOInspector struct {
+	ctrl     *gomock.Controller
+	recorder *MockEngineIOInspectorMockRecorder
+}
+
+// MockEngineIOInspectorMockRecorder is the mock recorder for MockEngineIOInspector.
+type MockEngineIOInspectorMockRecorder struct {
+	mock *MockEngineIOInspector
+}
+
+// NewMockEngineIOInspector creates a new mock instance.
+func NewMockEngineIOInspector(ctrl *gomock.Controller) *MockEngineIOInspector {
+	mock := &MockEngineIOInspector{ctrl: ctrl}
+	mock.recorder = &MockEngineIOInspectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEngineIOInspector) EXPECT() *MockEngineIOInspectorMockRecorder {
+	return m.recorder
+}
+
+// Buffered mocks base method.
+func (m *MockEngineIOInspector) Buffered() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Buffered")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Buffered indicates an expected call of Buffered.
+func (mr *MockEngineIOInspectorMockRecorder) Buffered() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Buffered", reflect.TypeOf((*MockEngineIOInspector)(nil).Buffered))
+}
+
+// Handshake mocks base method.
+func (m *MockEngineIOInspector) Handshake() *engineio_v4.HandshakeResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handshake")
+	ret0, _ := ret[0].(*engineio_v4.HandshakeResponse)
+	return ret0
+}
+
+// Handshake indicates an expected call of Handshake.
+func (mr *MockEngineIOInspectorMockRecorder) Handshake() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handshake", reflect.TypeOf((*MockEngineIOInspector)(nil).Handshake))
+}
+
+// Upgrade mocks base method.
+func (m *MockEngineIOInspector) Upgrade() (engineio_v4.EngineIOTransport, engineio_v4.EngineIOTransport) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upgrade")
+	ret0, _ := ret[0].(engineio_v4.EngineIOTransport)
+	ret1, _ := ret[1].(engineio_v4.EngineIOTransport)
+	return ret0, ret1
+}
+
+// Upgrade indicates an expected call of Upgrade.
+func (mr *MockEngineIOInspectorMockRecorder) Upgrade() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upgrade", reflect.TypeOf((*MockEngineIOInspector)(nil).Upgrade))
+}
+
 // MockLogger is a mock of Logger interface.
 type MockLogger struct {
 	ctrl     *gomock.Controller
@@ -284,3 +445,239 @@ func (mr *MockTimerMockRecorder) After(d interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "After", reflect.TypeOf((*MockTimer)(nil).After), d)
 }
+
+// MockMetrics is a mock of Metrics interface.
+type MockMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsMockRecorder
+}
+
+// MockMetricsMockRecorder is the mock recorder for MockMetrics.
+type MockMetricsMockRecorder struct {
+	mock *MockMetrics
+}
+
+// NewMockMetrics creates a new mock instance.
+func NewMockMetrics(ctrl *gomock.Controller) *MockMetrics {
+	mock := &MockMetrics{ctrl: ctrl}
+	mock.recorder = &MockMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
+	return m.recorder
+}
+
+// AckRoundTrip mocks base method.
+func (m *MockMetrics) AckRoundTrip(namespace string, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AckRoundTrip", namespace, duration)
+}
+
+// AckRoundTrip indicates an expected call of AckRoundTrip.
+func (mr *MockMetricsMockRecorder) AckRoundTrip(namespace, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckRoundTrip", reflect.TypeOf((*MockMetrics)(nil).AckRoundTrip), namespace, duration)
+}
+
+// EventDropped mocks base method.
+func (m *MockMetrics) EventDropped(namespace, event, reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EventDropped", namespace, event, reason)
+}
+
+// EventDropped indicates an expected call of EventDropped.
+func (mr *MockMetricsMockRecorder) EventDropped(namespace, event, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventDropped", reflect.TypeOf((*MockMetrics)(nil).EventDropped), namespace, event, reason)
+}
+
+// HandlerDuration mocks base method.
+func (m *MockMetrics) HandlerDuration(namespace, event string, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandlerDuration", namespace, event, duration)
+}
+
+// HandlerDuration indicates an expected call of HandlerDuration.
+func (mr *MockMetricsMockRecorder) HandlerDuration(namespace, event, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandlerDuration", reflect.TypeOf((*MockMetrics)(nil).HandlerDuration), namespace, event, duration)
+}
+
+// PacketReceived mocks base method.
+func (m *MockMetrics) PacketReceived(transport, packetType string, bytes int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PacketReceived", transport, packetType, bytes)
+}
+
+// PacketReceived indicates an expected call of PacketReceived.
+func (mr *MockMetricsMockRecorder) PacketReceived(transport, packetType, bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacketReceived", reflect.TypeOf((*MockMetrics)(nil).PacketReceived), transport, packetType, bytes)
+}
+
+// PacketSent mocks base method.
+func (m *MockMetrics) PacketSent(transport, packetType string, bytes int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PacketSent", transport, packetType, bytes)
+}
+
+// PacketSent indicates an expected call of PacketSent.
+func (mr *MockMetricsMockRecorder) PacketSent(transport, packetType, bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacketSent", reflect.TypeOf((*MockMetrics)(nil).PacketSent), transport, packetType, bytes)
+}
+
+// PendingAcks mocks base method.
+func (m *MockMetrics) PendingAcks(delta int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PendingAcks", delta)
+}
+
+// PendingAcks indicates an expected call of PendingAcks.
+func (mr *MockMetricsMockRecorder) PendingAcks(delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingAcks", reflect.TypeOf((*MockMetrics)(nil).PendingAcks), delta)
+}
+
+// PollingRequest mocks base method.
+func (m *MockMetrics) PollingRequest(method string, duration time.Duration, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PollingRequest", method, duration, err)
+}
+
+// PollingRequest indicates an expected call of PollingRequest.
+func (mr *MockMetricsMockRecorder) PollingRequest(method, duration, err interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PollingRequest", reflect.TypeOf((*MockMetrics)(nil).PollingRequest), method, duration, err)
+}
+
+// Upgrade mocks base method.
+func (m *MockMetrics) Upgrade(from, to string, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Upgrade", from, to, err)
+}
+
+// Upgrade indicates an expected call of Upgrade.
+func (mr *MockMetricsMockRecorder) Upgrade(from, to, err interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upgrade", reflect.TypeOf((*MockMetrics)(nil).Upgrade), from, to, err)
+}
+
+// MockTracer is a mock of Tracer interface.
+type MockTracer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTracerMockRecorder
+}
+
+// MockTracerMockRecorder is the mock recorder for MockTracer.
+type MockTracerMockRecorder struct {
+	mock *MockTracer
+}
+
+// NewMockTracer creates a new mock instance.
+func NewMockTracer(ctrl *gomock.Controller) *MockTracer {
+	mock := &MockTracer{ctrl: ctrl}
+	mock.recorder = &MockTracerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTracer) EXPECT() *MockTracerMockRecorder {
+	return m.recorder
+}
+
+// StartAckWait mocks base method.
+func (m *MockTracer) StartAckWait(ctx context.Context, namespace, event string) (context.Context, func(error)) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartAckWait", ctx, namespace, event)
+	ret0, _ := ret[0].(context.Context)
+	ret1, _ := ret[1].(func(error))
+	return ret0, ret1
+}
+
+// StartAckWait indicates an expected call of StartAckWait.
+func (mr *MockTracerMockRecorder) StartAckWait(ctx, namespace, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartAckWait", reflect.TypeOf((*MockTracer)(nil).StartAckWait), ctx, namespace, event)
+}
+
+// StartEmit mocks base method.
+func (m *MockTracer) StartEmit(ctx context.Context, namespace, event string) (context.Context, func(error)) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartEmit", ctx, namespace, event)
+	ret0, _ := ret[0].(context.Context)
+	ret1, _ := ret[1].(func(error))
+	return ret0, ret1
+}
+
+// StartEmit indicates an expected call of StartEmit.
+func (mr *MockTracerMockRecorder) StartEmit(ctx, namespace, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartEmit", reflect.TypeOf((*MockTracer)(nil).StartEmit), ctx, namespace, event)
+}
+
+// StartHandler mocks base method.
+func (m *MockTracer) StartHandler(ctx context.Context, namespace, event string) (context.Context, func(error)) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartHandler", ctx, namespace, event)
+	ret0, _ := ret[0].(context.Context)
+	ret1, _ := ret[1].(func(error))
+	return ret0, ret1
+}
+
+// StartHandler indicates an expected call of StartHandler.
+func (mr *MockTracerMockRecorder) StartHandler(ctx, namespace, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartHandler", reflect.TypeOf((*MockTracer)(nil).StartHandler), ctx, namespace, event)
+}
+
+// MockPropagator is a mock of Propagator interface.
+type MockPropagator struct {
+	ctrl     *gomock.Controller
+	recorder *MockPropagatorMockRecorder
+}
+
+// MockPropagatorMockRecorder is the mock recorder for MockPropagator.
+type MockPropagatorMockRecorder struct {
+	mock *MockPropagator
+}
+
+// NewMockPropagator creates a new mock instance.
+func NewMockPropagator(ctrl *gomock.Controller) *MockPropagator {
+	mock := &MockPropagator{ctrl: ctrl}
+	mock.recorder = &MockPropagatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPropagator) EXPECT() *MockPropagatorMockRecorder {
+	return m.recorder
+}
+
+// Extract mocks base method.
+func (m *MockPropagator) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Extract", ctx, carrier)
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Extract indicates an expected call of Extract.
+func (mr *MockPropagatorMockRecorder) Extract(ctx, carrier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extract", reflect.TypeOf((*MockPropagator)(nil).Extract), ctx, carrier)
+}
+
+// Inject mocks base method.
+func (m *MockPropagator) Inject(ctx context.Context, carrier map[string]string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Inject", ctx, carrier)
+}
+
+// Inject indicates an expected call of Inject.
+func (mr *MockPropagatorMockRecorder) Inject(ctx, carrier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inject", reflect.TypeOf((*MockPropagator)(nil).Inject), ctx, carrier)
+}
@@ -0,0 +1,111 @@
+package socketio_v5_client_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	socketio "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+	socketio_v5_server "github.com/maldikhan/go.socket.io/socket.io/v5/server"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+var quietLogger = &utils.DefaultLogger{Level: utils.NONE}
+
+// newManager returns a manager connected to a server whose "/" and "/chat"
+// namespaces answer "count" with the number of arguments received.
+func newManager(t *testing.T) *socketio.Manager {
+	t.Helper()
+	server, err := socketio_v5_server.NewServer(socketio_v5_server.WithLogger(quietLogger))
+	require.NoError(t, err)
+	for _, name := range []string{"/", "/chat"} {
+		server.Of(name).OnConnection(func(socket *socketio_v5_server.Socket) {
+			socket.On("count", func(ack socketio_v5_server.Ack, args []interface{}) {
+				_ = ack(len(args))
+			})
+		})
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		_ = server.Close()
+		httpServer.Close()
+	})
+
+	manager, err := socketio.NewManager(
+		socketio.WithRawURL(httpServer.URL),
+		socketio.WithLogger(quietLogger),
+	)
+	require.NoError(t, err)
+	return manager
+}
+
+func connect(t *testing.T, manager *socketio.Manager, ns string) *socketio.Client {
+	t.Helper()
+	socket, err := manager.Socket(ns)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	require.NoError(t, socket.Connect(ctx))
+	t.Cleanup(func() { _ = socket.Close() })
+	require.Eventually(t, socket.Connected, 5*time.Second, time.Millisecond)
+	return socket
+}
+
+func count(t *testing.T, socket *socketio.Client) int {
+	t.Helper()
+	replies := make(chan int, 1)
+	require.NoError(t, socket.Emit("count", "hi", emit.WithAck(func(n int) { replies <- n })))
+	select {
+	case n := <-replies:
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ack")
+		return 0
+	}
+}
+
+func TestManager_NamespaceMiddleware(t *testing.T) {
+	manager := newManager(t)
+	root := connect(t, manager, "/")
+	chat := connect(t, manager, "/chat")
+
+	chat.UseOutgoing(func(ctx context.Context, msg *socketio_v5.Message, next socketio.PacketHandler) error {
+		if msg.Event != nil {
+			msg.Event.Payloads = append(msg.Event.Payloads, "tenant-1")
+		}
+		return next(ctx, msg)
+	})
+	seen := make(chan string, 10)
+	chat.UseIncoming(func(ctx context.Context, msg *socketio_v5.Message, next socketio.PacketHandler) error {
+		seen <- msg.NS
+		return next(ctx, msg)
+	})
+
+	assert.Equal(t, 1, count(t, root))
+	assert.Empty(t, seen, "/chat middleware saw a packet of /")
+
+	assert.Equal(t, 2, count(t, chat))
+	assert.Equal(t, "/chat", <-seen)
+}
+
+func TestManager_NamespaceState(t *testing.T) {
+	manager := newManager(t)
+	root := connect(t, manager, "/")
+	chat := connect(t, manager, "/chat")
+
+	assert.NotEmpty(t, chat.ID())
+	assert.NotEqual(t, root.ID(), chat.ID())
+	assert.Equal(t, root.EngineID(), chat.EngineID(), "the namespaces share the connection")
+
+	require.NoError(t, chat.Close())
+	assert.Eventually(t, func() bool { return !chat.Connected() }, 5*time.Second, time.Millisecond)
+	assert.Empty(t, chat.ID())
+	assert.True(t, root.Connected())
+	assert.NotEmpty(t, root.ID())
+}
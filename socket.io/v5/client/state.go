@@ -0,0 +1,97 @@
+package socketio_v5_client
+
+import (
+	"encoding/json"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+)
+
+// ID returns the socket id the server assigned to the client's namespace in
+// its CONNECT reply, or "" while the namespace is not connected. It matches
+// socket.id on the server side. The namespace is set by WithDefaultNamespace
+// or Manager.Socket, so each socket of a Manager reports its own state.
+func (c *Client) ID() string {
+	return c.defaultNs.ID()
+}
+
+// Connected reports whether the client's namespace is connected.
+func (c *Client) Connected() bool {
+	return c.defaultNs.Connected()
+}
+
+// EngineID returns the underlying engine.io session id.
+func (c *Client) EngineID() string {
+	return c.engineio.ID()
+}
+
+// Transport returns the name of the engine.io transport currently in use.
+func (c *Client) Transport() engineio_v4.EngineIOTransport {
+	return c.engineio.Transport()
+}
+
+// State returns the engine.io connection state.
+func (c *Client) State() engineio_v4.State {
+	return c.engineio.State()
+}
+
+// WatchState returns the engine.io connection state and a channel that is
+// closed on the next state change.
+func (c *Client) WatchState() (engineio_v4.State, <-chan struct{}) {
+	return c.engineio.WatchState()
+}
+
+// ID returns the socket id of the namespace, or "" while it is not connected.
+func (n *namespace) ID() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.sid
+}
+
+// Connected reports whether the server acknowledged the namespace CONNECT
+// and has not disconnected it since.
+func (n *namespace) Connected() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.connected
+}
+
+func (n *namespace) setConnected(connected bool, sid string) {
+	n.mu.Lock()
+	n.connected = connected
+	n.sid = sid
+	n.mu.Unlock()
+}
+
+// onEngineClose marks every namespace disconnected once the engine.io
+// session is gone.
+func (c *Client) onEngineClose(_ []byte) {
+	c.mutex.RLock()
+	namespaces := make([]*namespace, 0, len(c.namespaces))
+	for _, ns := range c.namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	c.mutex.RUnlock()
+
+	for _, ns := range namespaces {
+		ns.setConnected(false, "")
+	}
+}
+
+// connectSid extracts the socket id from a CONNECT payload, which is either
+// raw JSON (default parser) or an already decoded map.
+func connectSid(payload interface{}) string {
+	switch v := payload.(type) {
+	case json.RawMessage:
+		var data struct {
+			Sid string `json:"sid"`
+		}
+		if err := json.Unmarshal(v, &data); err == nil {
+			return data.Sid
+		}
+	case map[string]interface{}:
+		if sid, ok := v["sid"].(string); ok {
+			return sid
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package socketio_v5_client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+)
+
+func TestClient_ID_Connected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mocks.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	ns := &namespace{name: "/", handlers: make(map[string][]func([]interface{}))}
+	client := &Client{
+		logger:     mockLogger,
+		defaultNs:  ns,
+		namespaces: map[string]*namespace{"/": ns},
+	}
+
+	assert.False(t, client.Connected())
+	assert.Equal(t, "", client.ID())
+
+	client.handleConnect(ns, json.RawMessage(`{"sid":"socket-sid"}`))
+	assert.True(t, client.Connected())
+	assert.Equal(t, "socket-sid", client.ID())
+
+	client.handleDisconnect(ns, nil)
+	assert.False(t, client.Connected())
+	assert.Equal(t, "", client.ID())
+
+	client.handleConnect(ns, map[string]interface{}{"sid": "decoded-sid"})
+	assert.Equal(t, "decoded-sid", client.ID())
+
+	client.handleConnectError(ns, nil)
+	assert.False(t, client.Connected())
+
+	client.handleConnect(ns, nil)
+	assert.True(t, client.Connected())
+	assert.Equal(t, "", client.ID())
+
+	client.onEngineClose(nil)
+	assert.False(t, client.Connected())
+}
+
+func TestClient_EngineState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEngineIO := mocks.NewMockEngineIOClient(ctrl)
+	client := &Client{engineio: mockEngineIO}
+
+	changed := make(chan struct{})
+	mockEngineIO.EXPECT().ID().Return("engine-sid")
+	mockEngineIO.EXPECT().Transport().Return(engineio_v4.TransportPolling)
+	mockEngineIO.EXPECT().State().Return(engineio_v4.StateOpen)
+	mockEngineIO.EXPECT().WatchState().Return(engineio_v4.StateUpgrading, (<-chan struct{})(changed))
+
+	assert.Equal(t, "engine-sid", client.EngineID())
+	assert.Equal(t, engineio_v4.TransportPolling, client.Transport())
+	assert.Equal(t, engineio_v4.StateOpen, client.State())
+	state, ch := client.WatchState()
+	assert.Equal(t, engineio_v4.StateUpgrading, state)
+	assert.Equal(t, (<-chan struct{})(changed), ch)
+}
@@ -0,0 +1,303 @@
+package socketio_v5_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// W3C trace context carrier keys.
+const (
+	TraceParentKey = "traceparent"
+	TraceStateKey  = "tracestate"
+)
+
+// TraceContext is a W3C trace context as carried by W3CPropagator.
+type TraceContext struct {
+	TraceParent string
+	TraceState  string
+}
+
+type traceContextKey struct{}
+
+// ContextWithTraceContext returns a copy of ctx carrying tc.
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext returns the trace context carried by ctx, if any.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// W3CPropagator propagates the TraceContext stored with
+// ContextWithTraceContext as traceparent and tracestate. Tracers backed by an
+// SDK should use the SDK's propagator instead.
+type W3CPropagator struct{}
+
+func (W3CPropagator) Inject(ctx context.Context, carrier map[string]string) {
+	tc, ok := TraceContextFromContext(ctx)
+	if !ok || !validTraceParent(tc.TraceParent) {
+		return
+	}
+	carrier[TraceParentKey] = tc.TraceParent
+	if tc.TraceState != "" {
+		carrier[TraceStateKey] = tc.TraceState
+	}
+}
+
+func (W3CPropagator) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	traceParent := carrier[TraceParentKey]
+	if !validTraceParent(traceParent) {
+		return ctx
+	}
+	return ContextWithTraceContext(ctx, TraceContext{TraceParent: traceParent, TraceState: carrier[TraceStateKey]})
+}
+
+// validTraceParent checks the version-trace_id-parent_id-flags layout of a
+// traceparent header.
+func validTraceParent(value string) bool {
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return false
+	}
+	for i, size := range []int{2, 32, 16, 2} {
+		if len(parts[i]) != size || !isLowerHex(parts[i]) {
+			return false
+		}
+	}
+	return strings.Trim(parts[1], "0") != "" && strings.Trim(parts[2], "0") != ""
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// PropagationMode selects where trace context travels on events.
+type PropagationMode int
+
+const (
+	// PropagateArgument appends {key: carrier} as the last event argument.
+	PropagateArgument PropagationMode = iota
+	// PropagateEnvelope sends the arguments as the single argument
+	// {key: carrier, "args": [arguments...]}.
+	PropagateEnvelope
+)
+
+type tracePropagation struct {
+	propagator Propagator
+	mode       PropagationMode
+	key        string
+}
+
+// tracing returns the tracer, tolerating a Client built without NewClient.
+func (c *Client) tracing() Tracer {
+	if c.tracer == nil {
+		return utils.NopTracer{}
+	}
+	return c.tracer
+}
+
+var errHandlerPanic = errors.New("handler panicked")
+
+// traceHandler runs fn in a handler span started from ctx.
+func (c *Client) traceHandler(ctx context.Context, ns, event string, fn func(ctx context.Context)) {
+	ctx, end := c.tracing().StartHandler(ctx, ns, event)
+	err := errHandlerPanic
+	defer func() { end(err) }()
+	fn(ctx)
+	err = nil
+}
+
+// injectConnectTrace returns the CONNECT auth with the trace context of ctx
+// added, leaving auth itself untouched.
+func (c *Client) injectConnectTrace(ctx context.Context, auth map[string]interface{}) map[string]interface{} {
+	if c.propagation == nil {
+		return auth
+	}
+	carrier := make(map[string]string)
+	c.propagation.propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return auth
+	}
+	withTrace := make(map[string]interface{}, len(auth)+len(carrier))
+	for k, v := range auth {
+		withTrace[k] = v
+	}
+	for k, v := range carrier {
+		withTrace[k] = v
+	}
+	return withTrace
+}
+
+// injectEventTrace returns event with the trace context of ctx added to its
+// arguments, leaving event itself untouched.
+func (c *Client) injectEventTrace(ctx context.Context, event *socketio_v5.Event) *socketio_v5.Event {
+	if c.propagation == nil {
+		return event
+	}
+	carrier := make(map[string]string)
+	c.propagation.propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return event
+	}
+
+	withTrace := *event
+	payloads := event.Payloads
+	if payloads == nil {
+		payloads = []interface{}{}
+	}
+	switch c.propagation.mode {
+	case PropagateEnvelope:
+		withTrace.Payloads = []interface{}{map[string]interface{}{
+			c.propagation.key: carrier,
+			"args":            payloads,
+		}}
+	default:
+		withTrace.Payloads = append(append(make([]interface{}, 0, len(payloads)+1), payloads...),
+			map[string]interface{}{c.propagation.key: carrier})
+	}
+	return &withTrace
+}
+
+// extractEventTrace strips the trace context added by a propagating peer
+// from the decoded arguments and returns ctx carrying it. Arguments that
+// don't match the configured mode are returned unchanged.
+func (c *Client) extractEventTrace(ctx context.Context, payloads []interface{}) (context.Context, []interface{}) {
+	if c.propagation == nil || len(payloads) == 0 {
+		return ctx, payloads
+	}
+
+	var fields map[string]json.RawMessage
+	var rest []interface{}
+	switch c.propagation.mode {
+	case PropagateEnvelope:
+		if len(payloads) != 1 || !decodeObject(payloads[0], &fields) || len(fields) != 2 {
+			return ctx, payloads
+		}
+		var args []json.RawMessage
+		if err := json.Unmarshal(fields["args"], &args); err != nil {
+			return ctx, payloads
+		}
+		rest = make([]interface{}, len(args))
+		for i, arg := range args {
+			rest[i] = arg
+		}
+	default:
+		if !decodeObject(payloads[len(payloads)-1], &fields) || len(fields) != 1 {
+			return ctx, payloads
+		}
+		rest = payloads[:len(payloads)-1]
+	}
+
+	var carrier map[string]string
+	if raw, ok := fields[c.propagation.key]; !ok || json.Unmarshal(raw, &carrier) != nil {
+		return ctx, payloads
+	}
+	return c.propagation.propagator.Extract(ctx, carrier), rest
+}
+
+// decodeObject decodes a JSON object argument as delivered by the default
+// parser.
+func decodeObject(payload interface{}, fields *map[string]json.RawMessage) bool {
+	raw, ok := payload.(json.RawMessage)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(raw, fields) == nil && *fields != nil
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// wrapContextHandler wraps a handler whose first parameter is a
+// context.Context, binding the context of each call before the parser decodes
+// the remaining arguments. It returns nil for any other handler.
+func (c *Client) wrapContextHandler(ns, event string, handler interface{}) func(context.Context, []interface{}) {
+	value := reflect.ValueOf(handler)
+	if value.Kind() != reflect.Func || value.Type().NumIn() == 0 || value.Type().In(0) != contextType {
+		return nil
+	}
+	handlerType := value.Type()
+
+	in := make([]reflect.Type, handlerType.NumIn()-1)
+	for i := range in {
+		in[i] = handlerType.In(i + 1)
+	}
+	out := make([]reflect.Type, handlerType.NumOut())
+	for i := range out {
+		out[i] = handlerType.Out(i)
+	}
+	boundType := reflect.FuncOf(in, out, handlerType.IsVariadic())
+
+	return func(ctx context.Context, payloads []interface{}) {
+		bound := reflect.MakeFunc(boundType, func(args []reflect.Value) []reflect.Value {
+			args = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, args...)
+			if handlerType.IsVariadic() {
+				return value.CallSlice(args)
+			}
+			return value.Call(args)
+		})
+		wrapped := c.wrapHandler(ns, event, bound.Interface())
+		if wrapped == nil {
+			c.fieldLogger(ns, event).Errorf("Can't wrap handler for %s", event)
+			return
+		}
+		wrapped(payloads)
+	}
+}
+
+// WithTracer sets the tracer receiving emit, ack wait and handler spans.
+func WithTracer(tracer Tracer) ClientOption {
+	return func(c *InitClient) error {
+		if tracer == nil {
+			return errors.New("tracer is nil")
+		}
+		c.tracer = tracer
+		return nil
+	}
+}
+
+// WithTracePropagation injects the trace context of the Connect context into
+// the CONNECT auth and that of the emit context (see emit.WithContext) into
+// event arguments, under key as set by mode. Inbound events carrying it have
+// it stripped from their arguments and extracted into the context passed to
+// handlers with a leading context.Context parameter. A nil propagator
+// defaults to W3CPropagator. Both peers must agree on mode and key.
+func WithTracePropagation(propagator Propagator, mode PropagationMode, key string) ClientOption {
+	return func(c *InitClient) error {
+		if key == "" || key == "args" && mode == PropagateEnvelope {
+			return fmt.Errorf("invalid trace propagation key %q", key)
+		}
+		if mode != PropagateArgument && mode != PropagateEnvelope {
+			return fmt.Errorf("unknown propagation mode %d", mode)
+		}
+		if propagator == nil {
+			propagator = W3CPropagator{}
+		}
+		c.propagation = &tracePropagation{propagator: propagator, mode: mode, key: key}
+		return nil
+	}
+}
+
+// withPropagation copies a trace propagation setup, e.g. from a Manager.
+func withPropagation(propagation *tracePropagation) ClientOption {
+	return func(c *InitClient) error {
+		c.propagation = propagation
+		return nil
+	}
+}
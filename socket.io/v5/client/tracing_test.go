@@ -0,0 +1,284 @@
+package socketio_v5_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+	mocks "github.com/maldikhan/go.socket.io/socket.io/v5/client/mocks"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type span struct {
+	kind, namespace, event string
+	err                    error
+}
+
+type spanKey struct{}
+
+// recordingTracer records ended spans and marks the span context with the
+// span kind and a traceparent whose parent id counts the spans.
+type recordingTracer struct {
+	mu    sync.Mutex
+	count int
+	ended chan span
+}
+
+func newRecordingTracer() *recordingTracer {
+	return &recordingTracer{ended: make(chan span, 16)}
+}
+
+func (t *recordingTracer) start(ctx context.Context, kind, namespace, event string) (context.Context, func(error)) {
+	t.mu.Lock()
+	t.count++
+	parentID := fmt.Sprintf("%016x", t.count)
+	t.mu.Unlock()
+
+	ctx = context.WithValue(ctx, spanKey{}, kind)
+	ctx = ContextWithTraceContext(ctx, TraceContext{
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-" + parentID + "-01",
+	})
+	return ctx, func(err error) { t.ended <- span{kind, namespace, event, err} }
+}
+
+func (t *recordingTracer) StartEmit(ctx context.Context, namespace, event string) (context.Context, func(error)) {
+	return t.start(ctx, "emit", namespace, event)
+}
+
+func (t *recordingTracer) StartAckWait(ctx context.Context, namespace, event string) (context.Context, func(error)) {
+	return t.start(ctx, "ack wait", namespace, event)
+}
+
+func (t *recordingTracer) StartHandler(ctx context.Context, namespace, event string) (context.Context, func(error)) {
+	return t.start(ctx, "handler", namespace, event)
+}
+
+func (t *recordingTracer) next(tb testing.TB) span {
+	tb.Helper()
+	select {
+	case s := <-t.ended:
+		return s
+	case <-time.After(time.Second):
+		tb.Fatal("timeout waiting for a span")
+		return span{}
+	}
+}
+
+// newTracingClient builds a connected client over a mocked engine.io client
+// and returns it with the channel of sent frames.
+func newTracingClient(t *testing.T, options ...ClientOption) (*Client, <-chan string) {
+	ctrl := gomock.NewController(t)
+	mockEngineIO := mocks.NewMockEngineIOClient(ctrl)
+	mockEngineIO.EXPECT().On(gomock.Any(), gomock.Any()).AnyTimes()
+	sent := make(chan string, 16)
+	mockEngineIO.EXPECT().Send(gomock.Any()).DoAndReturn(func(data []byte) error {
+		sent <- string(data)
+		return nil
+	}).AnyTimes()
+
+	client, err := NewClient(append([]ClientOption{
+		WithEngineIOClient(mockEngineIO),
+		WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+	}, options...)...)
+	require.NoError(t, err)
+	client.onMessage([]byte(`0{"sid":"abc"}`))
+	return client, sent
+}
+
+func receiveString(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+		return ""
+	}
+}
+
+func TestW3CPropagator(t *testing.T) {
+	propagator := W3CPropagator{}
+
+	ctx := ContextWithTraceContext(context.Background(), TraceContext{TraceParent: testTraceParent, TraceState: "vendor=value"})
+	carrier := map[string]string{}
+	propagator.Inject(ctx, carrier)
+	assert.Equal(t, map[string]string{"traceparent": testTraceParent, "tracestate": "vendor=value"}, carrier)
+
+	tc, ok := TraceContextFromContext(propagator.Extract(context.Background(), carrier))
+	require.True(t, ok)
+	assert.Equal(t, TraceContext{TraceParent: testTraceParent, TraceState: "vendor=value"}, tc)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, ok := TraceContextFromContext(propagator.Extract(context.Background(), map[string]string{"traceparent": invalid}))
+		assert.False(t, ok, invalid)
+	}
+
+	carrier = map[string]string{}
+	propagator.Inject(context.Background(), carrier)
+	assert.Empty(t, carrier)
+}
+
+func TestTracePropagation(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  PropagationMode
+		frame string
+	}{
+		{
+			name:  "Argument",
+			mode:  PropagateArgument,
+			frame: `2["news","hi",{"_trace":{"traceparent":"` + testTraceParent + `"}}]`,
+		},
+		{
+			name:  "Envelope",
+			mode:  PropagateEnvelope,
+			frame: `2["news",{"_trace":{"traceparent":"` + testTraceParent + `"},"args":["hi"]}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, sent := newTracingClient(t, WithTracePropagation(nil, tt.mode, "_trace"))
+			traced := ContextWithTraceContext(context.Background(), TraceContext{TraceParent: testTraceParent})
+
+			require.NoError(t, client.Emit("news", "hi", emit.WithContext(traced)))
+			assert.Equal(t, tt.frame, receiveString(t, sent))
+
+			require.NoError(t, client.Emit("news", "hi"))
+			assert.Equal(t, `2["news","hi"]`, receiveString(t, sent), "no trace context, no metadata")
+
+			type received struct {
+				text string
+				tc   TraceContext
+			}
+			withCtx := make(chan received, 1)
+			client.On("news", func(ctx context.Context, text string) {
+				tc, _ := TraceContextFromContext(ctx)
+				withCtx <- received{text, tc}
+			})
+			args := make(chan int, 1)
+			client.OnAny(func(_ string, payloads []interface{}) { args <- len(payloads) })
+
+			client.onMessage([]byte(tt.frame))
+			select {
+			case got := <-withCtx:
+				assert.Equal(t, received{"hi", TraceContext{TraceParent: testTraceParent}}, got)
+			case <-time.After(time.Second):
+				t.Fatal("timeout")
+			}
+			assert.Equal(t, 1, <-args, "the trace metadata is stripped")
+		})
+	}
+
+	t.Run("Connect auth", func(t *testing.T) {
+		client, sent := newTracingClient(t, WithTracePropagation(nil, PropagateArgument, "_trace"))
+		client.SetHandshakeData(map[string]interface{}{"token": "secret"})
+		client.mutex.Lock()
+		client.ctx = ContextWithTraceContext(context.Background(), TraceContext{TraceParent: testTraceParent, TraceState: "a=b"})
+		client.mutex.Unlock()
+
+		client.connectSocketIO(nil)
+		frame := receiveString(t, sent)
+		require.True(t, strings.HasPrefix(frame, "0"))
+		assert.JSONEq(t, `{"token":"secret","traceparent":"`+testTraceParent+`","tracestate":"a=b"}`, frame[1:])
+		assert.Equal(t, map[string]interface{}{"token": "secret"}, client.handshakeData, "the handshake data is not modified")
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		_, err := NewClient(WithRawURL("http://localhost"), WithTracePropagation(nil, PropagateArgument, ""))
+		assert.Error(t, err)
+		_, err = NewClient(WithRawURL("http://localhost"), WithTracePropagation(nil, PropagationMode(7), "_trace"))
+		assert.Error(t, err)
+		_, err = NewClient(WithRawURL("http://localhost"), WithTracer(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestTracerSpans(t *testing.T) {
+	t.Run("Emit and ack", func(t *testing.T) {
+		tracer := newRecordingTracer()
+		client, sent := newTracingClient(t, WithTracer(tracer), WithTracePropagation(nil, PropagateArgument, "_trace"))
+
+		acked := make(chan string, 1)
+		require.NoError(t, client.Emit("join", "room", emit.WithAck(func(ctx context.Context, status string) {
+			acked <- ctx.Value(spanKey{}).(string) + ": " + status
+		})))
+		assert.Equal(t, span{"emit", "/", "join", nil}, tracer.next(t))
+
+		// The emit span context, the first span started, is sent.
+		frame := receiveString(t, sent)
+		assert.Equal(t, `21["join","room",{"_trace":{"traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000001-01"}}]`, frame)
+
+		client.onMessage([]byte(`31["ok"]`))
+		assert.Equal(t, span{"ack wait", "/", "join", nil}, tracer.next(t))
+		assert.Equal(t, "ack wait: ok", receiveString(t, acked))
+	})
+
+	t.Run("Ack timeout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockTimer := mocks.NewMockTimer(ctrl)
+		timeout := make(chan time.Time)
+		mockTimer.EXPECT().After(time.Second).Return(timeout)
+
+		tracer := newRecordingTracer()
+		client, _ := newTracingClient(t, WithTracer(tracer), WithTimer(mockTimer))
+
+		timedOut := make(chan struct{})
+		require.NoError(t, client.Emit("join", emit.WithTimeout(time.Second, func() { close(timedOut) })))
+		assert.Equal(t, span{"emit", "/", "join", nil}, tracer.next(t))
+
+		timeout <- time.Now()
+		<-timedOut
+		assert.Equal(t, span{"ack wait", "/", "join", ErrAckTimeout}, tracer.next(t))
+	})
+
+	t.Run("Handlers", func(t *testing.T) {
+		tracer := newRecordingTracer()
+		client, _ := newTracingClient(t, WithTracer(tracer))
+
+		client.On("news", func(ctx context.Context, text string) {
+			assert.Equal(t, "handler", ctx.Value(spanKey{}))
+		})
+		client.onMessage([]byte(`2["news","hi"]`))
+		assert.Equal(t, span{"handler", "/", "news", nil}, tracer.next(t))
+
+		client.On("boom", func() { panic("boom") })
+		client.onMessage([]byte(`2["boom"]`))
+		assert.Equal(t, span{"handler", "/", "boom", errHandlerPanic}, tracer.next(t))
+	})
+}
+
+func TestExtractEventTrace_Mismatch(t *testing.T) {
+	client := &Client{propagation: &tracePropagation{propagator: W3CPropagator{}, mode: PropagateArgument, key: "_trace"}}
+
+	for _, payloads := range [][]interface{}{
+		{json.RawMessage(`"hi"`)},
+		{json.RawMessage(`{"_trace":{"traceparent":"x"},"other":1}`)},
+		{json.RawMessage(`{"_trace":"not a carrier"}`)},
+		{map[string]interface{}{"_trace": map[string]string{"traceparent": testTraceParent}}},
+	} {
+		ctx, got := client.extractEventTrace(context.Background(), payloads)
+		assert.Equal(t, payloads, got)
+		_, ok := TraceContextFromContext(ctx)
+		assert.False(t, ok)
+	}
+}
@@ -0,0 +1,30 @@
+package socketio_v5
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBinaryUnsupported is returned by a parser that doesn't handle
+// BINARY_EVENT and BINARY_ACK packets, from Parse and Serialize alike.
+var ErrBinaryUnsupported = errors.New("binary packets are not supported")
+
+// DecodeError reports that an event argument could not be decoded into the
+// parameter type of the handler it was dispatched to.
+type DecodeError struct {
+	// Index of the argument within the event payloads, or -1 when the
+	// payloads as a whole don't fit the handler (e.g. too few arguments).
+	Index int
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("decode arguments: %v", e.Err)
+	}
+	return fmt.Sprintf("decode argument %d: %v", e.Index, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,123 @@
+package socketio_v5_inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
+
+// binaryPreview is the number of bytes of binary data WriteText shows.
+const binaryPreview = 16
+
+// WriteText writes the layered breakdown of the packet, one field per line
+// with JSON pretty-printed.
+func (p *Packet) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	field := func(name string, value string) {
+		// Continuation lines of multi-line values stay in the value column.
+		fmt.Fprintf(tw, "%s\t%s\n", name, strings.ReplaceAll(value, "\n", "\n\t"))
+	}
+
+	if p.Binary {
+		preview := p.Data
+		more := ""
+		if len(preview) > binaryPreview {
+			preview, more = preview[:binaryPreview], " ..."
+		}
+		field("binary", fmt.Sprintf("%d bytes % x%s", len(p.Data), preview, more))
+		return tw.Flush()
+	}
+
+	field("engine.io", p.Engine.String())
+	switch p.Engine {
+	case engineio_v4.PacketOpen:
+		field("handshake", indent(p.Data))
+	case engineio_v4.PacketPing, engineio_v4.PacketPong:
+		if len(p.Data) > 0 {
+			field("data", string(p.Data))
+		}
+	}
+
+	if s := p.SocketIO; s != nil {
+		name := PacketTypeName(s.Type)
+		if s.Type == socketio_v5.PacketBinaryEvent || s.Type == socketio_v5.PacketBinaryAck {
+			name = fmt.Sprintf("%s, %d attachments", name, s.Attachments)
+		}
+		field("socket.io", name)
+		field("namespace", s.Namespace)
+		if s.AckID != nil {
+			field("ack id", fmt.Sprint(*s.AckID))
+		}
+		if s.Event != "" {
+			field("event", s.Event)
+		}
+		for i, arg := range s.Args {
+			field(fmt.Sprintf("args[%d]", i), indent(arg))
+		}
+		if len(s.Data) > 0 {
+			field("data", indent(s.Data))
+		}
+	}
+	return tw.Flush()
+}
+
+func indent(data []byte) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+type packetJSON struct {
+	Offset   int                 `json:"offset"`
+	Binary   bool                `json:"binary,omitempty"`
+	Engine   string              `json:"engine,omitempty"`
+	Data     interface{}         `json:"data,omitempty"`
+	SocketIO *socketIOPacketJSON `json:"socketio,omitempty"`
+}
+
+type socketIOPacketJSON struct {
+	Type        string            `json:"type"`
+	Namespace   string            `json:"namespace"`
+	AckID       *int              `json:"ackId,omitempty"`
+	Attachments int               `json:"attachments,omitempty"`
+	Event       string            `json:"event,omitempty"`
+	Args        []json.RawMessage `json:"args,omitempty"`
+	Data        json.RawMessage   `json:"data,omitempty"`
+}
+
+// MarshalJSON encodes the breakdown with the packet types by name. Binary
+// data is base64 encoded, the handshake is kept as JSON.
+func (p *Packet) MarshalJSON() ([]byte, error) {
+	out := packetJSON{Offset: p.Offset, Binary: p.Binary}
+	switch {
+	case p.Binary:
+		out.Data = p.Data
+	case p.Engine == engineio_v4.PacketOpen:
+		out.Data = json.RawMessage(p.Data)
+	case p.SocketIO == nil && len(p.Data) > 0:
+		out.Data = string(p.Data)
+	}
+	if !p.Binary {
+		out.Engine = p.Engine.String()
+	}
+	if s := p.SocketIO; s != nil {
+		out.SocketIO = &socketIOPacketJSON{
+			Type:        PacketTypeName(s.Type),
+			Namespace:   s.Namespace,
+			AckID:       s.AckID,
+			Attachments: s.Attachments,
+			Event:       s.Event,
+			Args:        s.Args,
+			Data:        s.Data,
+		}
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,358 @@
+// Package socketio_v5_inspect decodes raw engine.io frames carrying socket.io
+// packets into a layered breakdown and validates them against the protocol,
+// reporting the byte offset of the first violation.
+package socketio_v5_inspect
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_parser "github.com/maldikhan/go.socket.io/engine.io/v4/parser"
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	socketio_v5_parser_default "github.com/maldikhan/go.socket.io/socket.io/v5/parser/default"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// Layers reported by FrameError.
+const (
+	LayerEngineIO = "engine.io"
+	LayerSocketIO = "socket.io"
+)
+
+// payloadSeparator joins the packets of a polling payload.
+const payloadSeparator = 0x1e
+
+var (
+	engineParser   = &engineio_v4_parser.EngineIOV4Parser{}
+	socketioParser = socketio_v5_parser_default.NewParser(
+		socketio_v5_parser_default.WithLogger(&utils.DefaultLogger{Level: utils.NONE}),
+	)
+)
+
+// FrameError is a protocol violation found at Offset, a byte offset within
+// the decoded input.
+type FrameError struct {
+	Offset int
+	Layer  string
+	Err    error
+}
+
+func (e *FrameError) Error() string {
+	return fmt.Sprintf("%s: offset %d: %v", e.Layer, e.Offset, e.Err)
+}
+
+func (e *FrameError) Unwrap() error {
+	return e.Err
+}
+
+// Packet is the breakdown of an engine.io packet.
+type Packet struct {
+	// Offset of the packet within the decoded input.
+	Offset int
+	Raw    []byte
+	// Binary is set for binary data, the attachments of binary socket.io
+	// packets: a websocket binary frame or a "b" polling packet.
+	Binary bool
+	// Engine is the engine.io packet type, unset for binary data.
+	Engine engineio_v4.EngineIOPacket
+	// Data is the engine.io payload, or the decoded binary data.
+	Data []byte
+	// SocketIO is the socket.io packet of an engine.io message.
+	SocketIO *SocketIOPacket
+}
+
+// SocketIOPacket is the breakdown of a socket.io packet.
+type SocketIOPacket struct {
+	Type      socketio_v5.SocketIOPacket
+	Namespace string
+	AckID     *int
+	// Attachments is the number of binary attachments a binary packet
+	// declares; the attachments follow as separate packets.
+	Attachments int
+	// Event is the name of an EVENT or BINARY_EVENT.
+	Event string
+	// Args are the event or ack arguments.
+	Args []json.RawMessage
+	// Data is the payload of a CONNECT or CONNECT_ERROR.
+	Data json.RawMessage
+}
+
+// PacketTypeName returns the name of a socket.io packet type as used by the
+// protocol specification, e.g. "BINARY_EVENT".
+func PacketTypeName(packetType socketio_v5.SocketIOPacket) string {
+	switch packetType {
+	case socketio_v5.PacketConnect:
+		return "CONNECT"
+	case socketio_v5.PacketDisconnect:
+		return "DISCONNECT"
+	case socketio_v5.PacketEvent:
+		return "EVENT"
+	case socketio_v5.PacketAck:
+		return "ACK"
+	case socketio_v5.PacketConnectError:
+		return "CONNECT_ERROR"
+	case socketio_v5.PacketBinaryEvent:
+		return "BINARY_EVENT"
+	case socketio_v5.PacketBinaryAck:
+		return "BINARY_ACK"
+	}
+	return "UNKNOWN"
+}
+
+// DecodePayload decodes a polling payload, packets separated by \x1e. A
+// payload without separators is a single packet, so websocket text frames
+// decode too, and data that isn't valid UTF-8 is a binary websocket frame.
+// It returns the packets decoded up to the first error.
+func DecodePayload(payload []byte) ([]*Packet, error) {
+	if !utf8.Valid(payload) {
+		return []*Packet{{Raw: payload, Binary: true, Data: payload}}, nil
+	}
+	var packets []*Packet
+	start := 0
+	for i := 0; i <= len(payload); i++ {
+		if i < len(payload) && payload[i] != payloadSeparator {
+			continue
+		}
+		packet, err := decodePacket(payload[start:i], start, true)
+		if err != nil {
+			return packets, err
+		}
+		packets = append(packets, packet)
+		start = i + 1
+	}
+	return packets, nil
+}
+
+// DecodePacket decodes a single engine.io packet, a websocket frame. Data
+// that isn't valid UTF-8 is a binary frame.
+func DecodePacket(frame []byte) (*Packet, error) {
+	if !utf8.Valid(frame) {
+		return &Packet{Raw: frame, Binary: true, Data: frame}, nil
+	}
+	return decodePacket(frame, 0, false)
+}
+
+func decodePacket(raw []byte, offset int, polling bool) (*Packet, error) {
+	fail := func(at int, err error) error {
+		return &FrameError{Offset: offset + at, Layer: LayerEngineIO, Err: err}
+	}
+
+	packet := &Packet{Offset: offset, Raw: raw}
+	if polling && len(raw) > 0 && raw[0] == 'b' {
+		data, err := base64.StdEncoding.DecodeString(string(raw[1:]))
+		if err != nil {
+			var corrupt base64.CorruptInputError
+			if errors.As(err, &corrupt) {
+				return nil, fail(1+int(corrupt), errors.New("invalid base64 in binary packet"))
+			}
+			return nil, fail(1, err)
+		}
+		packet.Binary = true
+		packet.Data = data
+		return packet, nil
+	}
+
+	msg, err := engineParser.Parse(raw)
+	if err != nil {
+		return nil, fail(0, err)
+	}
+	packet.Engine = msg.Type
+	packet.Data = msg.Data
+
+	switch msg.Type {
+	case engineio_v4.PacketOpen:
+		var handshake engineio_v4.HandshakeResponse
+		if at, err := unmarshal(msg.Data, &handshake); err != nil {
+			return nil, fail(1+at, fmt.Errorf("invalid handshake: %w", err))
+		}
+		if handshake.Sid == "" {
+			return nil, fail(1, errors.New("handshake without a sid"))
+		}
+	case engineio_v4.PacketPing, engineio_v4.PacketPong:
+		if len(msg.Data) > 0 && string(msg.Data) != "probe" {
+			return nil, fail(1, fmt.Errorf("unexpected %s data, want none or \"probe\"", msg.Type))
+		}
+	case engineio_v4.PacketClose, engineio_v4.PacketUpgrade, engineio_v4.PacketNoop:
+		if len(msg.Data) > 0 {
+			return nil, fail(1, fmt.Errorf("unexpected %s data", msg.Type))
+		}
+	case engineio_v4.PacketMessage:
+		packet.SocketIO, err = decodeSocketIO(msg.Data, offset+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return packet, nil
+}
+
+func decodeSocketIO(data []byte, offset int) (*SocketIOPacket, error) {
+	fail := func(at int, format string, args ...interface{}) error {
+		return &FrameError{Offset: offset + at, Layer: LayerSocketIO, Err: fmt.Errorf(format, args...)}
+	}
+
+	if len(data) == 0 {
+		return nil, fail(0, "empty packet")
+	}
+	if data[0] < '0' || data[0] > '6' {
+		return nil, fail(0, "unknown packet type %q", data[0])
+	}
+	packet := &SocketIOPacket{Type: socketio_v5.SocketIOPacket(data[0] - '0'), Namespace: "/"}
+	binary := packet.Type == socketio_v5.PacketBinaryEvent || packet.Type == socketio_v5.PacketBinaryAck
+	i := 1
+
+	if binary {
+		start := i
+		for i < len(data) && isDigit(data[i]) {
+			i++
+		}
+		if i == start {
+			return nil, fail(i, "missing attachment count")
+		}
+		if i == len(data) || data[i] != '-' {
+			return nil, fail(i, "missing '-' after the attachment count")
+		}
+		attachments, err := strconv.Atoi(string(data[start:i]))
+		if err != nil {
+			return nil, fail(start, "invalid attachment count: %v", err)
+		}
+		packet.Attachments = attachments
+		i++
+	}
+
+	if i < len(data) && data[i] == '/' {
+		start := i
+		for i < len(data) && data[i] != ',' {
+			i++
+		}
+		packet.Namespace = string(data[start:i])
+		if i < len(data) {
+			i++
+		}
+	}
+
+	if start := i; i < len(data) && isDigit(data[i]) {
+		for i < len(data) && isDigit(data[i]) {
+			i++
+		}
+		if i-start > 18 {
+			return nil, fail(start, "ack id too long")
+		}
+		switch packet.Type {
+		case socketio_v5.PacketEvent, socketio_v5.PacketAck, socketio_v5.PacketBinaryEvent, socketio_v5.PacketBinaryAck:
+		default:
+			return nil, fail(start, "ack id on a %s packet", PacketTypeName(packet.Type))
+		}
+		ackID, _ := strconv.Atoi(string(data[start:i]))
+		packet.AckID = &ackID
+	}
+
+	payload := data[i:]
+	if len(payload) > 0 {
+		var value interface{}
+		if at, err := unmarshal(payload, &value); err != nil {
+			return nil, fail(i+at, "invalid JSON: %v", err)
+		}
+	}
+
+	switch packet.Type {
+	case socketio_v5.PacketConnect:
+		if len(payload) > 0 && payload[0] != '{' {
+			return nil, fail(i, "CONNECT payload must be an object")
+		}
+		packet.Data = json.RawMessage(payload)
+	case socketio_v5.PacketDisconnect:
+		if len(payload) > 0 {
+			return nil, fail(i, "unexpected DISCONNECT payload")
+		}
+	case socketio_v5.PacketConnectError:
+		if len(payload) == 0 {
+			return nil, fail(i, "missing CONNECT_ERROR payload")
+		}
+		packet.Data = json.RawMessage(payload)
+	default:
+		name := PacketTypeName(packet.Type)
+		if len(payload) == 0 {
+			return nil, fail(i, "missing %s payload", name)
+		}
+		if payload[0] != '[' {
+			return nil, fail(i, "%s payload must be an array", name)
+		}
+		isAck := packet.Type == socketio_v5.PacketAck || packet.Type == socketio_v5.PacketBinaryAck
+		if isAck && packet.AckID == nil {
+			return nil, fail(i, "%s without an ack id", name)
+		}
+		event, err := socketioParser.ParseEvent(payload, isAck)
+		if err != nil {
+			return nil, fail(i, "%v", err)
+		}
+		packet.Event = event.Name
+		for _, arg := range event.Payloads {
+			packet.Args = append(packet.Args, arg.(json.RawMessage))
+		}
+		if !isAck && packet.Event == "" {
+			return nil, fail(i, "%s without an event name", name)
+		}
+		if binary {
+			if placeholders := countPlaceholders(packet.Args); placeholders != packet.Attachments {
+				return nil, fail(i, "%d attachments declared, %d placeholders found", packet.Attachments, placeholders)
+			}
+		}
+	}
+	return packet, nil
+}
+
+// unmarshal decodes data into v and returns the offset of a syntax error.
+func unmarshal(data []byte, v interface{}) (int, error) {
+	err := json.Unmarshal(data, v)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset counts the bytes read, including the offending one.
+		at := int(syntaxErr.Offset) - 1
+		if at < 0 {
+			at = 0
+		}
+		return at, err
+	}
+	return 0, err
+}
+
+// countPlaceholders counts the binary attachment placeholders,
+// {"_placeholder":true,"num":N}, in args.
+func countPlaceholders(args []json.RawMessage) int {
+	nums := make(map[float64]bool)
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if v["_placeholder"] == true {
+				if num, ok := v["num"].(float64); ok {
+					nums[num] = true
+					return
+				}
+			}
+			for _, value := range v {
+				walk(value)
+			}
+		case []interface{}:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	for _, arg := range args {
+		var v interface{}
+		if err := json.Unmarshal(arg, &v); err == nil {
+			walk(v)
+		}
+	}
+	return len(nums)
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
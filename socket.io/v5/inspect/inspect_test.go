@@ -0,0 +1,168 @@
+package socketio_v5_inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
+
+func TestDecodePacket(t *testing.T) {
+	t.Run("Event", func(t *testing.T) {
+		packet, err := DecodePacket([]byte(`42/admin,17["evt",{"a":1},"x"]`))
+		require.NoError(t, err)
+		assert.Equal(t, engineio_v4.PacketMessage, packet.Engine)
+		s := packet.SocketIO
+		require.NotNil(t, s)
+		assert.Equal(t, socketio_v5.PacketEvent, s.Type)
+		assert.Equal(t, "/admin", s.Namespace)
+		require.NotNil(t, s.AckID)
+		assert.Equal(t, 17, *s.AckID)
+		assert.Equal(t, "evt", s.Event)
+		assert.Equal(t, []json.RawMessage{json.RawMessage(`{"a":1}`), json.RawMessage(`"x"`)}, s.Args)
+	})
+
+	t.Run("Binary event", func(t *testing.T) {
+		packet, err := DecodePacket([]byte(`452-["upload",{"_placeholder":true,"num":0},[{"_placeholder":true,"num":1}]]`))
+		require.NoError(t, err)
+		assert.Equal(t, socketio_v5.PacketBinaryEvent, packet.SocketIO.Type)
+		assert.Equal(t, 2, packet.SocketIO.Attachments)
+		assert.Equal(t, "/", packet.SocketIO.Namespace)
+		assert.Equal(t, "upload", packet.SocketIO.Event)
+	})
+
+	t.Run("Other packets", func(t *testing.T) {
+		for frame, want := range map[string]string{
+			`0{"sid":"abc","upgrades":["websocket"],"pingInterval":25000}`: "open",
+			`2probe`:                               "ping",
+			`3`:                                    "pong",
+			`40/chat,{"t":1}`:                      "CONNECT",
+			`41/chat,`:                             "DISCONNECT",
+			`44{"message":"nope"}`:                 "CONNECT_ERROR",
+			`43/chat,5[]`:                          "ACK",
+			`461-3[{"_placeholder":true,"num":0}]`: "BINARY_ACK",
+			`6`:                                    "noop",
+		} {
+			packet, err := DecodePacket([]byte(frame))
+			require.NoError(t, err, frame)
+			if packet.SocketIO != nil {
+				assert.Equal(t, want, PacketTypeName(packet.SocketIO.Type), frame)
+			} else {
+				assert.Equal(t, want, packet.Engine.String(), frame)
+			}
+		}
+	})
+
+	t.Run("Binary frame", func(t *testing.T) {
+		packet, err := DecodePacket([]byte{0xff, 0x00, 0x01})
+		require.NoError(t, err)
+		assert.True(t, packet.Binary)
+		assert.Equal(t, []byte{0xff, 0x00, 0x01}, packet.Data)
+	})
+}
+
+func TestDecodePacket_Errors(t *testing.T) {
+	tests := []struct {
+		frame  string
+		layer  string
+		offset int
+		err    string
+	}{
+		{"", LayerEngineIO, 0, "empty message"},
+		{"9", LayerEngineIO, 0, "unknown engine.io packet type"},
+		{"0{}", LayerEngineIO, 1, "without a sid"},
+		{`0{"sid":1}`, LayerEngineIO, 1, "invalid handshake"},
+		{"2ping", LayerEngineIO, 1, "unexpected ping data"},
+		{"6x", LayerEngineIO, 1, "unexpected noop data"},
+		{"4", LayerSocketIO, 1, "empty packet"},
+		{"48", LayerSocketIO, 1, "unknown packet type"},
+		{`42["evt",{"a":1]`, LayerSocketIO, 15, "invalid JSON"},
+		{`42/admin,17["evt",{"a":}]`, LayerSocketIO, 23, "invalid JSON"},
+		{`42/admin,`, LayerSocketIO, 9, "missing EVENT payload"},
+		{`42{"a":1}`, LayerSocketIO, 2, "must be an array"},
+		{`42[1]`, LayerSocketIO, 2, "parse event error"},
+		{`43["x"]`, LayerSocketIO, 2, "ACK without an ack id"},
+		{`407{}`, LayerSocketIO, 2, "ack id on a CONNECT packet"},
+		{`40"x"`, LayerSocketIO, 2, "must be an object"},
+		{`41{}`, LayerSocketIO, 2, "unexpected DISCONNECT payload"},
+		{`44`, LayerSocketIO, 2, "missing CONNECT_ERROR payload"},
+		{`45["evt"]`, LayerSocketIO, 2, "missing attachment count"},
+		{`451["evt"]`, LayerSocketIO, 3, "missing '-'"},
+		{`452-["evt",{"_placeholder":true,"num":0}]`, LayerSocketIO, 4, "2 attachments declared, 1 placeholders found"},
+		{`421234567890123456789["evt"]`, LayerSocketIO, 2, "ack id too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.frame, func(t *testing.T) {
+			packet, err := DecodePacket([]byte(tt.frame))
+			assert.Nil(t, packet)
+			var frameErr *FrameError
+			require.True(t, errors.As(err, &frameErr), "%v", err)
+			assert.Equal(t, tt.layer, frameErr.Layer)
+			assert.Equal(t, tt.offset, frameErr.Offset)
+			assert.Contains(t, frameErr.Error(), tt.err)
+		})
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	packets, err := DecodePayload([]byte("451-[\"file\",{\"_placeholder\":true,\"num\":0}]\x1ebAQID\x1e2"))
+	require.NoError(t, err)
+	require.Len(t, packets, 3)
+	assert.Equal(t, "file", packets[0].SocketIO.Event)
+	assert.Equal(t, 43, packets[1].Offset)
+	assert.True(t, packets[1].Binary)
+	assert.Equal(t, []byte{1, 2, 3}, packets[1].Data)
+	assert.Equal(t, engineio_v4.PacketPing, packets[2].Engine)
+
+	// Offsets count from the start of the payload.
+	packets, err = DecodePayload([]byte("2\x1e42[\"a\",]"))
+	assert.Len(t, packets, 1)
+	var frameErr *FrameError
+	require.True(t, errors.As(err, &frameErr))
+	assert.Equal(t, 9, frameErr.Offset)
+
+	_, err = DecodePayload([]byte("2\x1eb!!"))
+	require.True(t, errors.As(err, &frameErr))
+	assert.Equal(t, 3, frameErr.Offset)
+}
+
+func TestPacket_WriteText(t *testing.T) {
+	packet, err := DecodePacket([]byte(`42/admin,17["evt",{"a":1}]`))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, packet.WriteText(&buf))
+	assert.Equal(t, "engine.io  message\n"+
+		"socket.io  EVENT\n"+
+		"namespace  /admin\n"+
+		"ack id     17\n"+
+		"event      evt\n"+
+		"args[0]    {\n"+
+		"             \"a\": 1\n"+
+		"           }\n", buf.String())
+
+	packet, err = DecodePacket([]byte{0xff, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, packet.WriteText(&buf))
+	assert.Equal(t, "binary  17 bytes ff 01 02 03 04 05 06 07 08 09 0a 0b 0c 0d 0e 0f ...\n", buf.String())
+}
+
+func TestPacket_MarshalJSON(t *testing.T) {
+	packets, err := DecodePayload([]byte("0{\"sid\":\"abc\"}\x1e451-/up,[\"f\",{\"_placeholder\":true,\"num\":0}]\x1ebAQ==\x1e2probe"))
+	require.NoError(t, err)
+	data, err := json.Marshal(packets)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"offset": 0, "engine": "open", "data": {"sid": "abc"}},
+		{"offset": 15, "engine": "message", "socketio": {"type": "BINARY_EVENT", "namespace": "/up", "attachments": 1,
+			"event": "f", "args": [{"_placeholder": true, "num": 0}]}},
+		{"offset": 59, "binary": true, "data": "AQ=="},
+		{"offset": 65, "engine": "ping", "data": "probe"}
+	]`, string(data))
+}
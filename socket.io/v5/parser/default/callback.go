@@ -2,9 +2,15 @@ package socketio_v5_parser_default
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
 )
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 func (p *SocketIOV5DefaultParser) WrapCallback(callback interface{}) func(in []interface{}) {
 
 	if p.payloadParser != nil {
@@ -16,6 +22,44 @@ func (p *SocketIOV5DefaultParser) WrapCallback(callback interface{}) func(in []i
 		return v
 	}
 
+	wrapped := p.WrapCallbackWithError(callback)
+	if wrapped == nil {
+		return nil
+	}
+
+	return func(in []interface{}) {
+		if err := wrapped(in); err != nil {
+			p.logger.Errorf("Error: %v", err)
+		}
+	}
+}
+
+// WrapCallbackWithError is like WrapCallback, but reports argument decoding
+// failures as *socketio_v5.DecodeError and passes through a non-nil error
+// returned by a callback whose last result is an error.
+func (p *SocketIOV5DefaultParser) WrapCallbackWithError(callback interface{}) func(in []interface{}) error {
+
+	if p.payloadParser != nil {
+		wrapped := p.payloadParser.WrapCallback(callback)
+		if wrapped == nil {
+			return nil
+		}
+		return func(in []interface{}) error {
+			wrapped(in)
+			return nil
+		}
+	}
+
+	switch v := callback.(type) {
+	case func([]interface{}):
+		return func(in []interface{}) error {
+			v(in)
+			return nil
+		}
+	case func([]interface{}) error:
+		return v
+	}
+
 	callbackValue := reflect.ValueOf(callback)
 	callbackType := callbackValue.Type()
 
@@ -24,10 +68,14 @@ func (p *SocketIOV5DefaultParser) WrapCallback(callback interface{}) func(in []i
 		return nil
 	}
 
-	return func(in []interface{}) {
+	returnsError := callbackType.NumOut() > 0 && callbackType.Out(callbackType.NumOut()-1) == errorType
+
+	return func(in []interface{}) error {
 		if len(in) < callbackType.NumIn() {
-			p.logger.Errorf("Error: expected %d arguments, got %d\n", callbackType.NumIn(), len(in))
-			return
+			return &socketio_v5.DecodeError{
+				Index: -1,
+				Err:   fmt.Errorf("expected %d arguments, got %d", callbackType.NumIn(), len(in)),
+			}
 		}
 
 		args := make([]reflect.Value, callbackType.NumIn())
@@ -36,16 +84,20 @@ func (p *SocketIOV5DefaultParser) WrapCallback(callback interface{}) func(in []i
 			argValue := reflect.New(argType).Interface()
 			data, ok := in[i].(json.RawMessage)
 			if !ok {
-				p.logger.Errorf("Wrong data in %d json entity", i)
-				return
+				return &socketio_v5.DecodeError{Index: i, Err: errors.New("wrong data in json entity")}
 			}
 			if err := json.Unmarshal(data, argValue); err != nil {
-				p.logger.Errorf("Error unmarshaling argument %d (%v): %v\n", i, in[i], err)
-				return
+				return &socketio_v5.DecodeError{Index: i, Err: err}
 			}
 			args[i] = reflect.ValueOf(argValue).Elem()
 		}
 
-		callbackValue.Call(args)
+		out := callbackValue.Call(args)
+		if returnsError {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 }
@@ -1,6 +1,7 @@
 package socketio_v5_parser_default
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -16,6 +17,18 @@ type SocketIOV5DefaultParser struct {
 var ErrParseEventUnsupported = errors.New("unsuported event")
 var ErrParsePackage = errors.New("parse package error")
 
+// errBinaryUnsupported is both ErrParseEventUnsupported and
+// socketio_v5.ErrBinaryUnsupported.
+type errBinaryUnsupported struct{}
+
+func (errBinaryUnsupported) Error() string {
+	return "unsuported event: binary events are not supported yet"
+}
+
+func (errBinaryUnsupported) Is(target error) bool {
+	return target == ErrParseEventUnsupported || target == socketio_v5.ErrBinaryUnsupported
+}
+
 func (p *SocketIOV5DefaultParser) checkData(data []byte) (socketio_v5.SocketIOPacket, error) {
 	if len(data) == 0 {
 		return socketio_v5.PacketUnknown, fmt.Errorf("%w: %v", ErrParsePackage, "empty message")
@@ -23,7 +36,10 @@ func (p *SocketIOV5DefaultParser) checkData(data []byte) (socketio_v5.SocketIOPa
 	eventType := socketio_v5.SocketIOPacket(data[0] - '0')
 	switch eventType {
 	case socketio_v5.PacketBinaryEvent, socketio_v5.PacketBinaryAck:
-		return eventType, fmt.Errorf("%w: %v", ErrParseEventUnsupported, errors.New("binary events are not supported yet"))
+		return eventType, errBinaryUnsupported{}
+	}
+	if eventType > socketio_v5.PacketBinaryAck {
+		return socketio_v5.PacketUnknown, fmt.Errorf("%w: unknown packet type %q", ErrParsePackage, data[0])
 	}
 	return eventType, nil
 }
@@ -103,6 +119,13 @@ func (p *SocketIOV5DefaultParser) Parse(data []byte) (*socketio_v5.Message, erro
 	switch msg.Type {
 	case socketio_v5.PacketEvent, socketio_v5.PacketAck:
 		msg.Event, err = p.ParseEvent(packetData, msg.Type == socketio_v5.PacketAck)
+	case socketio_v5.PacketConnect:
+		// The server answers CONNECT with {"sid":"..."}; keep it raw, like
+		// event payloads, and let the client decode what it needs.
+		if !json.Valid(packetData) {
+			return nil, fmt.Errorf("%w: %v", ErrParsePackage, errors.New("wrong connect payload"))
+		}
+		msg.Payload = json.RawMessage(packetData)
 	case socketio_v5.PacketConnectError:
 		errorMessage := string(packetData)
 		msg.ErrorMessage = &errorMessage
@@ -2,6 +2,7 @@ package socketio_v5_parser_default
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -35,6 +36,15 @@ func TestSocketIOV5DefaultParser_Parse(t *testing.T) {
 			},
 			wantErr: ErrParsePackage,
 		},
+		{
+			name:  "Unknown packet type",
+			input: []byte("9"),
+			want: &socketio_v5.Message{
+				Type: socketio_v5.PacketUnknown,
+				NS:   "/",
+			},
+			wantErr: ErrParsePackage,
+		},
 		{
 			name:  "Connect message",
 			input: []byte("0"),
@@ -89,6 +99,22 @@ func TestSocketIOV5DefaultParser_Parse(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name:  "Connect with sid payload",
+			input: []byte(`0/test,{"sid":"abc"}`),
+			want: &socketio_v5.Message{
+				Type:    socketio_v5.PacketConnect,
+				NS:      "/test",
+				Payload: json.RawMessage(`{"sid":"abc"}`),
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "Connect with malformed payload",
+			input:   []byte(`0{"sid":`),
+			want:    nil,
+			wantErr: ErrParsePackage,
+		},
 		{
 			name:    "Event with namespace only",
 			input:   []byte(`2/test,`),
@@ -194,6 +220,21 @@ func TestSocketIOV5DefaultParser_Parse(t *testing.T) {
 	}
 }
 
+func TestSocketIOV5DefaultParser_BinaryUnsupported(t *testing.T) {
+	parser := NewParser(WithLogger(logger))
+
+	_, err := parser.Parse([]byte(`51-["upload",{"_placeholder":true,"num":0}]`))
+	assert.ErrorIs(t, err, socketio_v5.ErrBinaryUnsupported)
+	assert.ErrorIs(t, err, ErrParseEventUnsupported)
+
+	_, err = parser.Serialize(&socketio_v5.Message{
+		Type:  socketio_v5.PacketBinaryEvent,
+		NS:    "/",
+		Event: &socketio_v5.Event{Name: "upload"},
+	})
+	assert.ErrorIs(t, err, socketio_v5.ErrBinaryUnsupported)
+}
+
 func TestSocketIOV5DefaultParser_parseEvent(t *testing.T) {
 	t.Parallel()
 
@@ -936,3 +977,69 @@ var typedEvent = []interface{}{
 	},
 	[]string{"a", "b", "c"},
 }
+
+func TestWrapCallbackWithError(t *testing.T) {
+	parser := NewParser(
+		WithLogger(logger),
+	)
+	payloads := []interface{}{json.RawMessage(`"text"`), json.RawMessage(`2`)}
+
+	t.Run("Returned error", func(t *testing.T) {
+		handlerErr := errors.New("handler error")
+		callback := parser.WrapCallbackWithError(func(s string, i int) error {
+			assert.Equal(t, "text", s)
+			assert.Equal(t, 2, i)
+			return handlerErr
+		})
+		assert.ErrorIs(t, callback(payloads), handlerErr)
+	})
+
+	t.Run("Nil error", func(t *testing.T) {
+		callback := parser.WrapCallbackWithError(func(string) error { return nil })
+		assert.NoError(t, callback(payloads))
+	})
+
+	t.Run("Decode error", func(t *testing.T) {
+		callback := parser.WrapCallbackWithError(func(string, string) {})
+		err := callback(payloads)
+		var decodeErr *socketio_v5.DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, 1, decodeErr.Index)
+		assert.Contains(t, err.Error(), "decode argument 1")
+	})
+
+	t.Run("Not enough arguments", func(t *testing.T) {
+		callback := parser.WrapCallbackWithError(func(string, int, bool) {})
+		err := callback(payloads)
+		var decodeErr *socketio_v5.DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, -1, decodeErr.Index)
+	})
+
+	t.Run("Wrong data", func(t *testing.T) {
+		callback := parser.WrapCallbackWithError(func(string) {})
+		var decodeErr *socketio_v5.DecodeError
+		require.ErrorAs(t, callback([]interface{}{1}), &decodeErr)
+	})
+
+	t.Run("Raw callbacks", func(t *testing.T) {
+		assert.NoError(t, parser.WrapCallbackWithError(func([]interface{}) {})(payloads))
+		rawErr := errors.New("raw")
+		assert.ErrorIs(t, parser.WrapCallbackWithError(func([]interface{}) error { return rawErr })(payloads), rawErr)
+		assert.Nil(t, parser.WrapCallbackWithError(1))
+	})
+
+	t.Run("3rd Party callback", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockPayloadParser := mock_socketio_v5_parser_default.NewMockPayloadParser(ctrl)
+		parser := NewParser(WithLogger(logger), WithPayloadParser(mockPayloadParser))
+
+		called := false
+		mockPayloadParser.EXPECT().WrapCallback(gomock.Any()).Return(func([]interface{}) { called = true })
+		assert.NoError(t, parser.WrapCallbackWithError(func() {})(payloads))
+		assert.True(t, called)
+
+		mockPayloadParser.EXPECT().WrapCallback(1).Return(nil)
+		assert.Nil(t, parser.WrapCallbackWithError(1))
+	})
+}
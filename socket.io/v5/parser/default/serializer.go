@@ -14,7 +14,7 @@ func (p *SocketIOV5DefaultParser) validateMessage(msg *socketio_v5.Message) erro
 	}
 	switch msg.Type {
 	case socketio_v5.PacketBinaryAck, socketio_v5.PacketBinaryEvent:
-		return errors.New("biniary events are not supported yet")
+		return socketio_v5.ErrBinaryUnsupported
 	case socketio_v5.PacketEvent:
 		if msg.Event == nil || msg.Event.Name == "" {
 			return errors.New("wrong event name")
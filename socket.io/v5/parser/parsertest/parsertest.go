@@ -0,0 +1,356 @@
+// Package parsertest is a conformance suite for socket.io v5 parsers, the
+// implementations of socketio_v5_client.Parser. A third-party parser runs it
+// from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		parsertest.Run(t, myparser.NewParser())
+//	}
+//
+// The golden packets are encodings of the reference JavaScript
+// socket.io-parser. Event arguments may be held in any representation that
+// WrapCallback decodes, e.g. json.RawMessage or []byte. Binary packets are
+// optional: a parser either handles them like the reference or returns an
+// error wrapping socketio_v5.ErrBinaryUnsupported.
+package parsertest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
+
+// Parser is the contract under test; it matches socketio_v5_client.Parser.
+type Parser interface {
+	WrapCallback(callback interface{}) func(in []interface{})
+	Parse([]byte) (*socketio_v5.Message, error)
+	Serialize(*socketio_v5.Message) ([]byte, error)
+}
+
+// ErrorReportingParser matches socketio_v5_client.ErrorReportingParser; a
+// parser implementing it is also checked for *socketio_v5.DecodeError.
+type ErrorReportingParser interface {
+	WrapCallbackWithError(callback interface{}) func(in []interface{}) error
+}
+
+// Run runs the whole suite against parser as subtests of t.
+func Run(t *testing.T, parser Parser) {
+	t.Helper()
+	t.Run("Parse", func(t *testing.T) { testParse(t, parser) })
+	t.Run("Serialize", func(t *testing.T) { testSerialize(t, parser) })
+	t.Run("Malformed", func(t *testing.T) { testMalformed(t, parser) })
+	t.Run("Binary", func(t *testing.T) { testBinary(t, parser) })
+	t.Run("WrapCallback", func(t *testing.T) { testWrapCallback(t, parser) })
+	t.Run("RoundTrip", func(t *testing.T) { testRoundTrip(t, parser) })
+}
+
+func testParse(t *testing.T, parser Parser) {
+	for _, g := range goldens {
+		g := g
+		t.Run(g.name, func(t *testing.T) {
+			msg, err := parse(parser, g.packet)
+			require.NoError(t, err, "packet %q", g.packet)
+			assertMessage(t, g, msg)
+		})
+	}
+}
+
+func testSerialize(t *testing.T, parser Parser) {
+	for _, g := range goldens {
+		g := g
+		if g.parseOnly {
+			continue
+		}
+		t.Run(g.name, func(t *testing.T) {
+			data, err := serialize(parser, g.message(t))
+			require.NoError(t, err)
+			assert.Equal(t, g.packet, string(data))
+		})
+	}
+
+	t.Run("Nil message", func(t *testing.T) {
+		_, err := serialize(parser, nil)
+		assert.Error(t, err)
+	})
+	t.Run("Event without name", func(t *testing.T) {
+		_, err := serialize(parser, &socketio_v5.Message{Type: socketio_v5.PacketEvent, NS: "/", Event: &socketio_v5.Event{}})
+		assert.Error(t, err)
+	})
+}
+
+func testMalformed(t *testing.T, parser Parser) {
+	for _, m := range malformed {
+		m := m
+		t.Run(m.name, func(t *testing.T) {
+			_, err := parse(parser, m.packet)
+			assert.Error(t, err, "packet %q was accepted", m.packet)
+		})
+	}
+}
+
+func testBinary(t *testing.T, parser Parser) {
+	for _, g := range binaryGoldens {
+		g := g
+		t.Run(g.name, func(t *testing.T) {
+			msg, err := parse(parser, g.packet)
+			if err != nil {
+				assert.ErrorIs(t, err, socketio_v5.ErrBinaryUnsupported, "Parse(%q)", g.packet)
+			} else {
+				assertMessage(t, g, msg)
+			}
+
+			data, err := serialize(parser, g.message(t))
+			if err != nil {
+				assert.ErrorIs(t, err, socketio_v5.ErrBinaryUnsupported, "Serialize")
+			} else {
+				assert.Equal(t, g.packet, string(data))
+			}
+		})
+	}
+}
+
+func testWrapCallback(t *testing.T, parser Parser) {
+	data, err := serialize(parser, &socketio_v5.Message{
+		Type: socketio_v5.PacketEvent,
+		NS:   "/",
+		Event: &socketio_v5.Event{
+			Name:     typedEvent[0].(string),
+			Payloads: append([]interface{}{}, typedEvent[1:]...),
+		},
+	})
+	require.NoError(t, err)
+	msg, err := parse(parser, string(data))
+	require.NoError(t, err)
+	require.NotNil(t, msg.Event)
+	require.Equal(t, typedEvent[0], msg.Event.Name)
+	payloads := msg.Event.Payloads
+
+	wrap := func(t *testing.T, callback interface{}) func([]interface{}) {
+		t.Helper()
+		wrapped := parser.WrapCallback(callback)
+		require.NotNil(t, wrapped)
+		return wrapped
+	}
+
+	t.Run("Typed arguments", func(t *testing.T) {
+		called := false
+		wrap(t, func(a string, b int, c typedArg, d []string) {
+			assert.Equal(t, typedEvent[1], a)
+			assert.Equal(t, typedEvent[2], b)
+			assert.Equal(t, typedEvent[3], c)
+			assert.Equal(t, typedEvent[4], d)
+			called = true
+		})(payloads)
+		assert.True(t, called)
+	})
+
+	t.Run("Fewer parameters than arguments", func(t *testing.T) {
+		called := false
+		wrap(t, func(a string, b int) {
+			assert.Equal(t, typedEvent[1], a)
+			assert.Equal(t, typedEvent[2], b)
+			called = true
+		})(payloads)
+		assert.True(t, called)
+	})
+
+	t.Run("More parameters than arguments", func(t *testing.T) {
+		called := false
+		wrap(t, func(_ string, _ int, _ typedArg, _ []string, _ string) { called = true })(payloads)
+		assert.False(t, called)
+	})
+
+	t.Run("Mismatched types", func(t *testing.T) {
+		called := false
+		wrap(t, func(_ int, _ int, _ int, _ int) { called = true })(payloads)
+		assert.False(t, called)
+	})
+
+	t.Run("Argument not from Parse", func(t *testing.T) {
+		called := false
+		wrap(t, func(_ string) { called = true })([]interface{}{1})
+		assert.False(t, called)
+	})
+
+	t.Run("Raw arguments", func(t *testing.T) {
+		called := false
+		wrap(t, func(args []interface{}) {
+			assert.Len(t, args, len(typedEvent)-1)
+			called = true
+		})(payloads)
+		assert.True(t, called)
+	})
+
+	t.Run("Not a function", func(t *testing.T) {
+		assert.Nil(t, parser.WrapCallback(1))
+	})
+
+	reporting, ok := parser.(ErrorReportingParser)
+	if !ok {
+		return
+	}
+	t.Run("Decode errors", func(t *testing.T) {
+		var decodeErr *socketio_v5.DecodeError
+
+		err := reporting.WrapCallbackWithError(func(_ string, _ string) {})(payloads)
+		require.True(t, errors.As(err, &decodeErr), "got %v", err)
+		assert.Equal(t, 1, decodeErr.Index)
+
+		err = reporting.WrapCallbackWithError(func(_ string, _ int, _ typedArg, _ []string, _ string) {})(payloads)
+		require.True(t, errors.As(err, &decodeErr), "got %v", err)
+		assert.Equal(t, -1, decodeErr.Index)
+
+		handlerErr := errors.New("handler failed")
+		err = reporting.WrapCallbackWithError(func(_ string) error { return handlerErr })(payloads)
+		assert.ErrorIs(t, err, handlerErr)
+	})
+}
+
+func testRoundTrip(t *testing.T, parser Parser) {
+	var got struct {
+		F float64
+		S string
+		N int
+		A struct{ A bool }
+	}
+	msg := &socketio_v5.Message{
+		Type:  socketio_v5.PacketEvent,
+		NS:    "/rt",
+		AckId: ackID(2147483647),
+		Event: &socketio_v5.Event{
+			Name:     "mixed",
+			Payloads: []interface{}{123.4, "data", 42, struct{ A bool }{A: true}},
+		},
+	}
+	data, err := serialize(parser, msg)
+	require.NoError(t, err)
+	parsed, err := parse(parser, string(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, msg.Type, parsed.Type)
+	assert.Equal(t, msg.NS, parsed.NS)
+	require.NotNil(t, parsed.AckId)
+	assert.Equal(t, *msg.AckId, *parsed.AckId)
+	require.NotNil(t, parsed.Event)
+	assert.Equal(t, "mixed", parsed.Event.Name)
+
+	parser.WrapCallback(func(f float64, s string, n int, a struct{ A bool }) {
+		got.F, got.S, got.N, got.A = f, s, n, a
+	})(parsed.Event.Payloads)
+	assert.Equal(t, 123.4, got.F)
+	assert.Equal(t, "data", got.S)
+	assert.Equal(t, 42, got.N)
+	assert.True(t, got.A.A)
+}
+
+// message is the Message a Go client or server would serialize into g.
+func (g golden) message(t *testing.T) *socketio_v5.Message {
+	t.Helper()
+	msg := &socketio_v5.Message{Type: g.typ, NS: g.ns, AckId: g.ackID}
+	if g.attachments > 0 {
+		attachments := g.attachments
+		msg.BinaryAttachments = &attachments
+	}
+	if g.args != "" {
+		var args []interface{}
+		require.NoError(t, json.Unmarshal([]byte(g.args), &args))
+		msg.Event = &socketio_v5.Event{Name: g.event, Payloads: args}
+	}
+	if g.payload != "" {
+		var payload interface{}
+		require.NoError(t, json.Unmarshal([]byte(g.payload), &payload))
+		msg.Payload = payload
+	}
+	return msg
+}
+
+func assertMessage(t *testing.T, g golden, msg *socketio_v5.Message) {
+	t.Helper()
+	require.NotNil(t, msg)
+	assert.Equal(t, g.typ, msg.Type, "type")
+	assert.Equal(t, g.ns, msg.NS, "namespace")
+	if g.ackID == nil {
+		assert.Nil(t, msg.AckId, "ack id")
+	} else if assert.NotNil(t, msg.AckId, "ack id") {
+		assert.Equal(t, *g.ackID, *msg.AckId, "ack id")
+	}
+	if g.attachments > 0 && msg.BinaryAttachments != nil {
+		assert.Equal(t, g.attachments, *msg.BinaryAttachments, "attachments")
+	}
+
+	if g.args == "" {
+		assert.Nil(t, msg.Event, "event")
+	} else if assert.NotNil(t, msg.Event, "event") {
+		assert.Equal(t, g.event, msg.Event.Name, "event name")
+		assert.JSONEq(t, g.args, argsJSON(t, msg.Event.Payloads), "arguments")
+	}
+
+	if g.payload == "" {
+		assert.Nil(t, msg.Payload, "payload")
+		assert.Nil(t, msg.ErrorMessage, "error message")
+	} else {
+		assert.JSONEq(t, g.payload, payloadJSON(t, msg), "payload")
+	}
+}
+
+// argsJSON renders parsed event arguments as a JSON array.
+func argsJSON(t *testing.T, payloads []interface{}) string {
+	t.Helper()
+	parts := make([]string, 0, len(payloads))
+	for _, payload := range payloads {
+		parts = append(parts, valueJSON(t, payload))
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// payloadJSON renders the CONNECT or CONNECT_ERROR payload, which a parser
+// keeps either in Payload or, as raw JSON, in ErrorMessage.
+func payloadJSON(t *testing.T, msg *socketio_v5.Message) string {
+	t.Helper()
+	if msg.Payload != nil {
+		return valueJSON(t, msg.Payload)
+	}
+	if msg.ErrorMessage != nil {
+		return *msg.ErrorMessage
+	}
+	return ""
+}
+
+func valueJSON(t *testing.T, value interface{}) string {
+	t.Helper()
+	switch v := value.(type) {
+	case json.RawMessage:
+		return string(v)
+	case []byte:
+		return string(v)
+	}
+	data, err := json.Marshal(value)
+	require.NoError(t, err, fmt.Sprintf("marshal %T", value))
+	return string(data)
+}
+
+// parse and serialize turn a parser panic into an error, so a crash on bad
+// input fails the subtest instead of the whole run.
+func parse(parser Parser, packet string) (msg *socketio_v5.Message, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return parser.Parse([]byte(packet))
+}
+
+func serialize(parser Parser, msg *socketio_v5.Message) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return parser.Serialize(msg)
+}
@@ -0,0 +1,125 @@
+package parsertest
+
+import socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+
+// golden is a packet as encoded by the reference socket.io-parser, and the
+// message it stands for.
+type golden struct {
+	name   string
+	packet string
+
+	typ   socketio_v5.SocketIOPacket
+	ns    string
+	ackID *int
+	event string
+	// args is the JSON array of the event or ack arguments, "" for a packet
+	// without any.
+	args string
+	// payload is the JSON of the CONNECT or CONNECT_ERROR payload.
+	payload string
+	// attachments is the number of binary attachments.
+	attachments int
+
+	// parseOnly marks packets the reference parser accepts but a Go
+	// serializer can't reproduce byte for byte, e.g. unsorted object keys.
+	parseOnly bool
+}
+
+func ackID(id int) *int {
+	return &id
+}
+
+var goldens = []golden{
+	{name: "Connect", packet: `0`, typ: socketio_v5.PacketConnect, ns: "/"},
+	{name: "Connect to namespace", packet: `0/admin,`, typ: socketio_v5.PacketConnect, ns: "/admin"},
+	{name: "Connect with auth", packet: `0/admin,{"token":"123"}`, typ: socketio_v5.PacketConnect, ns: "/admin", payload: `{"token":"123"}`},
+	{name: "Connect answer", packet: `0{"sid":"oSO0OpakMV_3jnilAAAA"}`, typ: socketio_v5.PacketConnect, ns: "/", payload: `{"sid":"oSO0OpakMV_3jnilAAAA"}`},
+	{name: "Disconnect", packet: `1`, typ: socketio_v5.PacketDisconnect, ns: "/"},
+	{name: "Disconnect from namespace", packet: `1/admin,`, typ: socketio_v5.PacketDisconnect, ns: "/admin"},
+	{name: "Event", packet: `2["hello",1,{"a":"b"}]`, typ: socketio_v5.PacketEvent, ns: "/", event: "hello", args: `[1,{"a":"b"}]`},
+	{name: "Event without arguments", packet: `2["ping"]`, typ: socketio_v5.PacketEvent, ns: "/", event: "ping", args: `[]`},
+	{name: "Event in namespace", packet: `2/chat,["message","hi",["a","b"],null,true]`, typ: socketio_v5.PacketEvent, ns: "/chat", event: "message", args: `["hi",["a","b"],null,true]`},
+	{name: "Event with ack", packet: `212["hello","world"]`, typ: socketio_v5.PacketEvent, ns: "/", ackID: ackID(12), event: "hello", args: `["world"]`},
+	{name: "Event in namespace with ack", packet: `2/chat,456["hello",{"nested":{"deep":[1,2,3]}}]`, typ: socketio_v5.PacketEvent, ns: "/chat", ackID: ackID(456), event: "hello", args: `[{"nested":{"deep":[1,2,3]}}]`},
+	{name: "Event with unicode", packet: `2["msg","héllo 世界 😀"]`, typ: socketio_v5.PacketEvent, ns: "/", event: "msg", args: `["héllo 世界 😀"]`},
+	{name: "Event with HTML characters", packet: `2["msg","<b>&</b>"]`, typ: socketio_v5.PacketEvent, ns: "/", event: "msg", args: `["<b>&</b>"]`, parseOnly: true},
+	{name: "Ack", packet: `312["ok",{"id":1}]`, typ: socketio_v5.PacketAck, ns: "/", ackID: ackID(12), args: `["ok",{"id":1}]`},
+	{name: "Ack without arguments", packet: `30[]`, typ: socketio_v5.PacketAck, ns: "/", ackID: ackID(0), args: `[]`},
+	{name: "Ack in namespace", packet: `3/chat,7["done"]`, typ: socketio_v5.PacketAck, ns: "/chat", ackID: ackID(7), args: `["done"]`},
+	{name: "Connect error", packet: `4{"message":"Not authorized"}`, typ: socketio_v5.PacketConnectError, ns: "/", payload: `{"message":"Not authorized"}`},
+	{name: "Connect error with data", packet: `4/admin,{"message":"Not authorized","data":{"code":403}}`, typ: socketio_v5.PacketConnectError, ns: "/admin", payload: `{"message":"Not authorized","data":{"code":403}}`, parseOnly: true},
+}
+
+// binaryGoldens are the BINARY_EVENT and BINARY_ACK headers; the
+// attachments follow as separate engine.io frames.
+var binaryGoldens = []golden{
+	{name: "Binary event", packet: `51-["upload",{"_placeholder":true,"num":0}]`, typ: socketio_v5.PacketBinaryEvent, ns: "/", event: "upload", args: `[{"_placeholder":true,"num":0}]`, attachments: 1},
+	{name: "Binary event in namespace with ack", packet: `52-/files,7["upload",{"_placeholder":true,"num":0},{"_placeholder":true,"num":1}]`, typ: socketio_v5.PacketBinaryEvent, ns: "/files", ackID: ackID(7), event: "upload", args: `[{"_placeholder":true,"num":0},{"_placeholder":true,"num":1}]`, attachments: 2},
+	{name: "Binary ack", packet: `61-/files,7[{"_placeholder":true,"num":0}]`, typ: socketio_v5.PacketBinaryAck, ns: "/files", ackID: ackID(7), args: `[{"_placeholder":true,"num":0}]`, attachments: 1},
+}
+
+// malformed are packets the reference parser rejects.
+var malformed = []struct {
+	name   string
+	packet string
+}{
+	{"Empty packet", ``},
+	{"Unknown packet type", `9`},
+	{"Event payload not an array", `2{"a":"b"}`},
+	{"Event without name", `2[]`},
+	{"Event name not a string", `2[{"a":1}]`},
+	{"Truncated event", `2["hello"`},
+	{"Invalid JSON argument", `2["hello",{invalid}]`},
+	{"Namespace without payload", `2/chat,`},
+	{"Ack payload not an array", `312"ok"`},
+	{"Ack id overflow", `29999999999999999999["hello"]`},
+	{"Invalid connect payload", `0{"token":`},
+}
+
+type aStruct struct {
+	B string  `json:"b"`
+	C int     `json:"c"`
+	D dStruct `json:"d"`
+}
+
+type bStruct struct {
+	A int `json:"d"`
+}
+
+type dStruct struct {
+	A string `json:"a"`
+	B int    `json:"b"`
+}
+
+type typedArg struct {
+	A aStruct                `json:"a"`
+	B bStruct                `json:"b"`
+	C map[string]interface{} `json:"c"`
+	D int                    `json:"d"`
+	E string                 `json:"e"`
+	F []string               `json:"f"`
+	G *string                `json:"g"`
+}
+
+// typedEvent is an event whose arguments a typed callback decodes.
+var typedEvent = []interface{}{
+	"eventName",
+	"test",
+	1,
+	typedArg{
+		A: aStruct{B: "c", C: 123, D: dStruct{A: "b", B: 123}},
+		B: bStruct{A: 123},
+		C: map[string]interface{}{
+			"d": float64(123),
+			"e": map[string]interface{}{
+				"a": map[string]interface{}{"b": "c", "c": "d"},
+				"c": "d",
+			},
+			"f": []interface{}{"a", "b", "c"},
+			"g": "h",
+		},
+		D: 123,
+		E: "e",
+	},
+	[]string{"a", "b", "c"},
+}
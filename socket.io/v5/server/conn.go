@@ -0,0 +1,204 @@
+package socketio_v5_server
+
+import (
+	"encoding/json"
+	"sync"
+
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// conn routes the packets of one engine.io session to its sockets, one per
+// namespace.
+type conn struct {
+	server  *Server
+	session EngineIOSession
+
+	mu           sync.Mutex
+	sockets      map[string]*Socket
+	connectTimer utils.Timer
+	closed       bool
+}
+
+func newConn(server *Server, session EngineIOSession) *conn {
+	c := &conn{
+		server:  server,
+		session: session,
+		sockets: make(map[string]*Socket),
+	}
+	c.connectTimer = server.clock.AfterFunc(server.connectTimeout, c.onConnectTimeout)
+
+	session.On("message", c.onMessage)
+	session.On("close", c.onClose)
+	return c
+}
+
+func (c *conn) onConnectTimeout() {
+	c.mu.Lock()
+	idle := len(c.sockets) == 0 && !c.closed
+	c.mu.Unlock()
+	if idle {
+		c.server.logger.Infof("socket.io: no namespace joined by %s, closing", c.session.ID())
+		_ = c.session.Close()
+	}
+}
+
+func (c *conn) send(msg *socketio_v5.Message) error {
+	data, err := c.server.parser.Serialize(msg)
+	if err != nil {
+		return err
+	}
+	return c.session.Send(data)
+}
+
+func (c *conn) onMessage(data []byte) {
+	msg, err := c.server.parser.Parse(data)
+	if err != nil {
+		c.server.logger.Warnf("socket.io: can't parse packet from %s: %v", c.session.ID(), err)
+		c.closeWithReason(ReasonParseError)
+		return
+	}
+
+	if msg.Type == socketio_v5.PacketConnect {
+		c.connect(msg)
+		return
+	}
+
+	c.mu.Lock()
+	socket := c.sockets[msg.NS]
+	c.mu.Unlock()
+	if socket == nil {
+		c.server.logger.Debugf("socket.io: %v for namespace %s not joined by %s", msg.Type, msg.NS, c.session.ID())
+		return
+	}
+
+	switch msg.Type {
+	case socketio_v5.PacketEvent:
+		if msg.Event == nil {
+			return
+		}
+		socket.handleEvent(msg.Event, msg.AckId)
+	case socketio_v5.PacketAck:
+		if msg.AckId == nil || msg.Event == nil {
+			return
+		}
+		socket.handleAck(*msg.AckId, msg.Event.Payloads)
+	case socketio_v5.PacketDisconnect:
+		socket.onClose(ReasonClientNamespaceDisconnect)
+	default:
+		c.server.logger.Warnf("socket.io: unexpected %v packet from %s", msg.Type, c.session.ID())
+	}
+}
+
+// connect runs the namespace middlewares for a CONNECT packet and answers
+// with CONNECT or CONNECT_ERROR. It runs on the transport goroutine, so no
+// packet of the namespace is handled before the socket exists.
+func (c *conn) connect(msg *socketio_v5.Message) {
+	ns := c.server.namespace(msg.NS)
+	if ns == nil {
+		c.connectError(msg.NS, &ConnectError{Message: "Invalid namespace"})
+		return
+	}
+
+	c.mu.Lock()
+	_, joined := c.sockets[msg.NS]
+	c.mu.Unlock()
+	if joined {
+		c.server.logger.Debugf("socket.io: %s already joined %s", c.session.ID(), msg.NS)
+		return
+	}
+
+	id, err := utils.GenerateID()
+	if err != nil {
+		c.server.logger.Errorf("socket.io: generate socket id: %v", err)
+		return
+	}
+
+	auth, _ := msg.Payload.(json.RawMessage)
+	socket := newSocket(c, ns, id, auth)
+
+	if err := ns.runMiddlewares(socket); err != nil {
+		c.connectError(msg.NS, err)
+		return
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.sockets[msg.NS] = socket
+	c.connectTimer.Stop()
+	c.mu.Unlock()
+
+	ns.add(socket)
+
+	if err := c.send(&socketio_v5.Message{
+		Type:    socketio_v5.PacketConnect,
+		NS:      msg.NS,
+		Payload: map[string]string{"sid": id},
+	}); err != nil {
+		c.server.logger.Warnf("socket.io: send connect to %s: %v", c.session.ID(), err)
+	}
+
+	ns.connected(socket)
+}
+
+func (c *conn) connectError(ns string, err error) {
+	connectErr, ok := err.(*ConnectError)
+	if !ok {
+		connectErr = &ConnectError{Message: err.Error()}
+	}
+
+	payload := map[string]interface{}{"message": connectErr.Message}
+	if connectErr.Data != nil {
+		payload["data"] = connectErr.Data
+	}
+
+	if err := c.send(&socketio_v5.Message{
+		Type:    socketio_v5.PacketConnectError,
+		NS:      ns,
+		Payload: payload,
+	}); err != nil {
+		c.server.logger.Warnf("socket.io: send connect error to %s: %v", c.session.ID(), err)
+	}
+}
+
+func (c *conn) remove(socket *Socket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sockets[socket.ns.name] == socket {
+		delete(c.sockets, socket.ns.name)
+	}
+}
+
+// closeWithReason closes the engine.io session and reports reason to the
+// sockets instead of the engine.io close reason.
+func (c *conn) closeWithReason(reason string) {
+	c.disconnectAll(reason)
+	_ = c.session.Close()
+}
+
+func (c *conn) onClose(engineReason []byte) {
+	reason := string(engineReason)
+	if reason == engineio_v4_server.ReasonForcedClose {
+		reason = ReasonForcedServerClose
+	}
+	c.disconnectAll(reason)
+}
+
+func (c *conn) disconnectAll(reason string) {
+	c.mu.Lock()
+	c.closed = true
+	c.connectTimer.Stop()
+	sockets := make([]*Socket, 0, len(c.sockets))
+	for _, socket := range c.sockets {
+		sockets = append(sockets, socket)
+	}
+	c.mu.Unlock()
+
+	for _, socket := range sockets {
+		socket.onClose(reason)
+	}
+}
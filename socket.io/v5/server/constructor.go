@@ -0,0 +1,124 @@
+package socketio_v5_server
+
+import (
+	"errors"
+	"time"
+
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	socketio_v5_parser_default "github.com/maldikhan/go.socket.io/socket.io/v5/parser/default"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+type ServerOption func(*InitServer) error
+
+// InitServer holds the options that only matter while the server is built.
+type InitServer struct {
+	engineOptions []engineio_v4_server.ServerOption
+	*Server
+}
+
+func NewServer(options ...ServerOption) (*Server, error) {
+	server := &InitServer{
+		Server: &Server{
+			logger:         &utils.DefaultLogger{},
+			connectTimeout: 45 * time.Second,
+			clock:          utils.RealClock{},
+			namespaces:     make(map[string]*Namespace),
+		},
+	}
+
+	for _, opt := range options {
+		if err := opt(server); err != nil {
+			return nil, err
+		}
+	}
+
+	if server.logger == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	if server.parser == nil {
+		server.parser = socketio_v5_parser_default.NewParser(
+			socketio_v5_parser_default.WithLogger(server.logger),
+		)
+	}
+
+	if server.clock == nil {
+		return nil, errors.New("clock is nil")
+	}
+
+	if server.engineio == nil {
+		engineio, err := engineio_v4_server.NewServer(
+			append([]engineio_v4_server.ServerOption{
+				engineio_v4_server.WithLogger(server.logger),
+				engineio_v4_server.WithClock(server.clock),
+			}, server.engineOptions...)...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		server.engineio = engineio
+	} else if len(server.engineOptions) > 0 {
+		return nil, errors.New("WithEngineIOOptions can't be combined with WithEngineIOServer")
+	}
+
+	server.Of("/")
+	server.engineio.OnConnection(server.onEngineConnection)
+
+	return server.Server, nil
+}
+
+// WithEngineIOServer runs the server on a custom engine.io server.
+func WithEngineIOServer(engineio EngineIOServer) ServerOption {
+	return func(s *InitServer) error {
+		s.engineio = engineio
+		return nil
+	}
+}
+
+// WithEngineIOOptions passes options to the default engine.io server, e.g.
+// engineio_v4_server.WithPingInterval.
+func WithEngineIOOptions(options ...engineio_v4_server.ServerOption) ServerOption {
+	return func(s *InitServer) error {
+		s.engineOptions = append(s.engineOptions, options...)
+		return nil
+	}
+}
+
+func WithLogger(logger Logger) ServerOption {
+	return func(s *InitServer) error {
+		s.logger = logger
+		return nil
+	}
+}
+
+func WithParser(parser Parser) ServerOption {
+	return func(s *InitServer) error {
+		s.parser = parser
+		return nil
+	}
+}
+
+// WithConnectTimeout sets how long an engine.io connection may stay without
+// joining any namespace before it is closed.
+func WithConnectTimeout(timeout time.Duration) ServerOption {
+	return func(s *InitServer) error {
+		if timeout <= 0 {
+			return errors.New("connect timeout must be positive")
+		}
+		s.connectTimeout = timeout
+		return nil
+	}
+}
+
+// WithClock drives the connect timeout, the emit ack timeouts and the
+// heartbeat of the default engine.io server from clock.
+func WithClock(clock utils.Clock) ServerOption {
+	return func(s *InitServer) error {
+		if clock == nil {
+			return errors.New("clock is nil")
+		}
+		s.clock = clock
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+//go:generate mockgen -source=$GOFILE -destination=mocks/deps.go -package=${packageName}
+
+package socketio_v5_server
+
+import (
+	"net/http"
+
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
+
+type Parser interface {
+	WrapCallback(callback interface{}) func(in []interface{})
+	Parse([]byte) (*socketio_v5.Message, error)
+	Serialize(*socketio_v5.Message) ([]byte, error)
+}
+
+// EngineIOServer is the engine.io layer the socket.io server runs on.
+type EngineIOServer interface {
+	http.Handler
+	OnConnection(handler func(*engineio_v4_server.Session))
+	Close() error
+}
+
+// EngineIOSession is one engine.io connection.
+type EngineIOSession interface {
+	ID() string
+	Request() *http.Request
+	On(event string, handler func([]byte))
+	Send(message []byte) error
+	Close() error
+}
+
+type Logger interface {
+	Debugf(format string, v ...any)
+	Infof(format string, v ...any)
+	Warnf(format string, v ...any)
+	Errorf(format string, v ...any)
+}
@@ -0,0 +1,324 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: dep.go
+
+// Package mock_socketio_v5_server is a generated GoMock package.
+package mock_socketio_v5_server
+
+import (
+	http "net/http"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+)
+
+// MockParser is a mock of Parser interface.
+type MockParser struct {
+	ctrl     *gomock.Controller
+	recorder *MockParserMockRecorder
+}
+
+// MockParserMockRecorder is the mock recorder for MockParser.
+type MockParserMockRecorder struct {
+	mock *MockParser
+}
+
+// NewMockParser creates a new mock instance.
+func NewMockParser(ctrl *gomock.Controller) *MockParser {
+	mock := &MockParser{ctrl: ctrl}
+	mock.recorder = &MockParserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockParser) EXPECT() *MockParserMockRecorder {
+	return m.recorder
+}
+
+// Parse mocks base method.
+func (m *MockParser) Parse(arg0 []byte) (*socketio_v5.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Parse", arg0)
+	ret0, _ := ret[0].(*socketio_v5.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Parse indicates an expected call of Parse.
+func (mr *MockParserMockRecorder) Parse(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Parse", reflect.TypeOf((*MockParser)(nil).Parse), arg0)
+}
+
+// Serialize mocks base method.
+func (m *MockParser) Serialize(arg0 *socketio_v5.Message) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Serialize", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Serialize indicates an expected call of Serialize.
+func (mr *MockParserMockRecorder) Serialize(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Serialize", reflect.TypeOf((*MockParser)(nil).Serialize), arg0)
+}
+
+// WrapCallback mocks base method.
+func (m *MockParser) WrapCallback(callback interface{}) func([]interface{}) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WrapCallback", callback)
+	ret0, _ := ret[0].(func([]interface{}))
+	return ret0
+}
+
+// WrapCallback indicates an expected call of WrapCallback.
+func (mr *MockParserMockRecorder) WrapCallback(callback interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WrapCallback", reflect.TypeOf((*MockParser)(nil).WrapCallback), callback)
+}
+
+// MockEngineIOServer is a mock of EngineIOServer interface.
+type MockEngineIOServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockEngineIOServerMockRecorder
+}
+
+// MockEngineIOServerMockRecorder is the mock recorder for MockEngineIOServer.
+type MockEngineIOServerMockRecorder struct {
+	mock *MockEngineIOServer
+}
+
+// NewMockEngineIOServer creates a new mock instance.
+func NewMockEngineIOServer(ctrl *gomock.Controller) *MockEngineIOServer {
+	mock := &MockEngineIOServer{ctrl: ctrl}
+	mock.recorder = &MockEngineIOServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEngineIOServer) EXPECT() *MockEngineIOServerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockEngineIOServer) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockEngineIOServerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockEngineIOServer)(nil).Close))
+}
+
+// OnConnection mocks base method.
+func (m *MockEngineIOServer) OnConnection(handler func(*engineio_v4_server.Session)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnConnection", handler)
+}
+
+// OnConnection indicates an expected call of OnConnection.
+func (mr *MockEngineIOServerMockRecorder) OnConnection(handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnConnection", reflect.TypeOf((*MockEngineIOServer)(nil).OnConnection), handler)
+}
+
+// ServeHTTP mocks base method.
+func (m *MockEngineIOServer) ServeHTTP(arg0 http.ResponseWriter, arg1 *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ServeHTTP", arg0, arg1)
+}
+
+// ServeHTTP indicates an expected call of ServeHTTP.
+func (mr *MockEngineIOServerMockRecorder) ServeHTTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServeHTTP", reflect.TypeOf((*MockEngineIOServer)(nil).ServeHTTP), arg0, arg1)
+}
+
+// MockEngineIOSession is a mock of EngineIOSession interface.
+type MockEngineIOSession struct {
+	ctrl     *gomock.Controller
+	recorder *MockEngineIOSessionMockRecorder
+}
+
+// MockEngineIOSessionMockRecorder is the mock recorder for MockEngineIOSession.
+type MockEngineIOSessionMockRecorder struct {
+	mock *MockEngineIOSession
+}
+
+// NewMockEngineIOSession creates a new mock instance.
+func NewMockEngineIOSession(ctrl *gomock.Controller) *MockEngineIOSession {
+	mock := &MockEngineIOSession{ctrl: ctrl}
+	mock.recorder = &MockEngineIOSessionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEngineIOSession) EXPECT() *MockEngineIOSessionMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockEngineIOSession) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockEngineIOSessionMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockEngineIOSession)(nil).Close))
+}
+
+// ID mocks base method.
+func (m *MockEngineIOSession) ID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ID indicates an expected call of ID.
+func (mr *MockEngineIOSessionMockRecorder) ID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ID", reflect.TypeOf((*MockEngineIOSession)(nil).ID))
+}
+
+// On mocks base method.
+func (m *MockEngineIOSession) On(event string, handler func([]byte)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "On", event, handler)
+}
+
+// On indicates an expected call of On.
+func (mr *MockEngineIOSessionMockRecorder) On(event, handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "On", reflect.TypeOf((*MockEngineIOSession)(nil).On), event, handler)
+}
+
+// Request mocks base method.
+func (m *MockEngineIOSession) Request() *http.Request {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Request")
+	ret0, _ := ret[0].(*http.Request)
+	return ret0
+}
+
+// Request indicates an expected call of Request.
+func (mr *MockEngineIOSessionMockRecorder) Request() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockEngineIOSession)(nil).Request))
+}
+
+// Send mocks base method.
+func (m *MockEngineIOSession) Send(message []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockEngineIOSessionMockRecorder) Send(message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockEngineIOSession)(nil).Send), message)
+}
+
+// MockLogger is a mock of Logger interface.
+type MockLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoggerMockRecorder
+}
+
+// MockLoggerMockRecorder is the mock recorder for MockLogger.
+type MockLoggerMockRecorder struct {
+	mock *MockLogger
+}
+
+// NewMockLogger creates a new mock instance.
+func NewMockLogger(ctrl *gomock.Controller) *MockLogger {
+	mock := &MockLogger{ctrl: ctrl}
+	mock.recorder = &MockLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLogger) EXPECT() *MockLoggerMockRecorder {
+	return m.recorder
+}
+
+// Debugf mocks base method.
+func (m *MockLogger) Debugf(format string, v ...any) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{format}
+	for _, a := range v {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Debugf", varargs...)
+}
+
+// Debugf indicates an expected call of Debugf.
+func (mr *MockLoggerMockRecorder) Debugf(format interface{}, v ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{format}, v...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debugf", reflect.TypeOf((*MockLogger)(nil).Debugf), varargs...)
+}
+
+// Errorf mocks base method.
+func (m *MockLogger) Errorf(format string, v ...any) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{format}
+	for _, a := range v {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Errorf", varargs...)
+}
+
+// Errorf indicates an expected call of Errorf.
+func (mr *MockLoggerMockRecorder) Errorf(format interface{}, v ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{format}, v...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Errorf", reflect.TypeOf((*MockLogger)(nil).Errorf), varargs...)
+}
+
+// Infof mocks base method.
+func (m *MockLogger) Infof(format string, v ...any) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{format}
+	for _, a := range v {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Infof", varargs...)
+}
+
+// Infof indicates an expected call of Infof.
+func (mr *MockLoggerMockRecorder) Infof(format interface{}, v ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{format}, v...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Infof", reflect.TypeOf((*MockLogger)(nil).Infof), varargs...)
+}
+
+// Warnf mocks base method.
+func (m *MockLogger) Warnf(format string, v ...any) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{format}
+	for _, a := range v {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Warnf", varargs...)
+}
+
+// Warnf indicates an expected call of Warnf.
+func (mr *MockLoggerMockRecorder) Warnf(format interface{}, v ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{format}, v...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnf", reflect.TypeOf((*MockLogger)(nil).Warnf), varargs...)
+}
@@ -0,0 +1,245 @@
+package socketio_v5_server
+
+import (
+	"errors"
+	"sync"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+)
+
+// Middleware runs for every CONNECT to a namespace, before the socket is
+// announced. Returning an error rejects the connection with a CONNECT_ERROR;
+// return a *ConnectError to send data along with the message.
+type Middleware func(socket *Socket) error
+
+// ConnectError rejects a namespace connection. Message and Data are sent to
+// the client as {"message": ..., "data": ...}.
+type ConnectError struct {
+	Message string
+	Data    interface{}
+}
+
+func (e *ConnectError) Error() string {
+	return e.Message
+}
+
+// Namespace is a communication channel sockets connect to, e.g. "/" or
+// "/admin".
+type Namespace struct {
+	server *Server
+	name   string
+
+	handlerMu          sync.RWMutex
+	middlewares        []Middleware
+	connectionHandlers []func(*Socket)
+
+	// mu guards sockets, rooms and every socket's room set.
+	mu      sync.RWMutex
+	sockets map[string]*Socket
+	rooms   map[string]map[string]*Socket
+}
+
+func newNamespace(server *Server, name string) *Namespace {
+	return &Namespace{
+		server:  server,
+		name:    name,
+		sockets: make(map[string]*Socket),
+		rooms:   make(map[string]map[string]*Socket),
+	}
+}
+
+// Name returns the namespace name.
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// Use adds a connection middleware. Middlewares run in order on the
+// connection's transport goroutine.
+func (n *Namespace) Use(middleware Middleware) {
+	n.handlerMu.Lock()
+	defer n.handlerMu.Unlock()
+	n.middlewares = append(n.middlewares, middleware)
+}
+
+// OnConnection adds a handler called for every connected socket. It runs
+// before any event of the socket is dispatched, so it is the place to
+// register the socket's handlers.
+func (n *Namespace) OnConnection(handler func(*Socket)) {
+	n.handlerMu.Lock()
+	defer n.handlerMu.Unlock()
+	n.connectionHandlers = append(n.connectionHandlers, handler)
+}
+
+// Sockets returns the connected sockets.
+func (n *Namespace) Sockets() []*Socket {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	sockets := make([]*Socket, 0, len(n.sockets))
+	for _, socket := range n.sockets {
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+// Socket returns the connected socket with the given id, or nil.
+func (n *Namespace) Socket(id string) *Socket {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.sockets[id]
+}
+
+// To targets the sockets in any of the rooms.
+func (n *Namespace) To(rooms ...string) *BroadcastOperator {
+	return (&BroadcastOperator{ns: n}).To(rooms...)
+}
+
+// Except targets every socket except those in the rooms.
+func (n *Namespace) Except(rooms ...string) *BroadcastOperator {
+	return (&BroadcastOperator{ns: n}).Except(rooms...)
+}
+
+// Emit sends an event to every socket of the namespace.
+func (n *Namespace) Emit(event string, args ...interface{}) error {
+	return (&BroadcastOperator{ns: n}).Emit(event, args...)
+}
+
+func (n *Namespace) runMiddlewares(socket *Socket) error {
+	n.handlerMu.RLock()
+	middlewares := n.middlewares
+	n.handlerMu.RUnlock()
+
+	for _, middleware := range middlewares {
+		if err := middleware(socket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add registers a socket and joins it to the room named after its id.
+func (n *Namespace) add(socket *Socket) {
+	n.mu.Lock()
+	n.sockets[socket.id] = socket
+	n.mu.Unlock()
+	socket.Join(socket.id)
+}
+
+func (n *Namespace) connected(socket *Socket) {
+	n.handlerMu.RLock()
+	handlers := n.connectionHandlers
+	n.handlerMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(socket)
+	}
+}
+
+// remove unregisters a socket and makes it leave every room.
+func (n *Namespace) remove(socket *Socket) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.sockets, socket.id)
+	for room := range socket.rooms {
+		n.leaveLocked(socket, room)
+	}
+}
+
+func (n *Namespace) leaveLocked(socket *Socket, room string) {
+	delete(socket.rooms, room)
+	members := n.rooms[room]
+	delete(members, socket.id)
+	if len(members) == 0 {
+		delete(n.rooms, room)
+	}
+}
+
+// BroadcastOperator selects the sockets a broadcast goes to. The zero set of
+// rooms means every socket of the namespace.
+type BroadcastOperator struct {
+	ns     *Namespace
+	rooms  []string
+	except []string
+}
+
+// To adds rooms to the target set.
+func (b *BroadcastOperator) To(rooms ...string) *BroadcastOperator {
+	return &BroadcastOperator{
+		ns:     b.ns,
+		rooms:  append(append([]string{}, b.rooms...), rooms...),
+		except: b.except,
+	}
+}
+
+// Except excludes the sockets in the rooms.
+func (b *BroadcastOperator) Except(rooms ...string) *BroadcastOperator {
+	return &BroadcastOperator{
+		ns:     b.ns,
+		rooms:  b.rooms,
+		except: append(append([]string{}, b.except...), rooms...),
+	}
+}
+
+// Emit sends an event to the targeted sockets. The packet is serialized
+// once; acknowledgements are not supported for broadcasts.
+func (b *BroadcastOperator) Emit(event string, args ...interface{}) error {
+	if err := checkEventName(event); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, ok := arg.(emit.EmitOption); ok {
+			return errors.New("acknowledgements are not supported for broadcasts")
+		}
+	}
+
+	data, err := b.ns.server.parser.Serialize(&socketio_v5.Message{
+		Type:  socketio_v5.PacketEvent,
+		NS:    b.ns.name,
+		Event: &socketio_v5.Event{Name: event, Payloads: args},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, socket := range b.targets() {
+		if err := socket.conn.session.Send(data); err != nil {
+			b.ns.server.logger.Debugf("socket.io: broadcast to %s: %v", socket.id, err)
+		}
+	}
+	return nil
+}
+
+func (b *BroadcastOperator) targets() []*Socket {
+	b.ns.mu.RLock()
+	defer b.ns.mu.RUnlock()
+
+	excluded := make(map[string]bool)
+	for _, room := range b.except {
+		for id := range b.ns.rooms[room] {
+			excluded[id] = true
+		}
+	}
+
+	var targets []*Socket
+	add := func(socket *Socket) {
+		if !excluded[socket.id] {
+			excluded[socket.id] = true // also dedups sockets in several rooms
+			targets = append(targets, socket)
+		}
+	}
+
+	if len(b.rooms) == 0 {
+		for _, socket := range b.ns.sockets {
+			add(socket)
+		}
+		return targets
+	}
+
+	for _, room := range b.rooms {
+		for _, socket := range b.ns.rooms[room] {
+			add(socket)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,80 @@
+package socketio_v5_server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// Server is a socket.io v5 server. It is an http.Handler meant to be mounted
+// on "/socket.io/".
+type Server struct {
+	engineio EngineIOServer
+	parser   Parser
+	logger   Logger
+
+	connectTimeout time.Duration
+	clock          utils.Clock
+
+	mu         sync.RWMutex
+	namespaces map[string]*Namespace
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.engineio.ServeHTTP(w, r)
+}
+
+// Of returns the namespace with the given name, creating it if needed.
+func (s *Server) Of(name string) *Namespace {
+	if name == "" {
+		name = "/"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ns, ok := s.namespaces[name]; ok {
+		return ns
+	}
+	ns := newNamespace(s, name)
+	s.namespaces[name] = ns
+	return ns
+}
+
+func (s *Server) namespace(name string) *Namespace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.namespaces[name]
+}
+
+// Use adds a connection middleware to the main namespace.
+func (s *Server) Use(middleware Middleware) {
+	s.Of("/").Use(middleware)
+}
+
+// OnConnection sets a connection handler on the main namespace.
+func (s *Server) OnConnection(handler func(*Socket)) {
+	s.Of("/").OnConnection(handler)
+}
+
+// To targets the rooms of the main namespace.
+func (s *Server) To(rooms ...string) *BroadcastOperator {
+	return s.Of("/").To(rooms...)
+}
+
+// Emit sends an event to every socket of the main namespace.
+func (s *Server) Emit(event string, args ...interface{}) error {
+	return s.Of("/").Emit(event, args...)
+}
+
+// Close disconnects every socket with ReasonServerShuttingDown.
+func (s *Server) Close() error {
+	return s.engineio.Close()
+}
+
+func (s *Server) onEngineConnection(session *engineio_v4_server.Session) {
+	newConn(s, session)
+}
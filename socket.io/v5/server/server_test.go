@@ -0,0 +1,448 @@
+package socketio_v5_server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	socketio_v5_client "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+	"github.com/maldikhan/go.socket.io/utils"
+	"github.com/maldikhan/go.socket.io/utils/fakeclock"
+)
+
+var quietLogger = &utils.DefaultLogger{Level: utils.NONE}
+
+func newTestServer(t *testing.T, options ...ServerOption) (*Server, *httptest.Server) {
+	t.Helper()
+	options = append([]ServerOption{
+		WithLogger(quietLogger),
+		WithEngineIOOptions(engineio_v4_server.WithPingInterval(time.Minute)),
+	}, options...)
+	server, err := NewServer(options...)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		_ = server.Close()
+		httpServer.Close()
+	})
+	return server, httpServer
+}
+
+// rawClient speaks socket.io frames over the Go engine.io client.
+type rawClient struct {
+	engine *engineio_v4_client.Client
+	frames chan string
+}
+
+func dialRaw(t *testing.T, httpServer *httptest.Server) *rawClient {
+	t.Helper()
+	u, err := url.Parse(httpServer.URL + "/socket.io/")
+	require.NoError(t, err)
+	engine, err := engineio_v4_client.NewClient(
+		engineio_v4_client.WithURL(u),
+		engineio_v4_client.WithLogger(quietLogger),
+	)
+	require.NoError(t, err)
+
+	c := &rawClient{engine: engine, frames: make(chan string, 20)}
+	engine.On("message", func(data []byte) { c.frames <- string(data) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	require.NoError(t, engine.Connect(ctx))
+	t.Cleanup(func() { _ = engine.Close() })
+	return c
+}
+
+func (c *rawClient) send(t *testing.T, frame string) {
+	t.Helper()
+	require.NoError(t, c.engine.Send([]byte(frame)))
+}
+
+func (c *rawClient) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case frame := <-c.frames:
+		return frame
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for frame")
+		return ""
+	}
+}
+
+// join connects a namespace and checks the CONNECT answer.
+func (c *rawClient) join(t *testing.T, connectFrame string) {
+	t.Helper()
+	c.send(t, connectFrame)
+	assert.Regexp(t, `^0(/\w+,)?\{"sid":"[\w-]{20}"\}$`, c.next(t))
+}
+
+func receive(t *testing.T, ch chan string) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout")
+		return ""
+	}
+}
+
+func TestServer_ConnectAndMiddleware(t *testing.T) {
+	server, httpServer := newTestServer(t)
+
+	admin := server.Of("/admin")
+	admin.Use(func(socket *Socket) error {
+		var auth struct {
+			Token string `json:"token"`
+		}
+		_ = json.Unmarshal(socket.Auth(), &auth)
+		if auth.Token != "secret" {
+			return &ConnectError{Message: "not authorized", Data: map[string]string{"reason": "token"}}
+		}
+		return nil
+	})
+	admin.Use(func(socket *Socket) error {
+		if socket.Request().Header.Get("X-Block") != "" {
+			return errors.New("blocked")
+		}
+		return nil
+	})
+	connected := make(chan string, 1)
+	admin.OnConnection(func(socket *Socket) { connected <- socket.ID() })
+
+	client := dialRaw(t, httpServer)
+
+	t.Run("Invalid namespace", func(t *testing.T) {
+		client.send(t, "0/nope,")
+		assert.Equal(t, `4/nope,{"message":"Invalid namespace"}`, client.next(t))
+	})
+
+	t.Run("Rejected by middleware", func(t *testing.T) {
+		client.send(t, `0/admin,{"token":"wrong"}`)
+		assert.Equal(t, `4/admin,{"data":{"reason":"token"},"message":"not authorized"}`, client.next(t))
+	})
+
+	t.Run("Accepted", func(t *testing.T) {
+		client.join(t, `0/admin,{"token":"secret"}`)
+		id := receive(t, connected)
+		require.NotNil(t, admin.Socket(id))
+		assert.Equal(t, []string{id}, admin.Socket(id).Rooms())
+	})
+}
+
+func TestServer_EventsAndAcks(t *testing.T) {
+	server, httpServer := newTestServer(t)
+
+	sockets := make(chan *Socket, 1)
+	messages := make(chan string, 1)
+	disconnects := make(chan string, 1)
+	caught := make(chan []interface{}, 1)
+	server.OnConnection(func(socket *Socket) {
+		socket.OnAny(func(event string, args []interface{}, ack Ack) {
+			switch event {
+			case "echo":
+				_ = ack(args...)
+				caught <- args
+			case "no-ack":
+				assert.Nil(t, ack)
+				caught <- args
+			}
+		})
+		socket.On("message", func(text string, n int) {
+			messages <- text
+		})
+		socket.On("sum", func(ack Ack, a, b int) {
+			_ = ack(a + b)
+		})
+		socket.On("disconnect", func(reason string) {
+			disconnects <- reason
+		})
+		sockets <- socket
+	})
+
+	client := dialRaw(t, httpServer)
+	client.join(t, "0")
+	socket := <-sockets
+
+	t.Run("Typed handler", func(t *testing.T) {
+		client.send(t, `2["message","hello",1]`)
+		assert.Equal(t, "hello", receive(t, messages))
+	})
+
+	t.Run("Client ack", func(t *testing.T) {
+		client.send(t, `27["sum",2,3]`)
+		assert.Equal(t, `37[5]`, client.next(t))
+	})
+
+	t.Run("Catch-all handler", func(t *testing.T) {
+		client.send(t, `29["echo","hi",1]`)
+		assert.Equal(t, `39["hi",1]`, client.next(t))
+		select {
+		case args := <-caught:
+			assert.Len(t, args, 2)
+		case <-time.After(2 * time.Second):
+			t.Fatal("catch-all handler not called")
+		}
+
+		client.send(t, `2["no-ack"]`)
+		select {
+		case args := <-caught:
+			assert.Empty(t, args)
+		case <-time.After(2 * time.Second):
+			t.Fatal("catch-all handler not called")
+		}
+	})
+
+	t.Run("Server emit", func(t *testing.T) {
+		require.NoError(t, socket.Emit("news", map[string]int{"n": 1}))
+		assert.Equal(t, `2["news",{"n":1}]`, client.next(t))
+	})
+
+	t.Run("Server emit with ack", func(t *testing.T) {
+		replies := make(chan string, 1)
+		require.NoError(t, socket.Emit("ping", emit.WithAck(func(reply string) { replies <- reply })))
+		assert.Equal(t, `21["ping"]`, client.next(t))
+		client.send(t, `31["pong"]`)
+		assert.Equal(t, "pong", receive(t, replies))
+	})
+
+	t.Run("Ack timeout", func(t *testing.T) {
+		timedOut := make(chan string, 1)
+		require.NoError(t, socket.Emit("ping",
+			emit.WithAck(func(string) { t.Error("late ack delivered") }),
+			emit.WithTimeout(50*time.Millisecond, func() { timedOut <- "timeout" }),
+		))
+		assert.Equal(t, `22["ping"]`, client.next(t))
+		assert.Equal(t, "timeout", receive(t, timedOut))
+		client.send(t, `32["pong"]`)
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	t.Run("Reserved event", func(t *testing.T) {
+		assert.Error(t, socket.Emit("disconnect"))
+	})
+
+	t.Run("Reserved event from client", func(t *testing.T) {
+		client.send(t, `2["disconnect","forged"]`)
+		client.send(t, `28["sum",1,1]`)
+		assert.Equal(t, `38[2]`, client.next(t))
+		time.Sleep(50 * time.Millisecond)
+
+		select {
+		case reason := <-disconnects:
+			t.Fatalf("disconnect handler ran with %q", reason)
+		default:
+		}
+		assert.True(t, socket.Connected())
+	})
+}
+
+func TestServer_Rooms(t *testing.T) {
+	server, httpServer := newTestServer(t)
+
+	sockets := make(chan *Socket, 3)
+	server.OnConnection(func(socket *Socket) { sockets <- socket })
+
+	clients := make([]*rawClient, 3)
+	serverSockets := make([]*Socket, 3)
+	for i := range clients {
+		clients[i] = dialRaw(t, httpServer)
+		clients[i].join(t, "0")
+		serverSockets[i] = <-sockets
+	}
+
+	serverSockets[0].Join("red")
+	serverSockets[1].Join("red", "blue")
+	serverSockets[2].Join("blue")
+
+	rooms := serverSockets[1].Rooms()
+	sort.Strings(rooms)
+	expected := []string{"blue", "red", serverSockets[1].ID()}
+	sort.Strings(expected)
+	assert.Equal(t, expected, rooms)
+
+	expectFrames := func(t *testing.T, frame string, receivers ...int) {
+		t.Helper()
+		for i, client := range clients {
+			got := ""
+			select {
+			case got = <-client.frames:
+			case <-time.After(100 * time.Millisecond):
+			}
+			if contains(receivers, i) {
+				assert.Equal(t, frame, got, "client %d", i)
+			} else {
+				assert.Empty(t, got, "client %d", i)
+			}
+		}
+	}
+
+	t.Run("To room", func(t *testing.T) {
+		require.NoError(t, server.To("red").Emit("hi", 1))
+		expectFrames(t, `2["hi",1]`, 0, 1)
+	})
+
+	t.Run("To several rooms is deduplicated", func(t *testing.T) {
+		require.NoError(t, server.To("red", "blue").Emit("hi", 2))
+		expectFrames(t, `2["hi",2]`, 0, 1, 2)
+	})
+
+	t.Run("Except", func(t *testing.T) {
+		require.NoError(t, server.To("red").Except("blue").Emit("hi", 3))
+		expectFrames(t, `2["hi",3]`, 0)
+	})
+
+	t.Run("Socket broadcast excludes sender", func(t *testing.T) {
+		require.NoError(t, serverSockets[1].To("blue").Emit("hi", 4))
+		expectFrames(t, `2["hi",4]`, 2)
+		require.NoError(t, serverSockets[0].Broadcast().Emit("hi", 5))
+		expectFrames(t, `2["hi",5]`, 1, 2)
+	})
+
+	t.Run("Leave", func(t *testing.T) {
+		serverSockets[0].Leave("red")
+		require.NoError(t, server.To("red").Emit("hi", 6))
+		expectFrames(t, `2["hi",6]`, 1)
+	})
+
+	t.Run("Namespace emit", func(t *testing.T) {
+		require.NoError(t, server.Emit("all"))
+		expectFrames(t, `2["all"]`, 0, 1, 2)
+	})
+
+	t.Run("Acks are rejected for broadcasts", func(t *testing.T) {
+		assert.Error(t, server.To("red").Emit("hi", emit.WithAck(func() {})))
+	})
+}
+
+func TestServer_DisconnectReasons(t *testing.T) {
+	server, httpServer := newTestServer(t)
+	chat := server.Of("/chat")
+
+	sockets := make(chan *Socket, 2)
+	reasons := make(chan string, 4)
+	onConnection := func(socket *Socket) {
+		socket.On("disconnect", func(reason string) { reasons <- reason })
+		sockets <- socket
+	}
+	server.OnConnection(onConnection)
+	chat.OnConnection(onConnection)
+
+	t.Run("Client namespace disconnect", func(t *testing.T) {
+		client := dialRaw(t, httpServer)
+		client.join(t, "0/chat,")
+		socket := <-sockets
+		client.send(t, "1/chat,")
+		assert.Equal(t, ReasonClientNamespaceDisconnect, receive(t, reasons))
+		assert.False(t, socket.Connected())
+		assert.Nil(t, chat.Socket(socket.ID()))
+	})
+
+	t.Run("Server namespace disconnect", func(t *testing.T) {
+		client := dialRaw(t, httpServer)
+		client.join(t, "0/chat,")
+		socket := <-sockets
+		require.NoError(t, socket.Disconnect(false))
+		assert.Equal(t, "1/chat,", client.next(t))
+		assert.Equal(t, ReasonServerNamespaceDisconnect, receive(t, reasons))
+		assert.ErrorIs(t, socket.Emit("late"), ErrSocketDisconnected)
+	})
+
+	t.Run("Forced server close disconnects every namespace", func(t *testing.T) {
+		client := dialRaw(t, httpServer)
+		client.join(t, "0")
+		root := <-sockets
+		client.join(t, "0/chat,")
+		<-sockets
+		require.NoError(t, root.Disconnect(true))
+		assert.Equal(t, ReasonForcedServerClose, receive(t, reasons))
+		assert.Equal(t, ReasonForcedServerClose, receive(t, reasons))
+	})
+
+	t.Run("Transport close", func(t *testing.T) {
+		client := dialRaw(t, httpServer)
+		client.join(t, "0")
+		<-sockets
+		require.NoError(t, client.engine.Close())
+		assert.Equal(t, ReasonTransportClose, receive(t, reasons))
+	})
+
+	t.Run("Server shutting down", func(t *testing.T) {
+		client := dialRaw(t, httpServer)
+		client.join(t, "0")
+		<-sockets
+		require.NoError(t, server.Close())
+		assert.Equal(t, ReasonServerShuttingDown, receive(t, reasons))
+	})
+}
+
+func TestServer_ConnectTimeoutClock(t *testing.T) {
+	_, err := NewServer(WithClock(nil))
+	assert.Error(t, err)
+
+	clock := fakeclock.New(time.Now())
+	server, httpServer := newTestServer(t, WithClock(clock))
+	engine := server.engineio.(*engineio_v4_server.Server)
+
+	dialRaw(t, httpServer)
+
+	// The engine.io heartbeat and the connect timeout.
+	clock.BlockUntil(2)
+	clock.Advance(45*time.Second - time.Millisecond)
+	assert.Equal(t, 1, engine.Count())
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, 0, engine.Count())
+}
+
+func TestServer_GoClient(t *testing.T) {
+	server, httpServer := newTestServer(t)
+	server.Of("/chat").OnConnection(func(socket *Socket) {
+		socket.On("echo", func(ack Ack, text string) {
+			_ = ack("echo: " + text)
+		})
+		_ = socket.Emit("welcome", socket.ID())
+	})
+
+	client, err := socketio_v5_client.NewClient(
+		socketio_v5_client.WithRawURL(httpServer.URL),
+		socketio_v5_client.WithDefaultNamespace("/chat"),
+		socketio_v5_client.WithLogger(quietLogger),
+	)
+	require.NoError(t, err)
+
+	welcome := make(chan string, 1)
+	client.On("welcome", func(id string) { welcome <- id })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Connect(ctx))
+	defer client.Close() //nolint:errcheck
+
+	id := receive(t, welcome)
+	assert.Equal(t, client.ID(), id)
+
+	replies := make(chan string, 1)
+	require.NoError(t, client.Emit("echo", "hi", emit.WithAck(func(reply string) { replies <- reply })))
+	assert.Equal(t, "echo: hi", receive(t, replies))
+}
+
+func contains(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
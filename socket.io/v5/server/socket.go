@@ -0,0 +1,441 @@
+package socketio_v5_server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"sync"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// Disconnect reasons passed to "disconnect" handlers, matching the socket.io
+// reference server.
+const (
+	ReasonServerNamespaceDisconnect = "server namespace disconnect"
+	ReasonClientNamespaceDisconnect = "client namespace disconnect"
+	ReasonForcedServerClose         = "forced server close"
+	ReasonServerShuttingDown        = "server shutting down"
+	ReasonTransportClose            = "transport close"
+	ReasonTransportError            = "transport error"
+	ReasonPingTimeout               = "ping timeout"
+	ReasonParseError                = "parse error"
+)
+
+var ErrSocketDisconnected = errors.New("socket is disconnected")
+
+// reservedEvents can't be emitted, as in the JS server.
+var reservedEvents = map[string]bool{
+	"connect":        true,
+	"connect_error":  true,
+	"disconnect":     true,
+	"disconnecting":  true,
+	"newListener":    true,
+	"removeListener": true,
+}
+
+func checkEventName(event string) error {
+	if event == "" {
+		return errors.New("empty event name")
+	}
+	if reservedEvents[event] {
+		return fmt.Errorf("%q is a reserved event name", event)
+	}
+	return nil
+}
+
+// Ack answers an event the client emitted with an acknowledgement callback.
+// A handler receives it when its first parameter is an Ack; calling it for
+// an event sent without a callback is a no-op.
+type Ack func(args ...interface{}) error
+
+var ackType = reflect.TypeOf(Ack(nil))
+
+// Socket is a client connected to a namespace.
+type Socket struct {
+	conn *conn
+	ns   *Namespace
+	id   string
+	auth json.RawMessage
+
+	handlerMu   sync.RWMutex
+	handlers    map[string][]func([]interface{}, Ack)
+	anyHandlers []func(string, []interface{}, Ack)
+
+	// mu guards connected and the ack state.
+	mu         sync.Mutex
+	connected  bool
+	acks       map[int]func([]interface{})
+	ackCounter int
+
+	// rooms is guarded by ns.mu.
+	rooms map[string]struct{}
+}
+
+func newSocket(c *conn, ns *Namespace, id string, auth json.RawMessage) *Socket {
+	return &Socket{
+		conn:      c,
+		ns:        ns,
+		id:        id,
+		auth:      auth,
+		handlers:  make(map[string][]func([]interface{}, Ack)),
+		connected: true,
+		acks:      make(map[int]func([]interface{})),
+		rooms:     make(map[string]struct{}),
+	}
+}
+
+// ID returns the socket id, which is also sent to the client.
+func (s *Socket) ID() string {
+	return s.id
+}
+
+// Namespace returns the namespace the socket is connected to.
+func (s *Socket) Namespace() *Namespace {
+	return s.ns
+}
+
+// Auth returns the raw CONNECT payload (the client's auth option), or nil.
+func (s *Socket) Auth() json.RawMessage {
+	return s.auth
+}
+
+// Request returns the engine.io handshake request.
+func (s *Socket) Request() *http.Request {
+	return s.conn.session.Request()
+}
+
+// Connected reports whether the socket is still connected.
+func (s *Socket) Connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connected
+}
+
+// On registers an event handler. Arguments are decoded by the parser into
+// the handler's parameter types; a leading Ack parameter receives the
+// acknowledgement function. "disconnect" handlers receive the reason.
+func (s *Socket) On(event string, handler interface{}) {
+	wrapped := s.wrapHandler(handler)
+	if wrapped == nil {
+		return
+	}
+
+	s.handlerMu.Lock()
+	defer s.handlerMu.Unlock()
+	s.handlers[event] = append(s.handlers[event], wrapped)
+}
+
+// OnAny registers a handler called for every event the client emits, after
+// the handlers registered with On. It receives the event name, the raw
+// arguments and the acknowledgement function, which is nil when the client
+// did not ask for one.
+func (s *Socket) OnAny(handler func(event string, args []interface{}, ack Ack)) {
+	s.handlerMu.Lock()
+	defer s.handlerMu.Unlock()
+	s.anyHandlers = append(s.anyHandlers, handler)
+}
+
+func (s *Socket) wrapHandler(handler interface{}) func([]interface{}, Ack) {
+	parser := s.ns.server.parser
+
+	switch h := handler.(type) {
+	case func([]interface{}):
+		return func(args []interface{}, _ Ack) { h(args) }
+	case func(Ack, []interface{}):
+		return func(args []interface{}, ack Ack) { h(ack, args) }
+	}
+
+	handlerValue := reflect.ValueOf(handler)
+	handlerType := handlerValue.Type()
+	if handlerType.Kind() != reflect.Func {
+		s.ns.server.logger.Errorf("socket.io: handler must be a function")
+		return nil
+	}
+
+	if handlerType.NumIn() == 0 || handlerType.In(0) != ackType {
+		wrapped := parser.WrapCallback(handler)
+		if wrapped == nil {
+			return nil
+		}
+		return func(args []interface{}, _ Ack) { wrapped(args) }
+	}
+
+	// Hand the parser a function without the Ack parameter, bound to the
+	// ack of the current event.
+	in := make([]reflect.Type, handlerType.NumIn()-1)
+	for i := range in {
+		in[i] = handlerType.In(i + 1)
+	}
+	out := make([]reflect.Type, handlerType.NumOut())
+	for i := range out {
+		out[i] = handlerType.Out(i)
+	}
+	boundType := reflect.FuncOf(in, out, false)
+
+	return func(args []interface{}, ack Ack) {
+		bound := reflect.MakeFunc(boundType, func(values []reflect.Value) []reflect.Value {
+			return handlerValue.Call(append([]reflect.Value{reflect.ValueOf(ack)}, values...))
+		})
+		if wrapped := parser.WrapCallback(bound.Interface()); wrapped != nil {
+			wrapped(args)
+		}
+	}
+}
+
+// Emit sends an event to the client. Pass emit.WithAck and emit.WithTimeout
+// from the client emit package to request an acknowledgement.
+func (s *Socket) Emit(event string, args ...interface{}) error {
+	if err := checkEventName(event); err != nil {
+		return err
+	}
+
+	options := &emit.EmitOptions{}
+	payloads := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if option, ok := arg.(emit.EmitOption); ok {
+			option(options)
+			continue
+		}
+		payloads = append(payloads, arg)
+	}
+
+	msg := &socketio_v5.Message{
+		Type:  socketio_v5.PacketEvent,
+		NS:    s.ns.name,
+		Event: &socketio_v5.Event{Name: event, Payloads: payloads},
+	}
+
+	if options.AckCallback() != nil || options.Timeout() != nil {
+		if err := s.registerAck(msg, options); err != nil {
+			return err
+		}
+	}
+
+	return s.send(msg)
+}
+
+func (s *Socket) registerAck(msg *socketio_v5.Message, options *emit.EmitOptions) error {
+	var callback func([]interface{})
+	if options.AckCallback() != nil {
+		callback = s.ns.server.parser.WrapCallback(options.AckCallback())
+		if callback == nil {
+			return errors.New("callback must be a function")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return ErrSocketDisconnected
+	}
+
+	s.ackCounter++
+	id := s.ackCounter
+	msg.AckId = &id
+
+	var timer utils.Timer
+	if timeout := options.Timeout(); timeout != nil {
+		timeoutCallback := options.TimeoutCallback()
+		timer = s.ns.server.clock.AfterFunc(*timeout, func() {
+			s.mu.Lock()
+			_, pending := s.acks[id]
+			delete(s.acks, id)
+			s.mu.Unlock()
+			if pending && timeoutCallback != nil {
+				s.safeGo(msg.Event.Name, func() { timeoutCallback() })
+			}
+		})
+	}
+
+	s.acks[id] = func(args []interface{}) {
+		if timer != nil {
+			timer.Stop()
+		}
+		if callback != nil {
+			callback(args)
+		}
+	}
+	return nil
+}
+
+func (s *Socket) send(msg *socketio_v5.Message) error {
+	if !s.Connected() {
+		return ErrSocketDisconnected
+	}
+	return s.conn.send(msg)
+}
+
+// Join adds the socket to the rooms.
+func (s *Socket) Join(rooms ...string) {
+	s.ns.mu.Lock()
+	defer s.ns.mu.Unlock()
+
+	if s.ns.sockets[s.id] != s {
+		return
+	}
+	for _, room := range rooms {
+		members, ok := s.ns.rooms[room]
+		if !ok {
+			members = make(map[string]*Socket)
+			s.ns.rooms[room] = members
+		}
+		members[s.id] = s
+		s.rooms[room] = struct{}{}
+	}
+}
+
+// Leave removes the socket from the room.
+func (s *Socket) Leave(room string) {
+	s.ns.mu.Lock()
+	defer s.ns.mu.Unlock()
+	s.ns.leaveLocked(s, room)
+}
+
+// Rooms returns the rooms the socket is in, including its own id.
+func (s *Socket) Rooms() []string {
+	s.ns.mu.RLock()
+	defer s.ns.mu.RUnlock()
+	rooms := make([]string, 0, len(s.rooms))
+	for room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// To broadcasts to the rooms, excluding this socket.
+func (s *Socket) To(rooms ...string) *BroadcastOperator {
+	return s.ns.To(rooms...).Except(s.id)
+}
+
+// Broadcast targets every socket of the namespace but this one.
+func (s *Socket) Broadcast() *BroadcastOperator {
+	return s.ns.Except(s.id)
+}
+
+// Disconnect makes the socket leave its namespace. With closeConn set the
+// whole engine.io connection is closed, disconnecting the client's sockets
+// in every namespace.
+func (s *Socket) Disconnect(closeConn bool) error {
+	if closeConn {
+		return s.conn.session.Close()
+	}
+	err := s.send(&socketio_v5.Message{
+		Type: socketio_v5.PacketDisconnect,
+		NS:   s.ns.name,
+	})
+	s.onClose(ReasonServerNamespaceDisconnect)
+	return err
+}
+
+func (s *Socket) handleEvent(event *socketio_v5.Event, ackID *int) {
+	// Reserved names belong to the server; a client must not be able to
+	// trigger e.g. the "disconnect" handlers with arguments of its choice.
+	if reservedEvents[event.Name] {
+		s.ns.server.logger.Warnf("socket.io: dropped reserved event %q from %s", event.Name, s.id)
+		return
+	}
+
+	s.handlerMu.RLock()
+	handlers := s.handlers[event.Name]
+	anyHandlers := s.anyHandlers
+	s.handlerMu.RUnlock()
+
+	if len(handlers) == 0 && len(anyHandlers) == 0 {
+		s.ns.server.logger.Debugf("socket.io: no handlers for event %s", event.Name)
+		return
+	}
+
+	ack := s.ackFunc(ackID)
+	for _, handler := range handlers {
+		h := handler
+		s.safeGo(event.Name, func() { h(event.Payloads, ack) })
+	}
+	anyAck := ack
+	if ackID == nil {
+		anyAck = nil
+	}
+	for _, handler := range anyHandlers {
+		h := handler
+		s.safeGo(event.Name, func() { h(event.Name, event.Payloads, anyAck) })
+	}
+}
+
+// ackFunc returns the Ack answering ackID once, or a no-op.
+func (s *Socket) ackFunc(ackID *int) Ack {
+	if ackID == nil {
+		return func(...interface{}) error { return nil }
+	}
+	var once sync.Once
+	return func(args ...interface{}) error {
+		err := errors.New("already acknowledged")
+		once.Do(func() {
+			err = s.send(&socketio_v5.Message{
+				Type:  socketio_v5.PacketAck,
+				NS:    s.ns.name,
+				AckId: ackID,
+				Event: &socketio_v5.Event{Payloads: args},
+			})
+		})
+		return err
+	}
+}
+
+func (s *Socket) handleAck(id int, payloads []interface{}) {
+	s.mu.Lock()
+	callback, ok := s.acks[id]
+	delete(s.acks, id)
+	s.mu.Unlock()
+
+	if !ok {
+		s.ns.server.logger.Debugf("socket.io: no ack callback for id %d", id)
+		return
+	}
+	s.safeGo("", func() { callback(payloads) })
+}
+
+func (s *Socket) onClose(reason string) {
+	s.mu.Lock()
+	if !s.connected {
+		s.mu.Unlock()
+		return
+	}
+	s.connected = false
+	s.acks = make(map[int]func([]interface{}))
+	s.mu.Unlock()
+
+	s.ns.remove(s)
+	s.conn.remove(s)
+
+	s.ns.server.logger.Debugf("socket.io: socket %s left %s: %s", s.id, s.ns.name, reason)
+
+	s.handlerMu.RLock()
+	handlers := s.handlers["disconnect"]
+	s.handlerMu.RUnlock()
+
+	encodedReason, _ := json.Marshal(reason)
+	args := []interface{}{json.RawMessage(encodedReason)}
+	ack := s.ackFunc(nil)
+	for _, handler := range handlers {
+		h := handler
+		s.safeGo("disconnect", func() { h(args, ack) })
+	}
+}
+
+// safeGo runs a handler in its own goroutine and recovers a panic.
+func (s *Socket) safeGo(event string, fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.ns.server.logger.Errorf("socket.io: panic in %s handler: %v\n%s", event, r, debug.Stack())
+			}
+		}()
+		fn()
+	}()
+}
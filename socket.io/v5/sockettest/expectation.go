@@ -0,0 +1,151 @@
+package sockettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	socketio_v5_server "github.com/maldikhan/go.socket.io/socket.io/v5/server"
+)
+
+// Expectation is an event the client is expected to emit, and the script
+// the server runs when it arrives. Configure it before the client emits.
+type Expectation struct {
+	server    *Server
+	event     string
+	namespace string
+
+	args    []interface{}
+	hasArgs bool
+	ack     []interface{}
+	hasAck  bool
+	pushes  []*socketio_v5.Event
+	drop    bool
+
+	// met is guarded by server.mu.
+	met  bool
+	done chan struct{}
+}
+
+// In expects the event on the namespace instead of "/".
+func (e *Expectation) In(namespace string) *Expectation {
+	e.namespace = namespace
+	return e
+}
+
+// WithArgs expects the event arguments to equal args once both are encoded
+// to JSON.
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.hasArgs = true
+	return e
+}
+
+// ReplyAck answers the event's acknowledgement callback with args. The emit
+// must request an ack.
+func (e *Expectation) ReplyAck(args ...interface{}) *Expectation {
+	e.ack = args
+	e.hasAck = true
+	return e
+}
+
+// ThenPush sends an event back to the emitting socket once the expectation
+// is met, after the ack.
+func (e *Expectation) ThenPush(event string, args ...interface{}) *Expectation {
+	e.pushes = append(e.pushes, &socketio_v5.Event{Name: event, Payloads: args})
+	return e
+}
+
+// ThenDrop drops every connection once the expectation is met, as Drop.
+func (e *Expectation) ThenDrop() *Expectation {
+	e.drop = true
+	return e
+}
+
+// Done is closed once the expectation is met and its script has run.
+func (e *Expectation) Done() <-chan struct{} {
+	return e.done
+}
+
+func (e *Expectation) String() string {
+	s := fmt.Sprintf("emit %q on %s", e.event, e.namespace)
+	if e.hasArgs {
+		s += " with " + formatArgs(e.args)
+	}
+	return s
+}
+
+func (e *Expectation) matchArgs(payloads []interface{}) error {
+	if !e.hasArgs {
+		return nil
+	}
+	expected, err := normalize(e.args)
+	if err != nil {
+		return fmt.Errorf("%s: can't encode expected args: %v", e, err)
+	}
+	actual, err := normalize(payloads)
+	if err != nil {
+		return fmt.Errorf("%s: can't decode args: %v", e, err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return fmt.Errorf("%s", e)
+	}
+	return nil
+}
+
+func (e *Expectation) run(socket *socketio_v5_server.Socket, ack socketio_v5_server.Ack) {
+	s := e.server
+
+	if e.hasAck {
+		if ack == nil {
+			s.errorf("sockettest: %s expects an ack callback, got none", e)
+		} else if err := ack(e.ack...); err != nil {
+			s.t.Logf("sockettest: ack %s: %v", e, err)
+		}
+	}
+
+	for _, event := range e.pushes {
+		if err := socket.Emit(event.Name, event.Payloads...); err != nil {
+			s.t.Logf("sockettest: push %q: %v", event.Name, err)
+		}
+	}
+
+	if e.drop {
+		s.Drop()
+	}
+}
+
+// normalize round-trips values through JSON so that typed expectations
+// compare equal to the raw payloads the parser decodes.
+func normalize(values []interface{}) (interface{}, error) {
+	if values == nil {
+		values = []interface{}{}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+func formatArgs(values []interface{}) string {
+	if values == nil {
+		values = []interface{}{}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Sprint(values)
+	}
+	return string(data)
+}
+
+func formatMismatch(mismatch []string) string {
+	if len(mismatch) == 0 {
+		return ""
+	}
+	return "; args differ from: " + strings.Join(mismatch, ", ")
+}
@@ -0,0 +1,482 @@
+// Package sockettest provides an in-process socket.io v5 server for testing
+// code built on socketio_v5_client.Client.
+//
+// The server runs on an httptest.Server, speaks engine.io v4 over polling and
+// websocket, and is scripted from the test:
+//
+//	srv := sockettest.NewServer(t)
+//	srv.ExpectEmit("join").WithArgs("room-1").ReplyAck("ok")
+//	srv.RejectConnect("/admin", "unauthorized", map[string]string{"reason": "token"})
+//
+//	client, _ := socketio_v5_client.NewClient(socketio_v5_client.WithRawURL(srv.URL()))
+//	...
+//	srv.Wait()
+//
+// Events that match no pending expectation, unsolicited acks and unparsable
+// packets fail the test, and so do expectations still pending at cleanup.
+package sockettest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	socketio_v5 "github.com/maldikhan/go.socket.io/socket.io/v5"
+	socketio_v5_parser_default "github.com/maldikhan/go.socket.io/socket.io/v5/parser/default"
+	socketio_v5_server "github.com/maldikhan/go.socket.io/socket.io/v5/server"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+type Parser interface {
+	WrapCallback(callback interface{}) func(in []interface{})
+	Parse([]byte) (*socketio_v5.Message, error)
+	Serialize(*socketio_v5.Message) ([]byte, error)
+}
+
+type ServerOption func(*Server) error
+
+// Server is a scripted socket.io server, built on socketio_v5_server. Its
+// methods are safe for concurrent use; Wait and WaitConnected must be called
+// from the test goroutine.
+type Server struct {
+	t       testing.TB
+	parser  Parser
+	logger  *utils.DefaultLogger
+	timeout time.Duration
+	io      *socketio_v5_server.Server
+	engine  *engineio_v4_server.Server
+	http    *httptest.Server
+
+	engineOptions []engineio_v4_server.ServerOption
+
+	mu           sync.Mutex
+	namespaces   map[string]bool
+	sessions     map[*engineio_v4_server.Session]struct{}
+	netConns     map[net.Conn]struct{}
+	dropped      map[string]bool
+	rejects      map[string]*socketio_v5_server.ConnectError
+	expectations []*Expectation
+	changed      chan struct{}
+	closed       bool
+}
+
+// NewServer starts a server and registers its Close with t.Cleanup.
+func NewServer(t testing.TB, options ...ServerOption) *Server {
+	t.Helper()
+
+	s := &Server{
+		t:          t,
+		timeout:    5 * time.Second,
+		logger:     &utils.DefaultLogger{Level: utils.NONE},
+		namespaces: make(map[string]bool),
+		sessions:   make(map[*engineio_v4_server.Session]struct{}),
+		netConns:   make(map[net.Conn]struct{}),
+		dropped:    make(map[string]bool),
+		rejects:    make(map[string]*socketio_v5_server.ConnectError),
+		changed:    make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		if err := opt(s); err != nil {
+			t.Fatalf("sockettest: %v", err)
+		}
+	}
+
+	if s.parser == nil {
+		s.parser = socketio_v5_parser_default.NewParser(
+			socketio_v5_parser_default.WithLogger(s.logger),
+		)
+	}
+
+	engine, err := engineio_v4_server.NewServer(
+		append([]engineio_v4_server.ServerOption{
+			engineio_v4_server.WithLogger(s.logger),
+		}, s.engineOptions...)...,
+	)
+	if err != nil {
+		t.Fatalf("sockettest: %v", err)
+	}
+	s.engine = engine
+
+	s.io, err = socketio_v5_server.NewServer(
+		socketio_v5_server.WithLogger(s.logger),
+		socketio_v5_server.WithParser(&checkedParser{Parser: s.parser, server: s}),
+		socketio_v5_server.WithEngineIOServer(&trackedEngine{Server: engine, server: s}),
+	)
+	if err != nil {
+		t.Fatalf("sockettest: %v", err)
+	}
+	s.namespace("/")
+
+	s.http = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	s.http.Config.ConnState = s.trackConn
+	s.http.Start()
+
+	t.Cleanup(s.Close)
+	return s
+}
+
+// WithEngineIOOptions configures the engine.io server, e.g. to offer a
+// single transport or shorten the heartbeat.
+func WithEngineIOOptions(options ...engineio_v4_server.ServerOption) ServerOption {
+	return func(s *Server) error {
+		s.engineOptions = append(s.engineOptions, options...)
+		return nil
+	}
+}
+
+func WithParser(parser Parser) ServerOption {
+	return func(s *Server) error {
+		if parser == nil {
+			return errors.New("parser is nil")
+		}
+		s.parser = parser
+		return nil
+	}
+}
+
+// WithTimeout sets how long Wait and WaitConnected block, 5s by default.
+func WithTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) error {
+		if timeout <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		s.timeout = timeout
+		return nil
+	}
+}
+
+// URL returns the server base URL, to be passed to WithRawURL.
+func (s *Server) URL() string {
+	return s.http.URL
+}
+
+// RejectConnect answers every CONNECT to the namespace with a CONNECT_ERROR
+// carrying {"message": message, "data": data}. A nil data is omitted.
+func (s *Server) RejectConnect(namespace, message string, data interface{}) *Server {
+	s.namespace(namespace)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejects[namespace] = &socketio_v5_server.ConnectError{Message: message, Data: data}
+	return s
+}
+
+// ExpectEmit adds an expectation for an event emitted by the client on the
+// main namespace. Expectations match in any order, each one once.
+func (s *Server) ExpectEmit(event string) *Expectation {
+	e := &Expectation{
+		server:    s,
+		event:     event,
+		namespace: "/",
+		done:      make(chan struct{}),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expectations = append(s.expectations, e)
+	return e
+}
+
+// Push sends an event to every socket connected to the main namespace.
+func (s *Server) Push(event string, args ...interface{}) error {
+	return s.PushNamespace("/", event, args...)
+}
+
+// PushNamespace sends an event to every socket connected to the namespace.
+// It fails when there is none.
+func (s *Server) PushNamespace(namespace, event string, args ...interface{}) error {
+	sockets := s.namespace(namespace).Sockets()
+	if len(sockets) == 0 {
+		return fmt.Errorf("sockettest: no socket connected to %s", namespace)
+	}
+	for _, socket := range sockets {
+		if err := socket.Emit(event, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Connected returns the number of sockets connected to the namespace.
+func (s *Server) Connected(namespace string) int {
+	return len(s.namespace(namespace).Sockets())
+}
+
+// Drop cuts every connection without a CLOSE packet, as a network failure
+// would. The dropped sessions are unknown to the server afterwards.
+func (s *Server) Drop() {
+	s.mu.Lock()
+	sessions := make([]*engineio_v4_server.Session, 0, len(s.sessions))
+	for session := range s.sessions {
+		s.dropped[session.ID()] = true
+		sessions = append(sessions, session)
+	}
+	s.sessions = make(map[*engineio_v4_server.Session]struct{})
+	netConns := make([]net.Conn, 0, len(s.netConns))
+	for netConn := range s.netConns {
+		netConns = append(netConns, netConn)
+	}
+	s.netConns = make(map[net.Conn]struct{})
+	s.mu.Unlock()
+
+	for _, netConn := range netConns {
+		_ = netConn.Close()
+	}
+	for _, session := range sessions {
+		_ = session.Drop()
+	}
+}
+
+// WaitConnected blocks until a socket is connected to the namespace and
+// fails the test after the timeout.
+func (s *Server) WaitConnected(namespace string) {
+	s.t.Helper()
+	if !s.waitFor(func() bool { return s.Connected(namespace) > 0 }) {
+		s.t.Fatalf("sockettest: no socket connected to %s after %v", namespace, s.timeout)
+	}
+}
+
+// Wait blocks until every expectation is met and fails the test with the
+// pending ones after the timeout.
+func (s *Server) Wait() {
+	s.t.Helper()
+	if !s.waitFor(func() bool { return len(s.pending()) == 0 }) {
+		s.AssertExpectations()
+		s.t.FailNow()
+	}
+}
+
+// AssertExpectations reports every pending expectation as a test error and
+// returns whether there was none.
+func (s *Server) AssertExpectations() bool {
+	s.t.Helper()
+	pending := s.pending()
+	for _, e := range pending {
+		s.t.Errorf("sockettest: missing %s", e)
+	}
+	return len(pending) == 0
+}
+
+func (s *Server) pending() []*Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []*Expectation
+	for _, e := range s.expectations {
+		if !e.met {
+			pending = append(pending, e)
+		}
+	}
+	return pending
+}
+
+func (s *Server) waitFor(condition func() bool) bool {
+	deadline := time.After(s.timeout)
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+		if condition() {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func (s *Server) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifyLocked()
+}
+
+// notifyLocked wakes up the waiters.
+func (s *Server) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// errorf fails the test, unless the server is already closed and the test
+// may be over.
+func (s *Server) errorf(format string, args ...interface{}) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if !closed {
+		s.t.Errorf(format, args...)
+	}
+}
+
+// Close shuts the server down and asserts the expectations unless the test
+// already failed. It is registered with t.Cleanup by NewServer.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	s.t.Helper()
+	if !s.t.Failed() {
+		s.AssertExpectations()
+	}
+
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	_ = s.io.Close()
+	s.http.Close()
+}
+
+// trackConn keeps the client connections, hijacked websockets included, for
+// Drop.
+func (s *Server) trackConn(netConn net.Conn, state http.ConnState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch state {
+	case http.StateNew:
+		s.netConns[netConn] = struct{}{}
+	case http.StateClosed:
+		delete(s.netConns, netConn)
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	dropped := s.dropped[r.URL.Query().Get("sid")]
+	s.mu.Unlock()
+	if dropped {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":1,"message":"Session ID unknown"}`))
+		return
+	}
+	s.io.ServeHTTP(w, r)
+}
+
+// namespace returns the namespace of the socket.io server, scripting it on
+// first use.
+func (s *Server) namespace(name string) *socketio_v5_server.Namespace {
+	ns := s.io.Of(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.namespaces[name] {
+		return ns
+	}
+	s.namespaces[name] = true
+
+	ns.Use(func(*socketio_v5_server.Socket) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if reject := s.rejects[name]; reject != nil {
+			return reject
+		}
+		return nil
+	})
+	ns.OnConnection(s.onConnection)
+	return ns
+}
+
+func (s *Server) onConnection(socket *socketio_v5_server.Socket) {
+	socket.OnAny(func(event string, args []interface{}, ack socketio_v5_server.Ack) {
+		s.handleEvent(socket, event, args, ack)
+	})
+	socket.On("disconnect", func(string) { s.notify() })
+	s.notify()
+}
+
+func (s *Server) handleEvent(socket *socketio_v5_server.Socket, event string, args []interface{}, ack socketio_v5_server.Ack) {
+	namespace := socket.Namespace().Name()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	var match *Expectation
+	var mismatch []string
+	for _, e := range s.expectations {
+		if e.met || e.event != event || e.namespace != namespace {
+			continue
+		}
+		if err := e.matchArgs(args); err != nil {
+			mismatch = append(mismatch, err.Error())
+			continue
+		}
+		match = e
+		break
+	}
+	if match != nil {
+		match.met = true
+	}
+	s.mu.Unlock()
+
+	if match == nil {
+		s.errorf("sockettest: unexpected emit %q on %s with %s%s",
+			event, namespace, formatArgs(args), formatMismatch(mismatch))
+		return
+	}
+
+	match.run(socket, ack)
+
+	s.mu.Lock()
+	close(match.done)
+	s.notifyLocked()
+	s.mu.Unlock()
+}
+
+// trackedEngine records the engine.io sessions for Drop.
+type trackedEngine struct {
+	*engineio_v4_server.Server
+	server *Server
+}
+
+func (e *trackedEngine) OnConnection(handler func(*engineio_v4_server.Session)) {
+	e.Server.OnConnection(func(session *engineio_v4_server.Session) {
+		e.server.mu.Lock()
+		e.server.sessions[session] = struct{}{}
+		e.server.mu.Unlock()
+		handler(session)
+	})
+}
+
+// checkedParser fails the test on the packets a client must not send: the
+// unparsable ones, acks, since the server never asks for one, and any other
+// packet type but CONNECT, DISCONNECT and EVENT. It also scripts the
+// namespace of every CONNECT before the server looks it up, so that any
+// namespace is accepted.
+type checkedParser struct {
+	Parser
+	server *Server
+}
+
+func (p *checkedParser) Parse(data []byte) (*socketio_v5.Message, error) {
+	msg, err := p.Parser.Parse(data)
+	if err != nil {
+		p.server.errorf("sockettest: can't parse packet %q: %v", data, err)
+		return nil, err
+	}
+
+	switch msg.Type {
+	case socketio_v5.PacketConnect:
+		p.server.namespace(msg.NS)
+	case socketio_v5.PacketDisconnect:
+	case socketio_v5.PacketEvent:
+		if msg.Event == nil {
+			p.server.errorf("sockettest: event packet without event: %q", data)
+		}
+	default:
+		p.server.errorf("sockettest: unexpected packet %q", data)
+	}
+	return msg, nil
+}
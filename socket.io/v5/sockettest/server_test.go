@@ -0,0 +1,229 @@
+package sockettest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	engineio_v4 "github.com/maldikhan/go.socket.io/engine.io/v4"
+	engineio_v4_client "github.com/maldikhan/go.socket.io/engine.io/v4/client"
+	engineio_v4_client_transport "github.com/maldikhan/go.socket.io/engine.io/v4/client/transport/websocket"
+	engineio_v4_server "github.com/maldikhan/go.socket.io/engine.io/v4/server"
+	socketio_v5_client "github.com/maldikhan/go.socket.io/socket.io/v5/client"
+	"github.com/maldikhan/go.socket.io/socket.io/v5/client/emit"
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+var quietLogger = &utils.DefaultLogger{Level: utils.NONE}
+
+// recorder captures the failures the server reports instead of failing the
+// running test.
+type recorder struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Failed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.errors) > 0
+}
+
+func (r *recorder) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.errors...)
+}
+
+func newClient(t *testing.T, srv *Server, options ...socketio_v5_client.ClientOption) *socketio_v5_client.Client {
+	t.Helper()
+	return dialClient(t, append([]socketio_v5_client.ClientOption{socketio_v5_client.WithRawURL(srv.URL())}, options...)...)
+}
+
+func dialClient(t *testing.T, options ...socketio_v5_client.ClientOption) *socketio_v5_client.Client {
+	t.Helper()
+	client, err := socketio_v5_client.NewClient(append([]socketio_v5_client.ClientOption{
+		socketio_v5_client.WithLogger(quietLogger),
+	}, options...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func newWebsocketClient(t *testing.T, srv *Server) *socketio_v5_client.Client {
+	t.Helper()
+	transport, err := engineio_v4_client_transport.NewTransport(
+		engineio_v4_client_transport.WithLogger(quietLogger),
+	)
+	require.NoError(t, err)
+	u, err := url.Parse(srv.URL() + "/socket.io/")
+	require.NoError(t, err)
+	engine, err := engineio_v4_client.NewClient(
+		engineio_v4_client.WithURL(u),
+		engineio_v4_client.WithLogger(quietLogger),
+		engineio_v4_client.WithSupportedTransports([]engineio_v4_client.Transport{transport}),
+		engineio_v4_client.WithTransport(transport),
+	)
+	require.NoError(t, err)
+	return dialClient(t, socketio_v5_client.WithEngineIOClient(engine))
+}
+
+func connect(t *testing.T, client *socketio_v5_client.Client) {
+	t.Helper()
+	// The client runs on the connect context, so it lives until cleanup.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	require.NoError(t, client.Connect(ctx))
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+		return ""
+	}
+}
+
+func TestServer_EmitAckAndPush(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []ServerOption
+		newClient func(*testing.T, *Server) *socketio_v5_client.Client
+	}{
+		{
+			name: "Polling",
+			options: []ServerOption{WithEngineIOOptions(
+				engineio_v4_server.WithAllowUpgrades(false),
+			)},
+			newClient: func(t *testing.T, srv *Server) *socketio_v5_client.Client { return newClient(t, srv) },
+		},
+		{
+			name: "Websocket",
+			options: []ServerOption{WithEngineIOOptions(
+				engineio_v4_server.WithTransports(engineio_v4.TransportWebsocket),
+			)},
+			newClient: newWebsocketClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer(t, tt.options...)
+			srv.ExpectEmit("join").
+				WithArgs("room-1", map[string]int{"limit": 10}).
+				ReplyAck("ok").
+				ThenPush("joined", "room-1")
+
+			client := tt.newClient(t, srv)
+			joined := make(chan string, 1)
+			client.On("joined", func(room string) { joined <- room })
+			news := make(chan string, 1)
+			client.On("news", func(title string) { news <- title })
+			connect(t, client)
+			srv.WaitConnected("/")
+
+			acks := make(chan string, 1)
+			require.NoError(t, client.Emit("join", "room-1", map[string]int{"limit": 10},
+				emit.WithAck(func(status string) { acks <- status })))
+
+			assert.Equal(t, "ok", receive(t, acks))
+			assert.Equal(t, "room-1", receive(t, joined))
+			srv.Wait()
+
+			require.NoError(t, srv.Push("news", "hello"))
+			assert.Equal(t, "hello", receive(t, news))
+		})
+	}
+}
+
+func TestServer_RejectConnect(t *testing.T) {
+	srv := NewServer(t)
+	srv.RejectConnect("/admin", "unauthorized", map[string]string{"reason": "token"})
+
+	client := newClient(t, srv, socketio_v5_client.WithDefaultNamespace("/admin"))
+	errs := make(chan string, 1)
+	client.On("error", func(payload interface{}) {
+		data, _ := json.Marshal(payload)
+		errs <- string(data)
+	})
+	connect(t, client)
+
+	assert.JSONEq(t, `{"message":"unauthorized","data":{"reason":"token"}}`, receive(t, errs))
+	assert.Equal(t, 0, srv.Connected("/admin"))
+}
+
+func TestServer_Drop(t *testing.T) {
+	srv := NewServer(t)
+	srv.ExpectEmit("bye").ThenDrop()
+
+	client := newWebsocketClient(t, srv)
+	connect(t, client)
+	srv.WaitConnected("/")
+
+	require.NoError(t, client.Emit("bye"))
+	srv.Wait()
+
+	require.Eventually(t, func() bool { return srv.Connected("/") == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Error(t, srv.Push("news", "lost"))
+}
+
+func TestServer_Assertions(t *testing.T) {
+	rec := &recorder{TB: t}
+	srv := NewServer(rec)
+	srv.ExpectEmit("join").WithArgs("room-1")
+	srv.ExpectEmit("leave")
+
+	client := newClient(t, srv)
+	connect(t, client)
+	srv.WaitConnected("/")
+
+	require.NoError(t, client.Emit("join", "room-2"))
+	require.NoError(t, client.Emit("chat", "hi"))
+	require.Eventually(t, func() bool { return len(rec.recorded()) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	srv.Close()
+	errs := rec.recorded()
+	require.Len(t, errs, 2, "pending expectations are not asserted once the test failed")
+	assert.Contains(t, errs, `sockettest: unexpected emit "join" on / with ["room-2"]; args differ from: emit "join" on / with ["room-1"]`)
+	assert.Contains(t, errs, `sockettest: unexpected emit "chat" on / with ["hi"]`)
+}
+
+func TestServer_AssertExpectations(t *testing.T) {
+	rec := &recorder{TB: t}
+	srv := NewServer(rec)
+	srv.ExpectEmit("join").In("/chat")
+	srv.ExpectEmit("leave")
+
+	client := newClient(t, srv, socketio_v5_client.WithDefaultNamespace("/chat"))
+	connect(t, client)
+	srv.WaitConnected("/chat")
+
+	require.NoError(t, client.Emit("join"))
+	select {
+	case <-srv.expectations[0].Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	assert.False(t, srv.AssertExpectations())
+	assert.Equal(t, []string{`sockettest: missing emit "leave" on /`}, rec.recorded())
+}
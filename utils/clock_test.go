@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealClock(t *testing.T) {
+	clock := RealClock{}
+
+	before := time.Now()
+	assert.False(t, clock.Now().Before(before))
+
+	<-clock.After(time.Millisecond)
+
+	timer := clock.NewTimer(time.Millisecond)
+	<-timer.C()
+	assert.False(t, timer.Stop())
+	assert.False(t, timer.Reset(time.Hour))
+	assert.True(t, timer.Stop())
+
+	ticker := clock.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Reset(2 * time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+
+	called := make(chan struct{})
+	fn := clock.AfterFunc(time.Millisecond, func() { close(called) })
+	<-called
+	assert.Nil(t, fn.C())
+}
+
+func TestWrapTicker(t *testing.T) {
+	ticker := time.NewTicker(time.Millisecond)
+	wrapped := WrapTicker(ticker)
+	assert.Equal(t, (<-chan time.Time)(ticker.C), wrapped.C())
+	<-wrapped.C()
+	wrapped.Stop()
+}
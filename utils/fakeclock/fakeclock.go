@@ -0,0 +1,196 @@
+// Package fakeclock provides a utils.Clock whose time only moves when a test
+// calls Advance, so heartbeats, backoffs and timeouts can be exercised
+// without sleeping.
+package fakeclock
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maldikhan/go.socket.io/utils"
+)
+
+// Clock is a manually advanced utils.Clock. The zero value is not usable;
+// create one with New.
+type Clock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter is a pending timer, ticker, After channel or AfterFunc.
+type waiter struct {
+	clock  *Clock
+	at     time.Time
+	period time.Duration // non-zero for tickers
+	c      chan time.Time
+	fn     func()
+}
+
+var _ utils.Clock = (*Clock)(nil)
+
+// New returns a Clock set to now.
+func New(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *Clock) NewTimer(d time.Duration) utils.Timer {
+	w := &waiter{clock: c, c: make(chan time.Time, 1)}
+	c.schedule(w, d)
+	return (*timer)(w)
+}
+
+func (c *Clock) AfterFunc(d time.Duration, f func()) utils.Timer {
+	w := &waiter{clock: c, fn: f}
+	c.schedule(w, d)
+	return (*timer)(w)
+}
+
+// NewTicker panics on a non-positive d, like time.NewTicker.
+func (c *Clock) NewTicker(d time.Duration) utils.Ticker {
+	if d <= 0 {
+		panic("fakeclock: non-positive interval for NewTicker")
+	}
+	w := &waiter{clock: c, c: make(chan time.Time, 1), period: d}
+	c.schedule(w, d)
+	return (*ticker)(w)
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker that
+// falls due in order of their deadlines. AfterFunc callbacks run on the
+// calling goroutine before Advance returns.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	for {
+		w := c.next(target)
+		if w == nil {
+			break
+		}
+		c.now = w.at
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			c.remove(w)
+		}
+		if w.fn != nil {
+			c.mu.Unlock()
+			w.fn()
+			c.mu.Lock()
+			continue
+		}
+		select {
+		case w.c <- c.now:
+		default:
+			// Like time.Ticker, drop ticks nobody is reading.
+		}
+	}
+	if target.After(c.now) {
+		c.now = target
+	}
+	c.mu.Unlock()
+}
+
+// BlockUntil blocks until at least n timers, tickers, After channels or
+// AfterFunc callbacks are pending. Use it to wait for the code under test to
+// start waiting before calling Advance.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// Waiters returns the number of pending timers, tickers and callbacks.
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// next returns the earliest waiter due at or before target.
+func (c *Clock) next(target time.Time) *waiter {
+	sort.SliceStable(c.waiters, func(i, j int) bool {
+		return c.waiters[i].at.Before(c.waiters[j].at)
+	})
+	if len(c.waiters) == 0 || c.waiters[0].at.After(target) {
+		return nil
+	}
+	return c.waiters[0]
+}
+
+// schedule (re)arms w to fire d from now and reports whether it was
+// already pending.
+func (c *Clock) schedule(w *waiter, d time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w.at = c.now.Add(d)
+	if c.pending(w) {
+		return true
+	}
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+	return false
+}
+
+// remove drops w and reports whether it was pending.
+func (c *Clock) remove(w *waiter) bool {
+	for i, p := range c.waiters {
+		if p == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Clock) pending(w *waiter) bool {
+	for _, p := range c.waiters {
+		if p == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Clock) stop(w *waiter) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remove(w)
+}
+
+type timer waiter
+
+func (t *timer) C() <-chan time.Time        { return t.c }
+func (t *timer) Stop() bool                 { return t.clock.stop((*waiter)(t)) }
+func (t *timer) Reset(d time.Duration) bool { return t.clock.schedule((*waiter)(t), d) }
+
+type ticker waiter
+
+func (t *ticker) C() <-chan time.Time { return t.c }
+func (t *ticker) Stop()               { t.clock.stop((*waiter)(t)) }
+
+func (t *ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("fakeclock: non-positive interval for Ticker.Reset")
+	}
+	t.clock.mu.Lock()
+	t.period = d
+	t.clock.mu.Unlock()
+	t.clock.schedule((*waiter)(t), d)
+}
@@ -0,0 +1,113 @@
+package fakeclock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestClock_Timer(t *testing.T) {
+	clock := New(start)
+	timer := clock.NewTimer(time.Second)
+	after := clock.After(2 * time.Second)
+	assert.Equal(t, 2, clock.Waiters())
+
+	clock.Advance(999 * time.Millisecond)
+	_, ok := fired(timer.C())
+	assert.False(t, ok)
+
+	clock.Advance(time.Millisecond)
+	at, ok := fired(timer.C())
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Second), at)
+	assert.Equal(t, 1, clock.Waiters())
+
+	clock.Advance(5 * time.Second)
+	at, ok = fired(after)
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(2*time.Second), at)
+	assert.Equal(t, start.Add(6*time.Second), clock.Now())
+
+	assert.False(t, timer.Reset(time.Second))
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop())
+	clock.Advance(time.Hour)
+	_, ok = fired(timer.C())
+	assert.False(t, ok)
+}
+
+func TestClock_Ticker(t *testing.T) {
+	clock := New(start)
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(time.Second)
+	at, ok := fired(ticker.C())
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Second), at)
+
+	// Ticks nobody reads are dropped.
+	clock.Advance(3 * time.Second)
+	at, ok = fired(ticker.C())
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(2*time.Second), at)
+	_, ok = fired(ticker.C())
+	assert.False(t, ok)
+
+	ticker.Reset(10 * time.Second)
+	clock.Advance(9 * time.Second)
+	_, ok = fired(ticker.C())
+	assert.False(t, ok)
+	clock.Advance(time.Second)
+	_, ok = fired(ticker.C())
+	assert.True(t, ok)
+
+	ticker.Stop()
+	assert.Equal(t, 0, clock.Waiters())
+	assert.Panics(t, func() { clock.NewTicker(0) })
+}
+
+func TestClock_AfterFunc(t *testing.T) {
+	clock := New(start)
+	var order []string
+	clock.AfterFunc(2*time.Second, func() { order = append(order, "second") })
+	clock.AfterFunc(time.Second, func() {
+		order = append(order, "first")
+		// Callbacks may schedule more work due within the same Advance.
+		clock.AfterFunc(500*time.Millisecond, func() { order = append(order, "nested") })
+	})
+	stopped := clock.AfterFunc(time.Second, func() { order = append(order, "stopped") })
+	assert.Nil(t, stopped.C())
+	assert.True(t, stopped.Stop())
+
+	clock.Advance(2 * time.Second)
+	assert.Equal(t, []string{"first", "nested", "second"}, order)
+}
+
+func TestClock_BlockUntil(t *testing.T) {
+	clock := New(start)
+	done := make(chan struct{})
+	go func() {
+		<-clock.After(time.Minute)
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waiter did not wake up")
+	}
+}
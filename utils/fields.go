@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keys of the fields the clients and transports attach to log entries.
+const (
+	FieldSid        = "sid"
+	FieldNamespace  = "namespace"
+	FieldTransport  = "transport"
+	FieldEvent      = "event"
+	FieldAckID      = "ackId"
+	FieldPacketType = "packetType"
+)
+
+// Field is a key/value pair attached to log entries.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Logger is the printf-style logger every component takes.
+type Logger interface {
+	Debugf(format string, v ...any)
+	Infof(format string, v ...any)
+	Warnf(format string, v ...any)
+	Errorf(format string, v ...any)
+}
+
+// FieldLogger is a Logger carrying key/value fields. Given one, the clients
+// derive loggers with the session id, namespace, transport, event, ack id and
+// packet type of what they log about, and pass them down to the transports.
+type FieldLogger interface {
+	Logger
+	With(fields ...Field) FieldLogger
+}
+
+// WithFields returns logger with fields added if it is a FieldLogger, or
+// logger itself otherwise.
+func WithFields(logger Logger, fields ...Field) Logger {
+	if fieldLogger, ok := logger.(FieldLogger); ok && len(fields) > 0 {
+		return fieldLogger.With(fields...)
+	}
+	return logger
+}
+
+// NewFieldLogger adapts a printf Logger, such as DefaultLogger, to
+// FieldLogger by appending the fields to every message as key=value pairs.
+func NewFieldLogger(logger Logger) FieldLogger {
+	return &printfFieldLogger{logger: logger}
+}
+
+type printfFieldLogger struct {
+	logger Logger
+	fields []Field
+	suffix string
+}
+
+func (l *printfFieldLogger) With(fields ...Field) FieldLogger {
+	all := append(append(make([]Field, 0, len(l.fields)+len(fields)), l.fields...), fields...)
+
+	var suffix strings.Builder
+	for _, field := range all {
+		suffix.WriteString(" ")
+		suffix.WriteString(field.Key)
+		suffix.WriteString("=")
+		suffix.WriteString(formatFieldValue(field.Value))
+	}
+	return &printfFieldLogger{logger: l.logger, fields: all, suffix: suffix.String()}
+}
+
+// formatFieldValue quotes values that would be ambiguous in a key=value list.
+func formatFieldValue(value any) string {
+	text := fmt.Sprint(value)
+	if text == "" || strings.ContainsAny(text, " =\"\t\n") {
+		return fmt.Sprintf("%q", text)
+	}
+	return text
+}
+
+func (l *printfFieldLogger) Debugf(format string, v ...any) {
+	l.logger.Debugf("%s", l.format(format, v))
+}
+
+func (l *printfFieldLogger) Infof(format string, v ...any) {
+	l.logger.Infof("%s", l.format(format, v))
+}
+
+func (l *printfFieldLogger) Warnf(format string, v ...any) {
+	l.logger.Warnf("%s", l.format(format, v))
+}
+
+func (l *printfFieldLogger) Errorf(format string, v ...any) {
+	l.logger.Errorf("%s", l.format(format, v))
+}
+
+func (l *printfFieldLogger) format(format string, v []any) string {
+	return fmt.Sprintf(format, v...) + l.suffix
+}
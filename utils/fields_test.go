@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lineLogger struct {
+	lines []string
+}
+
+func (l *lineLogger) Debugf(format string, v ...any) { l.add("DEBUG", format, v) }
+func (l *lineLogger) Infof(format string, v ...any)  { l.add("INFO", format, v) }
+func (l *lineLogger) Warnf(format string, v ...any)  { l.add("WARN", format, v) }
+func (l *lineLogger) Errorf(format string, v ...any) { l.add("ERROR", format, v) }
+
+func (l *lineLogger) add(level, format string, v []any) {
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, v...))
+}
+
+func TestFieldLogger(t *testing.T) {
+	lines := &lineLogger{}
+	logger := NewFieldLogger(lines)
+
+	logger.Infof("plain %d%%", 100)
+	session := logger.With(Field{Key: FieldSid, Value: "abc"}, Field{Key: FieldTransport, Value: "polling"})
+	session.Debugf("packet %s", "received")
+	session.With(Field{Key: FieldEvent, Value: "chat message"}, Field{Key: FieldAckID, Value: 3}).Warnf("no handler")
+	session.Errorf("empty %q", "")
+	logger.With(Field{Key: FieldNamespace, Value: ""}).Errorf("root")
+
+	assert.Equal(t, []string{
+		"INFO plain 100%",
+		"DEBUG packet received sid=abc transport=polling",
+		`WARN no handler sid=abc transport=polling event="chat message" ackId=3`,
+		`ERROR empty "" sid=abc transport=polling`,
+		`ERROR root namespace=""`,
+	}, lines.lines)
+}
+
+func TestWithFields(t *testing.T) {
+	plain := &lineLogger{}
+	assert.Same(t, plain, WithFields(plain, Field{Key: FieldSid, Value: "abc"}))
+
+	logger := NewFieldLogger(plain)
+	assert.Same(t, logger, WithFields(logger))
+
+	WithFields(logger, Field{Key: FieldSid, Value: "abc"}).Infof("hello")
+	assert.Equal(t, []string{"INFO hello sid=abc"}, plain.lines)
+}
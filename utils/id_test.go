@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateID(t *testing.T) {
+	id, err := GenerateID()
+	require.NoError(t, err)
+	assert.Len(t, id, 20)
+	_, err = base64.RawURLEncoding.DecodeString(id)
+	assert.NoError(t, err)
+
+	other, err := GenerateID()
+	require.NoError(t, err)
+	assert.NotEqual(t, id, other)
+}
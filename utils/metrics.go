@@ -0,0 +1,16 @@
+package utils
+
+import "time"
+
+// NopMetrics discards every measurement. It is the default Metrics of the
+// clients and transports; see the metrics package for a collecting one.
+type NopMetrics struct{}
+
+func (NopMetrics) PacketSent(transport, packetType string, bytes int)              {}
+func (NopMetrics) PacketReceived(transport, packetType string, bytes int)          {}
+func (NopMetrics) PollingRequest(method string, duration time.Duration, err error) {}
+func (NopMetrics) Upgrade(from, to string, err error)                              {}
+func (NopMetrics) AckRoundTrip(namespace string, duration time.Duration)           {}
+func (NopMetrics) PendingAcks(delta int)                                           {}
+func (NopMetrics) HandlerDuration(namespace, event string, duration time.Duration) {}
+func (NopMetrics) EventDropped(namespace, event, reason string)                    {}
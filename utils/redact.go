@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultRedactedKeys are the object keys a RedactionPolicy masks unless
+// Keys is set.
+var DefaultRedactedKeys = []string{"password", "token", "authorization"}
+
+// redactedValue replaces the value of a masked key.
+const redactedValue = `"[redacted]"`
+
+// RedactionPolicy masks secrets in the payloads written to debug logs, as an
+// alternative to the all-or-nothing WithDebugPayload. It reads engine.io and
+// socket.io packets, and polling payloads made of them: the JSON after the
+// packet header is logged with the values of sensitive keys masked at any
+// depth. Other packets are logged as they are.
+//
+// Every WithRedactionPolicy option takes a policy. A component given one
+// logs through it whatever its WithDebugPayload setting.
+type RedactionPolicy struct {
+	// Keys are the object keys whose values are masked, compared
+	// case-insensitively. Nil means DefaultRedactedKeys; an empty list masks
+	// no key.
+	Keys []string
+	// KeyFunc, when set, also masks the values of the keys it returns true
+	// for.
+	KeyFunc func(key string) bool
+	// MaxLength truncates each logged packet to this many bytes; zero keeps
+	// it whole.
+	MaxLength int
+	// Events overrides the policy for the socket.io events named by the
+	// keys. A nil policy hides their whole payload.
+	Events map[string]*RedactionPolicy
+}
+
+// Redact returns data, one or more packets, as it should be logged.
+func (p *RedactionPolicy) Redact(data []byte) string {
+	packets := bytes.Split(data, []byte{0x1e})
+	redacted := make([]string, len(packets))
+	for i, packet := range packets {
+		redacted[i] = p.redactPacket(packet)
+	}
+	return strings.Join(redacted, "\x1e")
+}
+
+func (p *RedactionPolicy) redactPacket(packet []byte) string {
+	start := bytes.IndexAny(packet, "[{")
+	if start < 0 || !json.Valid(packet[start:]) {
+		return truncate(string(packet), p.MaxLength)
+	}
+	header, body := packet[:start], packet[start:]
+
+	policy := p
+	if override, ok := p.Events[eventName(body)]; ok {
+		if override == nil {
+			return fmt.Sprintf("%s[redacted %d bytes]", header, len(body))
+		}
+		policy = override
+	}
+
+	var b strings.Builder
+	b.Write(header)
+	policy.mask(&b, body)
+	return truncate(b.String(), policy.MaxLength)
+}
+
+// eventName returns the name of a socket.io event, the first element of the
+// array, or "".
+func eventName(body []byte) string {
+	if body[0] != '[' {
+		return ""
+	}
+	var event []json.RawMessage
+	var name string
+	if json.Unmarshal(body, &event) != nil || len(event) == 0 || json.Unmarshal(event[0], &name) != nil {
+		return ""
+	}
+	return name
+}
+
+// mask writes the valid JSON value with the values of the sensitive keys
+// replaced.
+func (p *RedactionPolicy) mask(b *strings.Builder, value json.RawMessage) {
+	value = bytes.TrimSpace(value)
+	if value[0] != '{' && value[0] != '[' {
+		b.Write(value)
+		return
+	}
+
+	object := value[0] == '{'
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	_, _ = decoder.Token()
+	b.WriteByte(value[0])
+	for i := 0; decoder.More(); i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		masked := false
+		if object {
+			token, _ := decoder.Token()
+			key, _ := token.(string)
+			writeKey(b, key)
+			masked = p.masks(key)
+		}
+		var element json.RawMessage
+		if decoder.Decode(&element) != nil {
+			return
+		}
+		if masked {
+			b.WriteString(redactedValue)
+		} else {
+			p.mask(b, element)
+		}
+	}
+	b.WriteByte(value[len(value)-1])
+}
+
+func writeKey(b *strings.Builder, key string) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(key)
+	b.Write(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+	b.WriteByte(':')
+}
+
+func (p *RedactionPolicy) masks(key string) bool {
+	keys := p.Keys
+	if keys == nil {
+		keys = DefaultRedactedKeys
+	}
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return p.KeyFunc != nil && p.KeyFunc(key)
+}
+
+// truncate cuts s to max bytes, on a rune boundary, noting its length.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... [%d bytes]", s[:cut], len(s))
+}
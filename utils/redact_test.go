@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactionPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *RedactionPolicy
+		data   string
+		want   string
+	}{
+		{
+			name:   "Default keys at any depth",
+			policy: &RedactionPolicy{},
+			data:   `42/chat,3["login",{"user":"bob","Password":"s3cret","meta":{"token":{"id":1}}},["x",{"authorization":"Bearer x"}]]`,
+			want:   `42/chat,3["login",{"user":"bob","Password":"[redacted]","meta":{"token":"[redacted]"}},["x",{"authorization":"[redacted]"}]]`,
+		},
+		{
+			name:   "Handshake",
+			policy: &RedactionPolicy{},
+			data:   `0{"sid":"abc","upgrades":["websocket"]}`,
+			want:   `0{"sid":"abc","upgrades":["websocket"]}`,
+		},
+		{
+			name:   "Polling payload",
+			policy: &RedactionPolicy{},
+			data:   "40{\"token\":\"abc\"}\x1e2probe\x1ebAQID",
+			want:   "40{\"token\":\"[redacted]\"}\x1e2probe\x1ebAQID",
+		},
+		{
+			name:   "Keys and KeyFunc",
+			policy: &RedactionPolicy{Keys: []string{"pin"}, KeyFunc: func(key string) bool { return strings.HasPrefix(key, "x-") }},
+			data:   `2["ev",{"pin":1234,"token":"t","x-secret":"<&>"}]`,
+			want:   `2["ev",{"pin":"[redacted]","token":"t","x-secret":"[redacted]"}]`,
+		},
+		{
+			name:   "No keys",
+			policy: &RedactionPolicy{Keys: []string{}},
+			data:   `2["ev",{"password":"p"}]`,
+			want:   `2["ev",{"password":"p"}]`,
+		},
+		{
+			name:   "Invalid JSON",
+			policy: &RedactionPolicy{},
+			data:   `4hello {"token":`,
+			want:   `4hello {"token":`,
+		},
+		{
+			name:   "Truncated",
+			policy: &RedactionPolicy{MaxLength: 17},
+			data:   `2["ev","héllo wörld",{"token":"abc"}]`,
+			want:   `2["ev","héllo w... [46 bytes]`,
+		},
+		{
+			name: "Event overrides",
+			policy: &RedactionPolicy{Events: map[string]*RedactionPolicy{
+				"upload": nil,
+				"debug":  {Keys: []string{}},
+			}},
+			data: "2[\"upload\",{\"file\":\"...\"}]\x1e2[\"debug\",{\"token\":\"t\"}]\x1e2[\"other\",{\"token\":\"t\"}]",
+			want: "2[redacted 25 bytes]\x1e2[\"debug\",{\"token\":\"t\"}]\x1e2[\"other\",{\"token\":\"[redacted]\"}]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.Redact([]byte(tt.data)))
+		})
+	}
+}
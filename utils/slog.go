@@ -0,0 +1,51 @@
+//go:build go1.21
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// NewSlogLogger adapts a *slog.Logger to FieldLogger: messages are formatted
+// with fmt.Sprintf and fields become attributes.
+func NewSlogLogger(logger *slog.Logger) FieldLogger {
+	return &slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l *slogLogger) With(fields ...Field) FieldLogger {
+	attrs := make([]any, len(fields))
+	for i, field := range fields {
+		attrs[i] = slog.Any(field.Key, field.Value)
+	}
+	return &slogLogger{logger: l.logger.With(attrs...)}
+}
+
+func (l *slogLogger) Debugf(format string, v ...any) {
+	l.log(slog.LevelDebug, format, v)
+}
+
+func (l *slogLogger) Infof(format string, v ...any) {
+	l.log(slog.LevelInfo, format, v)
+}
+
+func (l *slogLogger) Warnf(format string, v ...any) {
+	l.log(slog.LevelWarn, format, v)
+}
+
+func (l *slogLogger) Errorf(format string, v ...any) {
+	l.log(slog.LevelError, format, v)
+}
+
+func (l *slogLogger) log(level slog.Level, format string, v []any) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	l.logger.Log(ctx, level, fmt.Sprintf(format, v...))
+}
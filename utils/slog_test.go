@@ -0,0 +1,34 @@
+//go:build go1.21
+
+package utils
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var out bytes.Buffer
+	handler := slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := NewSlogLogger(slog.New(handler))
+
+	logger.Debugf("dropped %s", "debug")
+	logger.With(Field{Key: FieldSid, Value: "abc"}).
+		With(Field{Key: FieldNamespace, Value: "/chat"}, Field{Key: FieldAckID, Value: 7}).
+		Warnf("ack timeout: %v", "1s")
+	logger.Errorf("plain")
+
+	assert.Equal(t, "level=WARN msg=\"ack timeout: 1s\" sid=abc namespace=/chat ackId=7\n"+
+		"level=ERROR msg=plain\n", out.String())
+}
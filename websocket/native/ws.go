@@ -2,51 +2,162 @@ package ws_native
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"net"
+	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
 	"golang.org/x/net/websocket"
 )
 
 type WebSocketConnection struct {
-	mu   sync.Mutex
-	conn *websocket.Conn
+	// Header holds extra headers sent with the opening handshake.
+	Header http.Header
+	// DialContext, when set, opens the connection instead of a net.Dialer,
+	// e.g. to use a custom resolver, source address or a Unix socket.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// TLSConfig is used for wss URLs.
+	TLSConfig *tls.Config
+	// MaxPayloadBytes caps the size of a received message; Receive discards
+	// a larger one and returns ErrFrameTooLarge. Zero means the websocket
+	// package default of 32MB.
+	MaxPayloadBytes int
+
+	// mu serializes writes. connMu guards conn, which a Dial replaces while
+	// a Receive on the previous connection may still be returning.
+	mu     sync.Mutex
+	connMu sync.RWMutex
+	conn   *websocket.Conn
 }
 
 var ErrNotConnected = errors.New("socket connection is not initialized")
 
+// ErrFrameTooLarge is returned by Receive for a message over MaxPayloadBytes.
+// The connection stays usable.
+var ErrFrameTooLarge = websocket.ErrFrameTooLarge
+
 func (ws *WebSocketConnection) Dial(ctx context.Context, url *url.URL, origin *url.URL) error {
 	var err error
 	config, err := websocket.NewConfig(url.String(), origin.String())
 	if err != nil {
 		return err
 	}
-	ws.conn, err = config.DialContext(ctx)
-	return err
+	for key, values := range ws.Header {
+		config.Header[key] = append(config.Header[key], values...)
+	}
+	if ws.TLSConfig != nil {
+		config.TlsConfig = ws.TLSConfig
+	}
+	var conn *websocket.Conn
+	if ws.DialContext == nil {
+		conn, err = config.DialContext(ctx)
+	} else if conn, err = ws.dial(ctx, config); err != nil {
+		err = &websocket.DialError{Config: config, Err: err}
+	}
+	if err != nil {
+		return err
+	}
+	conn.MaxPayloadBytes = ws.MaxPayloadBytes
+	ws.connMu.Lock()
+	ws.conn = conn
+	ws.connMu.Unlock()
+	return nil
+}
+
+func (ws *WebSocketConnection) current() *websocket.Conn {
+	ws.connMu.RLock()
+	defer ws.connMu.RUnlock()
+	return ws.conn
+}
+
+// dial opens the connection with DialContext, then runs the TLS and
+// websocket handshakes, bounded by ctx.
+func (ws *WebSocketConnection) dial(ctx context.Context, config *websocket.Config) (*websocket.Conn, error) {
+	host := config.Location.Hostname()
+	port := config.Location.Port()
+	if port == "" {
+		port = "80"
+		if config.Location.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	conn, err := ws.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+
+	// Abort the handshakes when ctx is done, and clear the deadline the
+	// watcher may have set once they are over.
+	handshakeDone := make(chan struct{})
+	watcherDone := make(chan struct{})
+	go func() {
+		defer close(watcherDone)
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Now())
+		case <-handshakeDone:
+		}
+	}()
+	defer func() {
+		close(handshakeDone)
+		<-watcherDone
+		_ = conn.SetDeadline(time.Time{})
+	}()
+
+	if config.Location.Scheme == "wss" {
+		tlsConfig := &tls.Config{}
+		if config.TlsConfig != nil {
+			tlsConfig = config.TlsConfig.Clone()
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = host
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	wsConn, err := websocket.NewClient(config, conn)
+	if err != nil {
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return wsConn, nil
 }
 
 func (ws *WebSocketConnection) Send(v []byte) error {
-	if ws.conn == nil {
+	conn := ws.current()
+	if conn == nil {
 		return ErrNotConnected
 	}
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
-	return websocket.Message.Send(ws.conn, string(v))
+	return websocket.Message.Send(conn, string(v))
 }
 
 func (ws *WebSocketConnection) Receive(v *[]byte) error {
-	if ws.conn == nil {
+	conn := ws.current()
+	if conn == nil {
 		return ErrNotConnected
 	}
-	return websocket.Message.Receive(ws.conn, v)
+	return websocket.Message.Receive(conn, v)
 }
 
 func (ws *WebSocketConnection) Close() error {
-	if ws.conn == nil {
+	conn := ws.current()
+	if conn == nil {
 		return nil
 	}
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
-	return ws.conn.Close()
+	return conn.Close()
 }
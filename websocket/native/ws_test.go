@@ -2,6 +2,10 @@ package ws_native
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"sync"
@@ -133,6 +137,28 @@ func TestWebSocketConnection_Receive(t *testing.T) {
 		assert.Equal(t, "Echo", string(received), "Received message should match sent message")
 	})
 
+	t.Run("Receive over MaxPayloadBytes", func(t *testing.T) {
+		t.Parallel()
+
+		receivedChan := make(chan string, 2)
+		server := createTestServer(t, receivedChan)
+		defer server.Close()
+
+		url, err := url.Parse(server.URL)
+		require.NoError(t, err, "Failed to parse server URL")
+		url.Scheme = "ws"
+
+		ws := &WebSocketConnection{MaxPayloadBytes: 4}
+		require.NoError(t, ws.Dial(ctx, url, origin))
+		require.NoError(t, ws.Send([]byte("Too long")))
+		require.NoError(t, ws.Send([]byte("Echo")))
+
+		var received []byte
+		assert.ErrorIs(t, ws.Receive(&received), ErrFrameTooLarge)
+		require.NoError(t, ws.Receive(&received), "the connection should stay usable")
+		assert.Equal(t, "Echo", string(received))
+	})
+
 	t.Run("Receive without connection", func(t *testing.T) {
 		t.Parallel()
 		ws := &WebSocketConnection{}
@@ -273,3 +299,110 @@ func createTestServer(t *testing.T, receivedChan chan<- string) *httptest.Server
 		}
 	}))
 }
+
+func TestWebSocketConnection_DialHeader(t *testing.T) {
+	headers := make(chan string, 1)
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		headers <- ws.Request().Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	u.Scheme = "ws"
+	origin, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+
+	ws := &WebSocketConnection{Header: http.Header{"Authorization": {"Bearer token"}}}
+	require.NoError(t, ws.Dial(context.Background(), u, origin))
+	defer ws.Close()
+	assert.Equal(t, "Bearer token", <-headers)
+}
+
+func TestWebSocketConnection_DialContext(t *testing.T) {
+	echo := websocket.Handler(func(ws *websocket.Conn) {
+		var msg string
+		if websocket.Message.Receive(ws, &msg) == nil {
+			_ = websocket.Message.Send(ws, msg)
+		}
+	})
+	origin, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+
+	for _, secure := range []bool{false, true} {
+		name := "ws"
+		if secure {
+			name = "wss"
+		}
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(echo)
+			var tlsConfig *tls.Config
+			if secure {
+				server.StartTLS()
+				tlsConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+			} else {
+				server.Start()
+			}
+			defer server.Close()
+
+			// Dial a name that doesn't resolve; the dialer knows where it is.
+			var dialed []string
+			ws := &WebSocketConnection{
+				TLSConfig: tlsConfig,
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					dialed = append(dialed, network+" "+addr)
+					return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+				},
+			}
+			u := &url.URL{Scheme: name, Host: "example.invalid", Path: "/"}
+			if secure {
+				// The test certificate is for example.com.
+				u.Host = "example.com"
+			}
+			require.NoError(t, ws.Dial(context.Background(), u, origin))
+			defer ws.Close()
+
+			port := "80"
+			if secure {
+				port = "443"
+			}
+			assert.Equal(t, []string{"tcp " + u.Host + ":" + port}, dialed)
+			require.NoError(t, ws.Send([]byte("hello")))
+			var reply []byte
+			require.NoError(t, ws.Receive(&reply))
+			assert.Equal(t, "hello", string(reply))
+		})
+	}
+
+	t.Run("Dial error", func(t *testing.T) {
+		ws := &WebSocketConnection{DialContext: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("no route")
+		}}
+		err := ws.Dial(context.Background(), &url.URL{Scheme: "ws", Host: "localhost:1", Path: "/"}, origin)
+		var dialErr *websocket.DialError
+		require.ErrorAs(t, err, &dialErr)
+		assert.ErrorContains(t, err, "no route")
+	})
+
+	t.Run("Context done during the handshake", func(t *testing.T) {
+		// A server that accepts and never answers.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				defer conn.Close()
+				time.Sleep(5 * time.Second)
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		ws := &WebSocketConnection{DialContext: (&net.Dialer{}).DialContext}
+		err = ws.Dial(ctx, &url.URL{Scheme: "ws", Host: listener.Addr().String(), Path: "/"}, origin)
+		var dialErr *websocket.DialError
+		require.ErrorAs(t, err, &dialErr)
+		assert.ErrorIs(t, dialErr.Err, context.DeadlineExceeded)
+	})
+}